@@ -0,0 +1,160 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+const addressTypeInternal = "INTERNAL"
+
+var (
+	addressURLRegex = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?regions/(?P<region>%[2]s)/addresses/(?P<address>%[2]s)$`, projectRgxStr, rfc1035))
+)
+
+func (w *Workflow) addressExists(project, region, address string) (bool, DError) {
+	return w.addressCache.resourceExists(func(project, region string, opts ...daisyCompute.ListCallOption) (interface{}, error) {
+		return w.ComputeClient.ListAddresses(project, region)
+	}, project, region, address)
+}
+
+// Address is used to reserve a static internal IP address in a subnetwork.
+type Address struct {
+	compute.Address
+	Resource
+}
+
+// MarshalJSON is a hacky workaround to compute.Address's implementation.
+func (a *Address) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*a)
+}
+
+func (a *Address) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	a.Name, a.Region, errs = a.Resource.populateWithRegion(ctx, s, a.Name, a.Region)
+
+	a.AddressType = strOr(a.AddressType, addressTypeInternal)
+	if subnetworkURLRegex.MatchString(a.Subnetwork) {
+		a.Subnetwork = extendPartialURL(a.Subnetwork, a.Project)
+	}
+
+	a.Description = strOr(a.Description, defaultDescription("Address", s.w.Name, s.w.username))
+	a.link = fmt.Sprintf("projects/%s/regions/%s/addresses/%s", a.Project, a.Region, a.Name)
+	return errs
+}
+
+func (a *Address) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot create address %q", a.daisyName)
+	errs := a.Resource.validateWithRegion(ctx, s, a.Region, pre)
+
+	if a.AddressType != addressTypeInternal {
+		errs = addErrs(errs, Errf("%s: AddressType must be %q: %q", pre, addressTypeInternal, a.AddressType))
+	}
+	var ip net.IP
+	if a.Address.Address != "" {
+		if ip = net.ParseIP(a.Address.Address); ip == nil {
+			errs = addErrs(errs, Errf("%s: bad Address: %q", pre, a.Address.Address))
+		}
+	}
+	if a.Subnetwork == "" {
+		errs = addErrs(errs, Errf("%s: Subnetwork not set", pre))
+	} else if sr, err := s.w.subnetworks.regUse(a.Subnetwork, s); err != nil {
+		errs = addErrs(errs, err)
+	} else {
+		if r := NamedSubexp(subnetworkURLRegex, sr.link)["region"]; r != a.Region {
+			errs = addErrs(errs, Errf("%s: subnetwork %q is in region %q, not %q", pre, a.Subnetwork, r, a.Region))
+		}
+		if ip != nil {
+			errs = addErrs(errs, checkIPInSubnetwork(s.w, ip, sr, pre))
+		}
+	}
+
+	// Register creation.
+	errs = addErrs(errs, s.w.addresses.regCreate(a.daisyName, &a.Resource, s, false))
+	return errs
+}
+
+// checkIPInSubnetwork checks that ip is in the primary IP range of the
+// subnetwork sr. The range of subnetworks created by the workflow is read
+// from the step creating them, the range of others from GCE.
+func checkIPInSubnetwork(w *Workflow, ip net.IP, sr *Resource, pre string) DError {
+	var cidr string
+	if sr.creator != nil && sr.creator.CreateSubnetworks != nil {
+		for _, sn := range *sr.creator.CreateSubnetworks {
+			if sn.daisyName == sr.daisyName {
+				cidr = sn.IpCidrRange
+			}
+		}
+	}
+	if cidr == "" {
+		m := NamedSubexp(subnetworkURLRegex, sr.link)
+		sn, err := w.ComputeClient.GetSubnetwork(m["project"], m["region"], m["subnetwork"])
+		if err != nil {
+			return Errf("%s: failed to get subnetwork %q: %v", pre, sr.link, err)
+		}
+		cidr = sn.IpCidrRange
+	}
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return Errf("%s: bad subnetwork IpCidrRange: %q, error: %v", pre, cidr, err)
+	}
+	if !ipNet.Contains(ip) {
+		return Errf("%s: Address %s is not in the IpCidrRange %s of subnetwork %q", pre, ip, cidr, sr.daisyName)
+	}
+	return nil
+}
+
+type addressRegistry struct {
+	baseResourceRegistry
+	// Reserved IPs of the created addresses, by address name.
+	ips map[string]string
+}
+
+func newAddressRegistry(w *Workflow) *addressRegistry {
+	ar := &addressRegistry{baseResourceRegistry: baseResourceRegistry{w: w, typeName: "address", urlRgx: addressURLRegex}}
+	ar.baseResourceRegistry.deleteFn = ar.deleteFn
+	ar.ips = map[string]string{}
+	ar.init()
+	return ar
+}
+
+func (ar *addressRegistry) deleteFn(res *Resource) DError {
+	m := NamedSubexp(addressURLRegex, res.link)
+	err := ar.w.ComputeClient.DeleteAddress(m["project"], m["region"], m["address"])
+	return deleteErr("address", err)
+}
+
+// setIP records the IP reserved by the address known by name.
+func (ar *addressRegistry) setIP(name, ip string) {
+	ar.mx.Lock()
+	defer ar.mx.Unlock()
+	ar.ips[name] = ip
+}
+
+// ip returns the IP reserved by the address known by name, once it is
+// created.
+func (ar *addressRegistry) ip(name string) (string, bool) {
+	ar.mx.Lock()
+	defer ar.mx.Unlock()
+	ip, ok := ar.ips[name]
+	return ip, ok
+}
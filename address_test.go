@@ -0,0 +1,101 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/api/compute/v1"
+)
+
+func TestAddressPopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	a := &Address{Address: compute.Address{Name: "name", Subnetwork: fmt.Sprintf("regions/%s/subnetworks/%s", testRegion, testSubnetwork)}, Resource: Resource{ExactName: true}}
+	if err := a.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &Address{
+		Address: compute.Address{
+			Name:        "name",
+			Region:      testRegion,
+			AddressType: addressTypeInternal,
+			Subnetwork:  fmt.Sprintf("projects/%s/regions/%s/subnetworks/%s", testProject, testRegion, testSubnetwork),
+			Description: defaultDescription("Address", w.Name, w.username),
+		},
+		Resource: Resource{
+			Project:   testProject,
+			ExactName: true,
+			RealName:  "name",
+			daisyName: "name",
+			link:      fmt.Sprintf("projects/%s/regions/%s/addresses/name", testProject, testRegion),
+		},
+	}
+	if diffRes := diff(a, want, 0); diffRes != "" {
+		t.Errorf("populated Address does not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestAddressValidate(t *testing.T) {
+	ctx := context.Background()
+	existingSubnetwork := fmt.Sprintf("projects/%s/regions/%s/subnetworks/%s", testProject, testRegion, testSubnetwork)
+
+	tests := []struct {
+		desc      string
+		a         compute.Address
+		shouldErr bool
+	}{
+		{"auto-allocated case", compute.Address{Subnetwork: "subnet"}, false},
+		{"specified IP case", compute.Address{Subnetwork: "subnet", Address: "192.168.1.10"}, false},
+		{"specified IP outside of subnetwork case", compute.Address{Subnetwork: "subnet", Address: "192.168.2.10"}, true},
+		{"existing subnetwork case", compute.Address{Subnetwork: existingSubnetwork, Address: "10.128.0.5"}, false},
+		{"existing subnetwork IP outside of subnetwork case", compute.Address{Subnetwork: existingSubnetwork, Address: "10.0.0.5"}, true},
+		{"bad IP case", compute.Address{Subnetwork: "subnet", Address: "192.168.1"}, true},
+		{"external address case", compute.Address{Subnetwork: "subnet", AddressType: "EXTERNAL"}, true},
+		{"no subnetwork case", compute.Address{}, true},
+		{"subnetwork dne case", compute.Address{Subnetwork: DNE}, true},
+	}
+
+	for _, tt := range tests {
+		w := testWorkflow()
+		sc, _ := w.NewStep("create-subnetwork")
+		sn := &Subnetwork{Subnetwork: compute.Subnetwork{Name: "subnet", Network: testNetwork, IpCidrRange: "192.168.1.0/24"}}
+		sc.CreateSubnetworks = &CreateSubnetworks{sn}
+		if err := sn.populate(ctx, sc); err != nil {
+			t.Fatalf("%s: unexpected subnetwork populate error: %v", tt.desc, err)
+		}
+		if err := w.subnetworks.regCreate(sn.daisyName, &sn.Resource, sc, false); err != nil {
+			t.Fatalf("%s: unexpected subnetwork registration error: %v", tt.desc, err)
+		}
+		s, _ := w.NewStep("s")
+		w.AddDependency(s, sc)
+
+		a := &Address{Address: tt.a}
+		a.Name = "address"
+		if err := a.populate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected populate error: %v", tt.desc, err)
+		}
+		err := a.validate(ctx, s)
+		if tt.shouldErr && err == nil {
+			t.Errorf("%s: should have returned an error but didn't", tt.desc)
+		} else if !tt.shouldErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff produces jittered, exponentially growing delays, for steps polling
+// a resource. Each delay doubles the previous one, from Base up to Max, and
+// is randomly shortened by up to half so that concurrent pollers spread out.
+//
+//	b := &Backoff{Base: time.Second, Max: time.Minute}
+//	for !done() {
+//		time.Sleep(b.Next())
+//	}
+type Backoff struct {
+	// Base is the first delay, it must be positive.
+	Base time.Duration
+	// Max caps the delays, they are not capped if 0.
+	Max time.Duration
+
+	attempt int
+}
+
+// Next returns the delay to wait before the next attempt.
+func (b *Backoff) Next() time.Duration {
+	d := b.Base
+	for i := 0; i < b.attempt && (b.Max == 0 || d < b.Max) && d <= math.MaxInt64/2; i++ {
+		d *= 2
+	}
+	if b.Max != 0 && d > b.Max {
+		d = b.Max
+	} else {
+		b.attempt++
+	}
+	if half := int64(d / 2); half > 0 {
+		d -= time.Duration(rand.Int63n(half + 1))
+	}
+	return d
+}
+
+// Reset makes the next delay start over from Base.
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
@@ -0,0 +1,62 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffNext(t *testing.T) {
+	tests := []struct {
+		desc string
+		b    *Backoff
+		want []time.Duration
+	}{
+		{"exponential case", &Backoff{Base: time.Second}, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+		{"capped case", &Backoff{Base: time.Second, Max: 3 * time.Second}, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+		{"base over max case", &Backoff{Base: 5 * time.Second, Max: 3 * time.Second}, []time.Duration{3 * time.Second, 3 * time.Second}},
+	}
+	for _, tt := range tests {
+		for i, want := range tt.want {
+			// Delays are shortened by up to half.
+			if got := tt.b.Next(); got < want/2 || got > want {
+				t.Errorf("%s: delay %d is %v, want between %v and %v", tt.desc, i, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestBackoffReset(t *testing.T) {
+	b := &Backoff{Base: time.Second, Max: time.Minute}
+	for i := 0; i < 10; i++ {
+		b.Next()
+	}
+	b.Reset()
+	if got := b.Next(); got > time.Second {
+		t.Errorf("delay after Reset is %v, want at most %v", got, time.Second)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := &Backoff{Base: time.Second, Max: time.Second}
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		seen[b.Next()] = true
+	}
+	if len(seen) < 2 {
+		t.Error("delays aren't jittered")
+	}
+}
@@ -261,7 +261,7 @@ func main() {
 			select {
 			case <-c:
 				fmt.Printf("\nCtrl-C caught, sending cancel signal to %q...\n", w.Name)
-				w.CancelWorkflow()
+				w.CancelWithCause(daisy.CancelKindUser, "Ctrl-C caught")
 				errors <- fmt.Errorf("workflow %q was canceled", w.Name)
 			case <-w.Cancel:
 			}
@@ -0,0 +1,85 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package compute
+
+import (
+	"sync"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// batchGetWorkers is the maximum number of concurrent requests of a batch get.
+var batchGetWorkers = 10
+
+// batchGet calls get for each of names, at most batchGetWorkers at a time. It
+// returns the resources found and the errors of the other names, keyed by
+// name. Duplicate names are only gotten once.
+func batchGet[T any](names []string, get func(name string) (T, error)) (map[string]T, map[string]error) {
+	results := map[string]T{}
+	errs := map[string]error{}
+	var mx sync.Mutex
+	var wg sync.WaitGroup
+	todo := make(chan string)
+	for i := 0; i < batchGetWorkers && i < len(names); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range todo {
+				res, err := get(name)
+				mx.Lock()
+				if err != nil {
+					errs[name] = err
+				} else {
+					results[name] = res
+				}
+				mx.Unlock()
+			}
+		}()
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			todo <- name
+		}
+	}
+	close(todo)
+	wg.Wait()
+	return results, errs
+}
+
+// GetImages gets GCE images concurrently. It returns the images found and
+// the errors of the other names, e.g. not found errors, keyed by name.
+func (c *client) GetImages(project string, names []string) (map[string]*compute.Image, map[string]error) {
+	return batchGet(names, func(name string) (*compute.Image, error) {
+		return c.i.GetImage(project, name)
+	})
+}
+
+// GetInstances gets GCE instances of a zone concurrently. It returns the
+// instances found and the errors of the other names, keyed by name.
+func (c *client) GetInstances(project, zone string, names []string) (map[string]*compute.Instance, map[string]error) {
+	return batchGet(names, func(name string) (*compute.Instance, error) {
+		return c.i.GetInstance(project, zone, name)
+	})
+}
+
+// GetDisks gets GCE disks of a zone concurrently. It returns the disks found
+// and the errors of the other names, keyed by name.
+func (c *client) GetDisks(project, zone string, names []string) (map[string]*compute.Disk, map[string]error) {
+	return batchGet(names, func(name string) (*compute.Disk, error) {
+		return c.i.GetDisk(project, zone, name)
+	})
+}
@@ -0,0 +1,136 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestBatchGet(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefixes := map[string]string{
+			"images":    fmt.Sprintf("/projects/%s/global/images/", testProject),
+			"instances": fmt.Sprintf("/projects/%s/zones/%s/instances/", testProject, testZone),
+			"disks":     fmt.Sprintf("/projects/%s/zones/%s/disks/", testProject, testZone),
+		}
+		for _, prefix := range prefixes {
+			if name := strings.TrimPrefix(r.URL.Path, prefix); r.Method == "GET" && name != r.URL.Path {
+				if strings.HasPrefix(name, "found") {
+					fmt.Fprintf(w, `{"Name":%q}`, name)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintln(w, "not found")
+				return
+			}
+		}
+		w.WriteHeader(500)
+		fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	names := []string{"found-1", "dne-1", "found-2", "dne-2", "found-1"}
+	check := func(kind string, found map[string]string, errs map[string]error) {
+		var gotFound []string
+		for name, res := range found {
+			if res != name {
+				t.Errorf("%s: got %q for name %q", kind, res, name)
+			}
+			gotFound = append(gotFound, name)
+		}
+		sort.Strings(gotFound)
+		if want := []string{"found-1", "found-2"}; strings.Join(gotFound, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: unexpected resources found, got: %v, want: %v", kind, gotFound, want)
+		}
+		if len(errs) != 2 {
+			t.Errorf("%s: want errors for 2 names, got: %v", kind, errs)
+		}
+		for _, name := range []string{"dne-1", "dne-2"} {
+			if gErr, ok := errs[name].(*googleapi.Error); !ok || gErr.Code != http.StatusNotFound {
+				t.Errorf("%s: want not found error for %q, got: %v", kind, name, errs[name])
+			}
+		}
+	}
+
+	images, errs := c.GetImages(testProject, names)
+	found := map[string]string{}
+	for n, i := range images {
+		found[n] = i.Name
+	}
+	check("images", found, errs)
+
+	instances, errs := c.GetInstances(testProject, testZone, names)
+	found = map[string]string{}
+	for n, i := range instances {
+		found[n] = i.Name
+	}
+	check("instances", found, errs)
+
+	disks, errs := c.GetDisks(testProject, testZone, names)
+	found = map[string]string{}
+	for n, d := range disks {
+		found[n] = d.Name
+	}
+	check("disks", found, errs)
+}
+
+func TestBatchGetWorkers(t *testing.T) {
+	defer func(n int) { batchGetWorkers = n }(batchGetWorkers)
+	batchGetWorkers = 3
+
+	_, c, err := NewTestClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mx sync.Mutex
+	var running, maxRunning int
+	c.GetImageFn = func(_, name string) (*compute.Image, error) {
+		mx.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mx.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mx.Lock()
+		running--
+		mx.Unlock()
+		return &compute.Image{Name: name}, nil
+	}
+
+	var names []string
+	for i := 0; i < 10; i++ {
+		names = append(names, fmt.Sprintf("image-%d", i))
+	}
+	images, errs := c.GetImages(testProject, names)
+	if len(images) != len(names) || len(errs) != 0 {
+		t.Errorf("want %d images and no errors, got: %d images, errors: %v", len(names), len(images), errs)
+	}
+	if maxRunning != batchGetWorkers {
+		t.Errorf("want %d concurrent requests, got: %d", batchGetWorkers, maxRunning)
+	}
+}
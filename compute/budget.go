@@ -0,0 +1,85 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrCallBudgetExceeded is wrapped by the errors of the requests made once
+// the client's call budget, set by SetCallBudget, is spent.
+var ErrCallBudgetExceeded = errors.New("API call budget exceeded")
+
+// callBudget counts the requests made by a client. Copies made with Copy have
+// their own.
+type callBudget struct {
+	// Maximum number of requests, no limit if 0.
+	max   atomic.Int64
+	calls atomic.Int64
+}
+
+// spend counts a request, it returns false if the budget is spent.
+func (b *callBudget) spend() bool {
+	n := b.calls.Add(1)
+	max := b.max.Load()
+	return max <= 0 || n <= max
+}
+
+// budgetTransport is a transport failing the requests made once the budget
+// is spent, without sending them.
+type budgetTransport struct {
+	base   http.RoundTripper
+	budget *callBudget
+}
+
+func (t *budgetTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !t.budget.spend() {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: more than %d requests made, %s %s not sent", ErrCallBudgetExceeded, t.budget.max.Load(), r.Method, r.URL.Path)
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
+
+// withCallBudget returns a copy of hc counting its requests against b.
+func withCallBudget(hc *http.Client, b *callBudget) *http.Client {
+	c := *hc
+	c.Transport = &budgetTransport{base: hc.Transport, budget: b}
+	return &c
+}
+
+// SetCallBudget makes the requests the client sends after n more requests
+// fail with an error wrapping ErrCallBudgetExceeded, without being sent. A n
+// of 0, the default, removes the limit. Calls whose requests fail this way
+// aren't retried.
+func (c *client) SetCallBudget(n int64) {
+	c.budget.max.Store(0)
+	c.budget.calls.Store(0)
+	c.budget.max.Store(n)
+}
+
+// CallCount returns the number of requests counted against the client's call
+// budget since it was last set.
+func (c *client) CallCount() int64 {
+	return c.budget.calls.Load()
+}
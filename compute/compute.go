@@ -17,10 +17,14 @@ package compute
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -30,28 +34,39 @@ import (
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 	"google.golang.org/api/transport"
+	htransport "google.golang.org/api/transport/http"
 )
 
-// Client is a client for interacting with Google Cloud Compute.
+// Client is a client for interacting with Google Cloud Compute. The clients
+// created by this package have more methods, e.g. Copy, SetCallBudget or
+// SetOperationProgressHook, which are left out of Client so that other
+// implementations of it don't need them. Use a type assertion to call them.
 type Client interface {
 	AttachDisk(project, zone, instance string, d *compute.AttachedDisk) error
 	DetachDisk(project, zone, instance, disk string) error
+	BulkInsertDisk(project, zone string, req *compute.BulkInsertDiskResource) error
+	CreateAddress(project, region string, a *compute.Address) error
 	CreateDisk(project, zone string, d *compute.Disk) error
 	CreateDiskAlpha(project, zone string, d *computeAlpha.Disk) error
 	CreateDiskBeta(project, zone string, d *computeBeta.Disk) error
 	CreateForwardingRule(project, region string, fr *compute.ForwardingRule) error
 	CreateFirewallRule(project string, i *compute.Firewall) error
 	CreateImage(project string, i *compute.Image) error
+	ForceCreateImage(project string, i *compute.Image) error
 	CreateImageAlpha(project string, i *computeAlpha.Image) error
 	CreateImageBeta(project string, i *computeBeta.Image) error
 	CreateInstance(project, zone string, i *compute.Instance) error
 	CreateInstanceAlpha(project, zone string, i *computeAlpha.Instance) error
 	CreateInstanceBeta(project, zone string, i *computeBeta.Instance) error
 	CreateNetwork(project string, n *compute.Network) error
+	PatchNetwork(project, name string, n *compute.Network) error
+	SwitchNetworkToCustomMode(project, name string) error
 	CreateSnapshot(project, zone, disk string, s *compute.Snapshot) error
 	CreateSnapshotWithGuestFlush(project, zone, disk string, s *compute.Snapshot) error
+	CreateSnapshotWithOptions(project, zone, disk string, s *compute.Snapshot, opts SnapshotOptions) error
 	CreateSubnetwork(project, region string, n *compute.Subnetwork) error
 	CreateTargetInstance(project, zone string, ti *compute.TargetInstance) error
+	DeleteAddress(project, region, name string) error
 	DeleteDisk(project, zone, name string) error
 	DeleteForwardingRule(project, region, name string) error
 	DeleteFirewallRule(project, name string) error
@@ -59,25 +74,41 @@ type Client interface {
 	DeleteInstance(project, zone, name string) error
 	StartInstance(project, zone, name string) error
 	StopInstance(project, zone, name string) error
+	SendDiagnosticInterrupt(project, zone, name string) error
 	DeleteNetwork(project, name string) error
 	DeleteSubnetwork(project, region, name string) error
 	DeleteTargetInstance(project, zone, name string) error
 	DeprecateImage(project, name string, deprecationstatus *compute.DeprecationStatus) error
 	DeprecateImageAlpha(project, name string, deprecationstatus *computeAlpha.DeprecationStatus) error
+	DeprecateImageBeta(project, name string, deprecationstatus *computeBeta.DeprecationStatus) error
 	GetMachineType(project, zone, machineType string) (*compute.MachineType, error)
+	GetMachineTypeSpec(project, zone, machineType string) (vCPUs int64, memoryMb int64, err error)
 	GetProject(project string) (*compute.Project, error)
+	Ping(ctx context.Context, project string) error
 	GetSerialPortOutput(project, zone, name string, port, start int64) (*compute.SerialPortOutput, error)
+	GetScreenshot(project, zone, name string) (*compute.Screenshot, error)
+	PollSerialForMarker(ctx context.Context, project, zone, name string, port int64, success, failure *regexp.Regexp, interval time.Duration) (matched string, isFailure bool, err error)
+	GetEffectiveFirewalls(project, zone, instance, networkInterface string) (*compute.InstancesGetEffectiveFirewallsResponse, error)
 	GetZone(project, zone string) (*compute.Zone, error)
+	IsZoneAvailable(project, zone string) (bool, error)
 	GetInstance(project, zone, name string) (*compute.Instance, error)
+	GetInstances(project, zone string, names []string) (map[string]*compute.Instance, map[string]error)
 	GetInstanceAlpha(project, zone, name string) (*computeAlpha.Instance, error)
 	GetInstanceBeta(project, zone, name string) (*computeBeta.Instance, error)
 	GetDisk(project, zone, name string) (*compute.Disk, error)
+	GetDisks(project, zone string, names []string) (map[string]*compute.Disk, map[string]error)
+	GetReservation(project, zone, name string) (*compute.Reservation, error)
+	GetNodeGroup(project, zone, name string) (*compute.NodeGroup, error)
+	GetNodeTemplate(project, region, name string) (*compute.NodeTemplate, error)
+	GetNodeType(project, zone, name string) (*compute.NodeType, error)
 	GetDiskAlpha(project, zone, name string) (*computeAlpha.Disk, error)
 	GetDiskBeta(project, zone, name string) (*computeBeta.Disk, error)
+	GetAddress(project, region, name string) (*compute.Address, error)
 	GetForwardingRule(project, region, name string) (*compute.ForwardingRule, error)
 	GetFirewallRule(project, name string) (*compute.Firewall, error)
 	GetGuestAttributes(project, zone, name, queryPath, variableKey string) (*compute.GuestAttributes, error)
 	GetImage(project, name string) (*compute.Image, error)
+	GetImages(project string, names []string) (map[string]*compute.Image, map[string]error)
 	GetImageAlpha(project, name string) (*computeAlpha.Image, error)
 	GetImageBeta(project, name string) (*computeBeta.Image, error)
 	GetImageFromFamily(project, family string) (*compute.Image, error)
@@ -85,6 +116,7 @@ type Client interface {
 	GetLicense(project, name string) (*compute.License, error)
 	GetNetwork(project, name string) (*compute.Network, error)
 	GetRegion(project, region string) (*compute.Region, error)
+	IsRegionAvailable(project, region string) (bool, error)
 	GetSubnetwork(project, region, name string) (*compute.Subnetwork, error)
 	GetTargetInstance(project, zone, name string) (*compute.TargetInstance, error)
 	InstanceStatus(project, zone, name string) (string, error)
@@ -97,11 +129,16 @@ type Client interface {
 	ListInstances(project, zone string, opts ...ListCallOption) ([]*compute.Instance, error)
 	AggregatedListDisks(project string, opts ...ListCallOption) ([]*compute.Disk, error)
 	ListDisks(project, zone string, opts ...ListCallOption) ([]*compute.Disk, error)
+	ListReservations(project, zone string, opts ...ListCallOption) ([]*compute.Reservation, error)
+	ListInstanceReferrers(project, zone, instance string, opts ...ListCallOption) ([]*compute.Reference, error)
+	ListAddresses(project, region string, opts ...ListCallOption) ([]*compute.Address, error)
 	AggregatedListForwardingRules(project string, opts ...ListCallOption) ([]*compute.ForwardingRule, error)
 	ListForwardingRules(project, zone string, opts ...ListCallOption) ([]*compute.ForwardingRule, error)
 	ListFirewallRules(project string, opts ...ListCallOption) ([]*compute.Firewall, error)
 	ListImages(project string, opts ...ListCallOption) ([]*compute.Image, error)
 	ListImagesAlpha(project string, opts ...ListCallOption) ([]*computeAlpha.Image, error)
+	ListImagesBeta(project string, opts ...ListCallOption) ([]*computeBeta.Image, error)
+	ListResourcesByLabel(project, labelKey, labelValue string) (*LabeledResources, error)
 	GetSnapshot(project, name string) (*compute.Snapshot, error)
 	ListSnapshots(project string, opts ...ListCallOption) ([]*compute.Snapshot, error)
 	DeleteSnapshot(project, name string) error
@@ -110,8 +147,26 @@ type Client interface {
 	ListSubnetworks(project, region string, opts ...ListCallOption) ([]*compute.Subnetwork, error)
 	ListTargetInstances(project, zone string, opts ...ListCallOption) ([]*compute.TargetInstance, error)
 	ResizeDisk(project, zone, disk string, drr *compute.DisksResizeRequest) error
+	UpdateDisk(project, zone, disk string, d *compute.Disk, paths []string) error
 	SetInstanceMetadata(project, zone, name string, md *compute.Metadata) error
+	MergeInstanceMetadata(project, zone, name string, add map[string]string, remove []string) error
+	MergeInstanceLabels(project, zone, name string, add map[string]string, remove []string) error
+	SetTags(project, zone, instance string, tags *compute.Tags) error
+	AddInstanceResourcePolicies(project, zone, instance string, req *compute.InstancesAddResourcePoliciesRequest) error
+	RemoveInstanceResourcePolicies(project, zone, instance string, req *compute.InstancesRemoveResourcePoliciesRequest) error
+	SetInstanceName(project, zone, instance string, req *compute.InstancesSetNameRequest) error
 	SetCommonInstanceMetadata(project string, md *compute.Metadata) error
+	MergeCommonInstanceMetadata(project string, add map[string]string, remove []string) error
+	AddProjectSSHKey(project, user, publicKey string) error
+	IsOSLoginEnabled(project, zone, instance string) (bool, error)
+	PatchInstanceGroupManager(project, zone, igm string, m *compute.InstanceGroupManager) error
+	PatchRegionInstanceGroupManager(project, region, igm string, m *compute.InstanceGroupManager) error
+	UpdatePerInstanceConfigs(project, zone, igm string, req *compute.InstanceGroupManagersUpdatePerInstanceConfigsReq) error
+	UpdateRegionPerInstanceConfigs(project, region, igm string, req *compute.RegionInstanceGroupManagerUpdateInstanceConfigReq) error
+	CreateRegionInstanceTemplate(project, region string, it *compute.InstanceTemplate) error
+	GetRegionInstanceTemplate(project, region, name string) (*compute.InstanceTemplate, error)
+	DeleteRegionInstanceTemplate(project, region, name string) error
+	ListRegionInstanceTemplates(project, region string, opts ...ListCallOption) ([]*compute.InstanceTemplate, error)
 	SetDiskAutoDelete(project, zone, instance string, autoDelete bool, deviceName string) error
 	ListMachineImages(project string, opts ...ListCallOption) ([]*compute.MachineImage, error)
 	DeleteMachineImage(project, name string) error
@@ -131,19 +186,32 @@ type Client interface {
 	DeleteRegionBackendService(project, region, name string) error
 	CreateRegionBackendService(project, region string, b *compute.BackendService) error
 	ListRegionBackendServices(project, region string, opts ...ListCallOption) ([]*compute.BackendService, error)
+	AggregatedListBackendServices(project string, opts ...ListCallOption) ([]*compute.BackendService, error)
 	GetRegionBackendService(project, region, name string) (*compute.BackendService, error)
+	GetBackendService(project, name string) (*compute.BackendService, error)
+	GetBackendServiceHealth(project, region, backendService string, group *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error)
+	GetGlobalBackendServiceHealth(project, backendService string, group *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error)
 	DeleteRegionHealthCheck(project, region, name string) error
 	CreateRegionHealthCheck(project, region string, h *compute.HealthCheck) error
 	ListRegionHealthChecks(project, region string, opts ...ListCallOption) ([]*compute.HealthCheck, error)
+	AggregatedListHealthChecks(project string, opts ...ListCallOption) ([]*compute.HealthCheck, error)
 	GetRegionHealthCheck(project, region, name string) (*compute.HealthCheck, error)
 	DeleteRegionNetworkEndpointGroup(project, region, name string) error
 	CreateRegionNetworkEndpointGroup(project, region string, n *compute.NetworkEndpointGroup) error
 	ListRegionNetworkEndpointGroups(project, region string, opts ...ListCallOption) ([]*compute.NetworkEndpointGroup, error)
 	GetRegionNetworkEndpointGroup(project, region, name string) (*compute.NetworkEndpointGroup, error)
+	DeleteNetworkEndpointGroup(project, zone, name string) error
+	CreateNetworkEndpointGroup(project, zone string, n *compute.NetworkEndpointGroup) error
+	ListNetworkEndpointGroups(project, zone string, opts ...ListCallOption) ([]*compute.NetworkEndpointGroup, error)
+	GetNetworkEndpointGroup(project, zone, name string) (*compute.NetworkEndpointGroup, error)
+	AttachNetworkEndpoints(project, zone, neg string, req *compute.NetworkEndpointGroupsAttachEndpointsRequest) error
+	DetachNetworkEndpoints(project, zone, neg string, req *compute.NetworkEndpointGroupsDetachEndpointsRequest) error
 
 	Retry(f func(opts ...googleapi.CallOption) (*compute.Operation, error), opts ...googleapi.CallOption) (op *compute.Operation, err error)
 	RetryBeta(f func(opts ...googleapi.CallOption) (*computeBeta.Operation, error), opts ...googleapi.CallOption) (op *computeBeta.Operation, err error)
+	RetryAlpha(f func(opts ...googleapi.CallOption) (*computeAlpha.Operation, error), opts ...googleapi.CallOption) (op *computeAlpha.Operation, err error)
 	BasePath() string
+	WaitForImageOperation(project, name string) error
 }
 
 // A ListCallOption is an option for a Google Compute API *ListCall.
@@ -162,6 +230,8 @@ func (o OrderBy) listCallOptionApply(i interface{}) interface{} {
 		return c.OrderBy(string(o))
 	case *computeAlpha.ImagesListCall:
 		return c.OrderBy(string(o))
+	case *computeBeta.ImagesListCall:
+		return c.OrderBy(string(o))
 	case *compute.ImagesListCall:
 		return c.OrderBy(string(o))
 	case *computeAlpha.MachineImagesListCall:
@@ -172,22 +242,40 @@ func (o OrderBy) listCallOptionApply(i interface{}) interface{} {
 		return c.OrderBy(string(o))
 	case *compute.MachineTypesListCall:
 		return c.OrderBy(string(o))
+	case *compute.RegionInstanceTemplatesListCall:
+		return c.OrderBy(string(o))
 	case *compute.ZonesListCall:
 		return c.OrderBy(string(o))
 	case *compute.InstancesListCall:
 		return c.OrderBy(string(o))
 	case *compute.DisksListCall:
 		return c.OrderBy(string(o))
+	case *compute.ReservationsListCall:
+		return c.OrderBy(string(o))
+	case *compute.InstancesListReferrersCall:
+		return c.OrderBy(string(o))
+	case *compute.NetworkEndpointGroupsListCall:
+		return c.OrderBy(string(o))
 	case *compute.NetworksListCall:
 		return c.OrderBy(string(o))
 	case *compute.SubnetworksListCall:
 		return c.OrderBy(string(o))
+	case *compute.AddressesListCall:
+		return c.OrderBy(string(o))
+	case *compute.SnapshotsListCall:
+		return c.OrderBy(string(o))
 	case *compute.InstancesAggregatedListCall:
 		return c.OrderBy(string(o))
 	case *compute.DisksAggregatedListCall:
 		return c.OrderBy(string(o))
 	case *compute.SubnetworksAggregatedListCall:
 		return c.OrderBy(string(o))
+	case *compute.ForwardingRulesAggregatedListCall:
+		return c.OrderBy(string(o))
+	case *compute.BackendServicesAggregatedListCall:
+		return c.OrderBy(string(o))
+	case *compute.HealthChecksAggregatedListCall:
+		return c.OrderBy(string(o))
 	}
 	return i
 }
@@ -203,6 +291,8 @@ func (o Filter) listCallOptionApply(i interface{}) interface{} {
 		return c.Filter(string(o))
 	case *computeAlpha.ImagesListCall:
 		return c.Filter(string(o))
+	case *computeBeta.ImagesListCall:
+		return c.Filter(string(o))
 	case *compute.ImagesListCall:
 		return c.Filter(string(o))
 	case *computeAlpha.MachineImagesListCall:
@@ -213,44 +303,148 @@ func (o Filter) listCallOptionApply(i interface{}) interface{} {
 		return c.Filter(string(o))
 	case *compute.MachineTypesListCall:
 		return c.Filter(string(o))
+	case *compute.RegionInstanceTemplatesListCall:
+		return c.Filter(string(o))
 	case *compute.ZonesListCall:
 		return c.Filter(string(o))
 	case *compute.InstancesListCall:
 		return c.Filter(string(o))
 	case *compute.DisksListCall:
 		return c.Filter(string(o))
+	case *compute.ReservationsListCall:
+		return c.Filter(string(o))
+	case *compute.InstancesListReferrersCall:
+		return c.Filter(string(o))
+	case *compute.NetworkEndpointGroupsListCall:
+		return c.Filter(string(o))
 	case *compute.NetworksListCall:
 		return c.Filter(string(o))
 	case *compute.SubnetworksListCall:
 		return c.Filter(string(o))
+	case *compute.AddressesListCall:
+		return c.Filter(string(o))
+	case *compute.SnapshotsListCall:
+		return c.Filter(string(o))
 	case *compute.InstancesAggregatedListCall:
 		return c.Filter(string(o))
 	case *compute.DisksAggregatedListCall:
 		return c.Filter(string(o))
 	case *compute.SubnetworksAggregatedListCall:
 		return c.Filter(string(o))
+	case *compute.ForwardingRulesAggregatedListCall:
+		return c.Filter(string(o))
+	case *compute.BackendServicesAggregatedListCall:
+		return c.Filter(string(o))
+	case *compute.HealthChecksAggregatedListCall:
+		return c.Filter(string(o))
 	}
 	return i
 }
 
+// LabelFilter returns a Filter matching resources labeled with key=value.
+func LabelFilter(key, value string) Filter {
+	return Filter(fmt.Sprintf("labels.%s=%s", key, value))
+}
+
+// LabeledResources holds the GCE resources of a project matching a label.
+type LabeledResources struct {
+	Instances []*compute.Instance
+	Disks     []*compute.Disk
+	Images    []*compute.Image
+}
+
 type clientImpl interface {
 	Client
 	zoneOperationsWait(project, zone, name string) error
 	regionOperationsWait(project, region, name string) error
 	globalOperationsWait(project, name string) error
+	zoneOperationsWaitBeta(project, zone, name string) error
+	regionOperationsWaitBeta(project, region, name string) error
+	globalOperationsWaitBeta(project, name string) error
+	waitForImageOperationBeta(project, name string) error
 }
 
 type client struct {
-	i        clientImpl
-	hc       *http.Client
-	raw      *compute.Service
-	rawBeta  *computeBeta.Service
-	rawAlpha *computeAlpha.Service
-}
+	i       clientImpl
+	hc      *http.Client
+	raw     *compute.Service
+	preview *previewServices
+	mtSpecs *machineTypeSpecCache
+	budget  *callBudget
+	// retryCtx interrupts the waits between retries, copies made with Copy
+	// have their own.
+	retryCtx *retryContext
+
+	// opStallTimeout is how long an operation may go without progress
+	// before waiting on it fails, operations are waited on with no time limit
+	// if 0.
+	opStallTimeout time.Duration
+	// opPollStrategy is how pending operations are checked.
+	opPollStrategy OperationPollStrategy
+	// opErrFormatter formats the errors of failed operations,
+	// defaultOperationErrorFormatter is used if nil.
+	opErrFormatter OperationErrorFormatter
+	// imageOpPollInterval is the time between checks of a pending image
+	// operation, defaultImageOperationPollInterval is used if 0.
+	imageOpPollInterval time.Duration
+	// opPollInterval is the time between checks of other pending operations,
+	// operationPollInterval is used if 0.
+	opPollInterval time.Duration
+	// opPollMaxInterval caps the time between checks of a pending operation,
+	// which doubles after each check without progress. The time doesn't grow
+	// if it's not more than the initial interval.
+	opPollMaxInterval time.Duration
+	// opProgress is told about the progress of waited on operations, copies
+	// made with Copy have their own.
+	opProgress *operationProgressHook
+}
+
+type machineTypeSpec struct {
+	vCPUs    int64
+	memoryMb int64
+}
+
+// machineTypeSpecCache caches machine type specs keyed by project, zone and
+// machine type. Specs of a machine type never change so entries are never
+// evicted.
+type machineTypeSpecCache struct {
+	mx    sync.Mutex
+	specs map[string]machineTypeSpec
+}
+
+// retryContext holds the context set by SetRetryContext.
+type retryContext struct {
+	mx  sync.Mutex
+	ctx context.Context
+}
+
+func (r *retryContext) get() context.Context {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// SetRetryContext makes the client stop retrying failed requests once ctx is
+// done, including while waiting to retry them: the call returns the error of
+// the last attempt right away. A nil ctx, the default, restores retrying
+// until the attempts are exhausted.
+func (c *client) SetRetryContext(ctx context.Context) {
+	c.retryCtx.mx.Lock()
+	defer c.retryCtx.mx.Unlock()
+	c.retryCtx.ctx = ctx
+}
+
+// retryWait is the base wait before retrying a failed request, the wait is
+// between 1 and 2 times retryWait, times the retry multiplier.
+var retryWait = 1 * time.Second
 
 // shouldRetryWithWait returns true if the HTTP response / error indicates
-// that the request should be attempted again.
-func shouldRetryWithWait(tripper http.RoundTripper, err error, multiplier int) bool {
+// that the request should be attempted again. It returns false without
+// waiting the full backoff if ctx is done.
+func shouldRetryWithWait(ctx context.Context, tripper http.RoundTripper, err error, multiplier int) bool {
 	if err == nil {
 		return false
 	}
@@ -271,9 +465,10 @@ func shouldRetryWithWait(tripper http.RoundTripper, err error, multiplier int) b
 		// The wait operation can return GOAWAY/ENHANCE_YOUR_CALM messages, so doubling the wait multiplier as it based on the retry count.
 		multiplier = multiplier * 2
 		retry = true
-	case !ok && tkValid:
-		// Not a googleapi.Error and the token is still valid.
-		return false
+	case !ok:
+		// Not a googleapi.Error, retry only if the token is no longer valid.
+		// This was probably a failure to get new token from metadata server.
+		retry = !tkValid
 	case apiErr.Code >= 500 && apiErr.Code <= 599:
 		retry = true
 	case apiErr.Code >= 429:
@@ -291,13 +486,223 @@ func shouldRetryWithWait(tripper http.RoundTripper, err error, multiplier int) b
 		return false
 	}
 
-	sleep := (time.Duration(rand.Intn(1000))*time.Millisecond + 1*time.Second) * time.Duration(multiplier)
-	time.Sleep(sleep)
-	return true
+	sleep := (time.Duration(rand.Int63n(int64(retryWait))) + retryWait) * time.Duration(multiplier)
+	t := time.NewTimer(sleep)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// HTTPSettings tunes the HTTP client used to talk to the Compute API. Zero
+// values keep the defaults.
+type HTTPSettings struct {
+	// Maximum number of idle connections across all hosts.
+	MaxIdleConns int
+	// Maximum number of idle connections kept per host.
+	MaxIdleConnsPerHost int
+	// Time limit for a single request, including reading the response body.
+	// It also bounds the Operations.Wait requests of OperationPollWait,
+	// which the API holds open for up to about 2 minutes until the operation
+	// is done, so a shorter Timeout makes waiting on operations fail. Set it
+	// above 2 minutes, or poll with OperationPollGet.
+	Timeout time.Duration
+	// Don't ask for gzip compressed responses. By default the transport sends
+	// "Accept-Encoding: gzip" and transparently decompresses responses, which
+	// cuts the size of large list responses. Disabling it can help debugging.
+	DisableCompression bool
+}
+
+func (s HTTPSettings) tunesTransport() bool {
+	return s.MaxIdleConns != 0 || s.MaxIdleConnsPerHost != 0 || s.DisableCompression
+}
+
+// baseTransport returns the transport underlying the authenticated transport
+// with the connection pool and compression settings applied.
+func (s HTTPSettings) baseTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	// Same default as the transport created by the API client libraries.
+	t.MaxIdleConnsPerHost = 100
+	if s.MaxIdleConns != 0 {
+		t.MaxIdleConns = s.MaxIdleConns
+	}
+	if s.MaxIdleConnsPerHost != 0 {
+		t.MaxIdleConnsPerHost = s.MaxIdleConnsPerHost
+	}
+	t.DisableCompression = s.DisableCompression
+	return t
+}
+
+// newHTTPClient creates the HTTP client and returns it along with the
+// endpoint set in opts.
+func newHTTPClient(ctx context.Context, settings HTTPSettings, opts ...option.ClientOption) (*http.Client, string, error) {
+	hc, ep, err := transport.NewHTTPClient(ctx, opts...)
+	if err != nil || settings == (HTTPSettings{}) {
+		return hc, ep, err
+	}
+	if settings.tunesTransport() {
+		// Transport settings can't be applied to a client passed in with
+		// option.WithHTTPClient, NewTransport fails in that case.
+		trans, err := htransport.NewTransport(ctx, settings.baseTransport(), opts...)
+		if err != nil {
+			return nil, "", err
+		}
+		hc = &http.Client{Transport: trans}
+	} else {
+		// Don't modify a client that may be shared.
+		c := *hc
+		hc = &c
+	}
+	hc.Timeout = settings.Timeout
+	return hc, ep, nil
+}
+
+// Version is the compute-daisy version sent in the User-Agent of API
+// requests. It can be set at build time with
+// -ldflags "-X github.com/GoogleCloudPlatform/compute-daisy/compute.Version=<version>".
+var Version = "dev"
+
+// userAgentOption is the ClientOption returned by WithUserAgent. NewClient
+// applies it to the API services, which append it to the User-Agent of the
+// API client library, instead of passing it to the transport.
+type userAgentOption struct {
+	option.ClientOption
+	ua string
+}
+
+// WithUserAgent returns a ClientOption appending ua to the User-Agent of the
+// requests made by the client, after the compute-daisy/<version> token.
+func WithUserAgent(ua string) option.ClientOption {
+	return userAgentOption{ua: ua}
+}
+
+// OtherAPIOptions returns the options of opts which clients of other Google
+// APIs, e.g. storage.NewClient, can be created with. The options only
+// understood by NewClient, like WithUserAgent, are removed, other clients
+// would panic on them. WithTokenSource is replaced with
+// option.WithTokenSource.
+func OtherAPIOptions(opts ...option.ClientOption) []option.ClientOption {
+	var other []option.ClientOption
+	for _, o := range opts {
+		switch o := o.(type) {
+		case userAgentOption, operationPollOption, withoutPreviewServicesOption:
+		case tokenSourceOption:
+			other = append(other, option.WithTokenSource(o.ts))
+		default:
+			other = append(other, o)
+		}
+	}
+	return other
+}
+
+// tokenSourceOption is the ClientOption returned by WithTokenSource.
+type tokenSourceOption struct {
+	option.ClientOption
+	ts oauth2.TokenSource
+}
+
+// WithTokenSource returns a ClientOption authenticating the client's requests
+// with tokens from ts, without looking up Application Default Credentials.
+// Unlike option.WithTokenSource, the client's HTTP transport is an
+// oauth2.Transport, which lets failed requests be retried when the token
+// expired.
+func WithTokenSource(ts oauth2.TokenSource) option.ClientOption {
+	return tokenSourceOption{ts: ts}
+}
+
+// operationPollOption is the ClientOption returned by
+// WithOperationPollInterval and WithOperationPollMaxInterval.
+type operationPollOption struct {
+	option.ClientOption
+	interval, maxInterval time.Duration
+}
+
+// WithOperationPollInterval returns a ClientOption setting the time between
+// checks of a pending operation, image operations excepted, see
+// SetImageOperationPollInterval. The default is 1s.
+func WithOperationPollInterval(d time.Duration) option.ClientOption {
+	return operationPollOption{interval: d}
+}
+
+// WithOperationPollMaxInterval returns a ClientOption making the time between
+// checks of a pending operation double after each check without progress, up
+// to d. It is reset when the operation progresses. By default the time
+// doesn't grow.
+func WithOperationPollMaxInterval(d time.Duration) option.ClientOption {
+	return operationPollOption{maxInterval: d}
+}
+
+// withoutPreviewServicesOption is the ClientOption returned by
+// WithoutPreviewServices.
+type withoutPreviewServicesOption struct {
+	option.ClientOption
+}
+
+// WithoutPreviewServices returns a ClientOption disabling the beta and alpha
+// API services of the client, its beta and alpha methods fail instead. By
+// default the services are built on first use of a beta or alpha method.
+func WithoutPreviewServices() option.ClientOption {
+	return withoutPreviewServicesOption{}
 }
 
 // NewClient creates a new Google Cloud Compute client.
 func NewClient(ctx context.Context, opts ...option.ClientOption) (Client, error) {
+	return NewClientWithHTTPSettings(ctx, HTTPSettings{}, opts...)
+}
+
+// NewClientWithHTTPSettings creates a new Google Cloud Compute client whose
+// HTTP client is tuned with settings.
+func NewClientWithHTTPSettings(ctx context.Context, settings HTTPSettings, opts ...option.ClientOption) (Client, error) {
+	var uas []string
+	var ts oauth2.TokenSource
+	var poll operationPollOption
+	var noPreview bool
+	var clientOpts []option.ClientOption
+	for _, o := range opts {
+		switch o := o.(type) {
+		case userAgentOption:
+			uas = append(uas, o.ua)
+		case tokenSourceOption:
+			ts = o.ts
+		case operationPollOption:
+			if o.interval != 0 {
+				poll.interval = o.interval
+			}
+			if o.maxInterval != 0 {
+				poll.maxInterval = o.maxInterval
+			}
+		case withoutPreviewServicesOption:
+			noPreview = true
+		default:
+			clientOpts = append(clientOpts, o)
+		}
+	}
+	if ts != nil {
+		// The settings are applied to the client built here, NewTransport
+		// can't tune a client passed in with option.WithHTTPClient.
+		trans := &oauth2.Transport{Source: oauth2.ReuseTokenSource(nil, ts), Base: settings.baseTransport()}
+		clientOpts = append(clientOpts, option.WithHTTPClient(&http.Client{Transport: trans, Timeout: settings.Timeout}))
+		settings = HTTPSettings{}
+	}
+	hc, ep, err := newHTTPClient(ctx, settings, withClientScopes(clientOpts)...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP API client: %v", err)
+	}
+	c, err := newClient(hc, ep)
+	if err != nil {
+		return nil, err
+	}
+	c.appendUserAgent(uas...)
+	c.opPollInterval, c.opPollMaxInterval = poll.interval, poll.maxInterval
+	c.preview.disabled = noPreview
+	return c, nil
+}
+
+// withClientScopes prepends the client's OAuth scopes to opts.
+func withClientScopes(opts []option.ClientOption) []option.ClientOption {
 	// Set these scopes to be align with compute.NewService
 	o := []option.ClientOption{
 		option.WithScopes(
@@ -309,37 +714,137 @@ func NewClient(ctx context.Context, opts ...option.ClientOption) (Client, error)
 			compute.DevstorageReadWriteScope,
 		),
 	}
-	opts = append(o, opts...)
-	hc, ep, err := transport.NewHTTPClient(ctx, opts...)
-	if err != nil {
-		return nil, fmt.Errorf("error creating HTTP API client: %v", err)
+	return append(o, opts...)
+}
+
+// newClient creates a client making requests with hc to the endpoint ep, or
+// to the default endpoint if ep is empty. The beta and alpha API services are
+// built on first use, most clients only call GA methods.
+func newClient(hc *http.Client, ep string) (*client, error) {
+	c := &client{hc: hc, mtSpecs: &machineTypeSpecCache{specs: map[string]machineTypeSpec{}}, retryCtx: &retryContext{}, opProgress: &operationProgressHook{}}
+	if err := c.newServices(ep); err != nil {
+		return nil, err
 	}
-	rawService, err := compute.New(hc)
+	c.i = c
+	c.appendUserAgent("compute-daisy/" + Version)
+
+	return c, nil
+}
+
+// newServices sets the API services of c, making requests to the endpoint
+// ep, or to the default endpoint if ep is empty, and counting them against a
+// new call budget.
+func (c *client) newServices(ep string) error {
+	// The API services count their requests against the budget, hc is kept
+	// as is to check its token when retrying.
+	c.budget = &callBudget{}
+	shc := withCallBudget(c.hc, c.budget)
+	rawService, err := compute.New(shc)
 	if err != nil {
-		return nil, fmt.Errorf("compute client: %v", err)
+		return fmt.Errorf("compute client: %v", err)
 	}
 	if ep != "" {
 		rawService.BasePath = ep
 	}
-	rawBetaService, err := computeBeta.New(hc)
-	if err != nil {
-		return nil, fmt.Errorf("beta compute client: %v", err)
-	}
-	if ep != "" {
-		rawBetaService.BasePath = ep
-	}
-	rawAlphaService, err := computeAlpha.New(hc)
-	if err != nil {
-		return nil, fmt.Errorf("alpha compute client: %v", err)
-	}
-	if ep != "" {
-		rawAlphaService.BasePath = ep
-	}
+	c.raw = rawService
+	c.preview = &previewServices{hc: shc, ep: ep}
+	return nil
+}
 
-	c := &client{hc: hc, raw: rawService, rawBeta: rawBetaService, rawAlpha: rawAlphaService}
-	c.i = c
+// copy returns a copy of c with its own API services, call budget, retry
+// context and operation progress hook. The HTTP client and the caches are
+// shared.
+func (c *client) copy() (*client, error) {
+	cc := *c
+	cc.retryCtx = &retryContext{}
+	cc.opProgress = &operationProgressHook{}
+	if err := cc.newServices(c.preview.ep); err != nil {
+		return nil, err
+	}
+	cc.raw.BasePath = c.raw.BasePath
+	cc.raw.UserAgent = c.raw.UserAgent
+	cc.preview.ua = c.preview.ua
+	cc.preview.disabled = c.preview.disabled
+	cc.i = &cc
+	return &cc, nil
+}
+
+// Copy returns a copy of the client with its own call budget, retry context
+// and operation progress hook, e.g. for one workflow run, so that setting
+// them doesn't affect the other users of the client. The copy shares the HTTP
+// client and caches of the client, and starts with its settings.
+func (c *client) Copy() (Client, error) {
+	return c.copy()
+}
+
+// previewServices holds the beta and alpha API services of a client, built
+// on first use. Copies of the client have their own.
+type previewServices struct {
+	hc *http.Client
+	ep string
+	// ua is the User-Agent of the services, it must be set before they're
+	// used.
+	ua string
+	// disabled is set by WithoutPreviewServices, the services are never
+	// built.
+	disabled bool
+
+	betaOnce  sync.Once
+	beta      *computeBeta.Service
+	betaErr   error
+	alphaOnce sync.Once
+	alpha     *computeAlpha.Service
+	alphaErr  error
+}
+
+func (p *previewServices) betaService() (*computeBeta.Service, error) {
+	if p.disabled {
+		return nil, errors.New("beta compute client: disabled by WithoutPreviewServices")
+	}
+	p.betaOnce.Do(func() {
+		s, err := computeBeta.New(p.hc)
+		if err != nil {
+			p.betaErr = fmt.Errorf("beta compute client: %v", err)
+			return
+		}
+		if p.ep != "" {
+			s.BasePath = p.ep
+		}
+		s.UserAgent = p.ua
+		p.beta = s
+	})
+	return p.beta, p.betaErr
+}
 
-	return c, nil
+func (p *previewServices) alphaService() (*computeAlpha.Service, error) {
+	if p.disabled {
+		return nil, errors.New("alpha compute client: disabled by WithoutPreviewServices")
+	}
+	p.alphaOnce.Do(func() {
+		s, err := computeAlpha.New(p.hc)
+		if err != nil {
+			p.alphaErr = fmt.Errorf("alpha compute client: %v", err)
+			return
+		}
+		if p.ep != "" {
+			s.BasePath = p.ep
+		}
+		s.UserAgent = p.ua
+		p.alpha = s
+	})
+	return p.alpha, p.alphaErr
+}
+
+// appendUserAgent appends uas to the User-Agent of the GA, beta and alpha
+// API services.
+func (c *client) appendUserAgent(uas ...string) {
+	for _, ua := range uas {
+		if ua == "" {
+			continue
+		}
+		c.raw.UserAgent = strings.TrimSpace(c.raw.UserAgent + " " + ua)
+	}
+	c.preview.ua = c.raw.UserAgent
 }
 
 // BasePath returns the base path for this client.
@@ -347,66 +852,396 @@ func (c *client) BasePath() string {
 	return c.raw.BasePath
 }
 
-type operationGetterFunc func() (*compute.Operation, error)
+// Close closes the idle connections of the client's HTTP transport. It is
+// safe to call more than once, the client stays usable and opens new
+// connections as needed.
+func (c *client) Close() error {
+	if c.hc != nil {
+		c.hc.CloseIdleConnections()
+	}
+	return nil
+}
+
+// OperationPollStrategy is how the client checks on pending operations.
+type OperationPollStrategy int
+
+const (
+	// OperationPollWait uses the Operations.Wait method, which returns when
+	// the operation is done or after about 2 minutes. This is the default.
+	OperationPollWait OperationPollStrategy = iota
+	// OperationPollGet polls with Operations.Get, only reading the fields
+	// needed to track the operation. This saves bandwidth for workflows that
+	// issue many operations, at the cost of more requests.
+	OperationPollGet
+)
+
+// operationPollFields are the operation fields read by OperationPollGet.
+const operationPollFields = "status,progress,error,targetLink,operationType"
+
+// operation is the state of an operation, independent of the API version it
+// was read with.
+type operation struct {
+	status     string
+	progress   int64
+	targetLink string
+	opType     string
+	errs       []*compute.OperationErrorErrors
+	// The operation as read from the API, including its version specific
+	// fields, reported in errors.
+	raw interface{}
+}
+
+func gaOperation(op *compute.Operation) *operation {
+	o := &operation{status: op.Status, progress: op.Progress, targetLink: op.TargetLink, opType: op.OperationType, raw: op}
+	if op.Error != nil {
+		o.errs = op.Error.Errors
+		if o.errs == nil {
+			o.errs = []*compute.OperationErrorErrors{}
+		}
+	}
+	return o
+}
+
+func betaOperation(op *computeBeta.Operation) *operation {
+	o := &operation{status: op.Status, progress: op.Progress, targetLink: op.TargetLink, opType: op.OperationType, raw: op}
+	if op.Error != nil {
+		o.errs = []*compute.OperationErrorErrors{}
+		for _, e := range op.Error.Errors {
+			o.errs = append(o.errs, &compute.OperationErrorErrors{Code: e.Code, Location: e.Location, Message: e.Message})
+		}
+	}
+	return o
+}
+
+type operationGetterFunc func() (*operation, error)
 
 func (c *client) zoneOperationsWait(project, zone, name string) error {
-	return c.operationsWaitHelper(project, name, func() (op *compute.Operation, err error) {
-		op, err = c.Retry(c.raw.ZoneOperations.Wait(project, zone, name).Do)
+	return c.operationsWaitHelper(project, name, c.operationPollInterval(), func() (*operation, error) {
+		var op *compute.Operation
+		var err error
+		if c.opPollStrategy == OperationPollGet {
+			op, err = c.Retry(c.raw.ZoneOperations.Get(project, zone, name).Fields(operationPollFields).Do)
+		} else {
+			op, err = c.Retry(c.raw.ZoneOperations.Wait(project, zone, name).Do)
+		}
 		if err != nil {
-			err = fmt.Errorf("failed to get zone operation %s: %v", name, err)
+			return nil, fmt.Errorf("failed to get zone operation %s: %v", name, err)
 		}
-		return op, err
+		return gaOperation(op), nil
 	})
 }
 
 func (c *client) regionOperationsWait(project, region, name string) error {
-	return c.operationsWaitHelper(project, name, func() (op *compute.Operation, err error) {
-		op, err = c.Retry(c.raw.RegionOperations.Wait(project, region, name).Do)
+	return c.operationsWaitHelper(project, name, c.operationPollInterval(), func() (*operation, error) {
+		var op *compute.Operation
+		var err error
+		if c.opPollStrategy == OperationPollGet {
+			op, err = c.Retry(c.raw.RegionOperations.Get(project, region, name).Fields(operationPollFields).Do)
+		} else {
+			op, err = c.Retry(c.raw.RegionOperations.Wait(project, region, name).Do)
+		}
 		if err != nil {
-			err = fmt.Errorf("failed to get region operation %s: %v", name, err)
+			return nil, fmt.Errorf("failed to get region operation %s: %v", name, err)
 		}
-		return op, err
+		return gaOperation(op), nil
 	})
 }
 
 func (c *client) globalOperationsWait(project, name string) error {
-	return c.operationsWaitHelper(project, name, func() (op *compute.Operation, err error) {
-		op, err = c.Retry(c.raw.GlobalOperations.Wait(project, name).Do)
+	return c.operationsWaitHelper(project, name, c.operationPollInterval(), c.globalOperationGetter(project, name))
+}
+
+func (c *client) globalOperationGetter(project, name string) operationGetterFunc {
+	return func() (*operation, error) {
+		var op *compute.Operation
+		var err error
+		if c.opPollStrategy == OperationPollGet {
+			op, err = c.Retry(c.raw.GlobalOperations.Get(project, name).Fields(operationPollFields).Do)
+		} else {
+			op, err = c.Retry(c.raw.GlobalOperations.Wait(project, name).Do)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get global operation %s: %v", name, err)
+		}
+		return gaOperation(op), nil
+	}
+}
+
+// zoneOperationsWaitBeta waits for a zone operation returned by a beta API
+// mutation, reading it with the beta API.
+func (c *client) zoneOperationsWaitBeta(project, zone, name string) error {
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return err
+	}
+	return c.operationsWaitHelper(project, name, c.operationPollInterval(), func() (*operation, error) {
+		var op *computeBeta.Operation
+		var err error
+		if c.opPollStrategy == OperationPollGet {
+			op, err = c.RetryBeta(rawBeta.ZoneOperations.Get(project, zone, name).Fields(operationPollFields).Do)
+		} else {
+			op, err = c.RetryBeta(rawBeta.ZoneOperations.Wait(project, zone, name).Do)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get zone operation %s: %v", name, err)
+		}
+		return betaOperation(op), nil
+	})
+}
+
+// regionOperationsWaitBeta waits for a region operation returned by a beta
+// API mutation, reading it with the beta API.
+func (c *client) regionOperationsWaitBeta(project, region, name string) error {
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return err
+	}
+	return c.operationsWaitHelper(project, name, c.operationPollInterval(), func() (*operation, error) {
+		var op *computeBeta.Operation
+		var err error
+		if c.opPollStrategy == OperationPollGet {
+			op, err = c.RetryBeta(rawBeta.RegionOperations.Get(project, region, name).Fields(operationPollFields).Do)
+		} else {
+			op, err = c.RetryBeta(rawBeta.RegionOperations.Wait(project, region, name).Do)
+		}
 		if err != nil {
-			err = fmt.Errorf("failed to get global operation %s: %v", name, err)
+			return nil, fmt.Errorf("failed to get region operation %s: %v", name, err)
 		}
-		return op, err
+		return betaOperation(op), nil
 	})
 }
 
+// globalOperationsWaitBeta waits for a global operation returned by a beta
+// API mutation, reading it with the beta API.
+func (c *client) globalOperationsWaitBeta(project, name string) error {
+	return c.operationsWaitHelper(project, name, c.operationPollInterval(), c.globalOperationGetterBeta(project, name))
+}
+
+func (c *client) globalOperationGetterBeta(project, name string) operationGetterFunc {
+	return func() (*operation, error) {
+		rawBeta, err := c.preview.betaService()
+		if err != nil {
+			return nil, err
+		}
+		var op *computeBeta.Operation
+		if c.opPollStrategy == OperationPollGet {
+			op, err = c.RetryBeta(rawBeta.GlobalOperations.Get(project, name).Fields(operationPollFields).Do)
+		} else {
+			op, err = c.RetryBeta(rawBeta.GlobalOperations.Wait(project, name).Do)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get global operation %s: %v", name, err)
+		}
+		return betaOperation(op), nil
+	}
+}
+
+// WaitForImageOperation waits for a global image operation like any other
+// global operation, but checks on it less often. Image operations rarely
+// complete in under a minute, so polling them every second only adds API
+// load.
+func (c *client) WaitForImageOperation(project, name string) error {
+	return c.operationsWaitHelper(project, name, c.imageOperationPollInterval(), c.globalOperationGetter(project, name))
+}
+
+// waitForImageOperationBeta is WaitForImageOperation for an image operation
+// returned by a beta API mutation, reading it with the beta API.
+func (c *client) waitForImageOperationBeta(project, name string) error {
+	return c.operationsWaitHelper(project, name, c.imageOperationPollInterval(), c.globalOperationGetterBeta(project, name))
+}
+
+func (c *client) operationPollInterval() time.Duration {
+	if c.opPollInterval <= 0 {
+		return operationPollInterval
+	}
+	return c.opPollInterval
+}
+
+// OperationPollInterval returns the time between checks of a pending
+// operation, image operations excepted, and the time it may grow to, as set
+// by WithOperationPollInterval and WithOperationPollMaxInterval.
+func (c *client) OperationPollInterval() (time.Duration, time.Duration) {
+	interval := c.operationPollInterval()
+	if c.opPollMaxInterval > interval {
+		return interval, c.opPollMaxInterval
+	}
+	return interval, interval
+}
+
+func (c *client) imageOperationPollInterval() time.Duration {
+	if c.imageOpPollInterval == 0 {
+		return defaultImageOperationPollInterval
+	}
+	return c.imageOpPollInterval
+}
+
+// operationPollInterval is the default time between checks of a pending
+// operation.
+var operationPollInterval = 1 * time.Second
+
+// defaultImageOperationPollInterval is the time between checks of a pending
+// image operation.
+var defaultImageOperationPollInterval = 10 * time.Second
+
+// SetImageOperationPollInterval sets the time between checks of a pending
+// image operation in WaitForImageOperation. A d of 0 restores the default of
+// 10s.
+func (c *client) SetImageOperationPollInterval(d time.Duration) {
+	c.imageOpPollInterval = d
+}
+
+// SetOperationStallTimeout makes waiting on an operation fail if its progress
+// doesn't increase for d. Slow operations which are still progressing are
+// waited on for as long as they take. A d of 0, the default, disables the
+// timeout.
+func (c *client) SetOperationStallTimeout(d time.Duration) {
+	c.opStallTimeout = d
+}
+
+// SetOperationPollStrategy sets how the client checks on pending operations.
+func (c *client) SetOperationPollStrategy(strategy OperationPollStrategy) {
+	c.opPollStrategy = strategy
+}
+
+// SetOperationErrorFormatter sets how the errors of failed operations are
+// formatted in the error returned for them. A nil f restores the default
+// format.
+func (c *client) SetOperationErrorFormatter(f OperationErrorFormatter) {
+	c.opErrFormatter = f
+}
+
+// OperationProgress is the progress of an operation the client waits on.
+type OperationProgress struct {
+	Project string
+	// Name is the name of the operation.
+	Name string
+	// TargetLink is the URL of the resource the operation changes.
+	TargetLink string
+	// OperationType is the type of the operation, e.g. insert or delete.
+	OperationType string
+	// Progress is the progress of the operation, from 0 to 100.
+	Progress int64
+}
+
+// operationProgressHook holds the function set by SetOperationProgressHook.
+type operationProgressHook struct {
+	mx sync.Mutex
+	f  func(OperationProgress)
+}
+
+func (h *operationProgressHook) report(p OperationProgress) {
+	h.mx.Lock()
+	f := h.f
+	h.mx.Unlock()
+	if f != nil {
+		f(p)
+	}
+}
+
+// SetOperationProgressHook makes the client call f whenever the progress of
+// an operation it waits on increases, and once the operation is done. f is
+// called from the goroutine waiting on the operation and must not block. A
+// nil f, the default, removes the hook.
+func (c *client) SetOperationProgressHook(f func(OperationProgress)) {
+	c.opProgress.mx.Lock()
+	defer c.opProgress.mx.Unlock()
+	c.opProgress.f = f
+}
+
 // OperationErrorCodeFormat is the format of operation error code.
+//
+// Deprecated: use SetOperationErrorFormatter, which is safe to use
+// concurrently and per client. OperationErrorCodeFormat is still honored by
+// the default formatter.
 var OperationErrorCodeFormat = "Code: %s"
 
 var operationErrorMessageFormat = "Message: %s"
 
-func (c *client) operationsWaitHelper(project, name string, getOperation operationGetterFunc) error {
+// OperationErrorFormatter formats the errors of a failed operation.
+type OperationErrorFormatter func(errs []*compute.OperationErrorErrors) string
+
+// defaultOperationErrorFormatter formats each error's code and message on
+// their own lines.
+func defaultOperationErrorFormatter(errs []*compute.OperationErrorErrors) string {
+	var operrs string
+	for _, operr := range errs {
+		operrs = operrs + fmt.Sprintf(
+			fmt.Sprintf("\n%v\n%v", OperationErrorCodeFormat, operationErrorMessageFormat),
+			operr.Code, operr.Message)
+	}
+	return operrs
+}
+
+// operationError is returned for a failed operation. It keeps the error codes
+// so callers don't depend on how the errors are formatted.
+type operationError struct {
+	msg   string
+	codes []string
+}
+
+func (e *operationError) Error() string {
+	return e.msg
+}
+
+// HasOperationErrorCode returns whether err is an operation failure with an
+// error of the given code, e.g. "QUOTA_EXCEEDED".
+func HasOperationErrorCode(err error, code string) bool {
+	var opErr *operationError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	for _, c := range opErr.codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *client) operationsWaitHelper(project, name string, interval time.Duration, getOperation operationGetterFunc) error {
+	progress := int64(-1)
+	lastProgress := time.Now()
+	wait := interval
 	for {
 		op, err := getOperation()
 		if err != nil {
 			return err
 		}
 
-		switch op.Status {
+		switch op.status {
 		case "PENDING", "RUNNING":
-			time.Sleep(1 * time.Second)
+			if op.progress > progress {
+				progress = op.progress
+				lastProgress = time.Now()
+				c.opProgress.report(OperationProgress{Project: project, Name: name, TargetLink: op.targetLink, OperationType: op.opType, Progress: progress})
+				wait = interval
+			} else if c.opStallTimeout > 0 && time.Since(lastProgress) >= c.opStallTimeout {
+				return fmt.Errorf("operation %s made no progress for %v, stalled at %d%%: %+v", name, c.opStallTimeout, op.progress, op.raw)
+			} else if wait < c.opPollMaxInterval {
+				if wait *= 2; wait > c.opPollMaxInterval {
+					wait = c.opPollMaxInterval
+				}
+			}
+			time.Sleep(wait)
 			continue
 		case "DONE":
-			if op.Error != nil {
-				var operrs string
-				for _, operr := range op.Error.Errors {
-					operrs = operrs + fmt.Sprintf(
-						fmt.Sprintf("\n%v\n%v", OperationErrorCodeFormat, operationErrorMessageFormat),
-						operr.Code, operr.Message)
+			c.opProgress.report(OperationProgress{Project: project, Name: name, TargetLink: op.targetLink, OperationType: op.opType, Progress: 100})
+			if op.errs != nil {
+				format := c.opErrFormatter
+				if format == nil {
+					format = defaultOperationErrorFormatter
+				}
+				var codes []string
+				for _, operr := range op.errs {
+					codes = append(codes, operr.Code)
+				}
+				return &operationError{
+					msg:   fmt.Sprintf("operation failed %+v: %s", op.raw, format(op.errs)),
+					codes: codes,
 				}
-				return fmt.Errorf("operation failed %+v: %s", op, operrs)
 			}
 		default:
-			return fmt.Errorf("unknown operation status %q: %+v", op.Status, op)
+			return fmt.Errorf("unknown operation status %q: %+v", op.status, op.raw)
 		}
 		return nil
 	}
@@ -421,7 +1256,7 @@ func (c *client) Retry(f func(opts ...googleapi.CallOption) (*compute.Operation,
 		if err == nil {
 			return op, nil
 		}
-		if !shouldRetryWithWait(c.hc.Transport, err, i) {
+		if !shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, i) {
 			return nil, err
 		}
 	}
@@ -437,7 +1272,7 @@ func (c *client) RetryBeta(f func(opts ...googleapi.CallOption) (*computeBeta.Op
 		if err == nil {
 			return op, nil
 		}
-		if !shouldRetryWithWait(c.hc.Transport, err, i) {
+		if !shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, i) {
 			return nil, err
 		}
 	}
@@ -453,7 +1288,7 @@ func (c *client) RetryAlpha(f func(opts ...googleapi.CallOption) (*computeAlpha.
 		if err == nil {
 			return op, nil
 		}
-		if !shouldRetryWithWait(c.hc.Transport, err, i) {
+		if !shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, i) {
 			return nil, err
 		}
 	}
@@ -499,9 +1334,24 @@ func (c *client) CreateDisk(project, zone string, d *compute.Disk) error {
 	return nil
 }
 
+// BulkInsertDisk creates a set of GCE persistent disks in a single request.
+// Only the single operation returned by the request is waited on.
+func (c *client) BulkInsertDisk(project, zone string, req *compute.BulkInsertDiskResource) error {
+	op, err := c.Retry(c.raw.Disks.BulkInsert(project, zone, req).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
 // CreateDiskAlpha creates a GCE persistent disk.
 func (c *client) CreateDiskAlpha(project, zone string, d *computeAlpha.Disk) error {
-	op, err := c.RetryAlpha(c.rawAlpha.Disks.Insert(project, zone, d).Do)
+	rawAlpha, err := c.preview.alphaService()
+	if err != nil {
+		return err
+	}
+	op, err := c.RetryAlpha(rawAlpha.Disks.Insert(project, zone, d).Do)
 	if err != nil {
 		return err
 	}
@@ -520,12 +1370,16 @@ func (c *client) CreateDiskAlpha(project, zone string, d *computeAlpha.Disk) err
 
 // CreateDiskBeta creates a GCE persistent disk.
 func (c *client) CreateDiskBeta(project, zone string, d *computeBeta.Disk) error {
-	op, err := c.RetryBeta(c.rawBeta.Disks.Insert(project, zone, d).Do)
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return err
+	}
+	op, err := c.RetryBeta(rawBeta.Disks.Insert(project, zone, d).Do)
 	if err != nil {
 		return err
 	}
 
-	if err := c.i.zoneOperationsWait(project, zone, op.Name); err != nil {
+	if err := c.i.zoneOperationsWaitBeta(project, zone, op.Name); err != nil {
 		return err
 	}
 
@@ -537,6 +1391,25 @@ func (c *client) CreateDiskBeta(project, zone string, d *computeBeta.Disk) error
 	return nil
 }
 
+// CreateAddress reserves a GCE address.
+func (c *client) CreateAddress(project, region string, a *compute.Address) error {
+	op, err := c.Retry(c.raw.Addresses.Insert(project, region, a).Do)
+	if err != nil {
+		return err
+	}
+
+	if err := c.i.regionOperationsWait(project, region, op.Name); err != nil {
+		return err
+	}
+
+	var createdAddress *compute.Address
+	if createdAddress, err = c.i.GetAddress(project, region, a.Name); err != nil {
+		return err
+	}
+	*a = *createdAddress
+	return nil
+}
+
 // CreateForwardingRule creates a GCE forwarding rule.
 func (c *client) CreateForwardingRule(project, region string, fr *compute.ForwardingRule) error {
 	op, err := c.Retry(c.raw.ForwardingRules.Insert(project, region, fr).Do)
@@ -579,12 +1452,26 @@ func (c *client) CreateFirewallRule(project string, i *compute.Firewall) error {
 // url (full or partial) to the source disk, sourceFile is the full Google
 // Cloud Storage URL where the disk image is stored.
 func (c *client) CreateImage(project string, i *compute.Image) error {
-	op, err := c.Retry(c.raw.Images.Insert(project, i).Do)
+	return c.createImage(project, i, false)
+}
+
+// ForceCreateImage creates a GCE image like CreateImage, even if the source
+// disk is attached to a running instance.
+func (c *client) ForceCreateImage(project string, i *compute.Image) error {
+	return c.createImage(project, i, true)
+}
+
+func (c *client) createImage(project string, i *compute.Image, force bool) error {
+	call := c.raw.Images.Insert(project, i)
+	if force {
+		call = call.ForceCreate(true)
+	}
+	op, err := c.Retry(call.Do)
 	if err != nil {
 		return err
 	}
 
-	if err := c.i.globalOperationsWait(project, op.Name); err != nil {
+	if err := c.i.WaitForImageOperation(project, op.Name); err != nil {
 		return err
 	}
 
@@ -601,12 +1488,16 @@ func (c *client) CreateImage(project string, i *compute.Image) error {
 // url (full or partial) to the source disk, sourceFile is the full Google
 // Cloud Storage URL where the disk image is stored.
 func (c *client) CreateImageBeta(project string, i *computeBeta.Image) error {
-	op, err := c.RetryBeta(c.rawBeta.Images.Insert(project, i).Do)
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return err
+	}
+	op, err := c.RetryBeta(rawBeta.Images.Insert(project, i).Do)
 	if err != nil {
 		return err
 	}
 
-	if err := c.i.globalOperationsWait(project, op.Name); err != nil {
+	if err := c.i.waitForImageOperationBeta(project, op.Name); err != nil {
 		return err
 	}
 
@@ -623,12 +1514,16 @@ func (c *client) CreateImageBeta(project string, i *computeBeta.Image) error {
 // url (full or partial) to the source disk, sourceFile is the full Google
 // Cloud Storage URL where the disk image is stored.
 func (c *client) CreateImageAlpha(project string, i *computeAlpha.Image) error {
-	op, err := c.RetryAlpha(c.rawAlpha.Images.Insert(project, i).Do)
+	rawAlpha, err := c.preview.alphaService()
+	if err != nil {
+		return err
+	}
+	op, err := c.RetryAlpha(rawAlpha.Images.Insert(project, i).Do)
 	if err != nil {
 		return err
 	}
 
-	if err := c.i.globalOperationsWait(project, op.Name); err != nil {
+	if err := c.i.WaitForImageOperation(project, op.Name); err != nil {
 		return err
 	}
 
@@ -644,7 +1539,7 @@ func (c *client) CreateImageAlpha(project string, i *computeAlpha.Image) error {
 func (c *client) DeleteRegionTargetHTTPProxy(project, region, name string) error {
 	op, err := c.Retry(c.raw.RegionTargetHttpProxies.Delete(project, region, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 	return c.i.regionOperationsWait(project, region, op.Name)
 }
@@ -669,7 +1564,7 @@ func (c *client) CreateRegionTargetHTTPProxy(project, region string, p *compute.
 // GetRegionTargetHTTPProxy gets a GCE RegionTargetHTTPProxy.
 func (c *client) GetRegionTargetHTTPProxy(project, region, name string) (*compute.TargetHttpProxy, error) {
 	i, err := c.raw.RegionTargetHttpProxies.Get(project, region, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.RegionTargetHttpProxies.Get(project, region, name).Do()
 	}
 	return i, err
@@ -684,7 +1579,7 @@ func (c *client) ListRegionTargetHTTPProxies(project, region string, opts ...Lis
 		call = opt.listCallOptionApply(call).(*compute.RegionTargetHttpProxiesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -703,7 +1598,7 @@ func (c *client) ListRegionTargetHTTPProxies(project, region string, opts ...Lis
 func (c *client) DeleteRegionBackendService(project, region, name string) error {
 	op, err := c.Retry(c.raw.RegionBackendServices.Delete(project, region, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 	return c.i.regionOperationsWait(project, region, op.Name)
 }
@@ -728,12 +1623,41 @@ func (c *client) CreateRegionBackendService(project, region string, p *compute.B
 // GetRegionBackendService gets a GCE RegionBackendService.
 func (c *client) GetRegionBackendService(project, region, name string) (*compute.BackendService, error) {
 	i, err := c.raw.RegionBackendServices.Get(project, region, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.RegionBackendServices.Get(project, region, name).Do()
 	}
 	return i, err
 }
 
+// GetBackendService gets a global GCE BackendService.
+func (c *client) GetBackendService(project, name string) (*compute.BackendService, error) {
+	i, err := c.raw.BackendServices.Get(project, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.BackendServices.Get(project, name).Do()
+	}
+	return i, err
+}
+
+// GetBackendServiceHealth gets the health of the endpoints of a backend group
+// of a GCE RegionBackendService.
+func (c *client) GetBackendServiceHealth(project, region, backendService string, group *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error) {
+	h, err := c.raw.RegionBackendServices.GetHealth(project, region, backendService, group).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.RegionBackendServices.GetHealth(project, region, backendService, group).Do()
+	}
+	return h, err
+}
+
+// GetGlobalBackendServiceHealth gets the health of the endpoints of a backend
+// group of a global GCE BackendService.
+func (c *client) GetGlobalBackendServiceHealth(project, backendService string, group *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error) {
+	h, err := c.raw.BackendServices.GetHealth(project, backendService, group).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.BackendServices.GetHealth(project, backendService, group).Do()
+	}
+	return h, err
+}
+
 // ListRegionBackendServices lists GCE RegionBackendServices.
 func (c *client) ListRegionBackendServices(project, region string, opts ...ListCallOption) ([]*compute.BackendService, error) {
 	var is []*compute.BackendService
@@ -743,7 +1667,7 @@ func (c *client) ListRegionBackendServices(project, region string, opts ...ListC
 		call = opt.listCallOptionApply(call).(*compute.RegionBackendServicesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -758,11 +1682,37 @@ func (c *client) ListRegionBackendServices(project, region string, opts ...ListC
 	}
 }
 
+// AggregatedListBackendServices gets an aggregated list of GCE BackendServices, both global and
+// regional.
+func (c *client) AggregatedListBackendServices(project string, opts ...ListCallOption) ([]*compute.BackendService, error) {
+	var bss []*compute.BackendService
+	var pt string
+	call := c.raw.BackendServices.AggregatedList(project)
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.BackendServicesAggregatedListCall)
+	}
+	for ail, err := call.PageToken(pt).Do(); ; ail, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+			ail, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, sl := range ail.Items {
+			bss = append(bss, sl.BackendServices...)
+		}
+		if ail.NextPageToken == "" {
+			return bss, nil
+		}
+		pt = ail.NextPageToken
+	}
+}
+
 // DeleteRegionURLMap deletes a GCE RegionURLMap.
 func (c *client) DeleteRegionURLMap(project, region, name string) error {
 	op, err := c.Retry(c.raw.RegionUrlMaps.Delete(project, region, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 	return c.i.regionOperationsWait(project, region, op.Name)
 }
@@ -787,7 +1737,7 @@ func (c *client) CreateRegionURLMap(project, region string, p *compute.UrlMap) e
 // GetRegionURLMap gets a GCE RegionURLMap.
 func (c *client) GetRegionURLMap(project, region, name string) (*compute.UrlMap, error) {
 	i, err := c.raw.RegionUrlMaps.Get(project, region, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.RegionUrlMaps.Get(project, region, name).Do()
 	}
 	return i, err
@@ -802,7 +1752,7 @@ func (c *client) ListRegionURLMaps(project, region string, opts ...ListCallOptio
 		call = opt.listCallOptionApply(call).(*compute.RegionUrlMapsListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -821,7 +1771,7 @@ func (c *client) ListRegionURLMaps(project, region string, opts ...ListCallOptio
 func (c *client) DeleteRegionHealthCheck(project, region, name string) error {
 	op, err := c.Retry(c.raw.RegionHealthChecks.Delete(project, region, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 	return c.i.regionOperationsWait(project, region, op.Name)
 }
@@ -846,7 +1796,7 @@ func (c *client) CreateRegionHealthCheck(project, region string, p *compute.Heal
 // GetRegionHealthCheck gets a GCE RegionHealthCheck.
 func (c *client) GetRegionHealthCheck(project, region, name string) (*compute.HealthCheck, error) {
 	i, err := c.raw.RegionHealthChecks.Get(project, region, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.RegionHealthChecks.Get(project, region, name).Do()
 	}
 	return i, err
@@ -861,7 +1811,7 @@ func (c *client) ListRegionHealthChecks(project, region string, opts ...ListCall
 		call = opt.listCallOptionApply(call).(*compute.RegionHealthChecksListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -876,11 +1826,37 @@ func (c *client) ListRegionHealthChecks(project, region string, opts ...ListCall
 	}
 }
 
+// AggregatedListHealthChecks gets an aggregated list of GCE HealthChecks, both global and
+// regional.
+func (c *client) AggregatedListHealthChecks(project string, opts ...ListCallOption) ([]*compute.HealthCheck, error) {
+	var hcs []*compute.HealthCheck
+	var pt string
+	call := c.raw.HealthChecks.AggregatedList(project)
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.HealthChecksAggregatedListCall)
+	}
+	for ail, err := call.PageToken(pt).Do(); ; ail, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+			ail, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, sl := range ail.Items {
+			hcs = append(hcs, sl.HealthChecks...)
+		}
+		if ail.NextPageToken == "" {
+			return hcs, nil
+		}
+		pt = ail.NextPageToken
+	}
+}
+
 // DeleteRegionNetworkEndpointGroup deletes a GCE RegionNetworkEndpointGroup.
 func (c *client) DeleteRegionNetworkEndpointGroup(project, region, name string) error {
 	op, err := c.Retry(c.raw.RegionNetworkEndpointGroups.Delete(project, region, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 	return c.i.regionOperationsWait(project, region, op.Name)
 }
@@ -905,7 +1881,7 @@ func (c *client) CreateRegionNetworkEndpointGroup(project, region string, p *com
 // GetRegionNetworkEndpointGroup gets a GCE RegionNetworkEndpointGroup.
 func (c *client) GetRegionNetworkEndpointGroup(project, region, name string) (*compute.NetworkEndpointGroup, error) {
 	i, err := c.raw.RegionNetworkEndpointGroups.Get(project, region, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.RegionNetworkEndpointGroups.Get(project, region, name).Do()
 	}
 	return i, err
@@ -920,7 +1896,7 @@ func (c *client) ListRegionNetworkEndpointGroups(project, region string, opts ..
 		call = opt.listCallOptionApply(call).(*compute.RegionNetworkEndpointGroupsListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -935,6 +1911,83 @@ func (c *client) ListRegionNetworkEndpointGroups(project, region string, opts ..
 	}
 }
 
+// DeleteNetworkEndpointGroup deletes a zonal GCE NetworkEndpointGroup.
+func (c *client) DeleteNetworkEndpointGroup(project, zone, name string) error {
+	op, err := c.Retry(c.raw.NetworkEndpointGroups.Delete(project, zone, name).Do)
+	if err != nil {
+		return notFoundErr(err)
+	}
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// CreateNetworkEndpointGroup creates a zonal GCE NetworkEndpointGroup.
+func (c *client) CreateNetworkEndpointGroup(project, zone string, n *compute.NetworkEndpointGroup) error {
+	op, err := c.Retry(c.raw.NetworkEndpointGroups.Insert(project, zone, n).Do)
+	if err != nil {
+		return err
+	}
+	if err := c.i.zoneOperationsWait(project, zone, op.Name); err != nil {
+		return err
+	}
+	var createdNetworkEndpointGroup *compute.NetworkEndpointGroup
+	if createdNetworkEndpointGroup, err = c.i.GetNetworkEndpointGroup(project, zone, n.Name); err != nil {
+		return err
+	}
+	*n = *createdNetworkEndpointGroup
+	return nil
+}
+
+// GetNetworkEndpointGroup gets a zonal GCE NetworkEndpointGroup.
+func (c *client) GetNetworkEndpointGroup(project, zone, name string) (*compute.NetworkEndpointGroup, error) {
+	n, err := c.raw.NetworkEndpointGroups.Get(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.NetworkEndpointGroups.Get(project, zone, name).Do()
+	}
+	return n, err
+}
+
+// ListNetworkEndpointGroups lists zonal GCE NetworkEndpointGroups.
+func (c *client) ListNetworkEndpointGroups(project, zone string, opts ...ListCallOption) ([]*compute.NetworkEndpointGroup, error) {
+	var ns []*compute.NetworkEndpointGroup
+	var pt string
+	call := c.raw.NetworkEndpointGroups.List(project, zone)
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.NetworkEndpointGroupsListCall)
+	}
+	for nl, err := call.PageToken(pt).Do(); ; nl, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+			nl, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		ns = append(ns, nl.Items...)
+
+		if nl.NextPageToken == "" {
+			return ns, nil
+		}
+		pt = nl.NextPageToken
+	}
+}
+
+// AttachNetworkEndpoints attaches network endpoints to a zonal GCE NetworkEndpointGroup.
+func (c *client) AttachNetworkEndpoints(project, zone, neg string, req *compute.NetworkEndpointGroupsAttachEndpointsRequest) error {
+	op, err := c.Retry(c.raw.NetworkEndpointGroups.AttachNetworkEndpoints(project, zone, neg, req).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// DetachNetworkEndpoints detaches network endpoints from a zonal GCE NetworkEndpointGroup.
+func (c *client) DetachNetworkEndpoints(project, zone, neg string, req *compute.NetworkEndpointGroupsDetachEndpointsRequest) error {
+	op, err := c.Retry(c.raw.NetworkEndpointGroups.DetachNetworkEndpoints(project, zone, neg, req).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
 func (c *client) CreateInstance(project, zone string, i *compute.Instance) error {
 	op, err := c.Retry(c.raw.Instances.Insert(project, zone, i).Do)
 	if err != nil {
@@ -955,7 +2008,11 @@ func (c *client) CreateInstance(project, zone string, i *compute.Instance) error
 
 // CreateInstanceAlpha creates a GCE image using Alpha API.
 func (c *client) CreateInstanceAlpha(project, zone string, i *computeAlpha.Instance) error {
-	op, err := c.RetryAlpha(c.rawAlpha.Instances.Insert(project, zone, i).Do)
+	rawAlpha, err := c.preview.alphaService()
+	if err != nil {
+		return err
+	}
+	op, err := c.RetryAlpha(rawAlpha.Instances.Insert(project, zone, i).Do)
 	if err != nil {
 		return err
 	}
@@ -974,12 +2031,16 @@ func (c *client) CreateInstanceAlpha(project, zone string, i *computeAlpha.Insta
 
 // CreateInstanceBeta creates a GCE image using Beta API.
 func (c *client) CreateInstanceBeta(project, zone string, i *computeBeta.Instance) error {
-	op, err := c.RetryBeta(c.rawBeta.Instances.Insert(project, zone, i).Do)
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return err
+	}
+	op, err := c.RetryBeta(rawBeta.Instances.Insert(project, zone, i).Do)
 	if err != nil {
 		return err
 	}
 
-	if err := c.i.zoneOperationsWait(project, zone, op.Name); err != nil {
+	if err := c.i.zoneOperationsWaitBeta(project, zone, op.Name); err != nil {
 		return err
 	}
 
@@ -1009,6 +2070,28 @@ func (c *client) CreateNetwork(project string, n *compute.Network) error {
 	return nil
 }
 
+// PatchNetwork updates a GCE network with the fields set in n, such as its
+// routing config.
+func (c *client) PatchNetwork(project, name string, n *compute.Network) error {
+	op, err := c.Retry(c.raw.Networks.Patch(project, name, n).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.globalOperationsWait(project, op.Name)
+}
+
+// SwitchNetworkToCustomMode switches an auto mode GCE network to custom
+// subnet mode, its subnetworks are kept.
+func (c *client) SwitchNetworkToCustomMode(project, name string) error {
+	op, err := c.Retry(c.raw.Networks.SwitchToCustomMode(project, name).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.globalOperationsWait(project, op.Name)
+}
+
 func (c *client) CreateSubnetwork(project, region string, n *compute.Subnetwork) error {
 	op, err := c.Retry(c.raw.Subnetworks.Insert(project, region, n).Do)
 	if err != nil {
@@ -1051,7 +2134,7 @@ func (c *client) CreateTargetInstance(project, zone string, ti *compute.TargetIn
 func (c *client) DeleteFirewallRule(project, name string) error {
 	op, err := c.Retry(c.raw.Firewalls.Delete(project, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.globalOperationsWait(project, op.Name)
@@ -1061,7 +2144,7 @@ func (c *client) DeleteFirewallRule(project, name string) error {
 func (c *client) DeleteImage(project, name string) error {
 	op, err := c.Retry(c.raw.Images.Delete(project, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.globalOperationsWait(project, op.Name)
@@ -1071,7 +2154,7 @@ func (c *client) DeleteImage(project, name string) error {
 func (c *client) DeleteDisk(project, zone, name string) error {
 	op, err := c.Retry(c.raw.Disks.Delete(project, zone, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.zoneOperationsWait(project, zone, op.Name)
@@ -1087,11 +2170,21 @@ func (c *client) SetDiskAutoDelete(project, zone, instance string, autoDelete bo
 	return c.i.zoneOperationsWait(project, zone, op.Name)
 }
 
+// DeleteAddress releases a GCE address.
+func (c *client) DeleteAddress(project, region, name string) error {
+	op, err := c.Retry(c.raw.Addresses.Delete(project, region, name).Do)
+	if err != nil {
+		return notFoundErr(err)
+	}
+
+	return c.i.regionOperationsWait(project, region, op.Name)
+}
+
 // DeleteForwardingRule deletes a GCE ForwardingRule.
 func (c *client) DeleteForwardingRule(project, region, name string) error {
 	op, err := c.Retry(c.raw.ForwardingRules.Delete(project, region, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.regionOperationsWait(project, region, op.Name)
@@ -1101,7 +2194,7 @@ func (c *client) DeleteForwardingRule(project, region, name string) error {
 func (c *client) DeleteInstance(project, zone, name string) error {
 	op, err := c.Retry(c.raw.Instances.Delete(project, zone, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.zoneOperationsWait(project, zone, op.Name)
@@ -1117,6 +2210,18 @@ func (c *client) StartInstance(project, zone, name string) error {
 	return c.i.zoneOperationsWait(project, zone, op.Name)
 }
 
+// SendDiagnosticInterrupt sends a diagnostic interrupt (NMI) to a running
+// GCE instance, the guest kernel can be configured to panic and write a
+// crash dump when it receives one. The API doesn't return an operation, the
+// interrupt is delivered when the call returns.
+func (c *client) SendDiagnosticInterrupt(project, zone, name string) error {
+	err := c.raw.Instances.SendDiagnosticInterrupt(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.Instances.SendDiagnosticInterrupt(project, zone, name).Do()
+	}
+	return err
+}
+
 // StopInstance stops a GCE instance.
 func (c *client) StopInstance(project, zone, name string) error {
 	op, err := c.Retry(c.raw.Instances.Stop(project, zone, name).Do)
@@ -1131,7 +2236,7 @@ func (c *client) StopInstance(project, zone, name string) error {
 func (c *client) DeleteNetwork(project, name string) error {
 	op, err := c.Retry(c.raw.Networks.Delete(project, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.globalOperationsWait(project, op.Name)
@@ -1141,7 +2246,7 @@ func (c *client) DeleteNetwork(project, name string) error {
 func (c *client) DeleteSubnetwork(project, region, name string) error {
 	op, err := c.Retry(c.raw.Subnetworks.Delete(project, region, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.regionOperationsWait(project, region, op.Name)
@@ -1151,7 +2256,7 @@ func (c *client) DeleteSubnetwork(project, region, name string) error {
 func (c *client) DeleteTargetInstance(project, zone, name string) error {
 	op, err := c.Retry(c.raw.TargetInstances.Delete(project, zone, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.zoneOperationsWait(project, zone, op.Name)
@@ -1169,22 +2274,62 @@ func (c *client) DeprecateImage(project, name string, deprecationstatus *compute
 
 // DeprecateImageAlpha sets deprecation status on a GCE image using the Alpha API.
 func (c *client) DeprecateImageAlpha(project, name string, deprecationstatus *computeAlpha.DeprecationStatus) error {
-	op, err := c.RetryAlpha(c.rawAlpha.Images.Deprecate(project, name, deprecationstatus).Do)
+	rawAlpha, err := c.preview.alphaService()
+	if err != nil {
+		return err
+	}
+	op, err := c.RetryAlpha(rawAlpha.Images.Deprecate(project, name, deprecationstatus).Do)
 	if err != nil {
 		return err
 	}
 	return c.i.globalOperationsWait(project, op.Name)
 }
 
+// DeprecateImageBeta sets deprecation status on a GCE image using the Beta API.
+func (c *client) DeprecateImageBeta(project, name string, deprecationstatus *computeBeta.DeprecationStatus) error {
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return err
+	}
+	op, err := c.RetryBeta(rawBeta.Images.Deprecate(project, name, deprecationstatus).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.globalOperationsWaitBeta(project, op.Name)
+}
+
 // GetMachineType gets a GCE MachineType.
 func (c *client) GetMachineType(project, zone, machineType string) (*compute.MachineType, error) {
 	mt, err := c.raw.MachineTypes.Get(project, zone, machineType).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.MachineTypes.Get(project, zone, machineType).Do()
 	}
 	return mt, err
 }
 
+// GetMachineTypeSpec gets the number of vCPUs and the memory in MB of a GCE
+// MachineType. Results are cached per project, zone and machine type.
+func (c *client) GetMachineTypeSpec(project, zone, machineType string) (int64, int64, error) {
+	key := project + "/" + zone + "/" + machineType
+	c.mtSpecs.mx.Lock()
+	spec, ok := c.mtSpecs.specs[key]
+	c.mtSpecs.mx.Unlock()
+	if ok {
+		return spec.vCPUs, spec.memoryMb, nil
+	}
+
+	// The lock isn't held across the call, concurrent misses may each get
+	// the machine type, which is harmless.
+	mt, err := c.i.GetMachineType(project, zone, machineType)
+	if err != nil {
+		return 0, 0, err
+	}
+	c.mtSpecs.mx.Lock()
+	c.mtSpecs.specs[key] = machineTypeSpec{vCPUs: mt.GuestCpus, memoryMb: mt.MemoryMb}
+	c.mtSpecs.mx.Unlock()
+	return mt.GuestCpus, mt.MemoryMb, nil
+}
+
 // ListMachineTypes gets a list of GCE MachineTypes.
 func (c *client) ListMachineTypes(project, zone string, opts ...ListCallOption) ([]*compute.MachineType, error) {
 	var mts []*compute.MachineType
@@ -1194,7 +2339,7 @@ func (c *client) ListMachineTypes(project, zone string, opts ...ListCallOption)
 		call = opt.listCallOptionApply(call).(*compute.MachineTypesListCall)
 	}
 	for mtl, err := call.PageToken(pt).Do(); ; mtl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			mtl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1212,30 +2357,146 @@ func (c *client) ListMachineTypes(project, zone string, opts ...ListCallOption)
 // GetProject gets a GCE Project.
 func (c *client) GetProject(project string) (*compute.Project, error) {
 	p, err := c.raw.Projects.Get(project).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Projects.Get(project).Do()
 	}
 	return p, err
 }
 
+// GetScreenshot gets a screenshot of the display of a GCE instance, its
+// Contents are a base64 encoded PNG image. The instance must have its display
+// device enabled.
+func (c *client) GetScreenshot(project, zone, name string) (*compute.Screenshot, error) {
+	sc, err := c.raw.Instances.GetScreenshot(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.Instances.GetScreenshot(project, zone, name).Do()
+	}
+	return sc, err
+}
+
 // GetSerialPortOutput gets the serial port output of a GCE instance.
 func (c *client) GetSerialPortOutput(project, zone, name string, port, start int64) (*compute.SerialPortOutput, error) {
 	sp, err := c.raw.Instances.GetSerialPortOutput(project, zone, name).Start(start).Port(port).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Instances.GetSerialPortOutput(project, zone, name).Start(start).Port(port).Do()
 	}
 	return sp, err
 }
 
+const (
+	// maxSerialPollBackoff is how many times the interval of
+	// PollSerialForMarker grows at most.
+	maxSerialPollBackoff = 16
+	// maxSerialPollErrors is the number of consecutive errors reading the
+	// serial port output after which PollSerialForMarker gives up.
+	maxSerialPollErrors = 3
+)
+
+// PollSerialForMarker tails the serial port output of a GCE instance until a
+// line, including a trailing partial one, matches failure or success, either
+// of which may be nil. It returns the line from the match onward, and whether
+// it matched failure, which is checked first. The output is read every
+// interval, the interval doubles up to 16 times while there's no new output
+// or reading it fails, and is reset once new output arrives. It returns ctx.Err() once ctx is done, and an
+// error after 3 consecutive failures to read the output.
+func (c *client) PollSerialForMarker(ctx context.Context, project, zone, name string, port int64, success, failure *regexp.Regexp, interval time.Duration) (string, bool, error) {
+	if success == nil && failure == nil {
+		return "", false, errors.New("no success or failure marker given")
+	}
+	if interval <= 0 {
+		return "", false, fmt.Errorf("bad poll interval: %v", interval)
+	}
+	var start int64
+	var tail string
+	var errs int
+	wait := interval
+	backoff := func() {
+		if wait *= 2; wait > maxSerialPollBackoff*interval {
+			wait = maxSerialPollBackoff * interval
+		}
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		case <-timer.C:
+		}
+		sp, err := c.i.GetSerialPortOutput(project, zone, name, port, start)
+		switch {
+		case err != nil:
+			if errs++; errs >= maxSerialPollErrors {
+				return "", false, fmt.Errorf("error getting serial port %d output of instance %q: %v", port, name, err)
+			}
+			backoff()
+		case sp.Contents == "":
+			errs = 0
+			start = sp.Next
+			backoff()
+		default:
+			errs = 0
+			start = sp.Next
+			wait = interval
+			// The last line may be continued by the next chunk of output, it's
+			// matched now and again once it's continued.
+			lines := strings.Split(tail+sp.Contents, "\n")
+			tail = lines[len(lines)-1]
+			for _, ln := range lines {
+				if failure != nil {
+					if loc := failure.FindStringIndex(ln); loc != nil {
+						return strings.TrimSpace(ln[loc[0]:]), true, nil
+					}
+				}
+				if success != nil {
+					if loc := success.FindStringIndex(ln); loc != nil {
+						return strings.TrimSpace(ln[loc[0]:]), false, nil
+					}
+				}
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// GetEffectiveFirewalls gets the firewalls in effect for a network interface
+// of a GCE instance.
+func (c *client) GetEffectiveFirewalls(project, zone, instance, networkInterface string) (*compute.InstancesGetEffectiveFirewallsResponse, error) {
+	fw, err := c.raw.Instances.GetEffectiveFirewalls(project, zone, instance, networkInterface).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.Instances.GetEffectiveFirewalls(project, zone, instance, networkInterface).Do()
+	}
+	return fw, err
+}
+
 // GetZone gets a GCE Zone.
 func (c *client) GetZone(project, zone string) (*compute.Zone, error) {
 	z, err := c.raw.Zones.Get(project, zone).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Zones.Get(project, zone).Do()
 	}
 	return z, err
 }
 
+// IsZoneAvailable checks if a GCE zone is "UP" and not deprecated, i.e.
+// resources can be created in it.
+func (c *client) IsZoneAvailable(project, zone string) (bool, error) {
+	z, err := c.i.GetZone(project, zone)
+	if err != nil {
+		return false, err
+	}
+	return locationAvailable(z.Status, z.Deprecated), nil
+}
+
+// locationAvailable interprets the status of a zone or region, resources can
+// be created in it if it is "UP" and not deprecated.
+func locationAvailable(status string, deprecated *compute.DeprecationStatus) bool {
+	if deprecated != nil && deprecated.State != "" && deprecated.State != "ACTIVE" {
+		return false
+	}
+	return status == "UP"
+}
+
 // ListZones gets a list GCE Zones.
 func (c *client) ListZones(project string, opts ...ListCallOption) ([]*compute.Zone, error) {
 	var zs []*compute.Zone
@@ -1245,7 +2506,7 @@ func (c *client) ListZones(project string, opts ...ListCallOption) ([]*compute.Z
 		call = opt.listCallOptionApply(call).(*compute.ZonesListCall)
 	}
 	for zl, err := call.PageToken(pt).Do(); ; zl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			zl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1269,7 +2530,7 @@ func (c *client) ListRegions(project string, opts ...ListCallOption) ([]*compute
 		call = opt.listCallOptionApply(call).(*compute.RegionsListCall)
 	}
 	for rl, err := call.PageToken(pt).Do(); ; rl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			rl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1287,7 +2548,7 @@ func (c *client) ListRegions(project string, opts ...ListCallOption) ([]*compute
 // GetInstance gets a GCE Instance using GA API.
 func (c *client) GetInstance(project, zone, name string) (*compute.Instance, error) {
 	i, err := c.raw.Instances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Instances.Get(project, zone, name).Do()
 	}
 	return i, err
@@ -1295,18 +2556,26 @@ func (c *client) GetInstance(project, zone, name string) (*compute.Instance, err
 
 // GetInstanceAlpha gets a GCE Instance using Alpha API.
 func (c *client) GetInstanceAlpha(project, zone, name string) (*computeAlpha.Instance, error) {
-	i, err := c.rawAlpha.Instances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawAlpha.Instances.Get(project, zone, name).Do()
+	rawAlpha, err := c.preview.alphaService()
+	if err != nil {
+		return nil, err
+	}
+	i, err := rawAlpha.Instances.Get(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return rawAlpha.Instances.Get(project, zone, name).Do()
 	}
 	return i, err
 }
 
 // GetInstanceBeta gets a GCE Instance using Beta API.
 func (c *client) GetInstanceBeta(project, zone, name string) (*computeBeta.Instance, error) {
-	i, err := c.rawBeta.Instances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawBeta.Instances.Get(project, zone, name).Do()
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return nil, err
+	}
+	i, err := rawBeta.Instances.Get(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return rawBeta.Instances.Get(project, zone, name).Do()
 	}
 	return i, err
 }
@@ -1320,7 +2589,7 @@ func (c *client) AggregatedListInstances(project string, opts ...ListCallOption)
 		call = opt.listCallOptionApply(call).(*compute.InstancesAggregatedListCall)
 	}
 	for ial, err := call.PageToken(pt).Do(); ; ial, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			ial, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1345,7 +2614,7 @@ func (c *client) ListInstances(project, zone string, opts ...ListCallOption) ([]
 		call = opt.listCallOptionApply(call).(*compute.InstancesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1363,7 +2632,7 @@ func (c *client) ListInstances(project, zone string, opts ...ListCallOption) ([]
 // GetDisk gets a GCE Disk.
 func (c *client) GetDisk(project, zone, name string) (*compute.Disk, error) {
 	d, err := c.raw.Disks.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Disks.Get(project, zone, name).Do()
 	}
 	return d, err
@@ -1371,18 +2640,26 @@ func (c *client) GetDisk(project, zone, name string) (*compute.Disk, error) {
 
 // GetDiskAlpha gets a GCE Disk.
 func (c *client) GetDiskAlpha(project, zone, name string) (*computeAlpha.Disk, error) {
-	d, err := c.rawAlpha.Disks.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawAlpha.Disks.Get(project, zone, name).Do()
+	rawAlpha, err := c.preview.alphaService()
+	if err != nil {
+		return nil, err
+	}
+	d, err := rawAlpha.Disks.Get(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return rawAlpha.Disks.Get(project, zone, name).Do()
 	}
 	return d, err
 }
 
 // GetDiskBeta gets a GCE Disk.
 func (c *client) GetDiskBeta(project, zone, name string) (*computeBeta.Disk, error) {
-	d, err := c.rawBeta.Disks.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawBeta.Disks.Get(project, zone, name).Do()
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return nil, err
+	}
+	d, err := rawBeta.Disks.Get(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return rawBeta.Disks.Get(project, zone, name).Do()
 	}
 	return d, err
 }
@@ -1396,7 +2673,7 @@ func (c *client) AggregatedListDisks(project string, opts ...ListCallOption) ([]
 		call = opt.listCallOptionApply(call).(*compute.DisksAggregatedListCall)
 	}
 	for ial, err := call.PageToken(pt).Do(); ; ial, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			ial, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1421,7 +2698,7 @@ func (c *client) ListDisks(project, zone string, opts ...ListCallOption) ([]*com
 		call = opt.listCallOptionApply(call).(*compute.DisksListCall)
 	}
 	for dl, err := call.PageToken(pt).Do(); ; dl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			dl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1436,10 +2713,104 @@ func (c *client) ListDisks(project, zone string, opts ...ListCallOption) ([]*com
 	}
 }
 
+// GetReservation gets a GCE Reservation.
+func (c *client) GetReservation(project, zone, name string) (*compute.Reservation, error) {
+	r, err := c.raw.Reservations.Get(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.Reservations.Get(project, zone, name).Do()
+	}
+	return r, err
+}
+
+// GetNodeGroup gets a GCE sole-tenant NodeGroup.
+func (c *client) GetNodeGroup(project, zone, name string) (*compute.NodeGroup, error) {
+	ng, err := c.raw.NodeGroups.Get(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.NodeGroups.Get(project, zone, name).Do()
+	}
+	return ng, err
+}
+
+// GetNodeTemplate gets a GCE sole-tenant NodeTemplate.
+func (c *client) GetNodeTemplate(project, region, name string) (*compute.NodeTemplate, error) {
+	nt, err := c.raw.NodeTemplates.Get(project, region, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.NodeTemplates.Get(project, region, name).Do()
+	}
+	return nt, err
+}
+
+// GetNodeType gets a GCE sole-tenant NodeType.
+func (c *client) GetNodeType(project, zone, name string) (*compute.NodeType, error) {
+	nt, err := c.raw.NodeTypes.Get(project, zone, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.NodeTypes.Get(project, zone, name).Do()
+	}
+	return nt, err
+}
+
+// ListInstanceReferrers gets a list of the GCE resources referring to an
+// instance, e.g. the instance groups it is a member of.
+func (c *client) ListInstanceReferrers(project, zone, instance string, opts ...ListCallOption) ([]*compute.Reference, error) {
+	var rs []*compute.Reference
+	var pt string
+	call := c.raw.Instances.ListReferrers(project, zone, instance)
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.InstancesListReferrersCall)
+	}
+	for rl, err := call.PageToken(pt).Do(); ; rl, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+			rl, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, rl.Items...)
+
+		if rl.NextPageToken == "" {
+			return rs, nil
+		}
+		pt = rl.NextPageToken
+	}
+}
+
+// ListReservations gets a list of GCE Reservations.
+func (c *client) ListReservations(project, zone string, opts ...ListCallOption) ([]*compute.Reservation, error) {
+	var rs []*compute.Reservation
+	var pt string
+	call := c.raw.Reservations.List(project, zone)
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.ReservationsListCall)
+	}
+	for rl, err := call.PageToken(pt).Do(); ; rl, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+			rl, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, rl.Items...)
+
+		if rl.NextPageToken == "" {
+			return rs, nil
+		}
+		pt = rl.NextPageToken
+	}
+}
+
+// GetAddress gets a GCE address.
+func (c *client) GetAddress(project, region, name string) (*compute.Address, error) {
+	a, err := c.raw.Addresses.Get(project, region, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.Addresses.Get(project, region, name).Do()
+	}
+	return a, err
+}
+
 // GetForwardingRule gets a GCE ForwardingRule.
 func (c *client) GetForwardingRule(project, region, name string) (*compute.ForwardingRule, error) {
 	n, err := c.raw.ForwardingRules.Get(project, region, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.ForwardingRules.Get(project, region, name).Do()
 	}
 	return n, err
@@ -1454,7 +2825,7 @@ func (c *client) AggregatedListForwardingRules(project string, opts ...ListCallO
 		call = opt.listCallOptionApply(call).(*compute.ForwardingRulesAggregatedListCall)
 	}
 	for ail, err := call.PageToken(pt).Do(); ; ail, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			ail, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1470,6 +2841,30 @@ func (c *client) AggregatedListForwardingRules(project string, opts ...ListCallO
 	}
 }
 
+// ListAddresses gets a list of GCE addresses.
+func (c *client) ListAddresses(project, region string, opts ...ListCallOption) ([]*compute.Address, error) {
+	var as []*compute.Address
+	var pt string
+	call := c.raw.Addresses.List(project, region)
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.AddressesListCall)
+	}
+	for al, err := call.PageToken(pt).Do(); ; al, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+			al, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		as = append(as, al.Items...)
+
+		if al.NextPageToken == "" {
+			return as, nil
+		}
+		pt = al.NextPageToken
+	}
+}
+
 // ListForwardingRules gets a list of GCE ForwardingRules.
 func (c *client) ListForwardingRules(project, region string, opts ...ListCallOption) ([]*compute.ForwardingRule, error) {
 	var frs []*compute.ForwardingRule
@@ -1479,7 +2874,7 @@ func (c *client) ListForwardingRules(project, region string, opts ...ListCallOpt
 		call = opt.listCallOptionApply(call).(*compute.ForwardingRulesListCall)
 	}
 	for frl, err := call.PageToken(pt).Do(); ; frl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			frl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1497,7 +2892,7 @@ func (c *client) ListForwardingRules(project, region string, opts ...ListCallOpt
 // GetFirewallRule gets a GCE FirewallRule.
 func (c *client) GetFirewallRule(project, name string) (*compute.Firewall, error) {
 	i, err := c.raw.Firewalls.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Firewalls.Get(project, name).Do()
 	}
 	return i, err
@@ -1512,7 +2907,7 @@ func (c *client) ListFirewallRules(project string, opts ...ListCallOption) ([]*c
 		call = opt.listCallOptionApply(call).(*compute.FirewallsListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1530,7 +2925,7 @@ func (c *client) ListFirewallRules(project string, opts ...ListCallOption) ([]*c
 // GetImage gets a GCE Image.
 func (c *client) GetImage(project, name string) (*compute.Image, error) {
 	i, err := c.raw.Images.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Images.Get(project, name).Do()
 	}
 	return i, err
@@ -1538,18 +2933,26 @@ func (c *client) GetImage(project, name string) (*compute.Image, error) {
 
 // GetImageAlpha gets a GCE Image using Alpha API
 func (c *client) GetImageAlpha(project, name string) (*computeAlpha.Image, error) {
-	i, err := c.rawAlpha.Images.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawAlpha.Images.Get(project, name).Do()
+	rawAlpha, err := c.preview.alphaService()
+	if err != nil {
+		return nil, err
+	}
+	i, err := rawAlpha.Images.Get(project, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return rawAlpha.Images.Get(project, name).Do()
 	}
 	return i, err
 }
 
 // GetImageBeta gets a GCE Image using Beta API
 func (c *client) GetImageBeta(project, name string) (*computeBeta.Image, error) {
-	i, err := c.rawBeta.Images.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawBeta.Images.Get(project, name).Do()
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return nil, err
+	}
+	i, err := rawBeta.Images.Get(project, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return rawBeta.Images.Get(project, name).Do()
 	}
 	return i, err
 }
@@ -1557,7 +2960,7 @@ func (c *client) GetImageBeta(project, name string) (*computeBeta.Image, error)
 // GetImageFromFamily gets a GCE Image from an image family.
 func (c *client) GetImageFromFamily(project, family string) (*compute.Image, error) {
 	i, err := c.raw.Images.GetFromFamily(project, family).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Images.GetFromFamily(project, family).Do()
 	}
 	return i, err
@@ -1565,9 +2968,13 @@ func (c *client) GetImageFromFamily(project, family string) (*compute.Image, err
 
 // GetImageFromFamilyBeta gets a GCE Image from an image family using Beta API.
 func (c *client) GetImageFromFamilyBeta(project, family string) (*computeBeta.Image, error) {
-	i, err := c.rawBeta.Images.GetFromFamily(project, family).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawBeta.Images.GetFromFamily(project, family).Do()
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return nil, err
+	}
+	i, err := rawBeta.Images.GetFromFamily(project, family).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return rawBeta.Images.GetFromFamily(project, family).Do()
 	}
 	return i, err
 }
@@ -1581,7 +2988,7 @@ func (c *client) ListImages(project string, opts ...ListCallOption) ([]*compute.
 		call = opt.listCallOptionApply(call).(*compute.ImagesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1596,17 +3003,68 @@ func (c *client) ListImages(project string, opts ...ListCallOption) ([]*compute.
 	}
 }
 
+// ListResourcesByLabel gets the GCE Instances, Disks and Images in a project
+// labeled with labelKey=labelValue.
+func (c *client) ListResourcesByLabel(project, labelKey, labelValue string) (*LabeledResources, error) {
+	f := LabelFilter(labelKey, labelValue)
+	var lr LabeledResources
+	var err error
+	if lr.Instances, err = c.i.AggregatedListInstances(project, f); err != nil {
+		return nil, err
+	}
+	if lr.Disks, err = c.i.AggregatedListDisks(project, f); err != nil {
+		return nil, err
+	}
+	if lr.Images, err = c.i.ListImages(project, f); err != nil {
+		return nil, err
+	}
+	return &lr, nil
+}
+
 // ListImagesAlpha gets a list of GCE Images using Alpha API.
 func (c *client) ListImagesAlpha(project string, opts ...ListCallOption) ([]*computeAlpha.Image, error) {
+	rawAlpha, err := c.preview.alphaService()
+	if err != nil {
+		return nil, err
+	}
 	var is []*computeAlpha.Image
 	var pt string
-	call := c.rawAlpha.Images.List(project)
+	call := rawAlpha.Images.List(project)
 
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*computeAlpha.ImagesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+			il, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		is = append(is, il.Items...)
+
+		if il.NextPageToken == "" {
+			return is, nil
+		}
+		pt = il.NextPageToken
+	}
+}
+
+// ListImagesBeta gets a list of GCE Images using Beta API.
+func (c *client) ListImagesBeta(project string, opts ...ListCallOption) ([]*computeBeta.Image, error) {
+	rawBeta, err := c.preview.betaService()
+	if err != nil {
+		return nil, err
+	}
+	var is []*computeBeta.Image
+	var pt string
+	call := rawBeta.Images.List(project)
+
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*computeBeta.ImagesListCall)
+	}
+	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1660,10 +3118,75 @@ func (c *client) CreateSnapshotWithGuestFlush(project, zone, disk string, s *com
 	return nil
 }
 
+// SnapshotOptions are the placement and chaining options of a snapshot
+// created with CreateSnapshotWithOptions. Zero values keep the defaults.
+type SnapshotOptions struct {
+	// Cloud Storage location to store the snapshot in, a region such as
+	// "us-central1" or a multi-region such as "us". Only one location is
+	// supported, by default the multi-region nearest to the disk is used.
+	StorageLocations []string
+	// Name of the snapshot chain to create the snapshot in. Snapshots of a
+	// chain are incremental to the previous snapshots of the same chain.
+	ChainName string
+	// Labels to add to the snapshot, in addition to those already set.
+	Labels map[string]string
+}
+
+var (
+	snapshotLocationRgx   = regexp.MustCompile(`^[a-z]+(-[a-z0-9]+)*$`)
+	snapshotChainNameRgx  = regexp.MustCompile(`^[a-z]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	snapshotLabelKeyRgx   = regexp.MustCompile(`^[\p{Ll}\p{Lo}][\p{Ll}\p{Lo}\p{N}_-]{0,62}$`)
+	snapshotLabelValueRgx = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{N}_-]{0,63}$`)
+)
+
+func (o SnapshotOptions) validate() error {
+	if len(o.StorageLocations) > 1 {
+		return fmt.Errorf("only one snapshot storage location is supported, got %q", o.StorageLocations)
+	}
+	for _, l := range o.StorageLocations {
+		if !snapshotLocationRgx.MatchString(l) {
+			return fmt.Errorf("bad snapshot storage location %q", l)
+		}
+	}
+	if o.ChainName != "" && !snapshotChainNameRgx.MatchString(o.ChainName) {
+		return fmt.Errorf("bad snapshot chain name %q, it must be 1-63 characters long and comply with RFC1035", o.ChainName)
+	}
+	for k, v := range o.Labels {
+		if !snapshotLabelKeyRgx.MatchString(k) {
+			return fmt.Errorf("bad snapshot label key %q", k)
+		}
+		if !snapshotLabelValueRgx.MatchString(v) {
+			return fmt.Errorf("bad value %q of snapshot label %q", v, k)
+		}
+	}
+	return nil
+}
+
+// CreateSnapshotWithOptions creates a GCE snapshot of disk with the storage
+// location, chain name and labels in opts, which override those set in s.
+func (c *client) CreateSnapshotWithOptions(project, zone, disk string, s *compute.Snapshot, opts SnapshotOptions) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if len(opts.StorageLocations) > 0 {
+		s.StorageLocations = opts.StorageLocations
+	}
+	if opts.ChainName != "" {
+		s.ChainName = opts.ChainName
+	}
+	if len(opts.Labels) > 0 && s.Labels == nil {
+		s.Labels = map[string]string{}
+	}
+	for k, v := range opts.Labels {
+		s.Labels[k] = v
+	}
+	return c.i.CreateSnapshot(project, zone, disk, s)
+}
+
 // GetSnapshot gets a GCE Snapshot.
 func (c *client) GetSnapshot(project, name string) (*compute.Snapshot, error) {
 	n, err := c.raw.Snapshots.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Snapshots.Get(project, name).Do()
 	}
 	return n, err
@@ -1673,7 +3196,7 @@ func (c *client) GetSnapshot(project, name string) (*compute.Snapshot, error) {
 func (c *client) DeleteSnapshot(project, name string) error {
 	op, err := c.Retry(c.raw.Snapshots.Delete(project, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.globalOperationsWait(project, op.Name)
@@ -1688,7 +3211,7 @@ func (c *client) ListSnapshots(project string, opts ...ListCallOption) ([]*compu
 		call = opt.listCallOptionApply(call).(*compute.SnapshotsListCall)
 	}
 	for sl, err := call.PageToken(pt).Do(); ; sl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			sl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1706,7 +3229,7 @@ func (c *client) ListSnapshots(project string, opts ...ListCallOption) ([]*compu
 // GetNetwork gets a GCE Network.
 func (c *client) GetNetwork(project, name string) (*compute.Network, error) {
 	n, err := c.raw.Networks.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Networks.Get(project, name).Do()
 	}
 	return n, err
@@ -1715,18 +3238,28 @@ func (c *client) GetNetwork(project, name string) (*compute.Network, error) {
 // GetRegion gets a GCE Region
 func (c *client) GetRegion(project, name string) (*compute.Region, error) {
 	n, err := c.raw.Regions.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Regions.Get(project, name).Do()
 	}
 	return n, err
 }
 
+// IsRegionAvailable checks if a GCE region is "UP" and not deprecated, i.e.
+// regional resources can be created in it.
+func (c *client) IsRegionAvailable(project, region string) (bool, error) {
+	r, err := c.i.GetRegion(project, region)
+	if err != nil {
+		return false, err
+	}
+	return locationAvailable(r.Status, r.Deprecated), nil
+}
+
 // Suspend an instance
 func (c *client) Suspend(project, zone, name string) error {
 	var op *compute.Operation
 	var err error
 	op, err = c.raw.Instances.Suspend(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		op, err = c.raw.Instances.Suspend(project, zone, name).Do()
 	}
 	if err != nil {
@@ -1740,7 +3273,7 @@ func (c *client) Resume(project, zone, name string) error {
 	var op *compute.Operation
 	var err error
 	op, err = c.raw.Instances.Resume(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		op, err = c.raw.Instances.Resume(project, zone, name).Do()
 	}
 	if err != nil {
@@ -1754,7 +3287,7 @@ func (c *client) SimulateMaintenanceEvent(project, zone, name string) error {
 	var op *compute.Operation
 	var err error
 	op, err = c.raw.Instances.SimulateMaintenanceEvent(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		op, err = c.raw.Instances.SimulateMaintenanceEvent(project, zone, name).Do()
 	}
 	if err != nil {
@@ -1772,7 +3305,7 @@ func (c *client) ListNetworks(project string, opts ...ListCallOption) ([]*comput
 		call = opt.listCallOptionApply(call).(*compute.NetworksListCall)
 	}
 	for nl, err := call.PageToken(pt).Do(); ; nl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			nl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1790,7 +3323,7 @@ func (c *client) ListNetworks(project string, opts ...ListCallOption) ([]*comput
 // GetSubnetwork gets a GCE subnetwork.
 func (c *client) GetSubnetwork(project, region, name string) (*compute.Subnetwork, error) {
 	n, err := c.raw.Subnetworks.Get(project, region, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Subnetworks.Get(project, region, name).Do()
 	}
 	return n, err
@@ -1805,7 +3338,7 @@ func (c *client) AggregatedListSubnetworks(project string, opts ...ListCallOptio
 		call = opt.listCallOptionApply(call).(*compute.SubnetworksAggregatedListCall)
 	}
 	for sal, err := call.PageToken(pt).Do(); ; sal, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			sal, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1830,7 +3363,7 @@ func (c *client) ListSubnetworks(project, region string, opts ...ListCallOption)
 		call = opt.listCallOptionApply(call).(*compute.SubnetworksListCall)
 	}
 	for nl, err := call.PageToken(pt).Do(); ; nl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			nl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1848,7 +3381,7 @@ func (c *client) ListSubnetworks(project, region string, opts ...ListCallOption)
 // GetTargetInstance gets a GCE TargetInstance.
 func (c *client) GetTargetInstance(project, zone, name string) (*compute.TargetInstance, error) {
 	n, err := c.raw.TargetInstances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.TargetInstances.Get(project, zone, name).Do()
 	}
 	return n, err
@@ -1863,7 +3396,7 @@ func (c *client) ListTargetInstances(project, zone string, opts ...ListCallOptio
 		call = opt.listCallOptionApply(call).(*compute.TargetInstancesListCall)
 	}
 	for til, err := call.PageToken(pt).Do(); ; til, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			til, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1881,7 +3414,7 @@ func (c *client) ListTargetInstances(project, zone string, opts ...ListCallOptio
 // GetLicense gets a GCE License.
 func (c *client) GetLicense(project, name string) (*compute.License, error) {
 	l, err := c.raw.Licenses.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.Licenses.Get(project, name).Do()
 	}
 	return l, err
@@ -1896,7 +3429,7 @@ func (c *client) ListLicenses(project string, opts ...ListCallOption) ([]*comput
 		call = opt.listCallOptionApply(call).(*compute.LicensesListCall)
 	}
 	for ll, err := call.PageToken(pt).Do(); ; ll, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			ll, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1914,7 +3447,7 @@ func (c *client) ListLicenses(project string, opts ...ListCallOption) ([]*comput
 // InstanceStatus returns an instances Status.
 func (c *client) InstanceStatus(project, zone, name string) (string, error) {
 	is, err := c.raw.Instances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		is, err = c.raw.Instances.Get(project, zone, name).Do()
 	}
 
@@ -1950,7 +3483,26 @@ func (c *client) ResizeDisk(project, zone, disk string, drr *compute.DisksResize
 	return c.i.zoneOperationsWait(project, zone, op.Name)
 }
 
-// SetInstanceMetadata sets an instances metadata.
+// UpdateDisk updates the fields of a GCE persistent disk given in paths, such
+// as the provisioned IOPS and throughput of a hyperdisk, to the values in d.
+// paths is sent as the update mask of the request and can't be empty.
+func (c *client) UpdateDisk(project, zone, disk string, d *compute.Disk, paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no fields to update given for disk %q", disk)
+	}
+	op, err := c.Retry(c.raw.Disks.Update(project, zone, disk, d).UpdateMask(strings.Join(paths, ",")).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// SetInstanceMetadata sets an instances metadata. It isn't retried on
+// fingerprint conflicts: md replaces all of the metadata, so retrying with a
+// current fingerprint would drop the concurrent change. It fails if md's
+// fingerprint is outdated, use MergeInstanceMetadata to retry the update on
+// the current metadata.
 func (c *client) SetInstanceMetadata(project, zone, name string, md *compute.Metadata) error {
 	op, err := c.Retry(c.raw.Instances.SetMetadata(project, zone, name, md).Do)
 	if err != nil {
@@ -1959,7 +3511,116 @@ func (c *client) SetInstanceMetadata(project, zone, name string, md *compute.Met
 	return c.i.zoneOperationsWait(project, zone, op.Name)
 }
 
-// SetCommonInstanceMetadata sets an instances metadata.
+// MergeInstanceMetadata sets the instance metadata items in add and deletes
+// the items whose keys are in remove, keeping the other items. Keys in both
+// add and remove are deleted. The update is retried once if the metadata
+// changed concurrently. Nothing is done if the metadata already matches.
+func (c *client) MergeInstanceMetadata(project, zone, name string, add map[string]string, remove []string) error {
+	return retryOnFingerprintConflict(func() error {
+		i, err := c.i.GetInstance(project, zone, name)
+		if err != nil {
+			return err
+		}
+		md := i.Metadata
+		if md == nil {
+			md = &compute.Metadata{}
+		}
+		md, changed := mergeMetadata(md, add, remove)
+		if !changed {
+			return nil
+		}
+		return c.i.SetInstanceMetadata(project, zone, name, md)
+	})
+}
+
+// MergeInstanceLabels sets the instance labels in add and deletes the labels
+// whose keys are in remove, keeping the other labels. Keys in both add and
+// remove are deleted. The update is retried once if the labels changed
+// concurrently. Nothing is done if the labels already match.
+func (c *client) MergeInstanceLabels(project, zone, name string, add map[string]string, remove []string) error {
+	return retryOnFingerprintConflict(func() error {
+		i, err := c.i.GetInstance(project, zone, name)
+		if err != nil {
+			return err
+		}
+		labels, changed := mergeLabels(i.Labels, add, remove)
+		if !changed {
+			return nil
+		}
+		req := &compute.InstancesSetLabelsRequest{Labels: labels, LabelFingerprint: i.LabelFingerprint}
+		op, err := c.Retry(c.raw.Instances.SetLabels(project, zone, name, req).Do)
+		if err != nil {
+			return err
+		}
+		return c.i.zoneOperationsWait(project, zone, op.Name)
+	})
+}
+
+// mergeLabels returns a copy of labels with the labels in add set and the
+// labels whose keys are in remove deleted, and whether it differs from
+// labels.
+func mergeLabels(labels, add map[string]string, remove []string) (map[string]string, bool) {
+	merged := map[string]string{}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	var changed bool
+	for k, v := range add {
+		if old, ok := merged[k]; !ok || old != v {
+			merged[k] = v
+			changed = true
+		}
+	}
+	for _, k := range remove {
+		if _, ok := merged[k]; ok {
+			delete(merged, k)
+			changed = true
+		}
+	}
+	return merged, changed
+}
+
+// SetTags sets an instances network tags.
+func (c *client) SetTags(project, zone, instance string, tags *compute.Tags) error {
+	op, err := c.Retry(c.raw.Instances.SetTags(project, zone, instance, tags).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// AddInstanceResourcePolicies adds resource policies to an instance.
+func (c *client) AddInstanceResourcePolicies(project, zone, instance string, req *compute.InstancesAddResourcePoliciesRequest) error {
+	op, err := c.Retry(c.raw.Instances.AddResourcePolicies(project, zone, instance, req).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// RemoveInstanceResourcePolicies removes resource policies from an instance.
+func (c *client) RemoveInstanceResourcePolicies(project, zone, instance string, req *compute.InstancesRemoveResourcePoliciesRequest) error {
+	op, err := c.Retry(c.raw.Instances.RemoveResourcePolicies(project, zone, instance, req).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// SetInstanceName renames a stopped instance.
+func (c *client) SetInstanceName(project, zone, instance string, req *compute.InstancesSetNameRequest) error {
+	op, err := c.Retry(c.raw.Instances.SetName(project, zone, instance, req).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// SetCommonInstanceMetadata sets a project's metadata. It isn't retried on
+// fingerprint conflicts: md replaces all of the metadata, so retrying with a
+// current fingerprint would drop the concurrent change. It fails if md's
+// fingerprint is outdated, use MergeCommonInstanceMetadata to retry the
+// update on the current metadata.
 func (c *client) SetCommonInstanceMetadata(project string, md *compute.Metadata) error {
 	op, err := c.Retry(c.raw.Projects.SetCommonInstanceMetadata(project, md).Do)
 	if err != nil {
@@ -1969,6 +3630,344 @@ func (c *client) SetCommonInstanceMetadata(project string, md *compute.Metadata)
 	return c.i.globalOperationsWait(project, op.Name)
 }
 
+const sshKeysMetadataKey = "ssh-keys"
+
+// mergeSSHKey adds a "user:publicKey" line to keys, the value of the ssh-keys
+// metadata, unless the line is already there. It returns whether keys was
+// changed.
+func mergeSSHKey(keys, user, publicKey string) (string, bool) {
+	line := fmt.Sprintf("%s:%s", user, strings.TrimSpace(publicKey))
+	for _, l := range strings.Split(keys, "\n") {
+		if strings.TrimSpace(l) == line {
+			return keys, false
+		}
+	}
+	if keys = strings.TrimRight(keys, "\n"); keys != "" {
+		keys += "\n"
+	}
+	return keys + line, true
+}
+
+// IsFingerprintConflict returns whether err is caused by a metadata update
+// using an outdated fingerprint.
+func IsFingerprintConflict(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusPreconditionFailed
+	}
+	return HasOperationErrorCode(err, "CONDITION_NOT_MET")
+}
+
+// ErrResourceNotFound is matched, with errors.Is, by the errors of the Delete
+// methods when the resource to delete doesn't exist. Cleanup code can treat
+// it as success. The errors still unwrap to the *googleapi.Error of the
+// request. Use IsNotFound to check the errors of other methods.
+var ErrResourceNotFound = errors.New("resource not found")
+
+// notFoundError is the error of a delete request for a resource which
+// doesn't exist.
+type notFoundError struct {
+	err error
+}
+
+func (e *notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrResourceNotFound
+}
+
+// notFoundErr makes a 404 error of a delete request match
+// ErrResourceNotFound.
+func notFoundErr(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return &notFoundError{err}
+	}
+	return err
+}
+
+// IsNotFound returns whether err is caused by a resource not existing, either
+// a 404 error of any request or an error matching ErrResourceNotFound.
+func IsNotFound(err error) bool {
+	return errors.Is(notFoundErr(err), ErrResourceNotFound)
+}
+
+// AddProjectSSHKey adds an SSH key for user to the ssh-keys project metadata,
+// keeping the existing keys. Nothing is done if the key is already there. The
+// update is retried once if the metadata changed concurrently.
+func (c *client) AddProjectSSHKey(project, user, publicKey string) error {
+	return retryOnFingerprintConflict(func() error { return c.addProjectSSHKey(project, user, publicKey) })
+}
+
+// retryOnFingerprintConflict runs the metadata update f, and runs it again
+// if the metadata changed concurrently.
+func retryOnFingerprintConflict(f func() error) error {
+	var err error
+	for i := 0; i < 2; i++ {
+		if err = f(); !IsFingerprintConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *client) addProjectSSHKey(project, user, publicKey string) error {
+	p, err := c.i.GetProject(project)
+	if err != nil {
+		return err
+	}
+	md := p.CommonInstanceMetadata
+	if md == nil {
+		md = &compute.Metadata{}
+	}
+
+	var item *compute.MetadataItems
+	for _, mi := range md.Items {
+		if mi.Key == sshKeysMetadataKey {
+			item = mi
+			break
+		}
+	}
+	if item == nil {
+		item = &compute.MetadataItems{Key: sshKeysMetadataKey}
+		md.Items = append(md.Items, item)
+	}
+	var keys string
+	if item.Value != nil {
+		keys = *item.Value
+	}
+	keys, changed := mergeSSHKey(keys, user, publicKey)
+	if !changed {
+		return nil
+	}
+	item.Value = &keys
+
+	// md holds the fingerprint of the metadata read above, the update fails
+	// if the metadata was changed since.
+	return c.i.SetCommonInstanceMetadata(project, md)
+}
+
+// MergeCommonInstanceMetadata sets the project metadata items in add and
+// deletes the items whose keys are in remove, keeping the other items, like
+// ssh-keys or enable-oslogin. Keys in both add and remove are deleted.
+// Unlike SetCommonInstanceMetadata, it can't
+// clobber changes made since the metadata was read: the update is retried
+// once if the metadata changed concurrently. Nothing is done if the metadata
+// already matches.
+func (c *client) MergeCommonInstanceMetadata(project string, add map[string]string, remove []string) error {
+	return retryOnFingerprintConflict(func() error { return c.mergeCommonInstanceMetadata(project, add, remove) })
+}
+
+func (c *client) mergeCommonInstanceMetadata(project string, add map[string]string, remove []string) error {
+	p, err := c.i.GetProject(project)
+	if err != nil {
+		return err
+	}
+	md := p.CommonInstanceMetadata
+	if md == nil {
+		md = &compute.Metadata{}
+	}
+	md, changed := mergeMetadata(md, add, remove)
+	if !changed {
+		return nil
+	}
+
+	// md holds the fingerprint of the metadata read above, the update fails
+	// if the metadata was changed since.
+	return c.i.SetCommonInstanceMetadata(project, md)
+}
+
+// mergeMetadata returns md with the items in add set and the items whose keys
+// are in remove deleted, and whether that changed md. md isn't modified.
+func mergeMetadata(md *compute.Metadata, add map[string]string, remove []string) (*compute.Metadata, bool) {
+	removed := map[string]bool{}
+	for _, k := range remove {
+		removed[k] = true
+	}
+	merged := &compute.Metadata{Fingerprint: md.Fingerprint}
+	var changed bool
+	seen := map[string]bool{}
+	for _, mi := range md.Items {
+		if removed[mi.Key] {
+			changed = true
+			continue
+		}
+		if v, ok := add[mi.Key]; ok {
+			seen[mi.Key] = true
+			if mi.Value == nil || *mi.Value != v {
+				v := v
+				mi = &compute.MetadataItems{Key: mi.Key, Value: &v}
+				changed = true
+			}
+		}
+		merged.Items = append(merged.Items, mi)
+	}
+	var keys []string
+	for k := range add {
+		if !seen[k] && !removed[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := add[k]
+		merged.Items = append(merged.Items, &compute.MetadataItems{Key: k, Value: &v})
+		changed = true
+	}
+	return merged, changed
+}
+
+// OSLoginMetadataKey is the metadata key enabling OS Login on a project or an
+// instance. SSH keys in ssh-keys metadata are ignored when OS Login is enabled.
+const OSLoginMetadataKey = "enable-oslogin"
+
+// OSLoginValueEnabled returns whether v, a value of the enable-oslogin
+// metadata, enables OS Login.
+func OSLoginValueEnabled(v string) bool {
+	return strings.EqualFold(strings.TrimSpace(v), "true")
+}
+
+// OSLoginSetting returns whether the enable-oslogin metadata in md enables OS
+// Login and whether it is set at all.
+func OSLoginSetting(md *compute.Metadata) (enabled, set bool) {
+	if md == nil {
+		return false, false
+	}
+	for _, mi := range md.Items {
+		if mi.Key == OSLoginMetadataKey && mi.Value != nil {
+			return OSLoginValueEnabled(*mi.Value), true
+		}
+	}
+	return false, false
+}
+
+// IsOSLoginEnabled returns whether OS Login is enabled for an instance. The
+// instance's enable-oslogin metadata takes precedence over the project's.
+func (c *client) IsOSLoginEnabled(project, zone, instance string) (bool, error) {
+	i, err := c.i.GetInstance(project, zone, instance)
+	if err != nil {
+		return false, err
+	}
+	if enabled, set := OSLoginSetting(i.Metadata); set {
+		return enabled, nil
+	}
+	p, err := c.i.GetProject(project)
+	if err != nil {
+		return false, err
+	}
+	enabled, _ := OSLoginSetting(p.CommonInstanceMetadata)
+	return enabled, nil
+}
+
+// PatchInstanceGroupManager patches a zonal managed instance group, e.g. to
+// set its StatefulPolicy.
+func (c *client) PatchInstanceGroupManager(project, zone, igm string, m *compute.InstanceGroupManager) error {
+	op, err := c.Retry(c.raw.InstanceGroupManagers.Patch(project, zone, igm, m).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// PatchRegionInstanceGroupManager patches a regional managed instance group,
+// e.g. to set its StatefulPolicy.
+func (c *client) PatchRegionInstanceGroupManager(project, region, igm string, m *compute.InstanceGroupManager) error {
+	op, err := c.Retry(c.raw.RegionInstanceGroupManagers.Patch(project, region, igm, m).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.regionOperationsWait(project, region, op.Name)
+}
+
+// UpdatePerInstanceConfigs inserts or updates the per-instance configs,
+// the preserved state of each instance, of a zonal managed instance group.
+func (c *client) UpdatePerInstanceConfigs(project, zone, igm string, req *compute.InstanceGroupManagersUpdatePerInstanceConfigsReq) error {
+	op, err := c.Retry(c.raw.InstanceGroupManagers.UpdatePerInstanceConfigs(project, zone, igm, req).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// UpdateRegionPerInstanceConfigs inserts or updates the per-instance configs,
+// the preserved state of each instance, of a regional managed instance group.
+func (c *client) UpdateRegionPerInstanceConfigs(project, region, igm string, req *compute.RegionInstanceGroupManagerUpdateInstanceConfigReq) error {
+	op, err := c.Retry(c.raw.RegionInstanceGroupManagers.UpdatePerInstanceConfigs(project, region, igm, req).Do)
+	if err != nil {
+		return err
+	}
+	return c.i.regionOperationsWait(project, region, op.Name)
+}
+
+// CreateRegionInstanceTemplate creates a regional GCE instance template, as
+// used by regional managed instance groups.
+func (c *client) CreateRegionInstanceTemplate(project, region string, it *compute.InstanceTemplate) error {
+	op, err := c.Retry(c.raw.RegionInstanceTemplates.Insert(project, region, it).Do)
+	if err != nil {
+		return err
+	}
+
+	if err := c.i.regionOperationsWait(project, region, op.Name); err != nil {
+		return err
+	}
+
+	var createdInstanceTemplate *compute.InstanceTemplate
+	if createdInstanceTemplate, err = c.i.GetRegionInstanceTemplate(project, region, it.Name); err != nil {
+		return err
+	}
+	*it = *createdInstanceTemplate
+	return nil
+}
+
+// GetRegionInstanceTemplate gets a regional GCE instance template.
+func (c *client) GetRegionInstanceTemplate(project, region, name string) (*compute.InstanceTemplate, error) {
+	it, err := c.raw.RegionInstanceTemplates.Get(project, region, name).Do()
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+		return c.raw.RegionInstanceTemplates.Get(project, region, name).Do()
+	}
+	return it, err
+}
+
+// DeleteRegionInstanceTemplate deletes a regional GCE instance template.
+func (c *client) DeleteRegionInstanceTemplate(project, region, name string) error {
+	op, err := c.Retry(c.raw.RegionInstanceTemplates.Delete(project, region, name).Do)
+	if err != nil {
+		return notFoundErr(err)
+	}
+
+	return c.i.regionOperationsWait(project, region, op.Name)
+}
+
+// ListRegionInstanceTemplates gets a list of regional GCE instance templates.
+func (c *client) ListRegionInstanceTemplates(project, region string, opts ...ListCallOption) ([]*compute.InstanceTemplate, error) {
+	var its []*compute.InstanceTemplate
+	var pt string
+	call := c.raw.RegionInstanceTemplates.List(project, region)
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.RegionInstanceTemplatesListCall)
+	}
+	for itl, err := call.PageToken(pt).Do(); ; itl, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
+			itl, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		its = append(its, itl.Items...)
+
+		if itl.NextPageToken == "" {
+			return its, nil
+		}
+		pt = itl.NextPageToken
+	}
+}
+
 // GetGuestAttributes gets a Guest Attributes.
 func (c *client) GetGuestAttributes(project, zone, name, queryPath, variableKey string) (*compute.GuestAttributes, error) {
 	call := c.raw.Instances.GetGuestAttributes(project, zone, name)
@@ -1979,7 +3978,7 @@ func (c *client) GetGuestAttributes(project, zone, name, queryPath, variableKey
 		call = call.VariableKey(variableKey)
 	}
 	a, err := call.Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return call.Do()
 	}
 	return a, err
@@ -1994,7 +3993,7 @@ func (c *client) ListMachineImages(project string, opts ...ListCallOption) ([]*c
 		call = opt.listCallOptionApply(call).(*compute.MachineImagesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -2013,7 +4012,7 @@ func (c *client) ListMachineImages(project string, opts ...ListCallOption) ([]*c
 func (c *client) DeleteMachineImage(project, name string) error {
 	op, err := c.Retry(c.raw.MachineImages.Delete(project, name).Do)
 	if err != nil {
-		return err
+		return notFoundErr(err)
 	}
 
 	return c.i.globalOperationsWait(project, op.Name)
@@ -2043,7 +4042,7 @@ func (c *client) CreateMachineImage(project string, mi *compute.MachineImage) er
 // GetMachineImage gets a GCE Machine Image.
 func (c *client) GetMachineImage(project, name string) (*compute.MachineImage, error) {
 	i, err := c.raw.MachineImages.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.retryCtx.get(), c.hc.Transport, err, 2) {
 		return c.raw.MachineImages.Get(project, name).Do()
 	}
 	return i, err
@@ -16,18 +16,29 @@ package compute
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/kylelemons/godebug/pretty"
+	"golang.org/x/oauth2"
 	computeAlpha "google.golang.org/api/compute/v0.alpha"
 	computeBeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
 	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 )
 
 var (
@@ -72,7 +83,7 @@ func TestShouldRetryWithWait(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		if got := shouldRetryWithWait(nil, tt.err, 0); got != tt.want {
+		if got := shouldRetryWithWait(context.Background(), nil, tt.err, 0); got != tt.want {
 			t.Errorf("%s case: shouldRetryWithWait == %t, want %t", tt.desc, got, tt.want)
 		}
 	}
@@ -115,6 +126,11 @@ func TestCreates(t *testing.T) {
 	c.zoneOperationsWaitFn = func(_, _, _ string) error { return waitErr }
 	c.regionOperationsWaitFn = func(_, _, _ string) error { return waitErr }
 	c.globalOperationsWaitFn = func(_, _ string) error { return waitErr }
+	c.WaitForImageOperationFn = func(_, _ string) error { return waitErr }
+	c.zoneOperationsWaitBetaFn = func(_, _, _ string) error { return waitErr }
+	c.regionOperationsWaitBetaFn = func(_, _, _ string) error { return waitErr }
+	c.globalOperationsWaitBetaFn = func(_, _ string) error { return waitErr }
+	c.waitForImageOperationBetaFn = func(_, _ string) error { return waitErr }
 
 	tests := []struct {
 		desc                       string
@@ -368,8 +384,12 @@ func TestStops(t *testing.T) {
 
 func TestDeletes(t *testing.T) {
 	var deleteURL, opGetURL *string
+	var notFound bool
 	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "DELETE" && r.URL.String() == *deleteURL {
+		if r.Method == "DELETE" && r.URL.String() == *deleteURL && notFound {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, "not found")
+		} else if r.Method == "DELETE" && r.URL.String() == *deleteURL {
 			fmt.Fprint(w, `{}`)
 		} else if r.Method == "POST" && r.URL.String() == *opGetURL {
 			fmt.Fprint(w, `{"Status":"DONE"}`)
@@ -474,14 +494,50 @@ func TestDeletes(t *testing.T) {
 			fmt.Sprintf("/projects/%s/regions/%s/networkEndpointGroups/%s?alt=json&prettyPrint=false", testProject, testRegion, testNetworkEndpointGroup),
 			fmt.Sprintf("/projects/%s/regions/%s/operations//wait?alt=json&prettyPrint=false", testProject, testRegion),
 		},
+		{
+			"networkEndpointGroups",
+			func() error { return c.DeleteNetworkEndpointGroup(testProject, testZone, testNetworkEndpointGroup) },
+			fmt.Sprintf("/projects/%s/zones/%s/networkEndpointGroups/%s?alt=json&prettyPrint=false", testProject, testZone, testNetworkEndpointGroup),
+			fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone),
+		},
 	}
 
 	for _, d := range deletes {
 		deleteURL = &d.deleteURL
 		opGetURL = &d.opGetURL
+		notFound = false
 		if err := d.do(); err != nil {
 			t.Errorf("%s: error running Delete: %v", d.name, err)
 		}
+
+		// Deleting a resource which doesn't exist returns an error matching
+		// ErrResourceNotFound, which still unwraps to the API error.
+		notFound = true
+		err := d.do()
+		var apiErr *googleapi.Error
+		if !errors.Is(err, ErrResourceNotFound) || !IsNotFound(err) || !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+			t.Errorf("%s: want a not found error, got: %v", d.name, err)
+		}
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		desc string
+		err  error
+		want bool
+	}{
+		{"nil case", nil, false},
+		{"404 case", &googleapi.Error{Code: http.StatusNotFound}, true},
+		{"wrapped 404 case", fmt.Errorf("error: %w", &googleapi.Error{Code: http.StatusNotFound}), true},
+		{"sentinel case", fmt.Errorf("error: %w", ErrResourceNotFound), true},
+		{"403 case", &googleapi.Error{Code: http.StatusForbidden}, false},
+		{"other error case", errors.New("error"), false},
+	}
+	for _, tt := range tests {
+		if got := IsNotFound(tt.err); got != tt.want {
+			t.Errorf("%s: IsNotFound got %t, want %t", tt.desc, got, tt.want)
+		}
 	}
 }
 
@@ -526,6 +582,27 @@ func TestDeprecateImageAlpha(t *testing.T) {
 		t.Fatalf("error running DeprecateImageAlpha: %v", err)
 	}
 }
+func TestDeprecateImageBeta(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/images/%s/deprecate?alt=json&prettyPrint=false", testProject, testImageBeta) {
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.DeprecateImageBeta(testProject, testImageBeta, &computeBeta.DeprecationStatus{}); err != nil {
+		t.Fatalf("error running DeprecateImageBeta: %v", err)
+	}
+}
+
 func TestAttachDisk(t *testing.T) {
 	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/attachDisk?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
@@ -547,6 +624,27 @@ func TestAttachDisk(t *testing.T) {
 	}
 }
 
+func TestBulkInsertDisk(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/disks/bulkInsert?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.BulkInsertDisk(testProject, testZone, &compute.BulkInsertDiskResource{}); err != nil {
+		t.Fatalf("error running BulkInsertDisk: %v", err)
+	}
+}
+
 func TestDetachDisk(t *testing.T) {
 	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/detachDisk?alt=json&deviceName=%s&prettyPrint=false", testProject, testZone, testInstance, testDisk) {
@@ -568,14 +666,235 @@ func TestDetachDisk(t *testing.T) {
 	}
 }
 
-func TestSuspendResume(t *testing.T) {
+func TestGetMachineTypeSpec(t *testing.T) {
+	var calls int
 	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/suspend?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+		if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/machineTypes/n1-standard-4?alt=json&prettyPrint=false", testProject, testZone) {
+			calls++
+			fmt.Fprint(w, `{"guestCpus":4,"memoryMb":15360}`)
+		} else if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/other/zones/%s/machineTypes/n1-standard-4?alt=json&prettyPrint=false", testZone) {
+			calls++
+			w.WriteHeader(404)
+			fmt.Fprint(w, `{"error":{"code":404}}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	for i := 0; i < 2; i++ {
+		vCPUs, memoryMb, err := c.GetMachineTypeSpec(testProject, testZone, "n1-standard-4")
+		if err != nil {
+			t.Fatalf("error running GetMachineTypeSpec: %v", err)
+		}
+		if vCPUs != 4 || memoryMb != 15360 {
+			t.Errorf("unexpected spec, got: %d vCPUs %d MB, want: 4 vCPUs 15360 MB", vCPUs, memoryMb)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected machine type to be fetched once, got %d calls", calls)
+	}
+
+	// The spec cached for testProject isn't returned for another project.
+	if _, _, err := c.GetMachineTypeSpec("other", testZone, "n1-standard-4"); err == nil {
+		t.Error("expected an error for a machine type not found in another project")
+	}
+	if calls != 2 {
+		t.Errorf("expected machine type of another project to be fetched, got %d calls", calls)
+	}
+}
+
+func TestListResourcesByLabel(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	var filters []ListCallOption
+	c.AggregatedListInstancesFn = func(project string, opts ...ListCallOption) ([]*compute.Instance, error) {
+		filters = append(filters, opts...)
+		return []*compute.Instance{{Name: "i1"}, {Name: "i2"}}, nil
+	}
+	c.AggregatedListDisksFn = func(project string, opts ...ListCallOption) ([]*compute.Disk, error) {
+		filters = append(filters, opts...)
+		return []*compute.Disk{{Name: "d1"}}, nil
+	}
+	c.ListImagesFn = func(project string, opts ...ListCallOption) ([]*compute.Image, error) {
+		filters = append(filters, opts...)
+		return nil, nil
+	}
+
+	got, err := c.ListResourcesByLabel(testProject, "daisy-workflow", "abc")
+	if err != nil {
+		t.Fatalf("error running ListResourcesByLabel: %v", err)
+	}
+	want := &LabeledResources{
+		Instances: []*compute.Instance{{Name: "i1"}, {Name: "i2"}},
+		Disks:     []*compute.Disk{{Name: "d1"}},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("ListResourcesByLabel returned unexpected resources (-got +want):\n%s", diff)
+	}
+	wantFilter := Filter("labels.daisy-workflow=abc")
+	if len(filters) != 3 {
+		t.Fatalf("expected a filter on each of the 3 list calls, got: %v", filters)
+	}
+	for _, f := range filters {
+		if f != wantFilter {
+			t.Errorf("unexpected filter, got: %v, want: %v", f, wantFilter)
+		}
+	}
+
+	e := errors.New("error")
+	c.AggregatedListDisksFn = func(project string, opts ...ListCallOption) ([]*compute.Disk, error) {
+		return nil, e
+	}
+	if _, err := c.ListResourcesByLabel(testProject, "daisy-workflow", "abc"); err != e {
+		t.Errorf("unexpected error, got: %v, want: %v", err, e)
+	}
+}
+
+func TestReservations(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/reservations/res?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"name":"res","specificReservation":{"count":"2","inUseCount":"1"}}`)
+		} else if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/reservations?alt=json&pageToken=&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"items":[{"name":"res"}],"nextPageToken":"next"}`)
+		} else if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/reservations?alt=json&pageToken=next&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"items":[{"name":"res2"}]}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	res, err := c.GetReservation(testProject, testZone, "res")
+	if err != nil {
+		t.Fatalf("error running GetReservation: %v", err)
+	}
+	if res.SpecificReservation == nil || res.SpecificReservation.Count != 2 || res.SpecificReservation.InUseCount != 1 {
+		t.Errorf("unexpected reservation: %+v", res)
+	}
+
+	rs, err := c.ListReservations(testProject, testZone)
+	if err != nil {
+		t.Fatalf("error running ListReservations: %v", err)
+	}
+	if len(rs) != 2 || rs[0].Name != "res" || rs[1].Name != "res2" {
+		t.Errorf("unexpected reservations: %v", rs)
+	}
+}
+
+func TestSoleTenancy(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/nodeGroups/ng?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"name":"ng","nodeTemplate":"nt"}`)
+		} else if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/regions/%s/nodeTemplates/nt?alt=json&prettyPrint=false", testProject, testRegion) {
+			fmt.Fprint(w, `{"name":"nt","nodeType":"n1-node-96-624"}`)
+		} else if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/nodeTypes/n1-node-96-624?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"name":"n1-node-96-624","guestCpus":96,"memoryMb":638976}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	ng, err := c.GetNodeGroup(testProject, testZone, "ng")
+	if err != nil {
+		t.Fatalf("error running GetNodeGroup: %v", err)
+	}
+	if ng.NodeTemplate != "nt" {
+		t.Errorf("unexpected node group: %+v", ng)
+	}
+	nt, err := c.GetNodeTemplate(testProject, testRegion, "nt")
+	if err != nil {
+		t.Fatalf("error running GetNodeTemplate: %v", err)
+	}
+	if nt.NodeType != "n1-node-96-624" {
+		t.Errorf("unexpected node template: %+v", nt)
+	}
+	nty, err := c.GetNodeType(testProject, testZone, "n1-node-96-624")
+	if err != nil {
+		t.Fatalf("error running GetNodeType: %v", err)
+	}
+	if nty.GuestCpus != 96 || nty.MemoryMb != 638976 {
+		t.Errorf("unexpected node type: %+v", nty)
+	}
+}
+
+func TestClose(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"name":%q}`, testInstance)
+	}))
+	defer ts.Close()
+
+	c, err := NewClientWithHTTPSettings(context.Background(), HTTPSettings{MaxIdleConns: 1}, option.WithEndpoint(ts.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	if _, err := c.GetInstance(testProject, testZone, testInstance); err != nil {
+		t.Fatalf("error running GetInstance: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.(*client).Close(); err != nil {
+			t.Errorf("Close call %d returned an error: %v", i+1, err)
+		}
+	}
+	// The client can still be used after Close.
+	if _, err := c.GetInstance(testProject, testZone, testInstance); err != nil {
+		t.Errorf("error running GetInstance after Close: %v", err)
+	}
+
+	// The test client's Close is a no-op unless overridden.
+	tc := &TestClient{}
+	if err := tc.Close(); err != nil {
+		t.Errorf("TestClient Close returned an error: %v", err)
+	}
+	called := false
+	tc.CloseFn = func() error { called = true; return nil }
+	if err := tc.Close(); err != nil || !called {
+		t.Errorf("TestClient Close should use CloseFn, called: %t, err: %v", called, err)
+	}
+}
+
+func TestNetworkEndpointGroup(t *testing.T) {
+	var attached []*compute.NetworkEndpoint
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		url := r.URL.String()
+		if r.Method == "POST" && url == fmt.Sprintf("/projects/%s/zones/%s/networkEndpointGroups?alt=json&prettyPrint=false", testProject, testZone) {
 			fmt.Fprint(w, `{}`)
-		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
-			fmt.Fprint(w, `{"Status":"DONE"}`)
-		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/resume?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+		} else if r.Method == "GET" && url == fmt.Sprintf("/projects/%s/zones/%s/networkEndpointGroups/%s?alt=json&prettyPrint=false", testProject, testZone, testNetworkEndpointGroup) {
+			fmt.Fprintf(w, `{"name":%q,"networkEndpointType":"GCE_VM_IP_PORT","size":%d}`, testNetworkEndpointGroup, len(attached))
+		} else if r.Method == "GET" && url == fmt.Sprintf("/projects/%s/zones/%s/networkEndpointGroups?alt=json&pageToken=&prettyPrint=false", testProject, testZone) {
+			fmt.Fprintf(w, `{"items":[{"name":%q}]}`, testNetworkEndpointGroup)
+		} else if r.Method == "POST" && url == fmt.Sprintf("/projects/%s/zones/%s/networkEndpointGroups/%s/attachNetworkEndpoints?alt=json&prettyPrint=false", testProject, testZone, testNetworkEndpointGroup) {
+			var req compute.NetworkEndpointGroupsAttachEndpointsRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			attached = append(attached, req.NetworkEndpoints...)
 			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && url == fmt.Sprintf("/projects/%s/zones/%s/networkEndpointGroups/%s/detachNetworkEndpoints?alt=json&prettyPrint=false", testProject, testZone, testNetworkEndpointGroup) {
+			attached = nil
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && url == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
 		} else {
 			w.WriteHeader(500)
 			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
@@ -586,10 +905,1858 @@ func TestSuspendResume(t *testing.T) {
 	}
 	defer svr.Close()
 
-	if err := c.Suspend(testProject, testZone, testInstance); err != nil {
-		t.Fatalf("error running Suspend: %v", err)
+	neg := &compute.NetworkEndpointGroup{Name: testNetworkEndpointGroup, NetworkEndpointType: "GCE_VM_IP_PORT"}
+	if err := c.CreateNetworkEndpointGroup(testProject, testZone, neg); err != nil {
+		t.Fatalf("error running CreateNetworkEndpointGroup: %v", err)
 	}
-	if err := c.Resume(testProject, testZone, testInstance); err != nil {
-		t.Fatalf("error running Resume: %v", err)
+	if neg.Name != testNetworkEndpointGroup || neg.NetworkEndpointType != "GCE_VM_IP_PORT" {
+		t.Errorf("unexpected NetworkEndpointGroup: %+v", neg)
+	}
+
+	ep := &compute.NetworkEndpoint{Instance: testInstance, IpAddress: "10.0.0.2", Port: 8080}
+	if err := c.AttachNetworkEndpoints(testProject, testZone, testNetworkEndpointGroup, &compute.NetworkEndpointGroupsAttachEndpointsRequest{NetworkEndpoints: []*compute.NetworkEndpoint{ep}}); err != nil {
+		t.Fatalf("error running AttachNetworkEndpoints: %v", err)
+	}
+	if len(attached) != 1 || attached[0].Instance != testInstance || attached[0].Port != 8080 {
+		t.Errorf("unexpected attached endpoints: %v", attached)
+	}
+	if neg, err = c.GetNetworkEndpointGroup(testProject, testZone, testNetworkEndpointGroup); err != nil {
+		t.Fatalf("error running GetNetworkEndpointGroup: %v", err)
+	}
+	if neg.Size != 1 {
+		t.Errorf("unexpected NetworkEndpointGroup size, got: %d, want: 1", neg.Size)
+	}
+
+	negs, err := c.ListNetworkEndpointGroups(testProject, testZone)
+	if err != nil {
+		t.Fatalf("error running ListNetworkEndpointGroups: %v", err)
+	}
+	if len(negs) != 1 || negs[0].Name != testNetworkEndpointGroup {
+		t.Errorf("unexpected NetworkEndpointGroups: %v", negs)
+	}
+
+	if err := c.DetachNetworkEndpoints(testProject, testZone, testNetworkEndpointGroup, &compute.NetworkEndpointGroupsDetachEndpointsRequest{NetworkEndpoints: []*compute.NetworkEndpoint{ep}}); err != nil {
+		t.Fatalf("error running DetachNetworkEndpoints: %v", err)
+	}
+	if len(attached) != 0 {
+		t.Errorf("endpoints still attached: %v", attached)
+	}
+}
+
+func TestNewClientWithHTTPSettings(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"name":%q}`, testInstance)
+	}))
+	defer ts.Close()
+
+	settings := HTTPSettings{MaxIdleConns: 10, MaxIdleConnsPerHost: 5, Timeout: 30 * time.Second}
+	c, err := NewClientWithHTTPSettings(context.Background(), settings, option.WithEndpoint(ts.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	if got := c.(*client).hc.Timeout; got != settings.Timeout {
+		t.Errorf("unexpected client timeout, got: %v, want: %v", got, settings.Timeout)
+	}
+	bt := settings.baseTransport()
+	if bt.MaxIdleConns != settings.MaxIdleConns || bt.MaxIdleConnsPerHost != settings.MaxIdleConnsPerHost {
+		t.Errorf("unexpected transport pool settings, got: %d/%d, want: %d/%d", bt.MaxIdleConns, bt.MaxIdleConnsPerHost, settings.MaxIdleConns, settings.MaxIdleConnsPerHost)
+	}
+	if i, err := c.GetInstance(testProject, testZone, testInstance); err != nil {
+		t.Errorf("error running GetInstance: %v", err)
+	} else if i.Name != testInstance {
+		t.Errorf("unexpected instance: %q", i.Name)
+	}
+
+	// Timeout only case, a passed in client is copied and not modified.
+	c, err = NewClientWithHTTPSettings(context.Background(), HTTPSettings{Timeout: time.Second}, option.WithEndpoint(ts.URL), option.WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	if got := c.(*client).hc.Timeout; got != time.Second {
+		t.Errorf("unexpected client timeout, got: %v, want: %v", got, time.Second)
+	}
+	if http.DefaultClient.Timeout != 0 {
+		t.Error("http.DefaultClient should not have been modified")
+	}
+}
+
+func TestHTTPCompression(t *testing.T) {
+	var acceptEncoding string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		body := fmt.Sprintf(`{"name":%q}`, testInstance)
+		if acceptEncoding != "gzip" {
+			fmt.Fprint(w, body)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		fmt.Fprint(gw, body)
+		gw.Close()
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		desc     string
+		settings HTTPSettings
+		want     string
+	}{
+		{"default case", HTTPSettings{}, "gzip"},
+		{"disabled case", HTTPSettings{DisableCompression: true}, ""},
+	}
+	for _, tt := range tests {
+		c, err := NewClientWithHTTPSettings(context.Background(), tt.settings, option.WithEndpoint(ts.URL), option.WithoutAuthentication())
+		if err != nil {
+			t.Fatalf("%s: error creating client: %v", tt.desc, err)
+		}
+		acceptEncoding = ""
+		if i, err := c.GetInstance(testProject, testZone, testInstance); err != nil {
+			t.Errorf("%s: error running GetInstance: %v", tt.desc, err)
+		} else if i.Name != testInstance {
+			t.Errorf("%s: unexpected instance: %q", tt.desc, i.Name)
+		}
+		if acceptEncoding != tt.want {
+			t.Errorf("%s: unexpected Accept-Encoding, got: %q, want: %q", tt.desc, acceptEncoding, tt.want)
+		}
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var userAgent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		fmt.Fprintf(w, `{"name":%q}`, testInstance)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		desc string
+		opts []option.ClientOption
+		want string
+	}{
+		{"default case", nil, "compute-daisy/" + Version},
+		{"user agent case", []option.ClientOption{WithUserAgent("my-tool/1.0")}, "compute-daisy/" + Version + " my-tool/1.0"},
+	}
+	for _, tt := range tests {
+		opts := append([]option.ClientOption{option.WithEndpoint(ts.URL), option.WithHTTPClient(http.DefaultClient)}, tt.opts...)
+		c, err := NewClient(context.Background(), opts...)
+		if err != nil {
+			t.Fatalf("%s: error creating client: %v", tt.desc, err)
+		}
+		userAgent = ""
+		if _, err := c.GetInstance(testProject, testZone, testInstance); err != nil {
+			t.Errorf("%s: error running GetInstance: %v", tt.desc, err)
+		}
+		if !strings.HasSuffix(userAgent, " "+tt.want) {
+			t.Errorf("%s: unexpected User-Agent, got: %q, want suffix: %q", tt.desc, userAgent, tt.want)
+		}
+	}
+}
+
+func TestLazyPreviewServices(t *testing.T) {
+	var userAgent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		fmt.Fprintf(w, `{"name":%q}`, testInstance)
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(context.Background(), option.WithEndpoint(ts.URL), option.WithHTTPClient(http.DefaultClient), WithUserAgent("my-tool/1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := c.(*client).preview
+	if p.beta != nil || p.alpha != nil {
+		t.Fatal("preview services built before use")
+	}
+	if _, err := c.GetInstanceBeta(testProject, testZone, testInstance); err != nil {
+		t.Errorf("error running GetInstanceBeta: %v", err)
+	}
+	if p.beta == nil || p.alpha != nil {
+		t.Error("only the beta service should be built after a beta call")
+	}
+	if want := "compute-daisy/" + Version + " my-tool/1.0"; !strings.HasSuffix(userAgent, " "+want) {
+		t.Errorf("unexpected User-Agent, got: %q, want suffix: %q", userAgent, want)
+	}
+	if _, err := c.GetInstanceAlpha(testProject, testZone, testInstance); err != nil {
+		t.Errorf("error running GetInstanceAlpha: %v", err)
+	}
+	if p.alpha == nil {
+		t.Error("alpha service not built after an alpha call")
+	}
+}
+
+func TestWithoutPreviewServices(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"name":%q}`, testInstance)
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(context.Background(), option.WithEndpoint(ts.URL), option.WithHTTPClient(http.DefaultClient), WithoutPreviewServices())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetInstance(testProject, testZone, testInstance); err != nil {
+		t.Errorf("error running GetInstance: %v", err)
+	}
+	if _, err := c.GetInstanceBeta(testProject, testZone, testInstance); err == nil {
+		t.Error("GetInstanceBeta should have failed without preview services")
+	}
+	if _, err := c.GetInstanceAlpha(testProject, testZone, testInstance); err == nil {
+		t.Error("GetInstanceAlpha should have failed without preview services")
+	}
+	cc, err := c.(*client).Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cc.GetInstanceBeta(testProject, testZone, testInstance); err == nil {
+		t.Error("GetInstanceBeta of a copy should have failed without preview services")
+	}
+	if p := c.(*client).preview; p.beta != nil || p.alpha != nil {
+		t.Error("preview services built although disabled")
+	}
+	if got := OtherAPIOptions(WithoutPreviewServices()); len(got) != 0 {
+		t.Errorf("OtherAPIOptions should drop WithoutPreviewServices, got: %v", got)
+	}
+}
+
+func TestLazyPreviewServicesConcurrentFirstUse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"name":%q}`, testInstance)
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(context.Background(), option.WithEndpoint(ts.URL), option.WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := c.(*client).preview
+
+	const n = 10
+	var wg sync.WaitGroup
+	services := make([]*computeBeta.Service, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := c.GetInstanceBeta(testProject, testZone, testInstance); err != nil {
+				t.Errorf("error running GetInstanceBeta: %v", err)
+			}
+			services[i], _ = p.betaService()
+		}(i)
+	}
+	wg.Wait()
+	for i, s := range services {
+		if s == nil || s != services[0] {
+			t.Errorf("call %d used another beta service", i)
+		}
+	}
+}
+
+func BenchmarkNewClient(b *testing.B) {
+	ctx := context.Background()
+	opts := []option.ClientOption{option.WithEndpoint("http://localhost"), option.WithHTTPClient(http.DefaultClient)}
+	b.Run("GA only", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := NewClient(ctx, opts...); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// The cost of every client before the preview services were built on
+	// first use.
+	b.Run("with preview services", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c, err := NewClient(ctx, opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			p := c.(*client).preview
+			if _, err := p.betaService(); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := p.alphaService(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestWithTokenSource(t *testing.T) {
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		fmt.Fprintf(w, `{"name":%q}`, testInstance)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		desc      string
+		tk        *oauth2.Token
+		wantRetry bool
+	}{
+		{"valid token case", &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}, false},
+		{"expired token case", &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(-time.Hour)}, true},
+	}
+	for _, tt := range tests {
+		c, err := NewClientWithHTTPSettings(context.Background(), HTTPSettings{Timeout: time.Minute}, option.WithEndpoint(ts.URL), WithTokenSource(oauth2.StaticTokenSource(tt.tk)))
+		if err != nil {
+			t.Fatalf("%s: error creating client: %v", tt.desc, err)
+		}
+		auth = ""
+		if _, err := c.GetInstance(testProject, testZone, testInstance); err != nil {
+			t.Errorf("%s: error running GetInstance: %v", tt.desc, err)
+		}
+		if auth != "Bearer token" {
+			t.Errorf("%s: unexpected Authorization header: %q", tt.desc, auth)
+		}
+		hc := c.(*client).hc
+		if hc.Timeout != time.Minute {
+			t.Errorf("%s: unexpected client timeout, got: %v, want: %v", tt.desc, hc.Timeout, time.Minute)
+		}
+		// Errors which aren't API errors are retried once the token expired.
+		if got := shouldRetryWithWait(context.Background(), hc.Transport, errors.New("foo"), 0); got != tt.wantRetry {
+			t.Errorf("%s: shouldRetryWithWait == %t, want %t", tt.desc, got, tt.wantRetry)
+		}
+	}
+}
+
+func TestShouldRetryWithWaitCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	// Without the cancelation this waits at least 10s.
+	if shouldRetryWithWait(ctx, nil, &googleapi.Error{Code: 500}, 10) {
+		t.Error("shouldRetryWithWait == true after the context was canceled, want false")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("shouldRetryWithWait returned after %v, the wait wasn't interrupted", d)
+	}
+}
+
+func TestSetRetryContext(t *testing.T) {
+	var calls int
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "unavailable")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.SetRetryContext(ctx)
+	if _, err := c.GetDisk(testProject, testZone, testDisk); err == nil {
+		t.Error("should have returned an error, but didn't")
+	}
+	if calls != 1 {
+		t.Errorf("request sent %d times with a canceled retry context, want 1", calls)
+	}
+}
+
+func TestRetryAlpha(t *testing.T) {
+	defer func(d time.Duration) { retryWait = d }(retryWait)
+	retryWait = time.Millisecond
+
+	tests := []struct {
+		desc      string
+		failures  int
+		wantCalls int
+		shouldErr bool
+	}{
+		{"transient 503 case", 2, 3, false},
+		{"persistent 503 case", 5, 3, true},
+	}
+	for _, tt := range tests {
+		var calls int
+		svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" || r.URL.String() != fmt.Sprintf("/projects/%s/zones/%s/disks?alt=json&prettyPrint=false", testProject, testZone) {
+				w.WriteHeader(500)
+				fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+				return
+			}
+			if calls++; calls <= tt.failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprintln(w, "unavailable")
+				return
+			}
+			fmt.Fprint(w, `{"Name":"op"}`)
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rawAlpha, err := c.preview.alphaService()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The call goes through the Client interface.
+		var ci Client = c
+		op, err := ci.RetryAlpha(rawAlpha.Disks.Insert(testProject, testZone, &computeAlpha.Disk{}).Do)
+		svr.Close()
+		if tt.shouldErr && err == nil {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if !tt.shouldErr && (err != nil || op == nil || op.Name != "op") {
+			t.Errorf("%s: want operation \"op\", got: %+v, error: %v", tt.desc, op, err)
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("%s: request sent %d times, want %d", tt.desc, calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestSuspendResume(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/suspend?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/resume?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			fmt.Fprint(w, `{}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.Suspend(testProject, testZone, testInstance); err != nil {
+		t.Fatalf("error running Suspend: %v", err)
+	}
+	if err := c.Resume(testProject, testZone, testInstance); err != nil {
+		t.Fatalf("error running Resume: %v", err)
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/setTags?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			var tags compute.Tags
+			if err := json.NewDecoder(r.Body).Decode(&tags); err != nil {
+				t.Fatal(err)
+			}
+			if len(tags.Items) != 1 || tags.Items[0] != "tag" || tags.Fingerprint != "fp" {
+				t.Errorf("unexpected tags: %+v", tags)
+			}
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.SetTags(testProject, testZone, testInstance, &compute.Tags{Items: []string{"tag"}, Fingerprint: "fp"}); err != nil {
+		t.Fatalf("error running SetTags: %v", err)
+	}
+}
+
+func TestSetInstanceName(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/setName?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			var req compute.InstancesSetNameRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			if req.CurrentName != testInstance || req.Name != "new-name" {
+				t.Errorf("unexpected set name request: %+v", req)
+			}
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	req := &compute.InstancesSetNameRequest{CurrentName: testInstance, Name: "new-name"}
+	if err := c.SetInstanceName(testProject, testZone, testInstance, req); err != nil {
+		t.Fatalf("error running SetInstanceName: %v", err)
+	}
+}
+
+func TestUpdateDisk(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/disks/%s?alt=json&prettyPrint=false&updateMask=provisionedIops%%2CprovisionedThroughput", testProject, testZone, testDisk) {
+			var d compute.Disk
+			if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+				t.Fatal(err)
+			}
+			if d.ProvisionedIops != 5000 || d.ProvisionedThroughput != 400 {
+				t.Errorf("unexpected disk: %+v", d)
+			}
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	d := &compute.Disk{ProvisionedIops: 5000, ProvisionedThroughput: 400}
+	if err := c.UpdateDisk(testProject, testZone, testDisk, d, []string{"provisionedIops", "provisionedThroughput"}); err != nil {
+		t.Fatalf("error running UpdateDisk: %v", err)
+	}
+
+	// Updating no field is an error, and no request is sent.
+	if err := c.UpdateDisk(testProject, testZone, testDisk, d, nil); err == nil {
+		t.Error("expected error for empty paths, got none")
+	}
+}
+
+func TestPatchNetwork(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" && r.URL.String() == fmt.Sprintf("/projects/%s/global/networks/%s?alt=json&prettyPrint=false", testProject, testNetwork) {
+			var n compute.Network
+			if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+				t.Fatal(err)
+			}
+			if n.RoutingConfig == nil || n.RoutingConfig.RoutingMode != "GLOBAL" {
+				t.Errorf("unexpected network: %+v", n)
+			}
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	n := &compute.Network{RoutingConfig: &compute.NetworkRoutingConfig{RoutingMode: "GLOBAL"}}
+	if err := c.PatchNetwork(testProject, testNetwork, n); err != nil {
+		t.Fatalf("error running PatchNetwork: %v", err)
+	}
+}
+
+func TestSwitchNetworkToCustomMode(t *testing.T) {
+	var switched bool
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/networks/%s/switchToCustomMode?alt=json&prettyPrint=false", testProject, testNetwork) {
+			switched = true
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.SwitchNetworkToCustomMode(testProject, testNetwork); err != nil {
+		t.Fatalf("error running SwitchNetworkToCustomMode: %v", err)
+	}
+	if !switched {
+		t.Error("switchToCustomMode was not called")
+	}
+}
+
+func TestInstanceResourcePolicies(t *testing.T) {
+	policy := fmt.Sprintf("projects/%s/regions/%s/resourcePolicies/policy", testProject, testRegion)
+	var added, removed []string
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/addResourcePolicies?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			var req compute.InstancesAddResourcePoliciesRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			added = req.ResourcePolicies
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/removeResourcePolicies?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			var req compute.InstancesRemoveResourcePoliciesRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			removed = req.ResourcePolicies
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.AddInstanceResourcePolicies(testProject, testZone, testInstance, &compute.InstancesAddResourcePoliciesRequest{ResourcePolicies: []string{policy}}); err != nil {
+		t.Fatalf("error running AddInstanceResourcePolicies: %v", err)
+	}
+	if len(added) != 1 || added[0] != policy {
+		t.Errorf("unexpected added resource policies: %q", added)
+	}
+	if err := c.RemoveInstanceResourcePolicies(testProject, testZone, testInstance, &compute.InstancesRemoveResourcePoliciesRequest{ResourcePolicies: []string{policy}}); err != nil {
+		t.Fatalf("error running RemoveInstanceResourcePolicies: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != policy {
+		t.Errorf("unexpected removed resource policies: %q", removed)
+	}
+}
+
+func TestPollSerialForMarker(t *testing.T) {
+	// The success marker is split across chunks, and empty chunks are
+	// interleaved.
+	chunks := []string{"booting\nstatus: st", "", "arting\nBOOT", "", "", "_SUCCESS: done\nmore"}
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/serialPort", testProject, testZone, testInstance) {
+			start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+			var contents string
+			if start < len(chunks) {
+				contents = chunks[start]
+			}
+			fmt.Fprintf(w, `{"contents":%q,"next":"%d"}`, contents, start+1)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	tests := []struct {
+		desc             string
+		success, failure *regexp.Regexp
+		want             string
+		wantFailure      bool
+	}{
+		{"success case", regexp.MustCompile("BOOT_SUCCESS"), regexp.MustCompile("BOOT_FAILURE"), "BOOT_SUCCESS: done", false},
+		{"failure case", regexp.MustCompile("BOOT_SUCCESS"), regexp.MustCompile("st.rting"), "starting", true},
+		{"no failure marker case", regexp.MustCompile("SUCCESS"), nil, "SUCCESS: done", false},
+		{"trailing partial line case", regexp.MustCompile("^more"), nil, "more", false},
+	}
+	for _, tt := range tests {
+		got, gotFailure, err := c.PollSerialForMarker(context.Background(), testProject, testZone, testInstance, 1, tt.success, tt.failure, time.Millisecond)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if got != tt.want || gotFailure != tt.wantFailure {
+			t.Errorf("%s: got: %q %v, want: %q %v", tt.desc, got, gotFailure, tt.want, tt.wantFailure)
+		}
+	}
+
+	// No match before the context is canceled.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := c.PollSerialForMarker(ctx, testProject, testZone, testInstance, 1, regexp.MustCompile("never"), nil, time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("unexpected error, got: %v, want: %v", err, context.DeadlineExceeded)
+	}
+
+	// No marker given.
+	if _, _, err := c.PollSerialForMarker(context.Background(), testProject, testZone, testInstance, 1, nil, nil, time.Millisecond); err == nil {
+		t.Error("expected error, got none")
+	}
+}
+
+func TestGetEffectiveFirewalls(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/getEffectiveFirewalls?alt=json&networkInterface=nic0&prettyPrint=false", testProject, testZone, testInstance) {
+			fmt.Fprint(w, `{"firewalls": [{"name": "allow-ssh", "direction": "INGRESS", "allowed": [{"IPProtocol": "tcp", "ports": ["22"]}]}], "firewallPolicys": [{"name": "policy", "type": "HIERARCHY"}]}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	got, err := c.GetEffectiveFirewalls(testProject, testZone, testInstance, "nic0")
+	if err != nil {
+		t.Fatalf("error running GetEffectiveFirewalls: %v", err)
+	}
+	if len(got.Firewalls) != 1 || got.Firewalls[0].Name != "allow-ssh" || got.Firewalls[0].Direction != "INGRESS" {
+		t.Fatalf("unexpected firewalls: %+v", got.Firewalls)
+	}
+	if a := got.Firewalls[0].Allowed; len(a) != 1 || a[0].IPProtocol != "tcp" || len(a[0].Ports) != 1 || a[0].Ports[0] != "22" {
+		t.Errorf("unexpected allowed rules: %+v", a)
+	}
+	if len(got.FirewallPolicys) != 1 || got.FirewallPolicys[0].Name != "policy" {
+		t.Errorf("unexpected firewall policies: %+v", got.FirewallPolicys)
+	}
+}
+
+func TestIsZoneAvailable(t *testing.T) {
+	zones := map[string]string{
+		"up":         `{"status":"UP"}`,
+		"down":       `{"status":"DOWN"}`,
+		"active":     `{"status":"UP","deprecated":{"state":"ACTIVE"}}`,
+		"deprecated": `{"status":"UP","deprecated":{"state":"DEPRECATED"}}`,
+	}
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for zone, body := range zones {
+			if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s?alt=json&prettyPrint=false", testProject, zone) {
+				fmt.Fprint(w, body)
+				return
+			}
+		}
+		w.WriteHeader(404)
+		fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	tests := []struct {
+		zone      string
+		want      bool
+		shouldErr bool
+	}{
+		{"up", true, false},
+		{"down", false, false},
+		{"active", true, false},
+		{"deprecated", false, false},
+		{"bad", false, true},
+	}
+	for _, tt := range tests {
+		got, err := c.IsZoneAvailable(testProject, tt.zone)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.zone)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.zone, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got: %t, want: %t", tt.zone, got, tt.want)
+		}
+	}
+}
+
+func TestIsRegionAvailable(t *testing.T) {
+	regions := map[string]string{
+		"up":         `{"status":"UP"}`,
+		"down":       `{"status":"DOWN"}`,
+		"deprecated": `{"status":"UP","deprecated":{"state":"DEPRECATED"}}`,
+	}
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for region, body := range regions {
+			if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/regions/%s?alt=json&prettyPrint=false", testProject, region) {
+				fmt.Fprint(w, body)
+				return
+			}
+		}
+		w.WriteHeader(404)
+		fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	tests := []struct {
+		region    string
+		want      bool
+		shouldErr bool
+	}{
+		{"up", true, false},
+		{"down", false, false},
+		{"deprecated", false, false},
+		{"bad", false, true},
+	}
+	for _, tt := range tests {
+		got, err := c.IsRegionAvailable(testProject, tt.region)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.region)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.region, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got: %t, want: %t", tt.region, got, tt.want)
+		}
+	}
+}
+
+func TestMergeSSHKey(t *testing.T) {
+	tests := []struct {
+		desc, keys, want string
+		wantChanged      bool
+	}{
+		{"empty case", "", "user:key", true},
+		{"append case", "other:key2", "other:key2\nuser:key", true},
+		{"trailing newline case", "other:key2\n", "other:key2\nuser:key", true},
+		{"duplicate case", "user:key\nother:key2", "user:key\nother:key2", false},
+	}
+	for _, tt := range tests {
+		got, changed := mergeSSHKey(tt.keys, "user", "key\n")
+		if got != tt.want || changed != tt.wantChanged {
+			t.Errorf("%s: got: (%q, %t), want: (%q, %t)", tt.desc, got, changed, tt.want, tt.wantChanged)
+		}
+	}
+}
+
+func TestAddProjectSSHKey(t *testing.T) {
+	var sets int
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s?alt=json&prettyPrint=false", testProject) {
+			fmt.Fprintf(w, `{"commonInstanceMetadata":{"fingerprint":"fp%d","items":[{"key":"foo","value":"bar"},{"key":"ssh-keys","value":"other:key2"}]}}`, sets)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/setCommonInstanceMetadata?alt=json&prettyPrint=false", testProject) {
+			var md compute.Metadata
+			if err := json.NewDecoder(r.Body).Decode(&md); err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprintf("fp%d", sets); md.Fingerprint != want {
+				t.Errorf("unexpected fingerprint, got: %q, want: %q", md.Fingerprint, want)
+			}
+			if len(md.Items) != 2 || md.Items[1].Value == nil || *md.Items[1].Value != "other:key2\nuser:key" {
+				t.Errorf("unexpected metadata items: %+v", md.Items)
+			}
+			sets++
+			if sets == 1 {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				fmt.Fprintln(w, "fingerprint mismatch")
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.AddProjectSSHKey(testProject, "user", "key"); err != nil {
+		t.Fatalf("error running AddProjectSSHKey: %v", err)
+	}
+	if sets != 2 {
+		t.Errorf("metadata update should have been retried once, got %d updates", sets)
+	}
+}
+
+func TestMergeCommonInstanceMetadata(t *testing.T) {
+	tests := []struct {
+		desc      string
+		add       map[string]string
+		remove    []string
+		conflicts int
+		want      string
+		wantSets  int
+		shouldErr bool
+	}{
+		{"add case", map[string]string{"new": "v", "foo": "baz"}, nil, 0, `[{"key":"foo","value":"baz"},{"key":"ssh-keys","value":"user:key"},{"key":"new","value":"v"}]`, 1, false},
+		{"remove case", nil, []string{"foo", "dne"}, 0, `[{"key":"ssh-keys","value":"user:key"}]`, 1, false},
+		{"add and remove case", map[string]string{"foo": "baz"}, []string{"foo"}, 0, `[{"key":"ssh-keys","value":"user:key"}]`, 1, false},
+		{"unchanged case", map[string]string{"foo": "bar"}, []string{"dne"}, 0, "", 0, false},
+		{"fingerprint conflict case", map[string]string{"new": "v"}, nil, 1, `[{"key":"foo","value":"bar"},{"key":"ssh-keys","value":"user:key"},{"key":"new","value":"v"}]`, 2, false},
+		{"repeated fingerprint conflict case", map[string]string{"new": "v"}, nil, 2, "", 2, true},
+	}
+	for _, tt := range tests {
+		var sets int
+		svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s?alt=json&prettyPrint=false", testProject) {
+				fmt.Fprintf(w, `{"commonInstanceMetadata":{"fingerprint":"fp%d","items":[{"key":"foo","value":"bar"},{"key":"ssh-keys","value":"user:key"}]}}`, sets)
+			} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/setCommonInstanceMetadata?alt=json&prettyPrint=false", testProject) {
+				var md compute.Metadata
+				if err := json.NewDecoder(r.Body).Decode(&md); err != nil {
+					t.Fatal(err)
+				}
+				if want := fmt.Sprintf("fp%d", sets); md.Fingerprint != want {
+					t.Errorf("%s: unexpected fingerprint, got: %q, want: %q", tt.desc, md.Fingerprint, want)
+				}
+				if got, _ := json.Marshal(md.Items); tt.want != "" && string(got) != tt.want {
+					t.Errorf("%s: unexpected metadata items, got: %s, want: %s", tt.desc, got, tt.want)
+				}
+				sets++
+				if sets <= tt.conflicts {
+					w.WriteHeader(http.StatusPreconditionFailed)
+					fmt.Fprintln(w, "fingerprint mismatch")
+					return
+				}
+				fmt.Fprint(w, `{}`)
+			} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject) {
+				fmt.Fprint(w, `{"Status":"DONE"}`)
+			} else {
+				w.WriteHeader(500)
+				fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+			}
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.MergeCommonInstanceMetadata(testProject, tt.add, tt.remove)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if sets != tt.wantSets {
+			t.Errorf("%s: unexpected number of metadata updates, got: %d, want: %d", tt.desc, sets, tt.wantSets)
+		}
+		svr.Close()
+	}
+}
+
+func TestMergeInstanceMetadata(t *testing.T) {
+	var sets int
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			fmt.Fprintf(w, `{"metadata":{"fingerprint":"fp%d","items":[{"key":"foo","value":"bar"},{"key":"other","value":"%d"}]}}`, sets, sets)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/setMetadata?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			var md compute.Metadata
+			if err := json.NewDecoder(r.Body).Decode(&md); err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprintf("fp%d", sets); md.Fingerprint != want {
+				t.Errorf("unexpected fingerprint, got: %q, want: %q", md.Fingerprint, want)
+			}
+			got, _ := json.Marshal(md.Items)
+			if want := fmt.Sprintf(`[{"key":"other","value":"%d"},{"key":"new","value":"v"}]`, sets); string(got) != want {
+				t.Errorf("unexpected metadata items, got: %s, want: %s", got, want)
+			}
+			sets++
+			if sets == 1 {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				fmt.Fprintln(w, "fingerprint mismatch")
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.MergeInstanceMetadata(testProject, testZone, testInstance, map[string]string{"new": "v"}, []string{"foo"}); err != nil {
+		t.Fatalf("error running MergeInstanceMetadata: %v", err)
+	}
+	if sets != 2 {
+		t.Errorf("metadata update should have been retried once, got %d updates", sets)
+	}
+}
+
+func TestMergeInstanceLabels(t *testing.T) {
+	// The labels are changed concurrently between the first read and update,
+	// the concurrent change is kept by the retry.
+	var sets int
+	var got map[string]string
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			if sets == 0 {
+				fmt.Fprint(w, `{"labelFingerprint":"fp0","labels":{"old":"1"}}`)
+			} else {
+				fmt.Fprint(w, `{"labelFingerprint":"fp1","labels":{"old":"1","other":"2"}}`)
+			}
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/setLabels?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+			var req compute.InstancesSetLabelsRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprintf("fp%d", sets); req.LabelFingerprint != want {
+				t.Errorf("unexpected label fingerprint, got: %q, want: %q", req.LabelFingerprint, want)
+			}
+			got = req.Labels
+			sets++
+			if sets == 1 {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				fmt.Fprintln(w, "fingerprint mismatch")
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.MergeInstanceLabels(testProject, testZone, testInstance, map[string]string{"foo": "bar"}, []string{"old"}); err != nil {
+		t.Fatalf("error running MergeInstanceLabels: %v", err)
+	}
+	if sets != 2 {
+		t.Errorf("labels update should have been retried once, got %d updates", sets)
+	}
+	if want := map[string]string{"foo": "bar", "other": "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected labels, got: %v, want: %v", got, want)
+	}
+
+	// Nothing is sent if the labels already match.
+	sets = 0
+	if err := c.MergeInstanceLabels(testProject, testZone, testInstance, map[string]string{"old": "1"}, []string{"dne"}); err != nil {
+		t.Fatalf("error running MergeInstanceLabels: %v", err)
+	}
+	if sets != 0 {
+		t.Errorf("labels which already match were updated %d times", sets)
+	}
+}
+
+func TestOperationsWaitStallTimeout(t *testing.T) {
+	defer func(i time.Duration) { operationPollInterval = i }(operationPollInterval)
+	operationPollInterval = 5 * time.Millisecond
+	stallTimeout := 50 * time.Millisecond
+
+	tests := []struct {
+		desc      string
+		progress  []int64
+		shouldErr bool
+	}{
+		// Takes longer than the stall timeout overall, but never stalls.
+		{"progressing case", []int64{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110, 120, 130, 140}, false},
+		{"progress then stall case", []int64{0, 10, 20, 30}, true},
+	}
+	for _, tt := range tests {
+		var calls int
+		var lastAdvance time.Time
+		svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations/op/wait?alt=json&prettyPrint=false", testProject, testZone) {
+				if !tt.shouldErr && calls == len(tt.progress) {
+					fmt.Fprint(w, `{"Status":"DONE","Progress":100}`)
+					return
+				}
+				// Stalled operations stay at their last progress.
+				i := calls
+				if i >= len(tt.progress) {
+					i = len(tt.progress) - 1
+				} else {
+					lastAdvance = time.Now()
+				}
+				calls++
+				fmt.Fprintf(w, `{"Status":"RUNNING","Progress":%d}`, tt.progress[i])
+			} else {
+				w.WriteHeader(500)
+				fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+			}
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetOperationStallTimeout(stallTimeout)
+
+		err = c.zoneOperationsWait(testProject, testZone, "op")
+		svr.Close()
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.shouldErr {
+			if calls <= len(tt.progress) {
+				t.Errorf("%s: timed out before progress stalled, after %d checks", tt.desc, calls)
+			}
+			if stalled := time.Since(lastAdvance); stalled < stallTimeout {
+				t.Errorf("%s: timed out after a stall of %v, want at least %v", tt.desc, stalled, stallTimeout)
+			}
+		}
+	}
+}
+
+func TestOperationsWaitProgressHook(t *testing.T) {
+	defer func(i time.Duration) { operationPollInterval = i }(operationPollInterval)
+	operationPollInterval = time.Millisecond
+
+	progress := []int64{0, 0, 30, 30, 60}
+	var calls int
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations/op/wait?alt=json&prettyPrint=false", testProject, testZone) {
+			if calls == len(progress) {
+				fmt.Fprint(w, `{"Status":"DONE","Progress":100,"TargetLink":"link"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"Status":"RUNNING","Progress":%d,"TargetLink":"link"}`, progress[calls])
+			calls++
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	var got []OperationProgress
+	c.SetOperationProgressHook(func(p OperationProgress) {
+		got = append(got, p)
+	})
+	if err := c.zoneOperationsWait(testProject, testZone, "op"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Only increases of the progress are reported.
+	var want []OperationProgress
+	for _, p := range []int64{0, 30, 60, 100} {
+		want = append(want, OperationProgress{Project: testProject, Name: "op", TargetLink: "link", Progress: p})
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("reported progress does not match expectation: (-got +want)\n%s", diff)
+	}
+
+	// Removing the hook stops the reports.
+	c.SetOperationProgressHook(nil)
+	got = nil
+	calls = 0
+	if err := c.zoneOperationsWait(testProject, testZone, "op"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != nil {
+		t.Errorf("progress reported after the hook was removed: %v", got)
+	}
+}
+
+func TestOperationsWaitNoStallTimeout(t *testing.T) {
+	defer func(i time.Duration) { operationPollInterval = i }(operationPollInterval)
+	operationPollInterval = time.Millisecond
+
+	var calls int
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations/op/wait?alt=json&prettyPrint=false", testProject) {
+			calls++
+			if calls == 20 {
+				fmt.Fprint(w, `{"Status":"DONE"}`)
+				return
+			}
+			fmt.Fprint(w, `{"Status":"RUNNING","Progress":0}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	// Without a stall timeout operations are waited on until done.
+	if err := c.globalOperationsWait(testProject, "op"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWaitForImageOperation(t *testing.T) {
+	defer func(i time.Duration) { operationPollInterval = i }(operationPollInterval)
+	operationPollInterval = time.Millisecond
+	interval := 20 * time.Millisecond
+
+	var checks []time.Time
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations/op/wait?alt=json&prettyPrint=false", testProject) {
+			checks = append(checks, time.Now())
+			if len(checks) == 3 {
+				fmt.Fprint(w, `{"Status":"DONE"}`)
+				return
+			}
+			fmt.Fprint(w, `{"Status":"RUNNING"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+	c.SetImageOperationPollInterval(interval)
+
+	if err := c.WaitForImageOperation(testProject, "op"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(checks) != 3 {
+		t.Fatalf("operation checked %d times, want 3", len(checks))
+	}
+	for i := 1; i < len(checks); i++ {
+		if d := checks[i].Sub(checks[i-1]); d < interval {
+			t.Errorf("operation checked %v after the previous check, want at least %v", d, interval)
+		}
+	}
+}
+
+func TestOperationPollInterval(t *testing.T) {
+	tests := []struct {
+		desc                  string
+		opts                  []option.ClientOption
+		wantInterval, wantMax time.Duration
+	}{
+		{"default case", nil, time.Second, time.Second},
+		{"interval case", []option.ClientOption{WithOperationPollInterval(100 * time.Millisecond)}, 100 * time.Millisecond, 100 * time.Millisecond},
+		{"backoff case", []option.ClientOption{WithOperationPollInterval(100 * time.Millisecond), WithOperationPollMaxInterval(5 * time.Second)}, 100 * time.Millisecond, 5 * time.Second},
+		{"default interval backoff case", []option.ClientOption{WithOperationPollMaxInterval(5 * time.Second)}, time.Second, 5 * time.Second},
+		{"max less than interval case", []option.ClientOption{WithOperationPollInterval(2 * time.Second), WithOperationPollMaxInterval(time.Second)}, 2 * time.Second, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		opts := append([]option.ClientOption{option.WithEndpoint("http://localhost"), option.WithHTTPClient(http.DefaultClient)}, tt.opts...)
+		c, err := NewClient(context.Background(), opts...)
+		if err != nil {
+			t.Fatalf("%s: error creating client: %v", tt.desc, err)
+		}
+		if interval, max := c.(*client).OperationPollInterval(); interval != tt.wantInterval || max != tt.wantMax {
+			t.Errorf("%s: got interval %v up to %v, want %v up to %v", tt.desc, interval, max, tt.wantInterval, tt.wantMax)
+		}
+	}
+}
+
+func TestOperationsWaitBackoff(t *testing.T) {
+	var checks []time.Time
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations/op/wait?alt=json&prettyPrint=false", testProject, testZone) {
+			checks = append(checks, time.Now())
+			if len(checks) == 5 {
+				fmt.Fprint(w, `{"Status":"DONE"}`)
+				return
+			}
+			// The operation doesn't progress after the first check.
+			fmt.Fprint(w, `{"Status":"RUNNING","Progress":10}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+	c.opPollInterval = 5 * time.Millisecond
+	c.opPollMaxInterval = 20 * time.Millisecond
+
+	if err := c.zoneOperationsWait(testProject, testZone, "op"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(checks) != 5 {
+		t.Fatalf("operation checked %d times, want 5", len(checks))
+	}
+	for i, want := range []time.Duration{5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond} {
+		if d := checks[i+1].Sub(checks[i]); d < want {
+			t.Errorf("check %d made %v after the previous check, want at least %v", i+2, d, want)
+		}
+	}
+}
+
+func TestListImagesBeta(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/global/images?alt=json&filter=foo&orderBy=bar&pageToken=&prettyPrint=false", testProject) {
+			fmt.Fprintf(w, `{"items":[{"name":%q}],"nextPageToken":"next"}`, testImageBeta)
+		} else if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/global/images?alt=json&filter=foo&orderBy=bar&pageToken=next&prettyPrint=false", testProject) {
+			fmt.Fprint(w, `{"items":[{"name":"image2"}]}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	is, err := c.ListImagesBeta(testProject, Filter("foo"), OrderBy("bar"))
+	if err != nil {
+		t.Fatalf("error running ListImagesBeta: %v", err)
+	}
+	if len(is) != 2 || is[0].Name != testImageBeta || is[1].Name != "image2" {
+		t.Errorf("unexpected images: %v", is)
+	}
+}
+
+func TestListSnapshots(t *testing.T) {
+	filter := fmt.Sprintf("sourceDisk eq .*/%s", testDisk)
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == fmt.Sprintf("/projects/%s/global/snapshots", testProject) {
+			if got := r.URL.Query().Get("filter"); got != filter {
+				t.Errorf("unexpected filter, got: %q, want: %q", got, filter)
+			}
+			if got := r.URL.Query().Get("orderBy"); got != "creationTimestamp desc" {
+				t.Errorf("unexpected orderBy, got: %q, want: %q", got, "creationTimestamp desc")
+			}
+			fmt.Fprint(w, `{"items":[{"name":"snapshot"}]}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	ss, err := c.ListSnapshots(testProject, Filter(filter), OrderBy("creationTimestamp desc"))
+	if err != nil {
+		t.Fatalf("error running ListSnapshots: %v", err)
+	}
+	if len(ss) != 1 || ss[0].Name != "snapshot" {
+		t.Errorf("unexpected snapshots: %v", ss)
+	}
+}
+
+func TestListMachineImages(t *testing.T) {
+	filter := "labels.cleanup=true"
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == fmt.Sprintf("/projects/%s/global/machineImages", testProject) {
+			if got := r.URL.Query().Get("filter"); got != filter {
+				t.Errorf("unexpected filter, got: %q, want: %q", got, filter)
+			}
+			if r.URL.Query().Get("pageToken") == "" {
+				fmt.Fprint(w, `{"items":[{"name":"mi1"}],"nextPageToken":"next"}`)
+				return
+			}
+			fmt.Fprint(w, `{"items":[{"name":"mi2"}]}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	mis, err := c.ListMachineImages(testProject, Filter(filter))
+	if err != nil {
+		t.Fatalf("error running ListMachineImages: %v", err)
+	}
+	if len(mis) != 2 || mis[0].Name != "mi1" || mis[1].Name != "mi2" {
+		t.Errorf("unexpected machine images: %v", mis)
+	}
+}
+
+func TestListInstanceReferrers(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == fmt.Sprintf("/projects/%s/zones/%s/instances/%s/referrers", testProject, testZone, testInstance) {
+			if got := r.URL.Query().Get("filter"); got != "foo" {
+				t.Errorf("unexpected filter, got: %q, want: %q", got, "foo")
+			}
+			if r.URL.Query().Get("pageToken") == "" {
+				fmt.Fprint(w, `{"items":[{"referrer":"ig1","referenceType":"MEMBER_OF"}],"nextPageToken":"next"}`)
+				return
+			}
+			fmt.Fprint(w, `{"items":[{"referrer":"ig2","referenceType":"MEMBER_OF"}]}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	rs, err := c.ListInstanceReferrers(testProject, testZone, testInstance, Filter("foo"))
+	if err != nil {
+		t.Fatalf("error running ListInstanceReferrers: %v", err)
+	}
+	if len(rs) != 2 || rs[0].Referrer != "ig1" || rs[1].Referrer != "ig2" {
+		t.Errorf("unexpected referrers: %v", rs)
+	}
+}
+
+func TestAggregatedListLoadBalancing(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var field string
+		switch r.URL.Path {
+		case fmt.Sprintf("/projects/%s/aggregated/backendServices", testProject):
+			field = "backendServices"
+		case fmt.Sprintf("/projects/%s/aggregated/healthChecks", testProject):
+			field = "healthChecks"
+		case fmt.Sprintf("/projects/%s/aggregated/forwardingRules", testProject):
+			field = "forwardingRules"
+		}
+		if r.Method != "GET" || field == "" {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+			return
+		}
+		if got := r.URL.Query().Get("filter"); got != "foo" {
+			t.Errorf("unexpected filter, got: %q, want: %q", got, "foo")
+		}
+		if r.URL.Query().Get("pageToken") == "" {
+			fmt.Fprintf(w, `{"items":{"global":{"%[1]s":[{"name":"global"}]},"regions/r1":{"%[1]s":[{"name":"r1"}]},"regions/r2":{}},"nextPageToken":"next"}`, field)
+			return
+		}
+		fmt.Fprintf(w, `{"items":{"regions/r3":{"%s":[{"name":"r3"}]}}}`, field)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	var got []string
+	bss, err := c.AggregatedListBackendServices(testProject, Filter("foo"))
+	if err != nil {
+		t.Fatalf("error running AggregatedListBackendServices: %v", err)
+	}
+	for _, bs := range bss {
+		got = append(got, bs.Name)
+	}
+	hcs, err := c.AggregatedListHealthChecks(testProject, Filter("foo"))
+	if err != nil {
+		t.Fatalf("error running AggregatedListHealthChecks: %v", err)
+	}
+	for _, hc := range hcs {
+		got = append(got, hc.Name)
+	}
+	frs, err := c.AggregatedListForwardingRules(testProject, Filter("foo"))
+	if err != nil {
+		t.Fatalf("error running AggregatedListForwardingRules: %v", err)
+	}
+	for _, fr := range frs {
+		got = append(got, fr.Name)
+	}
+
+	// Scopes are a map, only the order of the pages is stable.
+	for i := 0; i < len(got); i += 3 {
+		sort.Strings(got[i : i+2])
+	}
+	want := []string{"global", "r1", "r3", "global", "r1", "r3", "global", "r1", "r3"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected resources, got: %v, want: %v", got, want)
+	}
+}
+
+func TestIsOSLoginEnabled(t *testing.T) {
+	tests := []struct {
+		desc, instanceMD, projectMD string
+		want                        bool
+	}{
+		{"unset case", `{}`, `{}`, false},
+		{"project enabled case", `{}`, `{"items":[{"key":"enable-oslogin","value":"TRUE"}]}`, true},
+		{"instance enabled case", `{"items":[{"key":"enable-oslogin","value":"true"}]}`, `{}`, true},
+		{"instance disabled case", `{"items":[{"key":"enable-oslogin","value":"false"}]}`, `{"items":[{"key":"enable-oslogin","value":"true"}]}`, false},
+	}
+	for _, tt := range tests {
+		svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/instances/%s?alt=json&prettyPrint=false", testProject, testZone, testInstance) {
+				fmt.Fprintf(w, `{"metadata":%s}`, tt.instanceMD)
+			} else if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s?alt=json&prettyPrint=false", testProject) {
+				fmt.Fprintf(w, `{"commonInstanceMetadata":%s}`, tt.projectMD)
+			} else {
+				w.WriteHeader(500)
+				fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+			}
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := c.IsOSLoginEnabled(testProject, testZone, testInstance)
+		svr.Close()
+		if err != nil {
+			t.Errorf("%s: error running IsOSLoginEnabled: %v", tt.desc, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got: %t, want: %t", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestStatefulInstanceGroupManager(t *testing.T) {
+	igm := "test-igm"
+	var gotPolicy *compute.StatefulPolicy
+	var gotConfigs []*compute.PerInstanceConfig
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" && r.URL.String() == fmt.Sprintf("/projects/%s/regions/%s/instanceGroupManagers/%s?alt=json&prettyPrint=false", testProject, testRegion, igm) {
+			var m compute.InstanceGroupManager
+			if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+				t.Fatal(err)
+			}
+			gotPolicy = m.StatefulPolicy
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/regions/%s/instanceGroupManagers/%s/updatePerInstanceConfigs?alt=json&prettyPrint=false", testProject, testRegion, igm) {
+			var req compute.RegionInstanceGroupManagerUpdateInstanceConfigReq
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			gotConfigs = req.PerInstanceConfigs
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/regions/%s/operations//wait?alt=json&prettyPrint=false", testProject, testRegion) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	policy := &compute.StatefulPolicy{PreservedState: &compute.StatefulPolicyPreservedState{
+		Disks: map[string]compute.StatefulPolicyPreservedStateDiskDevice{"data": {AutoDelete: "NEVER"}},
+	}}
+	if err := c.PatchRegionInstanceGroupManager(testProject, testRegion, igm, &compute.InstanceGroupManager{StatefulPolicy: policy}); err != nil {
+		t.Fatalf("error running PatchRegionInstanceGroupManager: %v", err)
+	}
+	if diff := pretty.Compare(gotPolicy, policy); diff != "" {
+		t.Errorf("unexpected stateful policy: (-got +want)\n%s", diff)
+	}
+
+	configs := []*compute.PerInstanceConfig{{
+		Name: "replica-1",
+		PreservedState: &compute.PreservedState{
+			Disks:    map[string]compute.PreservedStatePreservedDisk{"data": {Source: "zones/z/disks/data-1", AutoDelete: "NEVER"}},
+			Metadata: map[string]string{"role": "primary"},
+		},
+	}}
+	if err := c.UpdateRegionPerInstanceConfigs(testProject, testRegion, igm, &compute.RegionInstanceGroupManagerUpdateInstanceConfigReq{PerInstanceConfigs: configs}); err != nil {
+		t.Fatalf("error running UpdateRegionPerInstanceConfigs: %v", err)
+	}
+	if diff := pretty.Compare(gotConfigs, configs); diff != "" {
+		t.Errorf("unexpected per-instance configs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestOperationsPollGet(t *testing.T) {
+	defer func(i time.Duration) { operationPollInterval = i }(operationPollInterval)
+	operationPollInterval = time.Millisecond
+
+	var calls int
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations/op?alt=json&fields=status%%2Cprogress%%2Cerror%%2CtargetLink%%2CoperationType&prettyPrint=false", testProject, testZone) {
+			calls++
+			if calls < 3 {
+				fmt.Fprintf(w, `{"status":"RUNNING","progress":%d}`, calls*10)
+				return
+			}
+			fmt.Fprint(w, `{"status":"DONE","progress":100}`)
+		} else if r.Method == "GET" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations/op?alt=json&fields=status%%2Cprogress%%2Cerror%%2CtargetLink%%2CoperationType&prettyPrint=false", testProject) {
+			fmt.Fprint(w, `{"status":"DONE","error":{"errors":[{"code":"BAD","message":"bad"}]}}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+	c.SetOperationPollStrategy(OperationPollGet)
+
+	if err := c.zoneOperationsWait(testProject, testZone, "op"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("unexpected number of polls, got: %d, want: 3", calls)
+	}
+	if err := c.globalOperationsWait(testProject, "op"); err == nil || !strings.Contains(err.Error(), fmt.Sprintf(OperationErrorCodeFormat, "BAD")) {
+		t.Errorf("operation error not returned, got: %v", err)
+	}
+}
+
+func TestOperationsWaitBeta(t *testing.T) {
+	defer func(i time.Duration) { operationPollInterval = i }(operationPollInterval)
+	operationPollInterval = time.Millisecond
+
+	var calls int
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations/op/wait?alt=json&prettyPrint=false", testProject, testZone) {
+			calls++
+			if calls < 3 {
+				fmt.Fprint(w, `{"status":"RUNNING"}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"DONE"}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/regions/%s/operations/op/wait?alt=json&prettyPrint=false", testProject, testRegion) {
+			fmt.Fprint(w, `{"status":"DONE","error":{"errors":[{"code":"BAD","message":"bad"}]}}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations/op/wait?alt=json&prettyPrint=false", testProject) {
+			fmt.Fprint(w, `{"status":"UNKNOWN"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	if err := c.zoneOperationsWaitBeta(testProject, testZone, "op"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("unexpected number of polls, got: %d, want: 3", calls)
+	}
+	if err := c.regionOperationsWaitBeta(testProject, testRegion, "op"); !HasOperationErrorCode(err, "BAD") {
+		t.Errorf("operation error not returned, got: %v", err)
+	}
+	if err := c.globalOperationsWaitBeta(testProject, "op"); err == nil || !strings.Contains(err.Error(), "UNKNOWN") {
+		t.Errorf("unknown status error not returned, got: %v", err)
+	}
+}
+
+func TestOperationErrorFormatter(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/global/operations/op/wait?alt=json&prettyPrint=false", testProject) {
+			fmt.Fprint(w, `{"status":"DONE","error":{"errors":[{"code":"CONDITION_NOT_MET","message":"bad fingerprint"}]}}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	c.SetOperationErrorFormatter(func(errs []*compute.OperationErrorErrors) string {
+		b, err := json.Marshal(errs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	})
+	err = c.globalOperationsWait(testProject, "op")
+	if want := `[{"code":"CONDITION_NOT_MET","message":"bad fingerprint"}]`; err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("operation error not formatted, got: %v, want suffix: %s", err, want)
+	}
+	if !IsFingerprintConflict(err) {
+		t.Errorf("fingerprint conflict not detected with custom formatter: %v", err)
+	}
+
+	c.SetOperationErrorFormatter(nil)
+	err = c.globalOperationsWait(testProject, "op")
+	if want := "\nCode: CONDITION_NOT_MET\nMessage: bad fingerprint"; err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("default format not restored, got: %v, want suffix: %q", err, want)
+	}
+}
+
+func TestCreateSnapshotWithOptions(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+	var got *compute.Snapshot
+	c.CreateSnapshotFn = func(project, zone, disk string, s *compute.Snapshot) error {
+		if project != testProject || zone != testZone || disk != testDisk {
+			t.Errorf("unexpected disk: %s/%s/%s", project, zone, disk)
+		}
+		got = s
+		return nil
+	}
+
+	tests := []struct {
+		desc      string
+		opts      SnapshotOptions
+		want      *compute.Snapshot
+		shouldErr bool
+	}{
+		{"no options case", SnapshotOptions{}, &compute.Snapshot{Name: "s", Labels: map[string]string{"a": "b"}}, false},
+		{
+			"all options case",
+			SnapshotOptions{StorageLocations: []string{"us-central1"}, ChainName: "chain-1", Labels: map[string]string{"c": "d"}},
+			&compute.Snapshot{Name: "s", StorageLocations: []string{"us-central1"}, ChainName: "chain-1", Labels: map[string]string{"a": "b", "c": "d"}},
+			false,
+		},
+		{"multi-region case", SnapshotOptions{StorageLocations: []string{"us"}}, &compute.Snapshot{Name: "s", StorageLocations: []string{"us"}, Labels: map[string]string{"a": "b"}}, false},
+		{"two locations case", SnapshotOptions{StorageLocations: []string{"us", "eu"}}, nil, true},
+		{"bad location case", SnapshotOptions{StorageLocations: []string{"US Central"}}, nil, true},
+		{"bad chain name case", SnapshotOptions{ChainName: "Chain_1"}, nil, true},
+		{"long chain name case", SnapshotOptions{ChainName: strings.Repeat("a", 64)}, nil, true},
+		{"bad label key case", SnapshotOptions{Labels: map[string]string{"1a": "b"}}, nil, true},
+		{"bad label value case", SnapshotOptions{Labels: map[string]string{"a": "B"}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		got = nil
+		err := c.CreateSnapshotWithOptions(testProject, testZone, testDisk, &compute.Snapshot{Name: "s", Labels: map[string]string{"a": "b"}}, tt.opts)
+		if tt.shouldErr {
+			if err == nil {
+				t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+			}
+			if got != nil {
+				t.Errorf("%s: snapshot shouldn't have been created", tt.desc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: unexpected snapshot, got: %+v, want: %+v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestRegionInstanceTemplates(t *testing.T) {
+	template := "test-template"
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := r.URL.String()
+		if r.Method == "POST" && u == fmt.Sprintf("/projects/%s/regions/%s/instanceTemplates?alt=json&prettyPrint=false", testProject, testRegion) {
+			fmt.Fprint(w, `{"name":"op-create"}`)
+		} else if r.Method == "GET" && u == fmt.Sprintf("/projects/%s/regions/%s/instanceTemplates/%s?alt=json&prettyPrint=false", testProject, testRegion, template) {
+			fmt.Fprintf(w, `{"name":%q,"region":%q}`, template, testRegion)
+		} else if r.Method == "DELETE" && u == fmt.Sprintf("/projects/%s/regions/%s/instanceTemplates/%s?alt=json&prettyPrint=false", testProject, testRegion, template) {
+			fmt.Fprint(w, `{"name":"op-delete"}`)
+		} else if r.Method == "GET" && u == fmt.Sprintf("/projects/%s/regions/%s/instanceTemplates?alt=json&pageToken=&prettyPrint=false", testProject, testRegion) {
+			fmt.Fprintf(w, `{"items":[{"name":%q}],"nextPageToken":"next"}`, template)
+		} else if r.Method == "GET" && u == fmt.Sprintf("/projects/%s/regions/%s/instanceTemplates?alt=json&pageToken=next&prettyPrint=false", testProject, testRegion) {
+			fmt.Fprint(w, `{"items":[{"name":"template2"}]}`)
+		} else if r.Method == "POST" && (u == fmt.Sprintf("/projects/%s/regions/%s/operations/op-create/wait?alt=json&prettyPrint=false", testProject, testRegion) ||
+			u == fmt.Sprintf("/projects/%s/regions/%s/operations/op-delete/wait?alt=json&prettyPrint=false", testProject, testRegion)) {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	it := &compute.InstanceTemplate{Name: template}
+	if err := c.CreateRegionInstanceTemplate(testProject, testRegion, it); err != nil {
+		t.Fatalf("error running CreateRegionInstanceTemplate: %v", err)
+	}
+	if it.Region != testRegion {
+		t.Errorf("created instance template not updated, got region: %q, want: %q", it.Region, testRegion)
+	}
+	if err := c.DeleteRegionInstanceTemplate(testProject, testRegion, template); err != nil {
+		t.Fatalf("error running DeleteRegionInstanceTemplate: %v", err)
+	}
+	its, err := c.ListRegionInstanceTemplates(testProject, testRegion)
+	if err != nil {
+		t.Fatalf("error running ListRegionInstanceTemplates: %v", err)
+	}
+	if len(its) != 2 || its[0].Name != template || its[1].Name != "template2" {
+		t.Errorf("unexpected instance templates: %v", its)
+	}
+}
+
+func TestCallBudget(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"name":%q}`, testInstance)
+	}))
+	defer ts.Close()
+
+	nc, err := NewClient(context.Background(), option.WithEndpoint(ts.URL), option.WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	c := nc.(*client)
+	c.SetCallBudget(2)
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetInstance(testProject, testZone, testInstance); err != nil {
+			t.Errorf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	if _, err := c.GetInstance(testProject, testZone, testInstance); !errors.Is(err, ErrCallBudgetExceeded) {
+		t.Errorf("call over budget: got error %v, want %v", err, ErrCallBudgetExceeded)
+	}
+	if requests != 2 {
+		t.Errorf("requests sent: got %d, want 2", requests)
+	}
+	if got := c.CallCount(); got != 3 {
+		t.Errorf("CallCount() = %d, want 3", got)
+	}
+
+	c.SetCallBudget(0)
+	if _, err := c.GetInstance(testProject, testZone, testInstance); err != nil {
+		t.Errorf("call without budget: unexpected error: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("requests sent: got %d, want 3", requests)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	var userAgent string
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		fmt.Fprintf(w, `{"name":%q}`, testInstance)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+	c.appendUserAgent("my-tool/1.0")
+	c.GetDiskFn = func(_, _, name string) (*compute.Disk, error) { return &compute.Disk{Name: name}, nil }
+	c.SetCallBudget(1)
+
+	copied, err := c.Copy()
+	if err != nil {
+		t.Fatalf("error copying client: %v", err)
+	}
+	cc, ok := copied.(*TestClient)
+	if !ok {
+		t.Fatalf("copy of a *TestClient is a %T", copied)
+	}
+	cc.SetCallBudget(2)
+	for i := 0; i < 2; i++ {
+		if _, err := cc.GetInstance(testProject, testZone, testInstance); err != nil {
+			t.Errorf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	if got := cc.CallCount(); got != 2 {
+		t.Errorf("copy: CallCount() = %d, want 2", got)
+	}
+	if got := c.CallCount(); got != 0 {
+		t.Errorf("client: CallCount() = %d, want 0", got)
+	}
+	if !strings.HasSuffix(userAgent, " my-tool/1.0") {
+		t.Errorf("copy lost the User-Agent, got: %q", userAgent)
+	}
+	if cc.BasePath() != c.BasePath() {
+		t.Errorf("copy base path: got %q, want %q", cc.BasePath(), c.BasePath())
+	}
+	if d, err := cc.GetDisk(testProject, testZone, testDisk); err != nil || d.Name != testDisk {
+		t.Errorf("copy lost the override method, got: %v, %v", d, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cc.SetRetryContext(ctx)
+	if c.retryCtx.get().Err() != nil {
+		t.Error("the retry context of the copy was set on the client")
+	}
+
+	var reported bool
+	cc.SetOperationProgressHook(func(OperationProgress) { reported = true })
+	c.opProgress.report(OperationProgress{})
+	if reported {
+		t.Error("the operation progress hook of the copy was set on the client")
 	}
 }
@@ -0,0 +1,93 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// PingErrorKind is the cause of a failed Ping.
+type PingErrorKind string
+
+// Causes of a failed Ping.
+const (
+	// PingAuth means the credentials are missing, invalid or expired.
+	PingAuth PingErrorKind = "auth"
+	// PingPermission means the credentials are valid but aren't allowed to
+	// read the project.
+	PingPermission PingErrorKind = "permission"
+	// PingNetwork means the API couldn't be reached.
+	PingNetwork PingErrorKind = "network"
+	// PingOther is any other error, e.g. the project doesn't exist.
+	PingOther PingErrorKind = "other"
+)
+
+// PingError is the error returned by Ping.
+type PingError struct {
+	Kind    PingErrorKind
+	Project string
+	Err     error
+}
+
+func (e *PingError) Error() string {
+	return fmt.Sprintf("ping of project %q failed (%s): %v", e.Project, e.Kind, e.Err)
+}
+
+func (e *PingError) Unwrap() error {
+	return e.Err
+}
+
+// pingErrorKind classifies the error of the request made by Ping.
+func pingErrorKind(err error) PingErrorKind {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusUnauthorized:
+			return PingAuth
+		case http.StatusForbidden:
+			return PingPermission
+		}
+		return PingOther
+	}
+	// Failures to get a token are wrapped in network errors, check them
+	// first.
+	var tokenErr *oauth2.RetrieveError
+	if errors.As(err, &tokenErr) {
+		return PingAuth
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return PingNetwork
+	}
+	return PingOther
+}
+
+// Ping checks that the client can reach the API and read project with its
+// credentials, before starting long running work. It makes a single cheap
+// request, without retries. A failure is returned as a *PingError telling
+// apart credential, permission and network errors.
+func (c *client) Ping(ctx context.Context, project string) error {
+	if _, err := c.raw.Projects.Get(project).Fields("name").Context(ctx).Do(); err != nil {
+		return &PingError{Kind: pingErrorKind(err), Project: project, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		desc     string
+		code     int
+		wantKind PingErrorKind
+	}{
+		{"ok case", http.StatusOK, ""},
+		{"unauthenticated case", http.StatusUnauthorized, PingAuth},
+		{"permission denied case", http.StatusForbidden, PingPermission},
+		{"project dne case", http.StatusNotFound, PingOther},
+	}
+	for _, tt := range tests {
+		var calls int
+		svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if r.Method != "GET" || r.URL.String() != fmt.Sprintf("/projects/%s?alt=json&fields=name&prettyPrint=false", testProject) {
+				w.WriteHeader(500)
+				fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+				return
+			}
+			w.WriteHeader(tt.code)
+			fmt.Fprintf(w, `{"Name":%q}`, testProject)
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.Ping(context.Background(), testProject)
+		svr.Close()
+		checkPingError(t, tt.desc, err, tt.wantKind)
+		if calls != 1 {
+			t.Errorf("%s: want 1 request, got %d", tt.desc, calls)
+		}
+	}
+}
+
+func TestPingNetworkError(t *testing.T) {
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Requests to a closed server fail to connect.
+	svr.Close()
+
+	checkPingError(t, "network error case", c.Ping(context.Background(), testProject), PingNetwork)
+}
+
+func TestPingErrorKind(t *testing.T) {
+	tokenErr := &url.Error{Op: "Get", URL: "https://compute.googleapis.com", Err: &oauth2.RetrieveError{}}
+	if got := pingErrorKind(tokenErr); got != PingAuth {
+		t.Errorf("token error: got kind %q, want %q", got, PingAuth)
+	}
+	if got := pingErrorKind(errors.New("error")); got != PingOther {
+		t.Errorf("other error: got kind %q, want %q", got, PingOther)
+	}
+}
+
+func checkPingError(t *testing.T, desc string, err error, wantKind PingErrorKind) {
+	t.Helper()
+	if wantKind == "" {
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", desc, err)
+		}
+		return
+	}
+	var pingErr *PingError
+	if !errors.As(err, &pingErr) {
+		t.Errorf("%s: want a *PingError, got: %v", desc, err)
+		return
+	}
+	if pingErr.Kind != wantKind || pingErr.Project != testProject {
+		t.Errorf("%s: got kind %q for project %q, want kind %q for project %q", desc, pingErr.Kind, pingErr.Project, wantKind, testProject)
+	}
+}
@@ -0,0 +1,226 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package compute
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/option"
+)
+
+// interaction is an API request and its response, as recorded by
+// NewRecordingClient.
+type interaction struct {
+	Method string
+	// URL is the request path and query, the host isn't recorded.
+	URL        string
+	Body       string `json:",omitempty"`
+	StatusCode int
+	Header     http.Header `json:",omitempty"`
+	Response   string      `json:",omitempty"`
+}
+
+var (
+	// runIDRgx matches the workflow ID which Daisy appends to the names it
+	// generates, e.g. the "-x7s2m" of "disk-wf-x7s2m".
+	runIDRgx = regexp.MustCompile(`-[bdghjlmnpqrstvwxyz0-9]{5}\b`)
+	// runTimeRgx matches the time in the scratch path of a workflow, e.g. the
+	// "20260102-15:04:05" of "daisy-wf-20260102-15:04:05-x7s2m".
+	runTimeRgx = regexp.MustCompile(`\d{8}-\d{2}:\d{2}:\d{2}`)
+)
+
+// key identifies the requests which are served the same responses on
+// replay. The parts of a request which differ between runs of a workflow,
+// its ID and start time, are left out so that a run can be replayed.
+func (i *interaction) key() string {
+	k := i.Method + " " + i.URL + "\n" + i.Body
+	k = runTimeRgx.ReplaceAllString(k, "*")
+	return runIDRgx.ReplaceAllString(k, "-*")
+}
+
+// readRequest returns a copy of r, which can be sent, and its body.
+func readRequest(r *http.Request) (*http.Request, string, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return r, "", nil
+	}
+	b, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	r = r.Clone(r.Context())
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	return r, string(b), nil
+}
+
+// recorder is a transport writing the interactions with base to enc, one JSON
+// object per line.
+type recorder struct {
+	base http.RoundTripper
+	mx   sync.Mutex
+	enc  *json.Encoder
+}
+
+func (rec *recorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	r, body, err := readRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rec.base.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	rec.mx.Lock()
+	defer rec.mx.Unlock()
+	i := &interaction{Method: r.Method, URL: r.URL.RequestURI(), Body: body, StatusCode: resp.StatusCode, Header: resp.Header, Response: string(b)}
+	if err := rec.enc.Encode(i); err != nil {
+		return nil, fmt.Errorf("error recording API interaction: %v", err)
+	}
+	return resp, nil
+}
+
+// replayer is a transport serving recorded responses. Identical requests are
+// served their responses in the recorded order, the last one is repeated once
+// all have been served.
+type replayer struct {
+	mx        sync.Mutex
+	responses map[string][]*interaction
+}
+
+func (rep *replayer) RoundTrip(r *http.Request) (*http.Response, error) {
+	_, body, err := readRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	key := (&interaction{Method: r.Method, URL: r.URL.RequestURI(), Body: body}).key()
+
+	rep.mx.Lock()
+	defer rep.mx.Unlock()
+	is := rep.responses[key]
+	if len(is) == 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s", r.Method, r.URL.RequestURI())
+	}
+	i := is[0]
+	if len(is) > 1 {
+		rep.responses[key] = is[1:]
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.StatusCode, http.StatusText(i.StatusCode)),
+		StatusCode:    i.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(i.Response)),
+		ContentLength: int64(len(i.Response)),
+		Request:       r,
+	}, nil
+}
+
+// recordingClient is a client which closes its recording file on Close.
+type recordingClient struct {
+	*client
+	f *os.File
+}
+
+func (c *recordingClient) Close() error {
+	err := c.client.Close()
+	if ferr := c.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// NewRecordingClient creates a Google Cloud Compute client which records
+// every API request it makes, and its response, to the file at path. The
+// recording can be served by a client created with NewReplayClient. Requests
+// are recorded without their authentication headers, but request and
+// response bodies are recorded as is. The client is an io.Closer, closing it
+// closes the recording.
+func NewRecordingClient(ctx context.Context, path string, opts ...option.ClientOption) (Client, error) {
+	hc, ep, err := newHTTPClient(ctx, HTTPSettings{}, withClientScopes(opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP API client: %v", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating API recording: %v", err)
+	}
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// Don't modify a client that may be shared.
+	hc = &http.Client{Transport: &recorder{base: base, enc: json.NewEncoder(f)}, Timeout: hc.Timeout}
+	c, err := newClient(hc, ep)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &recordingClient{client: c, f: f}, nil
+}
+
+// NewReplayClient creates a Google Cloud Compute client which serves the API
+// responses recorded by a client created with NewRecordingClient from the
+// file at path, without making any request. Responses are matched to
+// requests by method, path, query and body, ignoring the workflow ID suffix
+// of generated resource names and the time in scratch paths, which differ
+// between runs of a workflow. Use option.WithEndpoint if the recording client
+// used it.
+func NewReplayClient(ctx context.Context, path string, opts ...option.ClientOption) (Client, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening API recording: %v", err)
+	}
+	defer f.Close()
+	rep := &replayer{responses: map[string][]*interaction{}}
+	dec := json.NewDecoder(f)
+	for {
+		i := &interaction{}
+		if err := dec.Decode(i); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading API recording %s: %v", path, err)
+		}
+		rep.responses[i.key()] = append(rep.responses[i.key()], i)
+	}
+
+	opts = append(opts, option.WithHTTPClient(&http.Client{Transport: rep}))
+	hc, ep, err := newHTTPClient(ctx, HTTPSettings{}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP API client: %v", err)
+	}
+	c, err := newClient(hc, ep)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
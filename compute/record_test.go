@@ -0,0 +1,121 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package compute
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+func TestRecordReplay(t *testing.T) {
+	defer func(i time.Duration) { operationPollInterval = i }(operationPollInterval)
+	operationPollInterval = time.Millisecond
+
+	var waits int
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := r.URL.String()
+		if r.Method == "POST" && u == fmt.Sprintf("/projects/%s/zones/%s/disks?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"name":"op"}`)
+		} else if r.Method == "POST" && u == fmt.Sprintf("/projects/%s/zones/%s/operations/op/wait?alt=json&prettyPrint=false", testProject, testZone) {
+			waits++
+			if waits == 1 {
+				fmt.Fprint(w, `{"status":"RUNNING"}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"DONE"}`)
+		} else if r.Method == "GET" && u == fmt.Sprintf("/projects/%s/zones/%s/disks/%s?alt=json&prettyPrint=false", testProject, testZone, testDisk) {
+			fmt.Fprintf(w, `{"name":%q,"status":"READY","sizeGb":"10"}`, testDisk)
+		} else if r.Method == "GET" && u == fmt.Sprintf("/projects/%s/zones/%s/disks/dne?alt=json&prettyPrint=false", testProject, testZone) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":404,"message":"not found"}}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	// run makes the same calls against c, returning what the API returned.
+	run := func(c Client) (*compute.Disk, error) {
+		d := &compute.Disk{Name: testDisk, SizeGb: 10}
+		if err := c.CreateDisk(testProject, testZone, d); err != nil {
+			return nil, err
+		}
+		_, err := c.GetDisk(testProject, testZone, "dne")
+		return d, err
+	}
+
+	rc, err := NewRecordingClient(ctx, path, option.WithEndpoint(svr.URL), option.WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, recordedErr := run(rc)
+	if recordedErr == nil {
+		t.Fatal("getting a non existent disk should have failed")
+	}
+	if err := rc.(io.Closer).Close(); err != nil {
+		t.Fatalf("error closing recording client: %v", err)
+	}
+	svr.Close()
+
+	pc, err := NewReplayClient(ctx, path, option.WithEndpoint(svr.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, replayedErr := run(pc)
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed disk differs, got: %+v, want: %+v", replayed, recorded)
+	}
+	if replayedErr == nil || replayedErr.Error() != recordedErr.Error() {
+		t.Errorf("replayed error differs, got: %v, want: %v", replayedErr, recordedErr)
+	}
+
+	// Requests which weren't recorded fail.
+	if _, err := pc.GetDisk(testProject, testZone, "other"); err == nil {
+		t.Error("replaying a request which wasn't recorded should have failed")
+	}
+}
+
+func TestInteractionKey(t *testing.T) {
+	key := func(url, body string) string {
+		return (&interaction{Method: "POST", URL: url, Body: body}).key()
+	}
+	tests := []struct {
+		desc      string
+		a, b      string
+		wantEqual bool
+	}{
+		{"workflow id case", "/disks/d-wf-x7s2m", "/disks/d-wf-bq9dz", true},
+		{"scratch path case", `{"v":"gs://b/daisy-wf-20260102-15:04:05-x7s2m"}`, `{"v":"gs://b/daisy-wf-20261016-09:30:00-bq9dz"}`, true},
+		{"other name case", "/disks/d-wf-x7s2m", "/disks/e-wf-x7s2m", false},
+		{"longer suffix case", "/disks/d-wf-123456", "/disks/d-wf-654321", false},
+	}
+	for _, tt := range tests {
+		if got := key(tt.a, "") == key(tt.b, ""); got != tt.wantEqual {
+			t.Errorf("%s: keys of %q and %q equal: got %t, want %t", tt.desc, tt.a, tt.b, got, tt.wantEqual)
+		}
+	}
+}
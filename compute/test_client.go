@@ -18,6 +18,8 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"time"
 
 	computeAlpha "google.golang.org/api/compute/v0.alpha"
 	computeBeta "google.golang.org/api/compute/v0.beta"
@@ -51,18 +53,27 @@ type TestClient struct {
 
 	AttachDiskFn                       func(project, zone, instance string, d *compute.AttachedDisk) error
 	DetachDiskFn                       func(project, zone, instance, disk string) error
+	BulkInsertDiskFn                   func(project, zone string, req *compute.BulkInsertDiskResource) error
+	CreateAddressFn                    func(project, region string, a *compute.Address) error
 	CreateDiskFn                       func(project, zone string, d *compute.Disk) error
 	CreateForwardingRuleFn             func(project, region string, fr *compute.ForwardingRule) error
 	CreateFirewallRuleFn               func(project string, i *compute.Firewall) error
 	CreateImageFn                      func(project string, i *compute.Image) error
+	ForceCreateImageFn                 func(project string, i *compute.Image) error
+	WaitForImageOperationFn            func(project, name string) error
 	CreateInstanceFn                   func(project, zone string, i *compute.Instance) error
 	CreateNetworkFn                    func(project string, n *compute.Network) error
+	PatchNetworkFn                     func(project, name string, n *compute.Network) error
+	SwitchNetworkToCustomModeFn        func(project, name string) error
 	CreateSnapshotFn                   func(project, zone, disk string, s *compute.Snapshot) error
+	CreateSnapshotWithGuestFlushFn     func(project, zone, disk string, s *compute.Snapshot) error
+	CreateSnapshotWithOptionsFn        func(project, zone, disk string, s *compute.Snapshot, opts SnapshotOptions) error
 	CreateSubnetworkFn                 func(project, region string, n *compute.Subnetwork) error
 	CreateTargetInstanceFn             func(project, zone string, ti *compute.TargetInstance) error
 	StartInstanceFn                    func(project, zone, name string) error
 	StopInstanceFn                     func(project, zone, name string) error
 	DeleteDiskFn                       func(project, zone, name string) error
+	DeleteAddressFn                    func(project, region, name string) error
 	DeleteForwardingRuleFn             func(project, region, name string) error
 	DeleteFirewallRuleFn               func(project, name string) error
 	DeleteImageFn                      func(project, name string) error
@@ -71,34 +82,58 @@ type TestClient struct {
 	DeleteSubnetworkFn                 func(project, region, name string) error
 	DeleteTargetInstanceFn             func(project, zone, name string) error
 	DeprecateImageFn                   func(project, name string, deprecationstatus *compute.DeprecationStatus) error
+	DeprecateImageAlphaFn              func(project, name string, deprecationstatus *computeAlpha.DeprecationStatus) error
+	DeprecateImageBetaFn               func(project, name string, deprecationstatus *computeBeta.DeprecationStatus) error
 	GetMachineTypeFn                   func(project, zone, machineType string) (*compute.MachineType, error)
+	GetMachineTypeSpecFn               func(project, zone, machineType string) (int64, int64, error)
 	ListMachineTypesFn                 func(project, zone string, opts ...ListCallOption) ([]*compute.MachineType, error)
 	GetProjectFn                       func(project string) (*compute.Project, error)
+	PingFn                             func(ctx context.Context, project string) error
 	GetSerialPortOutputFn              func(project, zone, name string, port, start int64) (*compute.SerialPortOutput, error)
+	GetScreenshotFn                    func(project, zone, name string) (*compute.Screenshot, error)
+	SendDiagnosticInterruptFn          func(project, zone, name string) error
+	PollSerialForMarkerFn              func(ctx context.Context, project, zone, name string, port int64, success, failure *regexp.Regexp, interval time.Duration) (string, bool, error)
+	GetEffectiveFirewallsFn            func(project, zone, instance, networkInterface string) (*compute.InstancesGetEffectiveFirewallsResponse, error)
 	GetGuestAttributesFn               func(project, zone, name, queryPath, variableKey string) (*compute.GuestAttributes, error)
 	GetZoneFn                          func(project, zone string) (*compute.Zone, error)
+	IsZoneAvailableFn                  func(project, zone string) (bool, error)
 	ListZonesFn                        func(project string, opts ...ListCallOption) ([]*compute.Zone, error)
+	ListRegionsFn                      func(project string, opts ...ListCallOption) ([]*compute.Region, error)
 	GetInstanceFn                      func(project, zone, name string) (*compute.Instance, error)
+	GetInstancesFn                     func(project, zone string, names []string) (map[string]*compute.Instance, map[string]error)
 	AggregatedListInstancesFn          func(project string, opts ...ListCallOption) ([]*compute.Instance, error)
 	ListInstancesFn                    func(project, zone string, opts ...ListCallOption) ([]*compute.Instance, error)
 	ListSnapshotsFn                    func(project string, opts ...ListCallOption) ([]*compute.Snapshot, error)
 	GetSnapshotFn                      func(project, name string) (*compute.Snapshot, error)
 	DeleteSnapshotFn                   func(project, name string) error
 	GetDiskFn                          func(project, zone, name string) (*compute.Disk, error)
+	GetDisksFn                         func(project, zone string, names []string) (map[string]*compute.Disk, map[string]error)
 	AggregatedListDisksFn              func(project string, opts ...ListCallOption) ([]*compute.Disk, error)
 	ListDisksFn                        func(project, zone string, opts ...ListCallOption) ([]*compute.Disk, error)
+	GetReservationFn                   func(project, zone, name string) (*compute.Reservation, error)
+	GetNodeGroupFn                     func(project, zone, name string) (*compute.NodeGroup, error)
+	GetNodeTemplateFn                  func(project, region, name string) (*compute.NodeTemplate, error)
+	GetNodeTypeFn                      func(project, zone, name string) (*compute.NodeType, error)
+	ListReservationsFn                 func(project, zone string, opts ...ListCallOption) ([]*compute.Reservation, error)
+	ListInstanceReferrersFn            func(project, zone, instance string, opts ...ListCallOption) ([]*compute.Reference, error)
+	GetAddressFn                       func(project, region, name string) (*compute.Address, error)
 	GetForwardingRuleFn                func(project, region, name string) (*compute.ForwardingRule, error)
 	AggregatedListForwardingRulesFn    func(project string, opts ...ListCallOption) ([]*compute.ForwardingRule, error)
 	ListForwardingRulesFn              func(project, region string, opts ...ListCallOption) ([]*compute.ForwardingRule, error)
+	ListAddressesFn                    func(project, region string, opts ...ListCallOption) ([]*compute.Address, error)
 	GetFirewallRuleFn                  func(project, name string) (*compute.Firewall, error)
 	ListFirewallRulesFn                func(project string, opts ...ListCallOption) ([]*compute.Firewall, error)
 	GetImageFn                         func(project, name string) (*compute.Image, error)
+	GetImagesFn                        func(project string, names []string) (map[string]*compute.Image, map[string]error)
 	GetImageFromFamilyFn               func(project, family string) (*compute.Image, error)
 	ListImagesFn                       func(project string, opts ...ListCallOption) ([]*compute.Image, error)
+	ListImagesBetaFn                   func(project string, opts ...ListCallOption) ([]*computeBeta.Image, error)
+	ListResourcesByLabelFn             func(project, labelKey, labelValue string) (*LabeledResources, error)
 	GetLicenseFn                       func(project, name string) (*compute.License, error)
 	ListLicensesFn                     func(project string, opts ...ListCallOption) ([]*compute.License, error)
 	GetNetworkFn                       func(project, name string) (*compute.Network, error)
 	GetRegionFn                        func(project, name string) (*compute.Region, error)
+	IsRegionAvailableFn                func(project, region string) (bool, error)
 	AggregatedListSubnetworksFn        func(project string, opts ...ListCallOption) ([]*compute.Subnetwork, error)
 	ListNetworksFn                     func(project string, opts ...ListCallOption) ([]*compute.Network, error)
 	GetSubnetworkFn                    func(project, region, name string) (*compute.Subnetwork, error)
@@ -108,13 +143,33 @@ type TestClient struct {
 	InstanceStatusFn                   func(project, zone, name string) (string, error)
 	InstanceStoppedFn                  func(project, zone, name string) (bool, error)
 	ResizeDiskFn                       func(project, zone, disk string, drr *compute.DisksResizeRequest) error
+	UpdateDiskFn                       func(project, zone, disk string, d *compute.Disk, paths []string) error
 	SetInstanceMetadataFn              func(project, zone, name string, md *compute.Metadata) error
+	MergeInstanceMetadataFn            func(project, zone, name string, add map[string]string, remove []string) error
+	MergeInstanceLabelsFn              func(project, zone, name string, add map[string]string, remove []string) error
+	SetTagsFn                          func(project, zone, instance string, tags *compute.Tags) error
+	AddInstanceResourcePoliciesFn      func(project, zone, instance string, req *compute.InstancesAddResourcePoliciesRequest) error
+	RemoveInstanceResourcePoliciesFn   func(project, zone, instance string, req *compute.InstancesRemoveResourcePoliciesRequest) error
+	SetInstanceNameFn                  func(project, zone, instance string, req *compute.InstancesSetNameRequest) error
 	SetCommonInstanceMetadataFn        func(project string, md *compute.Metadata) error
+	AddProjectSSHKeyFn                 func(project, user, publicKey string) error
+	MergeCommonInstanceMetadataFn      func(project string, add map[string]string, remove []string) error
+	IsOSLoginEnabledFn                 func(project, zone, instance string) (bool, error)
+	PatchInstanceGroupManagerFn        func(project, zone, igm string, m *compute.InstanceGroupManager) error
+	PatchRegionInstanceGroupManagerFn  func(project, region, igm string, m *compute.InstanceGroupManager) error
+	UpdatePerInstanceConfigsFn         func(project, zone, igm string, req *compute.InstanceGroupManagersUpdatePerInstanceConfigsReq) error
+	UpdateRegionPerInstanceConfigsFn   func(project, region, igm string, req *compute.RegionInstanceGroupManagerUpdateInstanceConfigReq) error
+	CreateRegionInstanceTemplateFn     func(project, region string, it *compute.InstanceTemplate) error
+	GetRegionInstanceTemplateFn        func(project, region, name string) (*compute.InstanceTemplate, error)
+	DeleteRegionInstanceTemplateFn     func(project, region, name string) error
+	ListRegionInstanceTemplatesFn      func(project, region string, opts ...ListCallOption) ([]*compute.InstanceTemplate, error)
 	ListMachineImagesFn                func(project string, opts ...ListCallOption) ([]*compute.MachineImage, error)
 	DeleteMachineImageFn               func(project, name string) error
 	CreateMachineImageFn               func(project string, i *compute.MachineImage) error
 	GetMachineImageFn                  func(project, name string) (*compute.MachineImage, error)
+	CloseFn                            func() error
 	RetryFn                            func(f func(opts ...googleapi.CallOption) (*compute.Operation, error), opts ...googleapi.CallOption) (op *compute.Operation, err error)
+	RetryAlphaFn                       func(f func(opts ...googleapi.CallOption) (*computeAlpha.Operation, error), opts ...googleapi.CallOption) (op *computeAlpha.Operation, err error)
 	DeleteRegionTargetHTTPProxyFn      func(project, region, name string) error
 	CreateRegionTargetHTTPProxyFn      func(project, region string, p *compute.TargetHttpProxy) error
 	ListRegionTargetHTTPProxiesFn      func(project, region string, opts ...ListCallOption) ([]*compute.TargetHttpProxy, error)
@@ -126,15 +181,26 @@ type TestClient struct {
 	DeleteRegionBackendServiceFn       func(project, region, name string) error
 	CreateRegionBackendServiceFn       func(project, region string, b *compute.BackendService) error
 	ListRegionBackendServicesFn        func(project, region string, opts ...ListCallOption) ([]*compute.BackendService, error)
+	AggregatedListBackendServicesFn    func(project string, opts ...ListCallOption) ([]*compute.BackendService, error)
 	GetRegionBackendServiceFn          func(project, region, name string) (*compute.BackendService, error)
+	GetBackendServiceFn                func(project, name string) (*compute.BackendService, error)
+	GetBackendServiceHealthFn          func(project, region, backendService string, group *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error)
+	GetGlobalBackendServiceHealthFn    func(project, backendService string, group *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error)
 	DeleteRegionHealthCheckFn          func(project, region, name string) error
 	CreateRegionHealthCheckFn          func(project, region string, h *compute.HealthCheck) error
 	ListRegionHealthChecksFn           func(project, region string, opts ...ListCallOption) ([]*compute.HealthCheck, error)
+	AggregatedListHealthChecksFn       func(project string, opts ...ListCallOption) ([]*compute.HealthCheck, error)
 	GetRegionHealthCheckFn             func(project, region, name string) (*compute.HealthCheck, error)
 	DeleteRegionNetworkEndpointGroupFn func(project, region, name string) error
 	CreateRegionNetworkEndpointGroupFn func(project, region string, n *compute.NetworkEndpointGroup) error
 	ListRegionNetworkEndpointGroupsFn  func(project, region string, opts ...ListCallOption) ([]*compute.NetworkEndpointGroup, error)
 	GetRegionNetworkEndpointGroupFn    func(project, region, name string) (*compute.NetworkEndpointGroup, error)
+	DeleteNetworkEndpointGroupFn       func(project, zone, name string) error
+	CreateNetworkEndpointGroupFn       func(project, zone string, n *compute.NetworkEndpointGroup) error
+	ListNetworkEndpointGroupsFn        func(project, zone string, opts ...ListCallOption) ([]*compute.NetworkEndpointGroup, error)
+	GetNetworkEndpointGroupFn          func(project, zone, name string) (*compute.NetworkEndpointGroup, error)
+	AttachNetworkEndpointsFn           func(project, zone, neg string, req *compute.NetworkEndpointGroupsAttachEndpointsRequest) error
+	DetachNetworkEndpointsFn           func(project, zone, neg string, req *compute.NetworkEndpointGroupsDetachEndpointsRequest) error
 
 	// Alpha API calls
 	CreateInstanceAlphaFn func(project, zone string, i *computeAlpha.Instance) error
@@ -142,9 +208,22 @@ type TestClient struct {
 	// Beta API calls
 	CreateInstanceBetaFn func(project, zone string, i *computeBeta.Instance) error
 
-	zoneOperationsWaitFn   func(project, zone, name string) error
-	regionOperationsWaitFn func(project, region, name string) error
-	globalOperationsWaitFn func(project, name string) error
+	zoneOperationsWaitFn        func(project, zone, name string) error
+	regionOperationsWaitFn      func(project, region, name string) error
+	globalOperationsWaitFn      func(project, name string) error
+	zoneOperationsWaitBetaFn    func(project, zone, name string) error
+	regionOperationsWaitBetaFn  func(project, region, name string) error
+	globalOperationsWaitBetaFn  func(project, name string) error
+	waitForImageOperationBetaFn func(project, name string) error
+}
+
+// Close uses the override method CloseFn or does nothing, the HTTP client of
+// a TestClient may be shared.
+func (c *TestClient) Close() error {
+	if c.CloseFn != nil {
+		return c.CloseFn()
+	}
+	return nil
 }
 
 // Retry uses the override method RetryFn or the real implementation.
@@ -155,6 +234,14 @@ func (c *TestClient) Retry(f func(opts ...googleapi.CallOption) (*compute.Operat
 	return c.client.Retry(f, opts...)
 }
 
+// RetryAlpha uses the override method RetryAlphaFn or the real implementation.
+func (c *TestClient) RetryAlpha(f func(opts ...googleapi.CallOption) (*computeAlpha.Operation, error), opts ...googleapi.CallOption) (op *computeAlpha.Operation, err error) {
+	if c.RetryAlphaFn != nil {
+		return c.RetryAlphaFn(f, opts...)
+	}
+	return c.client.RetryAlpha(f, opts...)
+}
+
 // AttachDisk uses the override method AttachDiskFn or the real implementation.
 func (c *TestClient) AttachDisk(project, zone, instance string, ad *compute.AttachedDisk) error {
 	if c.AttachDiskFn != nil {
@@ -171,6 +258,22 @@ func (c *TestClient) DetachDisk(project, zone, instance, disk string) error {
 	return c.client.DetachDisk(project, zone, instance, disk)
 }
 
+// BulkInsertDisk uses the override method BulkInsertDiskFn or the real implementation.
+func (c *TestClient) BulkInsertDisk(project, zone string, req *compute.BulkInsertDiskResource) error {
+	if c.BulkInsertDiskFn != nil {
+		return c.BulkInsertDiskFn(project, zone, req)
+	}
+	return c.client.BulkInsertDisk(project, zone, req)
+}
+
+// CreateAddress uses the override method CreateAddressFn or the real implementation.
+func (c *TestClient) CreateAddress(project, region string, a *compute.Address) error {
+	if c.CreateAddressFn != nil {
+		return c.CreateAddressFn(project, region, a)
+	}
+	return c.client.CreateAddress(project, region, a)
+}
+
 // CreateDisk uses the override method CreateDiskFn or the real implementation.
 func (c *TestClient) CreateDisk(project, zone string, d *compute.Disk) error {
 	if c.CreateDiskFn != nil {
@@ -203,6 +306,22 @@ func (c *TestClient) CreateImage(project string, i *compute.Image) error {
 	return c.client.CreateImage(project, i)
 }
 
+// ForceCreateImage uses the override method ForceCreateImageFn or the real implementation.
+func (c *TestClient) ForceCreateImage(project string, i *compute.Image) error {
+	if c.ForceCreateImageFn != nil {
+		return c.ForceCreateImageFn(project, i)
+	}
+	return c.client.ForceCreateImage(project, i)
+}
+
+// WaitForImageOperation uses the override method WaitForImageOperationFn or the real implementation.
+func (c *TestClient) WaitForImageOperation(project, name string) error {
+	if c.WaitForImageOperationFn != nil {
+		return c.WaitForImageOperationFn(project, name)
+	}
+	return c.client.WaitForImageOperation(project, name)
+}
+
 // CreateInstance uses the override method CreateInstanceFn or the real implementation.
 func (c *TestClient) CreateInstance(project, zone string, i *compute.Instance) error {
 	if c.CreateInstanceFn != nil {
@@ -219,6 +338,22 @@ func (c *TestClient) CreateNetwork(project string, n *compute.Network) error {
 	return c.client.CreateNetwork(project, n)
 }
 
+// PatchNetwork uses the override method PatchNetworkFn or the real implementation.
+func (c *TestClient) PatchNetwork(project, name string, n *compute.Network) error {
+	if c.PatchNetworkFn != nil {
+		return c.PatchNetworkFn(project, name, n)
+	}
+	return c.client.PatchNetwork(project, name, n)
+}
+
+// SwitchNetworkToCustomMode uses the override method SwitchNetworkToCustomModeFn or the real implementation.
+func (c *TestClient) SwitchNetworkToCustomMode(project, name string) error {
+	if c.SwitchNetworkToCustomModeFn != nil {
+		return c.SwitchNetworkToCustomModeFn(project, name)
+	}
+	return c.client.SwitchNetworkToCustomMode(project, name)
+}
+
 // CreateSubnetwork uses the override method CreateSubnetworkFn or the real implementation.
 func (c *TestClient) CreateSubnetwork(project, region string, n *compute.Subnetwork) error {
 	if c.CreateSubnetworkFn != nil {
@@ -259,6 +394,14 @@ func (c *TestClient) DeleteDisk(project, zone, name string) error {
 	return c.client.DeleteDisk(project, zone, name)
 }
 
+// DeleteAddress uses the override method DeleteAddressFn or the real implementation.
+func (c *TestClient) DeleteAddress(project, region, name string) error {
+	if c.DeleteAddressFn != nil {
+		return c.DeleteAddressFn(project, region, name)
+	}
+	return c.client.DeleteAddress(project, region, name)
+}
+
 // DeleteForwardingRule uses the override method DeleteForwardingRuleFn or the real implementation.
 func (c *TestClient) DeleteForwardingRule(project, region, name string) error {
 	if c.DeleteForwardingRuleFn != nil {
@@ -323,6 +466,22 @@ func (c *TestClient) DeprecateImage(project, name string, deprecationstatus *com
 	return c.client.DeprecateImage(project, name, deprecationstatus)
 }
 
+// DeprecateImageAlpha uses the override method DeprecateImageAlphaFn or the real implementation.
+func (c *TestClient) DeprecateImageAlpha(project, name string, deprecationstatus *computeAlpha.DeprecationStatus) error {
+	if c.DeprecateImageAlphaFn != nil {
+		return c.DeprecateImageAlphaFn(project, name, deprecationstatus)
+	}
+	return c.client.DeprecateImageAlpha(project, name, deprecationstatus)
+}
+
+// DeprecateImageBeta uses the override method DeprecateImageBetaFn or the real implementation.
+func (c *TestClient) DeprecateImageBeta(project, name string, deprecationstatus *computeBeta.DeprecationStatus) error {
+	if c.DeprecateImageBetaFn != nil {
+		return c.DeprecateImageBetaFn(project, name, deprecationstatus)
+	}
+	return c.client.DeprecateImageBeta(project, name, deprecationstatus)
+}
+
 // GetProject uses the override method GetProjectFn or the real implementation.
 func (c *TestClient) GetProject(project string) (*compute.Project, error) {
 	if c.GetProjectFn != nil {
@@ -331,6 +490,14 @@ func (c *TestClient) GetProject(project string) (*compute.Project, error) {
 	return c.client.GetProject(project)
 }
 
+// Ping uses the override method PingFn or the real implementation.
+func (c *TestClient) Ping(ctx context.Context, project string) error {
+	if c.PingFn != nil {
+		return c.PingFn(ctx, project)
+	}
+	return c.client.Ping(ctx, project)
+}
+
 // GetMachineType uses the override method GetMachineTypeFn or the real implementation.
 func (c *TestClient) GetMachineType(project, zone, machineType string) (*compute.MachineType, error) {
 	if c.GetMachineTypeFn != nil {
@@ -339,6 +506,14 @@ func (c *TestClient) GetMachineType(project, zone, machineType string) (*compute
 	return c.client.GetMachineType(project, zone, machineType)
 }
 
+// GetMachineTypeSpec uses the override method GetMachineTypeSpecFn or the real implementation.
+func (c *TestClient) GetMachineTypeSpec(project, zone, machineType string) (int64, int64, error) {
+	if c.GetMachineTypeSpecFn != nil {
+		return c.GetMachineTypeSpecFn(project, zone, machineType)
+	}
+	return c.client.GetMachineTypeSpec(project, zone, machineType)
+}
+
 // ListMachineTypes uses the override method ListMachineTypesFn or the real implementation.
 func (c *TestClient) ListMachineTypes(project, zone string, opts ...ListCallOption) ([]*compute.MachineType, error) {
 	if c.ListMachineTypesFn != nil {
@@ -355,6 +530,14 @@ func (c *TestClient) GetZone(project, zone string) (*compute.Zone, error) {
 	return c.client.GetZone(project, zone)
 }
 
+// IsZoneAvailable uses the override method IsZoneAvailableFn or the real implementation.
+func (c *TestClient) IsZoneAvailable(project, zone string) (bool, error) {
+	if c.IsZoneAvailableFn != nil {
+		return c.IsZoneAvailableFn(project, zone)
+	}
+	return c.client.IsZoneAvailable(project, zone)
+}
+
 // ListZones uses the override method ListZonesFn or the real implementation.
 func (c *TestClient) ListZones(project string, opts ...ListCallOption) ([]*compute.Zone, error) {
 	if c.ListZonesFn != nil {
@@ -363,6 +546,14 @@ func (c *TestClient) ListZones(project string, opts ...ListCallOption) ([]*compu
 	return c.client.ListZones(project, opts...)
 }
 
+// ListRegions uses the override method ListRegionsFn or the real implementation.
+func (c *TestClient) ListRegions(project string, opts ...ListCallOption) ([]*compute.Region, error) {
+	if c.ListRegionsFn != nil {
+		return c.ListRegionsFn(project, opts...)
+	}
+	return c.client.ListRegions(project, opts...)
+}
+
 // CreateSnapshot uses the override method CreateSnapshotFn or the real implementation.
 func (c *TestClient) CreateSnapshot(project, zone, disk string, s *compute.Snapshot) error {
 	if c.CreateSnapshotFn != nil {
@@ -371,6 +562,22 @@ func (c *TestClient) CreateSnapshot(project, zone, disk string, s *compute.Snaps
 	return c.client.CreateSnapshot(project, zone, disk, s)
 }
 
+// CreateSnapshotWithGuestFlush uses the override method CreateSnapshotWithGuestFlushFn or the real implementation.
+func (c *TestClient) CreateSnapshotWithGuestFlush(project, zone, disk string, s *compute.Snapshot) error {
+	if c.CreateSnapshotWithGuestFlushFn != nil {
+		return c.CreateSnapshotWithGuestFlushFn(project, zone, disk, s)
+	}
+	return c.client.CreateSnapshotWithGuestFlush(project, zone, disk, s)
+}
+
+// CreateSnapshotWithOptions uses the override method CreateSnapshotWithOptionsFn or the real implementation.
+func (c *TestClient) CreateSnapshotWithOptions(project, zone, disk string, s *compute.Snapshot, opts SnapshotOptions) error {
+	if c.CreateSnapshotWithOptionsFn != nil {
+		return c.CreateSnapshotWithOptionsFn(project, zone, disk, s, opts)
+	}
+	return c.client.CreateSnapshotWithOptions(project, zone, disk, s, opts)
+}
+
 // GetSnapshot uses the override method GetSnapshotFn or the real implementation.
 func (c *TestClient) GetSnapshot(project, name string) (*compute.Snapshot, error) {
 	if c.GetSnapshotFn != nil {
@@ -403,6 +610,14 @@ func (c *TestClient) GetInstance(project, zone, name string) (*compute.Instance,
 	return c.client.GetInstance(project, zone, name)
 }
 
+// GetInstances uses the override method GetInstancesFn or the real implementation.
+func (c *TestClient) GetInstances(project, zone string, names []string) (map[string]*compute.Instance, map[string]error) {
+	if c.GetInstancesFn != nil {
+		return c.GetInstancesFn(project, zone, names)
+	}
+	return c.client.GetInstances(project, zone, names)
+}
+
 // ListInstances uses the override method ListInstancesFn or the real implementation.
 func (c *TestClient) ListInstances(project, zone string, opts ...ListCallOption) ([]*compute.Instance, error) {
 	if c.ListInstancesFn != nil {
@@ -427,6 +642,14 @@ func (c *TestClient) GetDisk(project, zone, name string) (*compute.Disk, error)
 	return c.client.GetDisk(project, zone, name)
 }
 
+// GetDisks uses the override method GetDisksFn or the real implementation.
+func (c *TestClient) GetDisks(project, zone string, names []string) (map[string]*compute.Disk, map[string]error) {
+	if c.GetDisksFn != nil {
+		return c.GetDisksFn(project, zone, names)
+	}
+	return c.client.GetDisks(project, zone, names)
+}
+
 // AggregatedListDisks uses the override method ListInstancesFn or the real implementation.
 func (c *TestClient) AggregatedListDisks(project string, opts ...ListCallOption) ([]*compute.Disk, error) {
 	if c.AggregatedListDisksFn != nil {
@@ -443,6 +666,70 @@ func (c *TestClient) ListDisks(project, zone string, opts ...ListCallOption) ([]
 	return c.client.ListDisks(project, zone, opts...)
 }
 
+// GetReservation uses the override method GetReservationFn or the real implementation.
+func (c *TestClient) GetReservation(project, zone, name string) (*compute.Reservation, error) {
+	if c.GetReservationFn != nil {
+		return c.GetReservationFn(project, zone, name)
+	}
+	return c.client.GetReservation(project, zone, name)
+}
+
+// GetNodeGroup uses the override method GetNodeGroupFn or the real implementation.
+func (c *TestClient) GetNodeGroup(project, zone, name string) (*compute.NodeGroup, error) {
+	if c.GetNodeGroupFn != nil {
+		return c.GetNodeGroupFn(project, zone, name)
+	}
+	return c.client.GetNodeGroup(project, zone, name)
+}
+
+// GetNodeTemplate uses the override method GetNodeTemplateFn or the real implementation.
+func (c *TestClient) GetNodeTemplate(project, region, name string) (*compute.NodeTemplate, error) {
+	if c.GetNodeTemplateFn != nil {
+		return c.GetNodeTemplateFn(project, region, name)
+	}
+	return c.client.GetNodeTemplate(project, region, name)
+}
+
+// GetNodeType uses the override method GetNodeTypeFn or the real implementation.
+func (c *TestClient) GetNodeType(project, zone, name string) (*compute.NodeType, error) {
+	if c.GetNodeTypeFn != nil {
+		return c.GetNodeTypeFn(project, zone, name)
+	}
+	return c.client.GetNodeType(project, zone, name)
+}
+
+// ListInstanceReferrers uses the override method ListInstanceReferrersFn or the real implementation.
+func (c *TestClient) ListInstanceReferrers(project, zone, instance string, opts ...ListCallOption) ([]*compute.Reference, error) {
+	if c.ListInstanceReferrersFn != nil {
+		return c.ListInstanceReferrersFn(project, zone, instance, opts...)
+	}
+	return c.client.ListInstanceReferrers(project, zone, instance, opts...)
+}
+
+// ListReservations uses the override method ListReservationsFn or the real implementation.
+func (c *TestClient) ListReservations(project, zone string, opts ...ListCallOption) ([]*compute.Reservation, error) {
+	if c.ListReservationsFn != nil {
+		return c.ListReservationsFn(project, zone, opts...)
+	}
+	return c.client.ListReservations(project, zone, opts...)
+}
+
+// GetAddress uses the override method GetAddressFn or the real implementation.
+func (c *TestClient) GetAddress(project, region, name string) (*compute.Address, error) {
+	if c.GetAddressFn != nil {
+		return c.GetAddressFn(project, region, name)
+	}
+	return c.client.GetAddress(project, region, name)
+}
+
+// ListAddresses uses the override method ListAddressesFn or the real implementation.
+func (c *TestClient) ListAddresses(project, region string, opts ...ListCallOption) ([]*compute.Address, error) {
+	if c.ListAddressesFn != nil {
+		return c.ListAddressesFn(project, region, opts...)
+	}
+	return c.client.ListAddresses(project, region, opts...)
+}
+
 // GetForwardingRule uses the override method GetForwardingRuleFn or the real implementation.
 func (c *TestClient) GetForwardingRule(project, region, name string) (*compute.ForwardingRule, error) {
 	if c.GetForwardingRuleFn != nil {
@@ -459,7 +746,7 @@ func (c *TestClient) ListForwardingRules(project, region string, opts ...ListCal
 	return c.client.ListForwardingRules(project, region, opts...)
 }
 
-// AggregatedListForwardingRules uses the override method ListForwardingRulesFn or the real implementation.
+// AggregatedListForwardingRules uses the override method AggregatedListForwardingRulesFn or the real implementation.
 func (c *TestClient) AggregatedListForwardingRules(project string, opts ...ListCallOption) ([]*compute.ForwardingRule, error) {
 	if c.AggregatedListForwardingRulesFn != nil {
 		return c.AggregatedListForwardingRulesFn(project, opts...)
@@ -491,6 +778,14 @@ func (c *TestClient) GetImage(project, name string) (*compute.Image, error) {
 	return c.client.GetImage(project, name)
 }
 
+// GetImages uses the override method GetImagesFn or the real implementation.
+func (c *TestClient) GetImages(project string, names []string) (map[string]*compute.Image, map[string]error) {
+	if c.GetImagesFn != nil {
+		return c.GetImagesFn(project, names)
+	}
+	return c.client.GetImages(project, names)
+}
+
 // GetImageFromFamily uses the override method GetImageFromFamilyFn or the real implementation.
 func (c *TestClient) GetImageFromFamily(project, family string) (*compute.Image, error) {
 	if c.GetImageFromFamilyFn != nil {
@@ -507,6 +802,22 @@ func (c *TestClient) ListImages(project string, opts ...ListCallOption) ([]*comp
 	return c.client.ListImages(project, opts...)
 }
 
+// ListImagesBeta uses the override method ListImagesBetaFn or the real implementation.
+func (c *TestClient) ListImagesBeta(project string, opts ...ListCallOption) ([]*computeBeta.Image, error) {
+	if c.ListImagesBetaFn != nil {
+		return c.ListImagesBetaFn(project, opts...)
+	}
+	return c.client.ListImagesBeta(project, opts...)
+}
+
+// ListResourcesByLabel uses the override method ListResourcesByLabelFn or the real implementation.
+func (c *TestClient) ListResourcesByLabel(project, labelKey, labelValue string) (*LabeledResources, error) {
+	if c.ListResourcesByLabelFn != nil {
+		return c.ListResourcesByLabelFn(project, labelKey, labelValue)
+	}
+	return c.client.ListResourcesByLabel(project, labelKey, labelValue)
+}
+
 // GetLicense uses the override method GetLicenseFn or the real implementation.
 func (c *TestClient) GetLicense(project, name string) (*compute.License, error) {
 	if c.GetLicenseFn != nil {
@@ -539,6 +850,14 @@ func (c *TestClient) GetRegion(project, name string) (*compute.Region, error) {
 	return c.client.GetRegion(project, name)
 }
 
+// IsRegionAvailable uses the override method IsRegionAvailableFn or the real implementation.
+func (c *TestClient) IsRegionAvailable(project, region string) (bool, error) {
+	if c.IsRegionAvailableFn != nil {
+		return c.IsRegionAvailableFn(project, region)
+	}
+	return c.client.IsRegionAvailable(project, region)
+}
+
 // ListNetworks uses the override method ListNetworksFn or the real implementation.
 func (c *TestClient) ListNetworks(project string, opts ...ListCallOption) ([]*compute.Network, error) {
 	if c.ListNetworksFn != nil {
@@ -595,6 +914,38 @@ func (c *TestClient) GetSerialPortOutput(project, zone, name string, port, start
 	return c.client.GetSerialPortOutput(project, zone, name, port, start)
 }
 
+// SendDiagnosticInterrupt uses the override method SendDiagnosticInterruptFn or the real implementation.
+func (c *TestClient) SendDiagnosticInterrupt(project, zone, name string) error {
+	if c.SendDiagnosticInterruptFn != nil {
+		return c.SendDiagnosticInterruptFn(project, zone, name)
+	}
+	return c.client.SendDiagnosticInterrupt(project, zone, name)
+}
+
+// GetScreenshot uses the override method GetScreenshotFn or the real implementation.
+func (c *TestClient) GetScreenshot(project, zone, name string) (*compute.Screenshot, error) {
+	if c.GetScreenshotFn != nil {
+		return c.GetScreenshotFn(project, zone, name)
+	}
+	return c.client.GetScreenshot(project, zone, name)
+}
+
+// PollSerialForMarker uses the override method PollSerialForMarkerFn or the real implementation.
+func (c *TestClient) PollSerialForMarker(ctx context.Context, project, zone, name string, port int64, success, failure *regexp.Regexp, interval time.Duration) (string, bool, error) {
+	if c.PollSerialForMarkerFn != nil {
+		return c.PollSerialForMarkerFn(ctx, project, zone, name, port, success, failure, interval)
+	}
+	return c.client.PollSerialForMarker(ctx, project, zone, name, port, success, failure, interval)
+}
+
+// GetEffectiveFirewalls uses the override method GetEffectiveFirewallsFn or the real implementation.
+func (c *TestClient) GetEffectiveFirewalls(project, zone, instance, networkInterface string) (*compute.InstancesGetEffectiveFirewallsResponse, error) {
+	if c.GetEffectiveFirewallsFn != nil {
+		return c.GetEffectiveFirewallsFn(project, zone, instance, networkInterface)
+	}
+	return c.client.GetEffectiveFirewalls(project, zone, instance, networkInterface)
+}
+
 // GetGuestAttributes uses the override method GetGuestAttributesFn or the real implementation.
 func (c *TestClient) GetGuestAttributes(project, zone, name, queryPath, variableKey string) (*compute.GuestAttributes, error) {
 	if c.GetGuestAttributesFn != nil {
@@ -627,6 +978,14 @@ func (c *TestClient) ResizeDisk(project, zone, disk string, drr *compute.DisksRe
 	return c.client.ResizeDisk(project, zone, disk, drr)
 }
 
+// UpdateDisk uses the override method UpdateDiskFn or the real implementation.
+func (c *TestClient) UpdateDisk(project, zone, disk string, d *compute.Disk, paths []string) error {
+	if c.UpdateDiskFn != nil {
+		return c.UpdateDiskFn(project, zone, disk, d, paths)
+	}
+	return c.client.UpdateDisk(project, zone, disk, d, paths)
+}
+
 // SetInstanceMetadata uses the override method SetInstancemetadataFn or the real implementation.
 func (c *TestClient) SetInstanceMetadata(project, zone, name string, md *compute.Metadata) error {
 	if c.SetInstanceMetadataFn != nil {
@@ -635,6 +994,54 @@ func (c *TestClient) SetInstanceMetadata(project, zone, name string, md *compute
 	return c.client.SetInstanceMetadata(project, zone, name, md)
 }
 
+// MergeInstanceMetadata uses the override method MergeInstanceMetadataFn or the real implementation.
+func (c *TestClient) MergeInstanceMetadata(project, zone, name string, add map[string]string, remove []string) error {
+	if c.MergeInstanceMetadataFn != nil {
+		return c.MergeInstanceMetadataFn(project, zone, name, add, remove)
+	}
+	return c.client.MergeInstanceMetadata(project, zone, name, add, remove)
+}
+
+// MergeInstanceLabels uses the override method MergeInstanceLabelsFn or the real implementation.
+func (c *TestClient) MergeInstanceLabels(project, zone, name string, add map[string]string, remove []string) error {
+	if c.MergeInstanceLabelsFn != nil {
+		return c.MergeInstanceLabelsFn(project, zone, name, add, remove)
+	}
+	return c.client.MergeInstanceLabels(project, zone, name, add, remove)
+}
+
+// SetTags uses the override method SetTagsFn or the real implementation.
+func (c *TestClient) SetTags(project, zone, instance string, tags *compute.Tags) error {
+	if c.SetTagsFn != nil {
+		return c.SetTagsFn(project, zone, instance, tags)
+	}
+	return c.client.SetTags(project, zone, instance, tags)
+}
+
+// AddInstanceResourcePolicies uses the override method AddInstanceResourcePoliciesFn or the real implementation.
+func (c *TestClient) AddInstanceResourcePolicies(project, zone, instance string, req *compute.InstancesAddResourcePoliciesRequest) error {
+	if c.AddInstanceResourcePoliciesFn != nil {
+		return c.AddInstanceResourcePoliciesFn(project, zone, instance, req)
+	}
+	return c.client.AddInstanceResourcePolicies(project, zone, instance, req)
+}
+
+// RemoveInstanceResourcePolicies uses the override method RemoveInstanceResourcePoliciesFn or the real implementation.
+func (c *TestClient) RemoveInstanceResourcePolicies(project, zone, instance string, req *compute.InstancesRemoveResourcePoliciesRequest) error {
+	if c.RemoveInstanceResourcePoliciesFn != nil {
+		return c.RemoveInstanceResourcePoliciesFn(project, zone, instance, req)
+	}
+	return c.client.RemoveInstanceResourcePolicies(project, zone, instance, req)
+}
+
+// SetInstanceName uses the override method SetInstanceNameFn or the real implementation.
+func (c *TestClient) SetInstanceName(project, zone, instance string, req *compute.InstancesSetNameRequest) error {
+	if c.SetInstanceNameFn != nil {
+		return c.SetInstanceNameFn(project, zone, instance, req)
+	}
+	return c.client.SetInstanceName(project, zone, instance, req)
+}
+
 // SetCommonInstanceMetadata uses the override method SetCommonInstanceMetadataFn or the real implementation.
 func (c *TestClient) SetCommonInstanceMetadata(project string, md *compute.Metadata) error {
 	if c.SetCommonInstanceMetadataFn != nil {
@@ -643,6 +1050,94 @@ func (c *TestClient) SetCommonInstanceMetadata(project string, md *compute.Metad
 	return c.client.SetCommonInstanceMetadata(project, md)
 }
 
+// MergeCommonInstanceMetadata uses the override method MergeCommonInstanceMetadataFn or the real implementation.
+func (c *TestClient) MergeCommonInstanceMetadata(project string, add map[string]string, remove []string) error {
+	if c.MergeCommonInstanceMetadataFn != nil {
+		return c.MergeCommonInstanceMetadataFn(project, add, remove)
+	}
+	return c.client.MergeCommonInstanceMetadata(project, add, remove)
+}
+
+// AddProjectSSHKey uses the override method AddProjectSSHKeyFn or the real implementation.
+func (c *TestClient) AddProjectSSHKey(project, user, publicKey string) error {
+	if c.AddProjectSSHKeyFn != nil {
+		return c.AddProjectSSHKeyFn(project, user, publicKey)
+	}
+	return c.client.AddProjectSSHKey(project, user, publicKey)
+}
+
+// IsOSLoginEnabled uses the override method IsOSLoginEnabledFn or the real implementation.
+func (c *TestClient) IsOSLoginEnabled(project, zone, instance string) (bool, error) {
+	if c.IsOSLoginEnabledFn != nil {
+		return c.IsOSLoginEnabledFn(project, zone, instance)
+	}
+	return c.client.IsOSLoginEnabled(project, zone, instance)
+}
+
+// PatchInstanceGroupManager uses the override method PatchInstanceGroupManagerFn or the real implementation.
+func (c *TestClient) PatchInstanceGroupManager(project, zone, igm string, m *compute.InstanceGroupManager) error {
+	if c.PatchInstanceGroupManagerFn != nil {
+		return c.PatchInstanceGroupManagerFn(project, zone, igm, m)
+	}
+	return c.client.PatchInstanceGroupManager(project, zone, igm, m)
+}
+
+// PatchRegionInstanceGroupManager uses the override method PatchRegionInstanceGroupManagerFn or the real implementation.
+func (c *TestClient) PatchRegionInstanceGroupManager(project, region, igm string, m *compute.InstanceGroupManager) error {
+	if c.PatchRegionInstanceGroupManagerFn != nil {
+		return c.PatchRegionInstanceGroupManagerFn(project, region, igm, m)
+	}
+	return c.client.PatchRegionInstanceGroupManager(project, region, igm, m)
+}
+
+// UpdatePerInstanceConfigs uses the override method UpdatePerInstanceConfigsFn or the real implementation.
+func (c *TestClient) UpdatePerInstanceConfigs(project, zone, igm string, req *compute.InstanceGroupManagersUpdatePerInstanceConfigsReq) error {
+	if c.UpdatePerInstanceConfigsFn != nil {
+		return c.UpdatePerInstanceConfigsFn(project, zone, igm, req)
+	}
+	return c.client.UpdatePerInstanceConfigs(project, zone, igm, req)
+}
+
+// UpdateRegionPerInstanceConfigs uses the override method UpdateRegionPerInstanceConfigsFn or the real implementation.
+func (c *TestClient) UpdateRegionPerInstanceConfigs(project, region, igm string, req *compute.RegionInstanceGroupManagerUpdateInstanceConfigReq) error {
+	if c.UpdateRegionPerInstanceConfigsFn != nil {
+		return c.UpdateRegionPerInstanceConfigsFn(project, region, igm, req)
+	}
+	return c.client.UpdateRegionPerInstanceConfigs(project, region, igm, req)
+}
+
+// CreateRegionInstanceTemplate uses the override method CreateRegionInstanceTemplateFn or the real implementation.
+func (c *TestClient) CreateRegionInstanceTemplate(project, region string, it *compute.InstanceTemplate) error {
+	if c.CreateRegionInstanceTemplateFn != nil {
+		return c.CreateRegionInstanceTemplateFn(project, region, it)
+	}
+	return c.client.CreateRegionInstanceTemplate(project, region, it)
+}
+
+// GetRegionInstanceTemplate uses the override method GetRegionInstanceTemplateFn or the real implementation.
+func (c *TestClient) GetRegionInstanceTemplate(project, region, name string) (*compute.InstanceTemplate, error) {
+	if c.GetRegionInstanceTemplateFn != nil {
+		return c.GetRegionInstanceTemplateFn(project, region, name)
+	}
+	return c.client.GetRegionInstanceTemplate(project, region, name)
+}
+
+// DeleteRegionInstanceTemplate uses the override method DeleteRegionInstanceTemplateFn or the real implementation.
+func (c *TestClient) DeleteRegionInstanceTemplate(project, region, name string) error {
+	if c.DeleteRegionInstanceTemplateFn != nil {
+		return c.DeleteRegionInstanceTemplateFn(project, region, name)
+	}
+	return c.client.DeleteRegionInstanceTemplate(project, region, name)
+}
+
+// ListRegionInstanceTemplates uses the override method ListRegionInstanceTemplatesFn or the real implementation.
+func (c *TestClient) ListRegionInstanceTemplates(project, region string, opts ...ListCallOption) ([]*compute.InstanceTemplate, error) {
+	if c.ListRegionInstanceTemplatesFn != nil {
+		return c.ListRegionInstanceTemplatesFn(project, region, opts...)
+	}
+	return c.client.ListRegionInstanceTemplates(project, region, opts...)
+}
+
 // zoneOperationsWait uses the override method zoneOperationsWaitFn or the real implementation.
 func (c *TestClient) zoneOperationsWait(project, zone, name string) error {
 	if c.zoneOperationsWaitFn != nil {
@@ -667,6 +1162,38 @@ func (c *TestClient) globalOperationsWait(project, name string) error {
 	return c.client.globalOperationsWait(project, name)
 }
 
+// zoneOperationsWaitBeta uses the override method zoneOperationsWaitBetaFn or the real implementation.
+func (c *TestClient) zoneOperationsWaitBeta(project, zone, name string) error {
+	if c.zoneOperationsWaitBetaFn != nil {
+		return c.zoneOperationsWaitBetaFn(project, zone, name)
+	}
+	return c.client.zoneOperationsWaitBeta(project, zone, name)
+}
+
+// regionOperationsWaitBeta uses the override method regionOperationsWaitBetaFn or the real implementation.
+func (c *TestClient) regionOperationsWaitBeta(project, region, name string) error {
+	if c.regionOperationsWaitBetaFn != nil {
+		return c.regionOperationsWaitBetaFn(project, region, name)
+	}
+	return c.client.regionOperationsWaitBeta(project, region, name)
+}
+
+// globalOperationsWaitBeta uses the override method globalOperationsWaitBetaFn or the real implementation.
+func (c *TestClient) globalOperationsWaitBeta(project, name string) error {
+	if c.globalOperationsWaitBetaFn != nil {
+		return c.globalOperationsWaitBetaFn(project, name)
+	}
+	return c.client.globalOperationsWaitBeta(project, name)
+}
+
+// waitForImageOperationBeta uses the override method waitForImageOperationBetaFn or the real implementation.
+func (c *TestClient) waitForImageOperationBeta(project, name string) error {
+	if c.waitForImageOperationBetaFn != nil {
+		return c.waitForImageOperationBetaFn(project, name)
+	}
+	return c.client.waitForImageOperationBeta(project, name)
+}
+
 // ListMachineImages uses the override method ListMachineImagesFn or the real implementation.
 func (c *TestClient) ListMachineImages(project string, opts ...ListCallOption) ([]*compute.MachineImage, error) {
 	if c.ListMachineImagesFn != nil {
@@ -803,6 +1330,14 @@ func (c *TestClient) ListRegionBackendServices(project, region string, opts ...L
 	return c.client.ListRegionBackendServices(project, region, opts...)
 }
 
+// AggregatedListBackendServices uses the override method AggregatedListBackendServicesFn or the real implementation.
+func (c *TestClient) AggregatedListBackendServices(project string, opts ...ListCallOption) ([]*compute.BackendService, error) {
+	if c.AggregatedListBackendServicesFn != nil {
+		return c.AggregatedListBackendServicesFn(project, opts...)
+	}
+	return c.client.AggregatedListBackendServices(project, opts...)
+}
+
 // GetRegionBackendService uses the override method GetRegionBackendServiceFn or the real implementation.
 func (c *TestClient) GetRegionBackendService(project, region, name string) (*compute.BackendService, error) {
 	if c.GetRegionBackendServiceFn != nil {
@@ -811,6 +1346,30 @@ func (c *TestClient) GetRegionBackendService(project, region, name string) (*com
 	return c.client.GetRegionBackendService(project, region, name)
 }
 
+// GetBackendService uses the override method GetBackendServiceFn or the real implementation.
+func (c *TestClient) GetBackendService(project, name string) (*compute.BackendService, error) {
+	if c.GetBackendServiceFn != nil {
+		return c.GetBackendServiceFn(project, name)
+	}
+	return c.client.GetBackendService(project, name)
+}
+
+// GetBackendServiceHealth uses the override method GetBackendServiceHealthFn or the real implementation.
+func (c *TestClient) GetBackendServiceHealth(project, region, backendService string, group *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error) {
+	if c.GetBackendServiceHealthFn != nil {
+		return c.GetBackendServiceHealthFn(project, region, backendService, group)
+	}
+	return c.client.GetBackendServiceHealth(project, region, backendService, group)
+}
+
+// GetGlobalBackendServiceHealth uses the override method GetGlobalBackendServiceHealthFn or the real implementation.
+func (c *TestClient) GetGlobalBackendServiceHealth(project, backendService string, group *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error) {
+	if c.GetGlobalBackendServiceHealthFn != nil {
+		return c.GetGlobalBackendServiceHealthFn(project, backendService, group)
+	}
+	return c.client.GetGlobalBackendServiceHealth(project, backendService, group)
+}
+
 // DeleteRegionHealthCheck uses the override method DeleteRegionHealthCheckFn or the real implementation.
 func (c *TestClient) DeleteRegionHealthCheck(project, region, name string) error {
 	if c.DeleteRegionHealthCheckFn != nil {
@@ -835,6 +1394,14 @@ func (c *TestClient) ListRegionHealthChecks(project, region string, opts ...List
 	return c.client.ListRegionHealthChecks(project, region, opts...)
 }
 
+// AggregatedListHealthChecks uses the override method AggregatedListHealthChecksFn or the real implementation.
+func (c *TestClient) AggregatedListHealthChecks(project string, opts ...ListCallOption) ([]*compute.HealthCheck, error) {
+	if c.AggregatedListHealthChecksFn != nil {
+		return c.AggregatedListHealthChecksFn(project, opts...)
+	}
+	return c.client.AggregatedListHealthChecks(project, opts...)
+}
+
 // GetRegionHealthCheck uses the override method GetRegionHealthCheckFn or the real implementation.
 func (c *TestClient) GetRegionHealthCheck(project, region, name string) (*compute.HealthCheck, error) {
 	if c.GetRegionHealthCheckFn != nil {
@@ -874,3 +1441,64 @@ func (c *TestClient) GetRegionNetworkEndpointGroup(project, region, name string)
 	}
 	return c.client.GetRegionNetworkEndpointGroup(project, region, name)
 }
+
+// DeleteNetworkEndpointGroup uses the override method DeleteNetworkEndpointGroupFn or the real implementation.
+func (c *TestClient) DeleteNetworkEndpointGroup(project, zone, name string) error {
+	if c.DeleteNetworkEndpointGroupFn != nil {
+		return c.DeleteNetworkEndpointGroupFn(project, zone, name)
+	}
+	return c.client.DeleteNetworkEndpointGroup(project, zone, name)
+}
+
+// CreateNetworkEndpointGroup uses the override method CreateNetworkEndpointGroupFn or the real implementation.
+func (c *TestClient) CreateNetworkEndpointGroup(project, zone string, n *compute.NetworkEndpointGroup) error {
+	if c.CreateNetworkEndpointGroupFn != nil {
+		return c.CreateNetworkEndpointGroupFn(project, zone, n)
+	}
+	return c.client.CreateNetworkEndpointGroup(project, zone, n)
+}
+
+// ListNetworkEndpointGroups uses the override method ListNetworkEndpointGroupsFn or the real implementation.
+func (c *TestClient) ListNetworkEndpointGroups(project, zone string, opts ...ListCallOption) ([]*compute.NetworkEndpointGroup, error) {
+	if c.ListNetworkEndpointGroupsFn != nil {
+		return c.ListNetworkEndpointGroupsFn(project, zone, opts...)
+	}
+	return c.client.ListNetworkEndpointGroups(project, zone, opts...)
+}
+
+// GetNetworkEndpointGroup uses the override method GetNetworkEndpointGroupFn or the real implementation.
+func (c *TestClient) GetNetworkEndpointGroup(project, zone, name string) (*compute.NetworkEndpointGroup, error) {
+	if c.GetNetworkEndpointGroupFn != nil {
+		return c.GetNetworkEndpointGroupFn(project, zone, name)
+	}
+	return c.client.GetNetworkEndpointGroup(project, zone, name)
+}
+
+// AttachNetworkEndpoints uses the override method AttachNetworkEndpointsFn or the real implementation.
+func (c *TestClient) AttachNetworkEndpoints(project, zone, neg string, req *compute.NetworkEndpointGroupsAttachEndpointsRequest) error {
+	if c.AttachNetworkEndpointsFn != nil {
+		return c.AttachNetworkEndpointsFn(project, zone, neg, req)
+	}
+	return c.client.AttachNetworkEndpoints(project, zone, neg, req)
+}
+
+// DetachNetworkEndpoints uses the override method DetachNetworkEndpointsFn or the real implementation.
+func (c *TestClient) DetachNetworkEndpoints(project, zone, neg string, req *compute.NetworkEndpointGroupsDetachEndpointsRequest) error {
+	if c.DetachNetworkEndpointsFn != nil {
+		return c.DetachNetworkEndpointsFn(project, zone, neg, req)
+	}
+	return c.client.DetachNetworkEndpoints(project, zone, neg, req)
+}
+
+// Copy returns a copy of the client with its own call budget, retry context
+// and operation progress hook, which keeps the override methods.
+func (c *TestClient) Copy() (Client, error) {
+	cc, err := c.client.copy()
+	if err != nil {
+		return nil, err
+	}
+	tc := *c
+	tc.client = *cc
+	tc.client.i = &tc
+	return &tc, nil
+}
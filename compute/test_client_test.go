@@ -15,10 +15,15 @@
 package compute
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"regexp"
 	"testing"
+	"time"
 
+	computeAlpha "google.golang.org/api/compute/v0.alpha"
+	computeBeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
 	"google.golang.org/api/googleapi"
 )
@@ -43,61 +48,113 @@ func TestTestClient(t *testing.T) {
 		{"retry", func() {
 			c.Retry(func(_ ...googleapi.CallOption) (*compute.Operation, error) { realCalled = true; return nil, nil })
 		}, ""},
+		{"retry alpha", func() {
+			c.RetryAlpha(func(_ ...googleapi.CallOption) (*computeAlpha.Operation, error) { realCalled = true; return nil, nil })
+		}, ""},
 		{"attach disk", func() { c.AttachDisk("a", "b", "c", &compute.AttachedDisk{}) }, "/projects/a/zones/b/instances/c/attachDisk?alt=json&prettyPrint=false"},
 		{"detach disk", func() { c.DetachDisk("a", "b", "c", "d") }, "/projects/a/zones/b/instances/c/detachDisk?alt=json&deviceName=d&prettyPrint=false"},
 		{"resize disk", func() { c.ResizeDisk("a", "b", "c", &compute.DisksResizeRequest{SizeGb: 128}) }, "/projects/a/zones/b/disks/c/resize?alt=json&prettyPrint=false"},
+		{"update disk", func() { c.UpdateDisk("a", "b", "c", &compute.Disk{}, []string{"provisionedIops"}) }, "/projects/a/zones/b/disks/c?alt=json&prettyPrint=false&updateMask=provisionedIops"},
 		{"create disk", func() { c.CreateDisk("a", "b", &compute.Disk{}) }, "/projects/a/zones/b/disks?alt=json&prettyPrint=false"},
 		{"create firewall rule", func() { c.CreateFirewallRule("a", &compute.Firewall{}) }, "/projects/a/global/firewalls?alt=json&prettyPrint=false"},
 		{"create image", func() { c.CreateImage("a", &compute.Image{}) }, "/projects/a/global/images?alt=json&prettyPrint=false"},
 		{"create instance", func() { c.CreateInstance("a", "b", &compute.Instance{}) }, "/projects/a/zones/b/instances?alt=json&prettyPrint=false"},
 		{"create network", func() { c.CreateNetwork("a", &compute.Network{}) }, "/projects/a/global/networks?alt=json&prettyPrint=false"},
+		{"patch network", func() { c.PatchNetwork("a", "b", &compute.Network{}) }, "/projects/a/global/networks/b?alt=json&prettyPrint=false"},
+		{"switch network to custom mode", func() { c.SwitchNetworkToCustomMode("a", "b") }, "/projects/a/global/networks/b/switchToCustomMode?alt=json&prettyPrint=false"},
 		{"create subnetwork", func() { c.CreateSubnetwork("a", "b", &compute.Subnetwork{}) }, "/projects/a/regions/b/subnetworks?alt=json&prettyPrint=false"},
+		{"create address", func() { c.CreateAddress("a", "b", &compute.Address{}) }, "/projects/a/regions/b/addresses?alt=json&prettyPrint=false"},
 		{"instances start", func() { c.StartInstance("a", "b", "c") }, "/projects/a/zones/b/instances/c/start?alt=json&prettyPrint=false"},
 		{"instances stop", func() { c.StopInstance("a", "b", "c") }, "/projects/a/zones/b/instances/c/stop?alt=json&prettyPrint=false"},
 		{"delete disk", func() { c.DeleteDisk("a", "b", "c") }, "/projects/a/zones/b/disks/c?alt=json&prettyPrint=false"},
 		{"delete firewall rule", func() { c.DeleteFirewallRule("a", "b") }, "/projects/a/global/firewalls/b?alt=json&prettyPrint=false"},
 		{"delete image", func() { c.DeleteImage("a", "b") }, "/projects/a/global/images/b?alt=json&prettyPrint=false"},
 		{"delete instance", func() { c.DeleteInstance("a", "b", "c") }, "/projects/a/zones/b/instances/c?alt=json&prettyPrint=false"},
+		{"deprecate image alpha", func() { c.DeprecateImageAlpha("a", "b", &computeAlpha.DeprecationStatus{}) }, "/projects/a/global/images/b/deprecate?alt=json&prettyPrint=false"},
+		{"deprecate image beta", func() { c.DeprecateImageBeta("a", "b", &computeBeta.DeprecationStatus{}) }, "/projects/a/global/images/b/deprecate?alt=json&prettyPrint=false"},
 		{"delete network", func() { c.DeleteNetwork("a", "b") }, "/projects/a/global/networks/b?alt=json&prettyPrint=false"},
 		{"delete subnetwork", func() { c.DeleteSubnetwork("a", "b", "c") }, "/projects/a/regions/b/subnetworks/c?alt=json&prettyPrint=false"},
+		{"delete address", func() { c.DeleteAddress("a", "b", "c") }, "/projects/a/regions/b/addresses/c?alt=json&prettyPrint=false"},
+		{"get address", func() { c.GetAddress("a", "b", "c") }, "/projects/a/regions/b/addresses/c?alt=json&prettyPrint=false"},
 		{"deprecate image", func() { c.DeprecateImage("a", "b", &compute.DeprecationStatus{}) }, "/projects/a/global/images/b/deprecate?alt=json&prettyPrint=false"},
 		{"get serial port", func() { c.GetSerialPortOutput("a", "b", "c", 1, 2) }, "/projects/a/zones/b/instances/c/serialPort?alt=json&port=1&prettyPrint=false&start=2"},
+		{"get screenshot", func() { c.GetScreenshot("a", "b", "c") }, "/projects/a/zones/b/instances/c/screenshot?alt=json&prettyPrint=false"},
+		{"send diagnostic interrupt", func() { c.SendDiagnosticInterrupt("a", "b", "c") }, "/projects/a/zones/b/instances/c/sendDiagnosticInterrupt?alt=json&prettyPrint=false"},
+		{"poll serial for marker", func() {
+			c.PollSerialForMarker(context.Background(), "a", "b", "c", 1, regexp.MustCompile("d"), nil, time.Nanosecond)
+		}, "/projects/a/zones/b/instances/c/serialPort?alt=json&port=1&prettyPrint=false&start=0"},
 		{"get project", func() { c.GetProject("a") }, "/projects/a?alt=json&prettyPrint=false"},
+		{"ping", func() { c.Ping(context.Background(), "a") }, "/projects/a?alt=json&fields=name&prettyPrint=false"},
 		{"get machine type", func() { c.GetMachineType("a", "b", "c") }, "/projects/a/zones/b/machineTypes/c?alt=json&prettyPrint=false"},
 		{"list machine types", func() { c.ListMachineTypes("a", "b", listOpts...) }, "/projects/a/zones/b/machineTypes?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"get firewall rule", func() { c.GetFirewallRule("a", "b") }, "/projects/a/global/firewalls/b?alt=json&prettyPrint=false"},
 		{"list firewall rules", func() { c.ListFirewallRules("a", listOpts...) }, "/projects/a/global/firewalls?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"get zone", func() { c.GetZone("a", "b") }, "/projects/a/zones/b?alt=json&prettyPrint=false"},
 		{"list zones", func() { c.ListZones("a", listOpts...) }, "/projects/a/zones?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
+		{"list regions", func() { c.ListRegions("a") }, "/projects/a/regions?alt=json&pageToken=&prettyPrint=false"},
 		{"get instance", func() { c.GetInstance("a", "b", "c") }, "/projects/a/zones/b/instances/c?alt=json&prettyPrint=false"},
+		{"get instances", func() { c.GetInstances("a", "b", []string{"c"}) }, "/projects/a/zones/b/instances/c?alt=json&prettyPrint=false"},
 		{"aggregated list instances", func() { c.AggregatedListInstances("a", listOpts...) }, "/projects/a/aggregated/instances?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"list instances", func() { c.ListInstances("a", "b", listOpts...) }, "/projects/a/zones/b/instances?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"get image from family", func() { c.GetImageFromFamily("a", "b") }, "/projects/a/global/images/family/b?alt=json&prettyPrint=false"},
 		{"get image", func() { c.GetImage("a", "b") }, "/projects/a/global/images/b?alt=json&prettyPrint=false"},
+		{"get images", func() { c.GetImages("a", []string{"b"}) }, "/projects/a/global/images/b?alt=json&prettyPrint=false"},
 		{"list images", func() { c.ListImages("a", listOpts...) }, "/projects/a/global/images?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
+		{"list images beta", func() { c.ListImagesBeta("a", listOpts...) }, "/projects/a/global/images?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"get license", func() { c.GetLicense("a", "b") }, "/projects/a/global/licenses/b?alt=json&prettyPrint=false"},
 		{"get network", func() { c.GetNetwork("a", "b") }, "/projects/a/global/networks/b?alt=json&prettyPrint=false"},
 		{"list networks", func() { c.ListNetworks("a", listOpts...) }, "/projects/a/global/networks?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"get subnetwork", func() { c.GetSubnetwork("a", "b", "c") }, "/projects/a/regions/b/subnetworks/c?alt=json&prettyPrint=false"},
 		{"aggregated list subnetworks", func() { c.AggregatedListSubnetworks("a", listOpts...) }, "/projects/a/aggregated/subnetworks?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"list subnetworks", func() { c.ListSubnetworks("a", "b", listOpts...) }, "/projects/a/regions/b/subnetworks?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
+		{"list instance referrers", func() { c.ListInstanceReferrers("a", "b", "c", listOpts...) }, "/projects/a/zones/b/instances/c/referrers?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
+		{"list addresses", func() { c.ListAddresses("a", "b", listOpts...) }, "/projects/a/regions/b/addresses?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"get region", func() { c.GetRegion("a", "b") }, "/projects/a/regions/b?alt=json&prettyPrint=false"},
 		{"get disk", func() { c.GetDisk("a", "b", "c") }, "/projects/a/zones/b/disks/c?alt=json&prettyPrint=false"},
+		{"get disks", func() { c.GetDisks("a", "b", []string{"c"}) }, "/projects/a/zones/b/disks/c?alt=json&prettyPrint=false"},
 		{"aggregated list disks", func() { c.AggregatedListDisks("a", listOpts...) }, "/projects/a/aggregated/disks?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"list disks", func() { c.ListDisks("a", "b", listOpts...) }, "/projects/a/zones/b/disks?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"instance status", func() { c.InstanceStatus("a", "b", "c") }, "/projects/a/zones/b/instances/c?alt=json&prettyPrint=false"},
 		{"instance stopped", func() { c.InstanceStopped("a", "b", "c") }, "/projects/a/zones/b/instances/c?alt=json&prettyPrint=false"},
 		{"set instance metadata", func() { c.SetInstanceMetadata("a", "b", "c", nil) }, "/projects/a/zones/b/instances/c/setMetadata?alt=json&prettyPrint=false"},
+		{"merge instance metadata", func() { c.MergeInstanceMetadata("a", "b", "c", map[string]string{"d": "e"}, nil) }, "/projects/a/zones/b/instances/c?alt=json&prettyPrint=false"},
+		{"merge instance labels", func() { c.MergeInstanceLabels("a", "b", "c", map[string]string{"d": "e"}, nil) }, "/projects/a/zones/b/instances/c?alt=json&prettyPrint=false"},
+		{"set instance name", func() { c.SetInstanceName("a", "b", "c", nil) }, "/projects/a/zones/b/instances/c/setName?alt=json&prettyPrint=false"},
 		{"set project metadata", func() { c.SetCommonInstanceMetadata("a", nil) }, "/projects/a/setCommonInstanceMetadata?alt=json&prettyPrint=false"},
+		{"add project ssh key", func() { c.AddProjectSSHKey("a", "b", "c") }, "/projects/a?alt=json&prettyPrint=false"},
+		{"merge common instance metadata", func() { c.MergeCommonInstanceMetadata("a", map[string]string{"b": "c"}, nil) }, "/projects/a?alt=json&prettyPrint=false"},
+		{"is os login enabled", func() { c.IsOSLoginEnabled("a", "b", "c") }, "/projects/a/zones/b/instances/c?alt=json&prettyPrint=false"},
+		{"patch instance group manager", func() { c.PatchInstanceGroupManager("a", "b", "c", &compute.InstanceGroupManager{}) }, "/projects/a/zones/b/instanceGroupManagers/c?alt=json&prettyPrint=false"},
+		{"patch region instance group manager", func() { c.PatchRegionInstanceGroupManager("a", "b", "c", &compute.InstanceGroupManager{}) }, "/projects/a/regions/b/instanceGroupManagers/c?alt=json&prettyPrint=false"},
+		{"update per instance configs", func() {
+			c.UpdatePerInstanceConfigs("a", "b", "c", &compute.InstanceGroupManagersUpdatePerInstanceConfigsReq{})
+		}, "/projects/a/zones/b/instanceGroupManagers/c/updatePerInstanceConfigs?alt=json&prettyPrint=false"},
+		{"update region per instance configs", func() {
+			c.UpdateRegionPerInstanceConfigs("a", "b", "c", &compute.RegionInstanceGroupManagerUpdateInstanceConfigReq{})
+		}, "/projects/a/regions/b/instanceGroupManagers/c/updatePerInstanceConfigs?alt=json&prettyPrint=false"},
+		{"create region instance template", func() { c.CreateRegionInstanceTemplate("a", "b", &compute.InstanceTemplate{}) }, "/projects/a/regions/b/instanceTemplates?alt=json&prettyPrint=false"},
+		{"get region instance template", func() { c.GetRegionInstanceTemplate("a", "b", "c") }, "/projects/a/regions/b/instanceTemplates/c?alt=json&prettyPrint=false"},
+		{"delete region instance template", func() { c.DeleteRegionInstanceTemplate("a", "b", "c") }, "/projects/a/regions/b/instanceTemplates/c?alt=json&prettyPrint=false"},
+		{"list region instance templates", func() { c.ListRegionInstanceTemplates("a", "b", listOpts...) }, "/projects/a/regions/b/instanceTemplates?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"zone operation wait", func() { c.zoneOperationsWait("a", "b", "c") }, "/projects/a/zones/b/operations/c/wait?alt=json&prettyPrint=false"},
 		{"region operation wait", func() { c.regionOperationsWait("a", "b", "c") }, "/projects/a/regions/b/operations/c/wait?alt=json&prettyPrint=false"},
 		{"global operation wait", func() { c.globalOperationsWait("a", "b") }, "/projects/a/global/operations/b/wait?alt=json&prettyPrint=false"},
+		{"image operation wait", func() { c.WaitForImageOperation("a", "b") }, "/projects/a/global/operations/b/wait?alt=json&prettyPrint=false"},
+		{"zone operation wait beta", func() { c.zoneOperationsWaitBeta("a", "b", "c") }, "/projects/a/zones/b/operations/c/wait?alt=json&prettyPrint=false"},
+		{"region operation wait beta", func() { c.regionOperationsWaitBeta("a", "b", "c") }, "/projects/a/regions/b/operations/c/wait?alt=json&prettyPrint=false"},
+		{"global operation wait beta", func() { c.globalOperationsWaitBeta("a", "b") }, "/projects/a/global/operations/b/wait?alt=json&prettyPrint=false"},
+		{"image operation wait beta", func() { c.waitForImageOperationBeta("a", "b") }, "/projects/a/global/operations/b/wait?alt=json&prettyPrint=false"},
 		{"get guest attributes", func() { c.GetGuestAttributes("a", "b", "c", "d", "e") }, "/projects/a/zones/b/instances/c/getGuestAttributes?alt=json&prettyPrint=false&queryPath=d&variableKey=e"},
 		{"create machine image", func() { c.CreateMachineImage("a", &compute.MachineImage{}) }, "/projects/a/global/machineImages?alt=json&prettyPrint=false"},
 		{"get machine image", func() { c.GetMachineImage("a", "b") }, "/projects/a/global/machineImages/b?alt=json&prettyPrint=false"},
 		{"list machine images", func() { c.ListMachineImages("a", listOpts...) }, "/projects/a/global/machineImages?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
 		{"delete machine image", func() { c.DeleteMachineImage("a", "b") }, "/projects/a/global/machineImages/b?alt=json&prettyPrint=false"},
-		{"aggregated list forwarding rule", func() { c.AggregatedListForwardingRules("a", listOpts...) }, "/projects/a/aggregated/forwardingRules?alt=json&pageToken=&prettyPrint=false"},
+		{"aggregated list forwarding rule", func() { c.AggregatedListForwardingRules("a", listOpts...) }, "/projects/a/aggregated/forwardingRules?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
+		{"aggregated list backend services", func() { c.AggregatedListBackendServices("a", listOpts...) }, "/projects/a/aggregated/backendServices?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
+		{"aggregated list health checks", func() { c.AggregatedListHealthChecks("a", listOpts...) }, "/projects/a/aggregated/healthChecks?alt=json&filter=foo&orderBy=foo&pageToken=&prettyPrint=false"},
+		{"get backend service", func() { c.GetBackendService("a", "b") }, "/projects/a/global/backendServices/b?alt=json&prettyPrint=false"},
+		{"get backend service health", func() { c.GetBackendServiceHealth("a", "b", "c", &compute.ResourceGroupReference{}) }, "/projects/a/regions/b/backendServices/c/getHealth?alt=json&prettyPrint=false"},
+		{"get global backend service health", func() { c.GetGlobalBackendServiceHealth("a", "b", &compute.ResourceGroupReference{}) }, "/projects/a/global/backendServices/b/getHealth?alt=json&prettyPrint=false"},
 		{"delete network", func() { c.DeleteNetwork("a", "b") }, "/projects/a/global/networks/b?alt=json&prettyPrint=false"},
 	}
 
@@ -128,14 +185,21 @@ func TestTestClient(t *testing.T) {
 		fakeCalled = true
 		return nil, nil
 	}
+	c.RetryAlphaFn = func(_ func(_ ...googleapi.CallOption) (*computeAlpha.Operation, error), _ ...googleapi.CallOption) (op *computeAlpha.Operation, err error) {
+		fakeCalled = true
+		return nil, nil
+	}
 	c.AttachDiskFn = func(_, _, _ string, _ *compute.AttachedDisk) error { fakeCalled = true; return nil }
 	c.DetachDiskFn = func(_, _, _, _ string) error { fakeCalled = true; return nil }
 	c.ResizeDiskFn = func(_, _, _ string, _ *compute.DisksResizeRequest) error { fakeCalled = true; return nil }
+	c.UpdateDiskFn = func(_, _, _ string, _ *compute.Disk, _ []string) error { fakeCalled = true; return nil }
 	c.CreateDiskFn = func(_, _ string, _ *compute.Disk) error { fakeCalled = true; return nil }
 	c.CreateFirewallRuleFn = func(_ string, _ *compute.Firewall) error { fakeCalled = true; return nil }
 	c.CreateImageFn = func(_ string, _ *compute.Image) error { fakeCalled = true; return nil }
 	c.CreateInstanceFn = func(_, _ string, _ *compute.Instance) error { fakeCalled = true; return nil }
 	c.CreateNetworkFn = func(_ string, _ *compute.Network) error { fakeCalled = true; return nil }
+	c.PatchNetworkFn = func(_, _ string, _ *compute.Network) error { fakeCalled = true; return nil }
+	c.SwitchNetworkToCustomModeFn = func(_, _ string) error { fakeCalled = true; return nil }
 	c.CreateSubnetworkFn = func(_, _ string, _ *compute.Subnetwork) error { fakeCalled = true; return nil }
 	c.StartInstanceFn = func(_, _, _ string) error { fakeCalled = true; return nil }
 	c.StopInstanceFn = func(_, _, _ string) error { fakeCalled = true; return nil }
@@ -146,22 +210,38 @@ func TestTestClient(t *testing.T) {
 	c.DeleteNetworkFn = func(_, _ string) error { fakeCalled = true; return nil }
 	c.DeleteSubnetworkFn = func(_, _, _ string) error { fakeCalled = true; return nil }
 	c.DeprecateImageFn = func(_, _ string, _ *compute.DeprecationStatus) error { fakeCalled = true; return nil }
+	c.DeprecateImageAlphaFn = func(_, _ string, _ *computeAlpha.DeprecationStatus) error { fakeCalled = true; return nil }
+	c.DeprecateImageBetaFn = func(_, _ string, _ *computeBeta.DeprecationStatus) error { fakeCalled = true; return nil }
+	c.PollSerialForMarkerFn = func(_ context.Context, _, _, _ string, _ int64, _, _ *regexp.Regexp, _ time.Duration) (string, bool, error) {
+		fakeCalled = true
+		return "", false, nil
+	}
 	c.GetSerialPortOutputFn = func(_, _, _ string, _, _ int64) (*compute.SerialPortOutput, error) {
 		fakeCalled = true
 		return nil, nil
 	}
 	c.GetProjectFn = func(_ string) (*compute.Project, error) { fakeCalled = true; return nil, nil }
+	c.PingFn = func(_ context.Context, _ string) error { fakeCalled = true; return nil }
 	c.GetZoneFn = func(_, _ string) (*compute.Zone, error) { fakeCalled = true; return nil, nil }
+	c.IsZoneAvailableFn = func(_, _ string) (bool, error) { fakeCalled = true; return false, nil }
 	c.ListZonesFn = func(_ string, _ ...ListCallOption) ([]*compute.Zone, error) {
 		fakeCalled = true
 		return nil, nil
 	}
+	c.ListRegionsFn = func(_ string, _ ...ListCallOption) ([]*compute.Region, error) {
+		fakeCalled = true
+		return nil, nil
+	}
 	c.GetFirewallRuleFn = func(_, _ string) (*compute.Firewall, error) { fakeCalled = true; return nil, nil }
 	c.ListFirewallRulesFn = func(_ string, _ ...ListCallOption) ([]*compute.Firewall, error) {
 		fakeCalled = true
 		return nil, nil
 	}
 	c.GetInstanceFn = func(_, _, _ string) (*compute.Instance, error) { fakeCalled = true; return nil, nil }
+	c.GetInstancesFn = func(_, _ string, _ []string) (map[string]*compute.Instance, map[string]error) {
+		fakeCalled = true
+		return nil, nil
+	}
 	c.AggregatedListInstancesFn = func(_ string, _ ...ListCallOption) ([]*compute.Instance, error) {
 		fakeCalled = true
 		return nil, nil
@@ -171,6 +251,10 @@ func TestTestClient(t *testing.T) {
 		return nil, nil
 	}
 	c.GetDiskFn = func(_, _, _ string) (*compute.Disk, error) { fakeCalled = true; return nil, nil }
+	c.GetDisksFn = func(_, _ string, _ []string) (map[string]*compute.Disk, map[string]error) {
+		fakeCalled = true
+		return nil, nil
+	}
 	c.AggregatedListDisksFn = func(_ string, _ ...ListCallOption) ([]*compute.Disk, error) {
 		fakeCalled = true
 		return nil, nil
@@ -181,10 +265,18 @@ func TestTestClient(t *testing.T) {
 	}
 	c.GetImageFromFamilyFn = func(_, _ string) (*compute.Image, error) { fakeCalled = true; return nil, nil }
 	c.GetImageFn = func(_, _ string) (*compute.Image, error) { fakeCalled = true; return nil, nil }
+	c.GetImagesFn = func(_ string, _ []string) (map[string]*compute.Image, map[string]error) {
+		fakeCalled = true
+		return nil, nil
+	}
 	c.ListImagesFn = func(_ string, _ ...ListCallOption) ([]*compute.Image, error) {
 		fakeCalled = true
 		return nil, nil
 	}
+	c.ListImagesBetaFn = func(_ string, _ ...ListCallOption) ([]*computeBeta.Image, error) {
+		fakeCalled = true
+		return nil, nil
+	}
 	c.GetLicenseFn = func(_, _ string) (*compute.License, error) { fakeCalled = true; return nil, nil }
 	c.GetNetworkFn = func(_, _ string) (*compute.Network, error) { fakeCalled = true; return nil, nil }
 	c.ListNetworksFn = func(_ string, _ ...ListCallOption) ([]*compute.Network, error) {
@@ -201,6 +293,7 @@ func TestTestClient(t *testing.T) {
 		return nil, nil
 	}
 	c.GetRegionFn = func(_, _ string) (*compute.Region, error) { fakeCalled = true; return nil, nil }
+	c.IsRegionAvailableFn = func(_, _ string) (bool, error) { fakeCalled = true; return false, nil }
 	c.GetMachineTypeFn = func(_, _, _ string) (*compute.MachineType, error) { fakeCalled = true; return nil, nil }
 	c.ListMachineTypesFn = func(_, _ string, _ ...ListCallOption) ([]*compute.MachineType, error) {
 		fakeCalled = true
@@ -209,11 +302,49 @@ func TestTestClient(t *testing.T) {
 	c.InstanceStatusFn = func(_, _, _ string) (string, error) { fakeCalled = true; return "", nil }
 	c.InstanceStoppedFn = func(_, _, _ string) (bool, error) { fakeCalled = true; return false, nil }
 	c.SetInstanceMetadataFn = func(_, _, _ string, _ *compute.Metadata) error { fakeCalled = true; return nil }
+	c.MergeInstanceMetadataFn = func(_, _, _ string, _ map[string]string, _ []string) error { fakeCalled = true; return nil }
+	c.MergeInstanceLabelsFn = func(_, _, _ string, _ map[string]string, _ []string) error { fakeCalled = true; return nil }
+	c.SetInstanceNameFn = func(_, _, _ string, _ *compute.InstancesSetNameRequest) error { fakeCalled = true; return nil }
 	c.SetCommonInstanceMetadataFn = func(_ string, _ *compute.Metadata) error { fakeCalled = true; return nil }
+	c.AddProjectSSHKeyFn = func(_, _, _ string) error { fakeCalled = true; return nil }
+	c.MergeCommonInstanceMetadataFn = func(_ string, _ map[string]string, _ []string) error { fakeCalled = true; return nil }
+	c.IsOSLoginEnabledFn = func(_, _, _ string) (bool, error) { fakeCalled = true; return false, nil }
+	c.PatchInstanceGroupManagerFn = func(_, _, _ string, _ *compute.InstanceGroupManager) error { fakeCalled = true; return nil }
+	c.PatchRegionInstanceGroupManagerFn = func(_, _, _ string, _ *compute.InstanceGroupManager) error { fakeCalled = true; return nil }
+	c.UpdatePerInstanceConfigsFn = func(_, _, _ string, _ *compute.InstanceGroupManagersUpdatePerInstanceConfigsReq) error {
+		fakeCalled = true
+		return nil
+	}
+	c.UpdateRegionPerInstanceConfigsFn = func(_, _, _ string, _ *compute.RegionInstanceGroupManagerUpdateInstanceConfigReq) error {
+		fakeCalled = true
+		return nil
+	}
+	c.CreateRegionInstanceTemplateFn = func(_, _ string, _ *compute.InstanceTemplate) error { fakeCalled = true; return nil }
+	c.GetRegionInstanceTemplateFn = func(_, _, _ string) (*compute.InstanceTemplate, error) { fakeCalled = true; return nil, nil }
+	c.DeleteRegionInstanceTemplateFn = func(_, _, _ string) error { fakeCalled = true; return nil }
+	c.ListRegionInstanceTemplatesFn = func(_, _ string, _ ...ListCallOption) ([]*compute.InstanceTemplate, error) {
+		fakeCalled = true
+		return nil, nil
+	}
 	c.zoneOperationsWaitFn = func(_, _, _ string) error { fakeCalled = true; return nil }
 	c.regionOperationsWaitFn = func(_, _, _ string) error { fakeCalled = true; return nil }
 	c.globalOperationsWaitFn = func(_, _ string) error { fakeCalled = true; return nil }
+	c.WaitForImageOperationFn = func(_, _ string) error { fakeCalled = true; return nil }
+	c.zoneOperationsWaitBetaFn = func(_, _, _ string) error { fakeCalled = true; return nil }
+	c.regionOperationsWaitBetaFn = func(_, _, _ string) error { fakeCalled = true; return nil }
+	c.globalOperationsWaitBetaFn = func(_, _ string) error { fakeCalled = true; return nil }
+	c.waitForImageOperationBetaFn = func(_, _ string) error { fakeCalled = true; return nil }
 	c.GetGuestAttributesFn = func(_, _, _, _, _ string) (*compute.GuestAttributes, error) { fakeCalled = true; return nil, nil }
+	c.GetScreenshotFn = func(_, _, _ string) (*compute.Screenshot, error) { fakeCalled = true; return nil, nil }
+	c.SendDiagnosticInterruptFn = func(_, _, _ string) error { fakeCalled = true; return nil }
+	c.CreateAddressFn = func(_, _ string, _ *compute.Address) error { fakeCalled = true; return nil }
+	c.DeleteAddressFn = func(_, _, _ string) error { fakeCalled = true; return nil }
+	c.GetAddressFn = func(_, _, _ string) (*compute.Address, error) { fakeCalled = true; return nil, nil }
+	c.ListInstanceReferrersFn = func(_, _, _ string, _ ...ListCallOption) ([]*compute.Reference, error) {
+		fakeCalled = true
+		return nil, nil
+	}
+	c.ListAddressesFn = func(_, _ string, _ ...ListCallOption) ([]*compute.Address, error) { fakeCalled = true; return nil, nil }
 	c.CreateMachineImageFn = func(_ string, _ *compute.MachineImage) error { fakeCalled = true; return nil }
 	c.GetMachineImageFn = func(_, _ string) (*compute.MachineImage, error) { fakeCalled = true; return nil, nil }
 	c.ListMachineImagesFn = func(_ string, _ ...ListCallOption) ([]*compute.MachineImage, error) {
@@ -224,6 +355,23 @@ func TestTestClient(t *testing.T) {
 		fakeCalled = true
 		return nil, nil
 	}
+	c.AggregatedListBackendServicesFn = func(_ string, _ ...ListCallOption) ([]*compute.BackendService, error) {
+		fakeCalled = true
+		return nil, nil
+	}
+	c.AggregatedListHealthChecksFn = func(_ string, _ ...ListCallOption) ([]*compute.HealthCheck, error) {
+		fakeCalled = true
+		return nil, nil
+	}
+	c.GetBackendServiceFn = func(_, _ string) (*compute.BackendService, error) { fakeCalled = true; return nil, nil }
+	c.GetBackendServiceHealthFn = func(_, _, _ string, _ *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error) {
+		fakeCalled = true
+		return nil, nil
+	}
+	c.GetGlobalBackendServiceHealthFn = func(_, _ string, _ *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error) {
+		fakeCalled = true
+		return nil, nil
+	}
 	c.DeleteMachineImageFn = func(_, _ string) error { fakeCalled = true; return nil }
 	wantFakeCalled = true
 	wantRealCalled = false
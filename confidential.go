@@ -0,0 +1,113 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/compute/v1"
+)
+
+const (
+	defaultConfidentialInstanceType = "SEV"
+	uefiCompatibleFeature           = "UEFI_COMPATIBLE"
+)
+
+// confidentialMachineFamilies are the machine type families supporting each
+// confidential instance type.
+var confidentialMachineFamilies = map[string][]string{
+	"SEV":     {"n2d", "c2d", "c3d"},
+	"SEV_SNP": {"n2d"},
+	"TDX":     {"c3"},
+}
+
+func hasUEFICompatibleFeature(features []*compute.GuestOsFeature) bool {
+	for _, f := range features {
+		if f.Type == uefiCompatibleFeature {
+			return true
+		}
+	}
+	return false
+}
+
+// validateConfidentialCompute checks that a confidential instance uses a
+// machine type family supporting its confidential instance type and boots
+// from a UEFI compatible image or disk. Images and disks created by the
+// workflow can't be checked before they exist and are skipped.
+func (ib *InstanceBase) validateConfidentialCompute(ii InstanceInterface, s *Step) (errs DError) {
+	enabled, instanceType := ii.getConfidentialCompute()
+	if !enabled {
+		return nil
+	}
+	pre := fmt.Sprintf("cannot create confidential instance %q", ib.daisyName)
+	instanceType = strOr(instanceType, defaultConfidentialInstanceType)
+
+	families, ok := confidentialMachineFamilies[instanceType]
+	if !ok {
+		errs = addErrs(errs, Errf("%s: unknown ConfidentialInstanceType %q", pre, instanceType))
+	} else if mt := NamedSubexp(machineTypeURLRegex, ii.getMachineType())["machinetype"]; mt != "" {
+		if family := strings.SplitN(mt, "-", 2)[0]; !strIn(family, families) {
+			errs = addErrs(errs, Errf("%s: machine type %q doesn't support %s confidential computing, use one of the %q machine type families", pre, mt, instanceType, families))
+		}
+	}
+
+	disks := ii.getComputeDisks()
+	if len(disks) == 0 {
+		return errs
+	}
+	boot := disks[0]
+	w := s.w
+	if boot.hasInitializeParams {
+		m := NamedSubexp(imageURLRgx, boot.sourceImage)
+		if m == nil {
+			return errs
+		}
+		if r, ok := w.images.get(boot.sourceImage); ok && r.creator != nil {
+			return errs
+		}
+		var img *compute.Image
+		var err error
+		if m["family"] != "" {
+			img, err = w.ComputeClient.GetImageFromFamily(m["project"], m["family"])
+		} else {
+			img, err = w.ComputeClient.GetImage(m["project"], m["image"])
+		}
+		if err != nil {
+			return addErrs(errs, typedErr(apiError, fmt.Sprintf("%s: failed to get boot image %q", pre, boot.sourceImage), err))
+		}
+		if !hasUEFICompatibleFeature(img.GuestOsFeatures) {
+			errs = addErrs(errs, Errf("%s: boot image %q isn't UEFI compatible, use an image with the %s guest OS feature", pre, boot.sourceImage, uefiCompatibleFeature))
+		}
+		return errs
+	}
+
+	r, ok := w.disks.get(boot.source)
+	if !ok || r.creator != nil {
+		return errs
+	}
+	m := NamedSubexp(diskURLRgx, r.link)
+	if m == nil {
+		return errs
+	}
+	d, err := w.ComputeClient.GetDisk(m["project"], m["zone"], m["disk"])
+	if err != nil {
+		return addErrs(errs, typedErr(apiError, fmt.Sprintf("%s: failed to get boot disk %q", pre, boot.source), err))
+	}
+	if !hasUEFICompatibleFeature(d.GuestOsFeatures) {
+		errs = addErrs(errs, Errf("%s: boot disk %q isn't UEFI compatible, use a disk with the %s guest OS feature", pre, boot.source, uefiCompatibleFeature))
+	}
+	return errs
+}
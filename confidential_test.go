@@ -0,0 +1,126 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	computeBeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestValidateConfidentialCompute(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{w: w}
+	uefi := []*compute.GuestOsFeature{{Type: uefiCompatibleFeature}}
+	tc := w.ComputeClient.(*daisyCompute.TestClient)
+	tc.GetImageFn = func(_, name string) (*compute.Image, error) {
+		if name == "bios-image" {
+			return &compute.Image{Name: name}, nil
+		}
+		return &compute.Image{Name: name, GuestOsFeatures: uefi}, nil
+	}
+	tc.GetImageFromFamilyFn = func(_, family string) (*compute.Image, error) {
+		return &compute.Image{Name: family, GuestOsFeatures: uefi}, nil
+	}
+	tc.GetDiskFn = func(_, _, name string) (*compute.Disk, error) {
+		return &compute.Disk{Name: name}, nil
+	}
+	existingDisk := fmt.Sprintf("projects/%s/zones/%s/disks/existing", testProject, testZone)
+	w.disks.m = map[string]*Resource{
+		existingDisk: {link: existingDisk},
+		"wf-disk":    {link: fmt.Sprintf("projects/%s/zones/%s/disks/wf-disk", testProject, testZone), creator: &Step{}},
+	}
+
+	mt := func(t string) string {
+		return fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", testProject, testZone, t)
+	}
+	image := func(name string) []*compute.AttachedDisk {
+		return []*compute.AttachedDisk{{InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: fmt.Sprintf("projects/%s/global/images/%s", testProject, name)}}}
+	}
+	newInstance := func(machineType string, disks []*compute.AttachedDisk, confidential bool) *Instance {
+		i := &Instance{Instance: compute.Instance{
+			MachineType:                mt(machineType),
+			Disks:                      disks,
+			ConfidentialInstanceConfig: &compute.ConfidentialInstanceConfig{EnableConfidentialCompute: confidential},
+		}}
+		i.daisyName = "i"
+		return i
+	}
+
+	tests := []struct {
+		desc      string
+		i         *Instance
+		shouldErr bool
+	}{
+		{"not confidential case", newInstance("n1-standard-1", image("bios-image"), false), false},
+		{"confidential case", newInstance("n2d-standard-2", image("uefi-image"), true), false},
+		{"custom machine type case", newInstance("n2d-custom-2-4096", image("uefi-image"), true), false},
+		{"image family case", newInstance("c2d-standard-2", []*compute.AttachedDisk{{InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: fmt.Sprintf("projects/%s/global/images/family/fam", testProject)}}}, true), false},
+		{"workflow image case", newInstance("n2d-standard-2", []*compute.AttachedDisk{{InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: "wf-image"}}}, true), false},
+		{"workflow disk case", newInstance("n2d-standard-2", []*compute.AttachedDisk{{Source: "wf-disk"}}, true), false},
+		{"unsupported machine type case", newInstance("n1-standard-1", image("uefi-image"), true), true},
+		{"bios image case", newInstance("n2d-standard-2", image("bios-image"), true), true},
+		{"bios disk case", newInstance("n2d-standard-2", []*compute.AttachedDisk{{Source: existingDisk}}, true), true},
+	}
+	for _, tt := range tests {
+		if err := tt.i.validateConfidentialCompute(tt.i, s); tt.shouldErr && err == nil {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if !tt.shouldErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+
+	// Beta instance confidential instance types.
+	betaTests := []struct {
+		desc         string
+		machineType  string
+		instanceType string
+		shouldErr    bool
+	}{
+		{"SEV_SNP case", "n2d-standard-2", "SEV_SNP", false},
+		{"TDX case", "c3-standard-4", "TDX", false},
+		{"TDX unsupported machine type case", "n2d-standard-2", "TDX", true},
+		{"unknown type case", "n2d-standard-2", "FOO", true},
+	}
+	for _, tt := range betaTests {
+		i := &InstanceBeta{Instance: computeBeta.Instance{
+			MachineType:                mt(tt.machineType),
+			Disks:                      []*computeBeta.AttachedDisk{{InitializeParams: &computeBeta.AttachedDiskInitializeParams{SourceImage: fmt.Sprintf("projects/%s/global/images/uefi-image", testProject)}}},
+			ConfidentialInstanceConfig: &computeBeta.ConfidentialInstanceConfig{EnableConfidentialCompute: true, ConfidentialInstanceType: tt.instanceType},
+		}}
+		if err := i.validateConfidentialCompute(i, s); tt.shouldErr && err == nil {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if !tt.shouldErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestConfidentialInstanceTypeUsesBeta(t *testing.T) {
+	ci := &CreateInstances{
+		Instances:     []*Instance{{}},
+		InstancesBeta: []*InstanceBeta{{Instance: computeBeta.Instance{ConfidentialInstanceConfig: &computeBeta.ConfidentialInstanceConfig{EnableConfidentialCompute: true}}}},
+	}
+	if ci.instanceUsesBetaFeatures() {
+		t.Error("confidential instance without ConfidentialInstanceType should use the GA API")
+	}
+	ci.InstancesBeta[0].ConfidentialInstanceConfig.ConfidentialInstanceType = "SEV_SNP"
+	if !ci.instanceUsesBetaFeatures() {
+		t.Error("confidential instance with ConfidentialInstanceType should use the Beta API")
+	}
+}
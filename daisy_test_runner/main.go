@@ -530,7 +530,7 @@ func runTestCase(ctx context.Context, test *test, tc *junitTestCase, errors chan
 		select {
 		case <-c:
 			fmt.Printf("\nCtrl-C caught, sending cancel signal to %q...\n", test.name)
-			test.testCase.w.CancelWorkflow()
+			test.testCase.w.CancelWithCause(daisy.CancelKindUser, "Ctrl-C caught")
 			err := fmt.Errorf("test case %q was canceled", test.name)
 			errors <- err
 			tc.Failure = &junitFailure{FailMessage: err.Error(), FailType: "Canceled"}
@@ -18,14 +18,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/googleapi"
 )
 
 var (
@@ -58,6 +57,31 @@ func isDiskAttached(client daisyCompute.Client, deviceName, project, zone, insta
 	return false, nil
 }
 
+// diskSupportsMultiReader reports whether the disk dr can be attached in
+// READ_ONLY mode to several instances at once, Hyperdisks other than
+// Hyperdisk ML can't be.
+func (w *Workflow) diskSupportsMultiReader(dr *Resource) (bool, DError) {
+	var diskType string
+	if dr.creator != nil {
+		if dr.creator.CreateDisks != nil {
+			for _, d := range *dr.creator.CreateDisks {
+				if d.link == dr.link {
+					diskType = d.Type
+				}
+			}
+		}
+	} else {
+		m := NamedSubexp(diskURLRgx, dr.link)
+		d, err := w.ComputeClient.GetDisk(m["project"], m["zone"], m["disk"])
+		if err != nil {
+			return false, Errf("failed to get disk %q: %v", dr.link, err)
+		}
+		diskType = d.Type
+	}
+	t := path.Base(diskType)
+	return !strings.HasPrefix(t, "hyperdisk-") || t == "hyperdisk-ml", nil
+}
+
 // Disk is used to create a GCE disk in a project.
 type Disk struct {
 	compute.Disk
@@ -151,10 +175,7 @@ func (dr *diskRegistry) init() {
 func (dr *diskRegistry) deleteFn(res *Resource) DError {
 	m := NamedSubexp(diskURLRgx, res.link)
 	err := dr.w.ComputeClient.DeleteDisk(m["project"], m["zone"], m["disk"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete disk", err)
-	}
-	return newErr("failed to delete disk", err)
+	return deleteErr("disk", err)
 }
 
 // detachHelper marks s as the detacher between dName and iName.
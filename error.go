@@ -15,8 +15,12 @@
 package daisy
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/googleapi"
 )
 
 const (
@@ -52,6 +56,9 @@ type DError interface {
 	errorsType() []string
 	AnonymizedErrs() []string
 	CausedByErrType(t string) bool
+	// Unwrap returns the wrapped errors, so that errors.Is and errors.As
+	// find the errors a DError was created from, like a *googleapi.Error.
+	Unwrap() []error
 }
 
 // addErrs adds an error to a DError.
@@ -81,7 +88,7 @@ func Errf(format string, a ...interface{}) DError {
 func wrapErrf(e DError, formatPrefix string, a ...interface{}) DError {
 	f := fmt.Sprintf("%v: %v", formatPrefix, strings.Join(e.AnonymizedErrs(), "; "))
 	return &dErrImpl{
-		errs:           []error{fmt.Errorf("%v: %v", fmt.Sprintf(formatPrefix, a...), e.Error())},
+		errs:           []error{fmt.Errorf("%v: %w", fmt.Sprintf(formatPrefix, a...), e)},
 		errsType:       e.errorsType(),
 		anonymizedErrs: []string{f},
 	}
@@ -101,6 +108,53 @@ func newErr(anonymizedErrMsg string, e error) DError {
 	return &dErrImpl{errs: []error{e}, errsType: []string{""}, anonymizedErrs: []string{anonymizedErrMsg}}
 }
 
+// resourceErr prefixes e with the daisy name of the resource it's about.
+// Together with the prefix added by Step, errors read as a breadcrumb:
+// step "step-name" run error: resource "resource-name": error.
+// It's used for the API errors of creating resources, and of deleting,
+// starting and stopping them through their registry, which includes cleanup.
+// Validation errors name their resource in their message instead.
+// If e is nil, nil is returned, if daisyName is empty e is returned as is.
+func resourceErr(daisyName string, e DError) DError {
+	if e == nil || daisyName == "" {
+		return e
+	}
+	return wrapErrf(e, "resource %q", daisyName)
+}
+
+// resourceInUseReasons are the API error reasons, and resourceInUseCodes the
+// operation error codes, of deletes failing because the resource is still
+// used by another resource or isn't ready.
+var (
+	resourceInUseReasons = []string{"resourceInUseByAnotherResource", "resourceNotReady"}
+	resourceInUseCodes   = []string{"RESOURCE_IN_USE_BY_ANOTHER_RESOURCE", "RESOURCE_NOT_READY"}
+)
+
+// isResourceInUseErr returns whether e is caused by a resource being in use
+// or not ready. When the other resource was just deleted these errors are
+// transient, GCE is eventually consistent.
+func isResourceInUseErr(e DError) bool {
+	if e == nil {
+		return false
+	}
+	for _, err := range e.errors() {
+		var gErr *googleapi.Error
+		if errors.As(err, &gErr) {
+			for _, item := range gErr.Errors {
+				if strIn(item.Reason, resourceInUseReasons) {
+					return true
+				}
+			}
+		}
+		for _, code := range resourceInUseCodes {
+			if daisyCompute.HasOperationErrorCode(err, code) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ToDError returns a DError. ToDError is used to wrap another error as a DError.
 // If e is already a DError, e is copied and returned.
 // If e is a normal error, error message is reused as format.
@@ -201,6 +255,10 @@ func (e *dErrImpl) errors() []error {
 	return e.errs
 }
 
+func (e *dErrImpl) Unwrap() []error {
+	return e.errs
+}
+
 func (e *dErrImpl) CausedByErrType(t string) bool {
 	for _, et := range e.errsType {
 		if et == t {
@@ -18,6 +18,8 @@ import (
 	"errors"
 	"strings"
 	"testing"
+
+	"google.golang.org/api/googleapi"
 )
 
 func TestAddErrs(t *testing.T) {
@@ -84,6 +86,28 @@ func TestTypedErrf(t *testing.T) {
 	}
 }
 
+func TestDErrUnwrap(t *testing.T) {
+	gErr := &googleapi.Error{Code: 403, Message: "forbidden"}
+	tests := []struct {
+		desc string
+		err  DError
+	}{
+		{"newErr case", newErr("error", gErr)},
+		{"typedErr case", typedErr(apiError, "error", gErr)},
+		{"wrapped case", wrapErrf(newErr("error", gErr), "step %q run error", "s")},
+		{"multierror case", addErrs(Errf("foo"), gErr)},
+	}
+	for _, tt := range tests {
+		var got *googleapi.Error
+		if !errors.As(tt.err, &got) || got != gErr {
+			t.Errorf("%s: *googleapi.Error not found in %v", tt.desc, tt.err)
+		}
+	}
+	if errors.As(Errf("foo"), new(*googleapi.Error)) {
+		t.Error("*googleapi.Error found in an error not created from one")
+	}
+}
+
 func TestDErrImplAdd(t *testing.T) {
 	tests := []struct {
 		desc        string
@@ -147,3 +171,45 @@ func TestNestedAnonymizedDErrorMessage(t *testing.T) {
 	}
 
 }
+
+func TestResourceErr(t *testing.T) {
+	if err := resourceErr("r", nil); err != nil {
+		t.Errorf("resourceErr of a nil DError should be nil, got: %v", err)
+	}
+	e := typedErr(apiError, "failed to create disk", errors.New("private details"))
+	if err := resourceErr("", e); err != e {
+		t.Errorf("resourceErr without a name should return the DError as is, got: %v", err)
+	}
+
+	err := resourceErr("my-disk", e)
+	if want := `APIError: resource "my-disk": APIError: private details`; err.Error() != want {
+		t.Errorf("unexpected error message, got: %q, want: %q", err.Error(), want)
+	}
+	if !err.CausedByErrType(apiError) {
+		t.Error("resourceErr should keep the error type")
+	}
+	// The resource name isn't part of the anonymized message.
+	if got, want := strings.Join(err.AnonymizedErrs(), ","), "resource %q: APIError: failed to create disk"; got != want {
+		t.Errorf("unexpected anonymized message, got: %q, want: %q", got, want)
+	}
+}
+
+func TestIsResourceInUseErr(t *testing.T) {
+	tests := []struct {
+		desc string
+		err  DError
+		want bool
+	}{
+		{"nil case", nil, false},
+		{"in use case", newErr("msg", &googleapi.Error{Code: 400, Errors: []googleapi.ErrorItem{{Reason: "resourceInUseByAnotherResource"}}}), true},
+		{"not ready case", newErr("msg", &googleapi.Error{Code: 400, Errors: []googleapi.ErrorItem{{Reason: "resourceNotReady"}}}), true},
+		{"other API error case", newErr("msg", &googleapi.Error{Code: 400, Errors: []googleapi.ErrorItem{{Reason: "invalid"}}}), false},
+		{"untyped error case", Errf("resourceInUseByAnotherResource"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isResourceInUseErr(tt.err); got != tt.want {
+			t.Errorf("%s: got: %t, want: %t", tt.desc, got, tt.want)
+		}
+	}
+}
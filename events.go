@@ -0,0 +1,247 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/compute-daisy/compute"
+)
+
+// EventType is the type of a workflow Event.
+type EventType string
+
+// Types of workflow events.
+const (
+	// EventStepStarted is sent when a step starts running.
+	EventStepStarted EventType = "step-started"
+	// EventStepFinished is sent when a step is done running, Err is set if
+	// it failed.
+	EventStepFinished EventType = "step-finished"
+	// EventResourceCreated is sent when the workflow creates, or adopts
+	// because of ExistsOk, a resource.
+	EventResourceCreated EventType = "resource-created"
+	// EventResourceDeleted is sent when the workflow deletes a resource,
+	// including during cleanup.
+	EventResourceDeleted EventType = "resource-deleted"
+	// EventOperationProgress is sent when the progress of a Compute
+	// operation the workflow waits on increases.
+	EventOperationProgress EventType = "operation-progress"
+	// EventWarning is sent for each warning logged by the workflow.
+	EventWarning EventType = "warning"
+)
+
+// Event is a progress event of a running workflow, see Workflow.Events.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Workflow is the name of the workflow, including the names of its
+	// parent workflows, the event is about.
+	Workflow string
+	// StepName and StepType are set for step events, and for warnings logged
+	// by a step. StepName is also set for created resources, to the step
+	// creating them, and for the progress of the operations creating or
+	// deleting a resource of the workflow, to the step running them.
+	StepName string
+	StepType string
+	// Err is the error of a failed step.
+	Err DError
+	// Resource is set for resource events.
+	Resource *ResourceRef
+	// Operation is set for operation progress events.
+	Operation *compute.OperationProgress
+	// Message is the text of a warning.
+	Message string
+}
+
+// EventOverflowPolicy is what happens to events sent while the buffer of
+// the Events channel is full.
+type EventOverflowPolicy int
+
+const (
+	// EventOverflowDrop drops the events, they are counted by DroppedEvents.
+	EventOverflowDrop EventOverflowPolicy = iota
+	// EventOverflowBuffer queues the events in memory, without limit, until
+	// they are received.
+	EventOverflowBuffer
+)
+
+const defaultEventBufferSize = 100
+
+// eventStream sends the events of a workflow without ever blocking it.
+type eventStream struct {
+	mx      sync.Mutex
+	ch      chan Event
+	policy  EventOverflowPolicy
+	closed  bool
+	dropped int64
+	// Events queued with EventOverflowBuffer, and the signal for the
+	// goroutine forwarding them to ch.
+	pending []Event
+	wake    chan struct{}
+}
+
+func newEventStream(size int, policy EventOverflowPolicy) *eventStream {
+	if size <= 0 {
+		size = defaultEventBufferSize
+	}
+	es := &eventStream{ch: make(chan Event, size), policy: policy}
+	if policy == EventOverflowBuffer {
+		es.wake = make(chan struct{}, 1)
+		go es.forward()
+	}
+	return es
+}
+
+func (es *eventStream) send(e Event) {
+	es.mx.Lock()
+	defer es.mx.Unlock()
+	if es.closed {
+		return
+	}
+	if es.policy == EventOverflowBuffer {
+		es.pending = append(es.pending, e)
+		es.signal()
+		return
+	}
+	select {
+	case es.ch <- e:
+	default:
+		es.dropped++
+	}
+}
+
+func (es *eventStream) close() {
+	es.mx.Lock()
+	defer es.mx.Unlock()
+	if es.closed {
+		return
+	}
+	es.closed = true
+	if es.policy == EventOverflowBuffer {
+		// The channel is closed once the queued events are received.
+		es.signal()
+		return
+	}
+	close(es.ch)
+}
+
+func (es *eventStream) signal() {
+	select {
+	case es.wake <- struct{}{}:
+	default:
+	}
+}
+
+// forward sends the queued events to the channel, in order.
+func (es *eventStream) forward() {
+	for range es.wake {
+		es.mx.Lock()
+		pending, closed := es.pending, es.closed
+		es.pending = nil
+		es.mx.Unlock()
+		for _, e := range pending {
+			es.ch <- e
+		}
+		if closed {
+			close(es.ch)
+			return
+		}
+	}
+}
+
+// Events returns a channel receiving the progress events of the workflow,
+// for UIs to follow its run. Call it on the top level workflow before Run,
+// events from included and sub workflows are sent to the same channel.
+// The workflow never blocks on the channel: events sent while its buffer of
+// EventBufferSize events is full are dropped or queued according to
+// EventOverflow. The channel is closed once Run returns, after cleanup,
+// and once the queued events are received.
+func (w *Workflow) Events() <-chan Event {
+	for w.parent != nil {
+		w = w.parent
+	}
+	w.eventsMx.Lock()
+	defer w.eventsMx.Unlock()
+	if w.events == nil {
+		w.events = newEventStream(w.EventBufferSize, w.EventOverflow)
+	}
+	return w.events.ch
+}
+
+// DroppedEvents returns the number of events dropped because the buffer of
+// the Events channel was full.
+func (w *Workflow) DroppedEvents() int64 {
+	es := w.eventStream()
+	if es == nil {
+		return 0
+	}
+	es.mx.Lock()
+	defer es.mx.Unlock()
+	return es.dropped
+}
+
+func (w *Workflow) eventStream() *eventStream {
+	for w.parent != nil {
+		w = w.parent
+	}
+	w.eventsMx.Lock()
+	defer w.eventsMx.Unlock()
+	return w.events
+}
+
+// sendEvent sends e, about workflow w, if Events was called.
+func (w *Workflow) sendEvent(e Event) {
+	es := w.eventStream()
+	if es == nil {
+		return
+	}
+	e.Time = time.Now()
+	e.Workflow = getAbsoluteName(w)
+	es.send(e)
+}
+
+// closeEvents closes the Events channel of a top level workflow.
+func (w *Workflow) closeEvents() {
+	if w.parent != nil {
+		return
+	}
+	if es := w.eventStream(); es != nil {
+		es.close()
+	}
+}
+
+// sendWarningEvent sends a warning event for a log entry marked as a warning.
+func (w *Workflow) sendWarningEvent(e *LogEntry) {
+	msg := strings.TrimPrefix(e.Message, "WARNING: ")
+	if msg == e.Message {
+		return
+	}
+	w.sendEvent(Event{Type: EventWarning, StepName: e.StepName, StepType: e.StepType, Message: msg})
+}
+
+func (w *Workflow) sendResourceEvent(t EventType, stepName, typeName, name string, res *Resource) {
+	w.sendEvent(Event{Type: t, StepName: stepName, Resource: &ResourceRef{
+		Type:     typeName,
+		Name:     name,
+		RealName: res.RealName,
+		Project:  res.Project,
+		Link:     res.link,
+		Created:  res.createdAt,
+		Deleted:  res.deleted,
+	}})
+}
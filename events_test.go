@@ -0,0 +1,167 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// testEventsWorkflow returns a workflow with a step creating a disk and
+// logging a warning, and a step depending on it.
+func testEventsWorkflow() *Workflow {
+	w := testWorkflow()
+	disk := &Resource{RealName: "real-d", daisyName: "d", Project: testProject, link: fmt.Sprintf("projects/%s/zones/%s/disks/real-d", testProject, testZone)}
+	w.Steps = map[string]*Step{
+		"s0": {name: "s0", w: w, testType: &mockStep{
+			validateImpl: func(_ context.Context, s *Step) DError {
+				return w.disks.regCreate("d", disk, s, false)
+			},
+			runImpl: func(_ context.Context, s *Step) DError {
+				disk.markCreated()
+				s.w.LogStepInfo(s.name, "mockStep", "WARNING: careful")
+				return nil
+			},
+		}},
+		"s1": {name: "s1", w: w, testType: &mockStep{}},
+	}
+	w.Dependencies = map[string][]string{"s1": {"s0"}}
+	return w
+}
+
+// eventSummary is the part of an event checked by the tests, other fields
+// vary between runs.
+func eventSummary(e Event) string {
+	s := fmt.Sprintf("%s %s %s", e.Type, e.Workflow, e.StepName)
+	if e.Resource != nil {
+		s += fmt.Sprintf(" %s %s %s", e.Resource.Type, e.Resource.Name, e.Resource.Link)
+	}
+	if e.Operation != nil {
+		s += fmt.Sprintf(" %d%%", e.Operation.Progress)
+	}
+	if e.Err != nil {
+		s += " error"
+	}
+	return s + " " + e.Message
+}
+
+func TestEvents(t *testing.T) {
+	w := testEventsWorkflow()
+	events := w.Events()
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		for e := range events {
+			if e.Time.IsZero() {
+				t.Errorf("event has no time: %+v", e)
+			}
+			got = append(got, eventSummary(e))
+		}
+		close(done)
+	}()
+
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The channel is closed once Run returns.
+	<-done
+
+	link := fmt.Sprintf("projects/%s/zones/%s/disks/real-d", testProject, testZone)
+	want := []string{
+		"step-started test-wf s0 ",
+		"resource-created test-wf s0 disk d " + link + " ",
+		"warning test-wf s0 careful",
+		"step-finished test-wf s0 ",
+		"step-started test-wf s1 ",
+		"step-finished test-wf s1 ",
+		// The disk is deleted during cleanup.
+		"operation-progress test-wf  100% ",
+		"resource-deleted test-wf  disk d " + link + " ",
+	}
+	if diffRes := diff(got, want, 0); diffRes != "" {
+		t.Errorf("events do not match expectation: (-got +want)\n%s", diffRes)
+	}
+	if n := w.DroppedEvents(); n != 0 {
+		t.Errorf("dropped %d events, want none", n)
+	}
+}
+
+func TestEventsStepError(t *testing.T) {
+	w := testWorkflow()
+	w.Steps = map[string]*Step{
+		"s0": {name: "s0", w: w, testType: &mockStep{runImpl: func(context.Context, *Step) DError {
+			return Errf("failure")
+		}}},
+	}
+	events := w.Events()
+
+	if err := w.Run(context.Background()); err == nil {
+		t.Fatal("expected error from Run")
+	}
+	var got []string
+	for e := range events {
+		got = append(got, eventSummary(e))
+	}
+	want := []string{"step-started test-wf s0 ", "step-finished test-wf s0 error "}
+	if diffRes := diff(got, want, 0); diffRes != "" {
+		t.Errorf("events do not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestEventsOverflow(t *testing.T) {
+	tests := []struct {
+		desc        string
+		policy      EventOverflowPolicy
+		wantEvents  int
+		wantDropped int64
+	}{
+		{"drop case", EventOverflowDrop, 1, 7},
+		{"buffer case", EventOverflowBuffer, 8, 0},
+	}
+	for _, tt := range tests {
+		w := testEventsWorkflow()
+		w.EventBufferSize = 1
+		w.EventOverflow = tt.policy
+		events := w.Events()
+
+		// Nothing receives the events while the workflow runs, which must
+		// not block it.
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.desc, err)
+		}
+		var got int
+		for range events {
+			got++
+		}
+		if got != tt.wantEvents {
+			t.Errorf("%s: received %d events, want %d", tt.desc, got, tt.wantEvents)
+		}
+		if n := w.DroppedEvents(); n != tt.wantDropped {
+			t.Errorf("%s: dropped %d events, want %d", tt.desc, n, tt.wantDropped)
+		}
+	}
+}
+
+func TestEventsNotRequested(t *testing.T) {
+	// Events aren't collected unless Events is called.
+	w := testEventsWorkflow()
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.events != nil {
+		t.Error("events were collected without a call to Events")
+	}
+}
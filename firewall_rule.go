@@ -18,12 +18,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"regexp"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/googleapi"
 )
 
 var (
@@ -94,8 +92,5 @@ func newFirewallRuleRegistry(w *Workflow) *firewallRuleRegistry {
 func (frr *firewallRuleRegistry) deleteFn(res *Resource) DError {
 	m := NamedSubexp(firewallRuleURLRegex, res.link)
 	err := frr.w.ComputeClient.DeleteFirewallRule(m["project"], m["firewallRule"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete firewall", err)
-	}
-	return newErr("failed to delete firewall", err)
+	return deleteErr("firewall", err)
 }
@@ -18,12 +18,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"regexp"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/googleapi"
 )
 
 var (
@@ -102,8 +100,5 @@ func newForwardingRuleRegistry(w *Workflow) *forwardingRuleRegistry {
 func (tir *forwardingRuleRegistry) deleteFn(res *Resource) DError {
 	m := NamedSubexp(forwardingRuleURLRegex, res.link)
 	err := tir.w.ComputeClient.DeleteForwardingRule(m["project"], m["region"], m["forwardingRule"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete forwarding rule", err)
-	}
-	return newErr("failed to delete forwarding rule", err)
+	return deleteErr("forwarding rule", err)
 }
@@ -18,7 +18,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"path"
 	"regexp"
 	"strings"
@@ -31,6 +30,10 @@ import (
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 )
 
+// rawDiskContainerType is the only RawDisk.ContainerType supported by GCE, a
+// gzip compressed tarball containing a disk.raw file.
+const rawDiskContainerType = "TAR"
+
 var (
 	imageURLRgx = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?global/images\/((family/(?P<family>%[2]s))?|(?P<image>%[2]s))$`, projectRgxStr, rfc1035))
 )
@@ -53,7 +56,7 @@ func (w *Workflow) imageExists(project, family, image string) (bool, DError) {
 
 		img, err := w.ComputeClient.GetImageFromFamily(project, family)
 		if err != nil {
-			if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+			if daisyCompute.IsNotFound(err) {
 				return false, nil
 			}
 			return false, typedErr(apiError, "failed to get image from family", err)
@@ -112,6 +115,7 @@ type ImageInterface interface {
 	hasRawDisk() bool
 	getRawDiskSource() string
 	setRawDiskSource(rawDiskSource string)
+	getRawDiskContainerType() string
 	create(cc daisyCompute.Client) error
 	markCreatedInWorkflow()
 	delete(cc daisyCompute.Client) error
@@ -179,6 +183,10 @@ func (i *Image) getRawDiskSource() string {
 	return i.RawDisk.Source
 }
 
+func (i *Image) getRawDiskContainerType() string {
+	return i.RawDisk.ContainerType
+}
+
 func (i *Image) setRawDiskSource(rawDiskSource string) {
 	i.RawDisk.Source = rawDiskSource
 }
@@ -188,7 +196,7 @@ func (i *Image) create(cc daisyCompute.Client) error {
 }
 
 func (i *Image) markCreatedInWorkflow() {
-	i.createdInWorkflow = true
+	i.markCreated()
 }
 
 func (i *Image) delete(cc daisyCompute.Client) error {
@@ -254,6 +262,10 @@ func (i *ImageBeta) getRawDiskSource() string {
 	return i.RawDisk.Source
 }
 
+func (i *ImageBeta) getRawDiskContainerType() string {
+	return i.RawDisk.ContainerType
+}
+
 func (i *ImageBeta) setRawDiskSource(rawDiskSource string) {
 	i.RawDisk.Source = rawDiskSource
 }
@@ -263,7 +275,7 @@ func (i *ImageBeta) create(cc daisyCompute.Client) error {
 }
 
 func (i *ImageBeta) markCreatedInWorkflow() {
-	i.createdInWorkflow = true
+	i.markCreated()
 }
 
 func (i *ImageBeta) delete(cc daisyCompute.Client) error {
@@ -329,6 +341,10 @@ func (i *ImageAlpha) getRawDiskSource() string {
 	return i.RawDisk.Source
 }
 
+func (i *ImageAlpha) getRawDiskContainerType() string {
+	return i.RawDisk.ContainerType
+}
+
 func (i *ImageAlpha) setRawDiskSource(rawDiskSource string) {
 	i.RawDisk.Source = rawDiskSource
 }
@@ -338,7 +354,7 @@ func (i *ImageAlpha) create(cc daisyCompute.Client) error {
 }
 
 func (i *ImageAlpha) markCreatedInWorkflow() {
-	i.createdInWorkflow = true
+	i.markCreated()
 }
 
 func (i *ImageAlpha) delete(cc daisyCompute.Client) error {
@@ -427,6 +443,9 @@ func (ib *ImageBase) validate(ctx context.Context, ii ImageInterface, licenses [
 
 	// RawDisk.Source checking.
 	if ii.hasRawDisk() {
+		if ct := ii.getRawDiskContainerType(); ct != "" && ct != rawDiskContainerType {
+			errs = addErrs(errs, Errf("%s: bad RawDisk.ContainerType %q, only %q is supported", pre, ct, rawDiskContainerType))
+		}
 		sBkt, sObj, err := splitGCSPath(ii.getRawDiskSource())
 		errs = addErrs(errs, err)
 
@@ -480,8 +499,5 @@ func newImageRegistry(w *Workflow) *imageRegistry {
 func (ir *imageRegistry) deleteFn(res *Resource) DError {
 	m := NamedSubexp(imageURLRgx, res.link)
 	err := ir.w.ComputeClient.DeleteImage(m["project"], m["image"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete image", err)
-	}
-	return newErr("failed to delete image", err)
+	return deleteErr("image", err)
 }
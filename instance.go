@@ -19,7 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
-	"net/http"
+	"net"
 	"path"
 	"regexp"
 	"strings"
@@ -28,7 +28,6 @@ import (
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	computeBeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/googleapi"
 )
 
 const (
@@ -37,11 +36,14 @@ const (
 	defaultDiskType         = "pd-standard"
 	diskModeRO              = "READ_ONLY"
 	diskModeRW              = "READ_WRITE"
+	scratchDiskType         = "SCRATCH"
 )
 
 var (
 	instanceURLRgx = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?zones/(?P<zone>%[2]s)/instances/(?P<instance>%[2]s)$`, projectRgxStr, rfc1035))
 	validDiskModes = []string{diskModeRO, diskModeRW}
+	// Interfaces local SSDs can be attached with, SCSI is the default.
+	localSSDInterfaces = []string{"NVME", "SCSI"}
 )
 
 func checkDiskMode(m string) bool {
@@ -87,6 +89,11 @@ type InstanceInterface interface {
 	setMetadata(md map[string]string)
 	getSourceMachineImage() string
 	setSourceMachineImage(machineImage string)
+	getReservationAffinity() *compute.ReservationAffinity
+	getNodeAffinities() []*compute.SchedulingNodeAffinity
+	validateScheduling() DError
+	getConfidentialCompute() (enabled bool, instanceType string)
+	getIPs() InstanceIPs
 }
 
 // InstanceBase is a base struct for GA/Beta instances.
@@ -197,6 +204,9 @@ func (i *Instance) updateDisksAndNetworksBeforeCreate(w *Workflow) {
 		if subnetRes, ok := w.subnetworks.get(n.Subnetwork); ok {
 			n.Subnetwork = subnetRes.link
 		}
+		if ip, ok := w.addresses.ip(n.NetworkIP); ok {
+			n.NetworkIP = ip
+		}
 	}
 }
 
@@ -216,6 +226,41 @@ func (i *Instance) setSourceMachineImage(machineImage string) {
 	i.SourceMachineImage = machineImage
 }
 
+func (i *Instance) getReservationAffinity() *compute.ReservationAffinity {
+	return i.ReservationAffinity
+}
+
+func (i *Instance) getNodeAffinities() []*compute.SchedulingNodeAffinity {
+	if i.Scheduling == nil {
+		return nil
+	}
+	return i.Scheduling.NodeAffinities
+}
+
+func (i *Instance) getConfidentialCompute() (bool, string) {
+	if i.ConfidentialInstanceConfig == nil {
+		return false, ""
+	}
+	return i.ConfidentialInstanceConfig.EnableConfidentialCompute, ""
+}
+
+// getIPs returns the internal and external IPs of the network interfaces of
+// the instance, as read back when it's created.
+func (i *Instance) getIPs() InstanceIPs {
+	var ips InstanceIPs
+	for _, n := range i.NetworkInterfaces {
+		if n.NetworkIP != "" {
+			ips.Internal = append(ips.Internal, n.NetworkIP)
+		}
+		for _, ac := range n.AccessConfigs {
+			if ac.NatIP != "" {
+				ips.External = append(ips.External, ac.NatIP)
+			}
+		}
+	}
+	return ips
+}
+
 func (i *Instance) register(name string, s *Step, ir *instanceRegistry, errs DError) {
 	// Register disk attachments.
 	for _, d := range i.Disks {
@@ -305,6 +350,9 @@ func (i *InstanceBeta) updateDisksAndNetworksBeforeCreate(w *Workflow) {
 		if subnetRes, ok := w.subnetworks.get(n.Subnetwork); ok {
 			n.Subnetwork = subnetRes.link
 		}
+		if ip, ok := w.addresses.ip(n.NetworkIP); ok {
+			n.NetworkIP = ip
+		}
 	}
 }
 
@@ -324,6 +372,52 @@ func (i *InstanceBeta) setSourceMachineImage(machineImage string) {
 	i.SourceMachineImage = machineImage
 }
 
+func (i *InstanceBeta) getReservationAffinity() *compute.ReservationAffinity {
+	if i.ReservationAffinity == nil {
+		return nil
+	}
+	return &compute.ReservationAffinity{
+		ConsumeReservationType: i.ReservationAffinity.ConsumeReservationType,
+		Key:                    i.ReservationAffinity.Key,
+		Values:                 i.ReservationAffinity.Values,
+	}
+}
+
+func (i *InstanceBeta) getNodeAffinities() []*compute.SchedulingNodeAffinity {
+	if i.Scheduling == nil {
+		return nil
+	}
+	var nas []*compute.SchedulingNodeAffinity
+	for _, na := range i.Scheduling.NodeAffinities {
+		nas = append(nas, &compute.SchedulingNodeAffinity{Key: na.Key, Operator: na.Operator, Values: na.Values})
+	}
+	return nas
+}
+
+func (i *InstanceBeta) getConfidentialCompute() (bool, string) {
+	if i.ConfidentialInstanceConfig == nil {
+		return false, ""
+	}
+	return i.ConfidentialInstanceConfig.EnableConfidentialCompute, i.ConfidentialInstanceConfig.ConfidentialInstanceType
+}
+
+// getIPs returns the internal and external IPs of the network interfaces of
+// the instance, as read back when it's created.
+func (i *InstanceBeta) getIPs() InstanceIPs {
+	var ips InstanceIPs
+	for _, n := range i.NetworkInterfaces {
+		if n.NetworkIP != "" {
+			ips.Internal = append(ips.Internal, n.NetworkIP)
+		}
+		for _, ac := range n.AccessConfigs {
+			if ac.NatIP != "" {
+				ips.External = append(ips.External, ac.NatIP)
+			}
+		}
+	}
+	return ips
+}
+
 func (i *InstanceBeta) register(name string, s *Step, ir *instanceRegistry, errs DError) {
 	// Register disk attachments.
 	for _, d := range i.Disks {
@@ -402,7 +496,7 @@ func (i *Instance) populateDisks(w *Workflow) DError {
 			parts := NamedSubexp(diskTypeURLRgx, p.DiskType)
 			if parts["disktype"] == "local-ssd" {
 				d.AutoDelete = true
-				d.Type = "SCRATCH"
+				d.Type = scratchDiskType
 				p.DiskName = ""
 			}
 		} else if d.DeviceName == "" {
@@ -449,7 +543,7 @@ func (i *InstanceBeta) populateDisks(w *Workflow) DError {
 			parts := NamedSubexp(diskTypeURLRgx, p.DiskType)
 			if parts["disktype"] == "local-ssd" {
 				d.AutoDelete = true
-				d.Type = "SCRATCH"
+				d.Type = scratchDiskType
 				p.DiskName = ""
 			}
 		} else if d.DeviceName == "" {
@@ -601,8 +695,14 @@ func (ib *InstanceBase) validate(ctx context.Context, ii InstanceInterface, s *S
 	errs = addErrs(errs, ib.validateSerialPortsToLog())
 	errs = addErrs(errs, ib.validateDisks(ii, s))
 	errs = addErrs(errs, ib.validateMachineType(ii, s.w))
+	errs = addErrs(errs, ib.validateLocalSSDCount(ii, s))
 	errs = addErrs(errs, ii.validateNetworks(s))
 	errs = addErrs(errs, ib.validateSourceMachineImage(ii, s))
+	errs = addErrs(errs, ib.validateReservationAffinity(ii, s))
+	errs = addErrs(errs, ib.validateNodeAffinities(ii, s))
+	errs = addErrs(errs, ib.validateScheduling(ii))
+	errs = addErrs(errs, ib.validateConfidentialCompute(ii, s))
+	errs = addErrs(errs, ib.validateOSLogin(ii, s))
 
 	// Register creation.
 	errs = addErrs(errs, s.w.instances.regCreate(ib.daisyName, &ib.Resource, ib.OverWrite, s))
@@ -629,16 +729,20 @@ type computeDisk struct {
 	sourceImage         string
 	autoDelete          bool
 	diskType            string
+	attachType          string
+	diskInterface       string
+	diskSizeGb          int64
 }
 
 func (i *Instance) getComputeDisks() []*computeDisk {
 	var computeDisks []*computeDisk
 	for _, d := range i.Disks {
-		computeDisk := computeDisk{mode: d.Mode, source: d.Source, hasInitializeParams: d.InitializeParams != nil, autoDelete: d.AutoDelete}
+		computeDisk := computeDisk{mode: d.Mode, source: d.Source, hasInitializeParams: d.InitializeParams != nil, autoDelete: d.AutoDelete, attachType: d.Type, diskInterface: d.Interface}
 		if computeDisk.hasInitializeParams {
 			computeDisk.diskName = d.InitializeParams.DiskName
 			computeDisk.sourceImage = d.InitializeParams.SourceImage
 			computeDisk.diskType = d.InitializeParams.DiskType
+			computeDisk.diskSizeGb = d.InitializeParams.DiskSizeGb
 		}
 		computeDisks = append(computeDisks, &computeDisk)
 	}
@@ -648,11 +752,12 @@ func (i *Instance) getComputeDisks() []*computeDisk {
 func (i *InstanceBeta) getComputeDisks() []*computeDisk {
 	var computeDisks []*computeDisk
 	for _, d := range i.Disks {
-		computeDisk := computeDisk{mode: d.Mode, source: d.Source, hasInitializeParams: d.InitializeParams != nil, autoDelete: d.AutoDelete}
+		computeDisk := computeDisk{mode: d.Mode, source: d.Source, hasInitializeParams: d.InitializeParams != nil, autoDelete: d.AutoDelete, attachType: d.Type, diskInterface: d.Interface}
 		if computeDisk.hasInitializeParams {
 			computeDisk.diskName = d.InitializeParams.DiskName
 			computeDisk.sourceImage = d.InitializeParams.SourceImage
 			computeDisk.diskType = d.InitializeParams.DiskType
+			computeDisk.diskSizeGb = d.InitializeParams.DiskSizeGb
 		}
 		computeDisks = append(computeDisks, &computeDisk)
 	}
@@ -683,6 +788,7 @@ func (ib *InstanceBase) validateDisks(ii InstanceInterface, s *Step) (errs DErro
 		if d.source != "" && d.hasInitializeParams {
 			errs = addErrs(errs, Errf("cannot create instance: disk.source and disk.initializeParams are mutually exclusive"))
 		}
+		errs = addErrs(errs, validateLocalSSD(d))
 		if d.hasInitializeParams {
 			errs = addErrs(errs, ib.validateDiskInitializeParams(d, ii, s))
 		} else {
@@ -692,6 +798,29 @@ func (ib *InstanceBase) validateDisks(ii InstanceInterface, s *Step) (errs DErro
 	return
 }
 
+// validateLocalSSD checks that local SSDs are attached as scratch disks with
+// a supported interface and size, and that only local SSDs are scratch disks.
+func validateLocalSSD(d *computeDisk) (errs DError) {
+	isLocalSSD := d.hasInitializeParams && NamedSubexp(diskTypeURLRgx, d.diskType)["disktype"] == "local-ssd"
+	if !isLocalSSD {
+		if d.attachType == scratchDiskType {
+			errs = addErrs(errs, Errf("cannot create instance: only local-ssd disks can be attached as %s disks", scratchDiskType))
+		}
+		return
+	}
+
+	if d.attachType != scratchDiskType {
+		errs = addErrs(errs, Errf("cannot create instance: local-ssd disks must be attached as %s disks, got Type: %q", scratchDiskType, d.attachType))
+	}
+	if d.diskInterface != "" && !strIn(d.diskInterface, localSSDInterfaces) {
+		errs = addErrs(errs, Errf("cannot create instance: bad local-ssd Interface: %q, must be one of %q", d.diskInterface, localSSDInterfaces))
+	}
+	if d.diskSizeGb < 0 || d.diskSizeGb%localSSDSizeGb != 0 {
+		errs = addErrs(errs, Errf("cannot create instance: bad local-ssd InitializeParams.DiskSizeGb: %d, must be a multiple of %dGB", d.diskSizeGb, localSSDSizeGb))
+	}
+	return
+}
+
 func (ib *InstanceBase) validateDiskInitializeParams(d *computeDisk, ii InstanceInterface, s *Step) (errs DError) {
 	parts := NamedSubexp(diskTypeURLRgx, d.diskType)
 	if parts["project"] != ib.Project {
@@ -754,6 +883,12 @@ func (ib *InstanceBase) validateMachineType(ii InstanceInterface, w *Workflow) (
 		errs = addErrs(errs, Errf("cannot create instance in zone %q with MachineType in zone %q: %q", ii.getZone(), result["zone"], ii.getMachineType()))
 	}
 
+	// GCE rejects bad custom machine types without saying why, check them
+	// before looking them up.
+	if err := validateCustomMachineType(result["machinetype"]); err != nil {
+		return addErrs(errs, Errf("cannot create instance: %v", err))
+	}
+
 	if exists, err := w.machineTypeExists(result["project"], result["zone"], result["machinetype"]); err != nil {
 		errs = addErrs(errs, Errf("cannot create instance, bad machineType lookup: %q, error: %v", result["machinetype"], err))
 	} else if !exists {
@@ -762,21 +897,142 @@ func (ib *InstanceBase) validateMachineType(ii InstanceInterface, w *Workflow) (
 	return
 }
 
+// validateOSLogin warns if the instance sets ssh-keys metadata while OS Login
+// is enabled for it, GCE ignores the keys in that case. It errors instead if
+// the top level workflow sets StrictOSLogin. The instance's enable-oslogin
+// metadata takes precedence over the project's.
+func (ib *InstanceBase) validateOSLogin(ii InstanceInterface, s *Step) DError {
+	md := ii.getMetadata()
+	if _, ok := md["ssh-keys"]; !ok {
+		return nil
+	}
+	var enabled bool
+	if v, ok := md[daisyCompute.OSLoginMetadataKey]; ok {
+		enabled = daisyCompute.OSLoginValueEnabled(v)
+	} else {
+		var err error
+		if enabled, err = s.w.projectOSLoginEnabled(ib.Project); err != nil {
+			s.w.LogStepInfo(s.name, s.typeName(), "WARNING: could not check whether OS Login is enabled in project %q: %v", ib.Project, err)
+			return nil
+		}
+	}
+	if !enabled {
+		return nil
+	}
+	msg := fmt.Sprintf("ssh-keys metadata of instance %q is ignored because OS Login is enabled, set %s to false on the instance or use OS Login to grant access", ib.daisyName, daisyCompute.OSLoginMetadataKey)
+	root := s.w
+	for root.parent != nil {
+		root = root.parent
+	}
+	if root.StrictOSLogin {
+		return Errf("cannot create instance %q: %s", ib.daisyName, msg)
+	}
+	s.w.LogStepInfo(s.name, s.typeName(), "WARNING: %s.", msg)
+	return nil
+}
+
+// projectOSLoginEnabled returns whether the metadata of project enables OS
+// Login. The result is cached on the top level workflow.
+func (w *Workflow) projectOSLoginEnabled(project string) (bool, error) {
+	root := w
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.projectOSLoginMx.Lock()
+	defer root.projectOSLoginMx.Unlock()
+	if enabled, ok := root.projectOSLogin[project]; ok {
+		return enabled, nil
+	}
+	p, err := w.ComputeClient.GetProject(project)
+	if err != nil {
+		return false, err
+	}
+	var enabled bool
+	if p != nil {
+		enabled, _ = daisyCompute.OSLoginSetting(p.CommonInstanceMetadata)
+	}
+	if root.projectOSLogin == nil {
+		root.projectOSLogin = map[string]bool{}
+	}
+	root.projectOSLogin[project] = enabled
+	return enabled, nil
+}
+
+// checkSubnetworkRegion checks that the subnetwork at link is in the region
+// of zone, GCE can only attach an instance to a subnetwork of its region.
+func checkSubnetworkRegion(name, link, zone string) DError {
+	m := NamedSubexp(subnetworkURLRegex, link)
+	if m == nil || zone == "" {
+		return nil
+	}
+	if r := getRegionFromZone(zone); m["region"] != r {
+		return Errf("cannot create instance in zone %q: subnetwork %q is in region %q, not %q", zone, name, m["region"], r)
+	}
+	return nil
+}
+
+// checkNetworkCustomMode errors if the network nr is a custom mode VPC
+// network, instances must set a subnetwork to use one.
+func checkNetworkCustomMode(s *Step, name string, nr *Resource) DError {
+	var custom, found bool
+	if nr.creator != nil && nr.creator.CreateNetworks != nil {
+		for _, n := range *nr.creator.CreateNetworks {
+			if n.daisyName == nr.daisyName {
+				custom = n.AutoCreateSubnetworks != nil && !*n.AutoCreateSubnetworks
+				found = true
+			}
+		}
+	}
+	if !found {
+		m := NamedSubexp(networkURLRegex, nr.link)
+		if m == nil {
+			return nil
+		}
+		n, err := s.w.getNetwork(m["project"], m["network"])
+		if err != nil {
+			s.w.LogStepInfo(s.name, "CreateInstances", "WARNING: could not check the mode of network %q: %v", name, err)
+			return nil
+		}
+		if n == nil {
+			return nil
+		}
+		// Legacy networks have an IPv4Range and no subnetworks.
+		custom = !n.AutoCreateSubnetworks && n.IPv4Range == ""
+	}
+	if custom {
+		return Errf("cannot use network %q without a subnetwork: it's a custom mode VPC network, set the Subnetwork of the network interface", name)
+	}
+	return nil
+}
+
 func (i *Instance) validateNetworks(s *Step) (errs DError) {
 	for _, n := range i.NetworkInterfaces {
 		if n.Subnetwork != "" {
-			_, err := s.w.subnetworks.regUse(n.Subnetwork, s)
+			sr, err := s.w.subnetworks.regUse(n.Subnetwork, s)
 			if err != nil {
 				errs = addErrs(errs, err)
+			} else {
+				errs = addErrs(errs, checkSubnetworkRegion(n.Subnetwork, sr.link, i.Zone))
+			}
+		}
+
+		// A NetworkIP which isn't an IP is the name of an address reserved
+		// by the workflow.
+		if n.NetworkIP != "" && net.ParseIP(n.NetworkIP) == nil {
+			if _, err := s.w.addresses.regUse(n.NetworkIP, s); err != nil {
+				errs = addErrs(errs, err)
 			}
 		}
 
 		if n.Network != "" {
-			_, err := s.w.networks.regUse(n.Network, s)
+			nr, err := s.w.networks.regUse(n.Network, s)
 			if err != nil {
 				errs = addErrs(errs, err)
 				continue
 			}
+			if n.Subnetwork == "" {
+				errs = addErrs(errs, checkNetworkCustomMode(s, n.Network, nr))
+			}
 		}
 	}
 	return
@@ -785,18 +1041,31 @@ func (i *Instance) validateNetworks(s *Step) (errs DError) {
 func (i *InstanceBeta) validateNetworks(s *Step) (errs DError) {
 	for _, n := range i.NetworkInterfaces {
 		if n.Subnetwork != "" {
-			_, err := s.w.subnetworks.regUse(n.Subnetwork, s)
+			sr, err := s.w.subnetworks.regUse(n.Subnetwork, s)
 			if err != nil {
 				errs = addErrs(errs, err)
+			} else {
+				errs = addErrs(errs, checkSubnetworkRegion(n.Subnetwork, sr.link, i.Zone))
+			}
+		}
+
+		// A NetworkIP which isn't an IP is the name of an address reserved
+		// by the workflow.
+		if n.NetworkIP != "" && net.ParseIP(n.NetworkIP) == nil {
+			if _, err := s.w.addresses.regUse(n.NetworkIP, s); err != nil {
+				errs = addErrs(errs, err)
 			}
 		}
 
 		if n.Network != "" {
-			_, err := s.w.networks.regUse(n.Network, s)
+			nr, err := s.w.networks.regUse(n.Network, s)
 			if err != nil {
 				errs = addErrs(errs, err)
 				continue
 			}
+			if n.Subnetwork == "" {
+				errs = addErrs(errs, checkNetworkCustomMode(s, n.Network, nr))
+			}
 		}
 	}
 	return
@@ -830,16 +1099,13 @@ func (ir *instanceRegistry) deleteFn(res *Resource) DError {
 	}
 	// Proceed to instance deletion
 	err := ir.w.ComputeClient.DeleteInstance(m["project"], m["zone"], m["instance"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete instance", err)
-	}
-	return newErr("failed to delete instance", err)
+	return deleteErr("instance", err)
 }
 
 func (ir *instanceRegistry) startFn(res *Resource) DError {
 	m := NamedSubexp(instanceURLRgx, res.link)
 	err := ir.w.ComputeClient.StartInstance(m["project"], m["zone"], m["instance"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+	if daisyCompute.IsNotFound(err) {
 		return typedErr(resourceDNEError, "failed to start instance", err)
 	}
 	return newErr("failed to start instance", err)
@@ -848,7 +1114,7 @@ func (ir *instanceRegistry) startFn(res *Resource) DError {
 func (ir *instanceRegistry) stopFn(res *Resource) DError {
 	m := NamedSubexp(instanceURLRgx, res.link)
 	err := ir.w.ComputeClient.StopInstance(m["project"], m["zone"], m["instance"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+	if daisyCompute.IsNotFound(err) {
 		return typedErr(resourceDNEError, "failed to stop instance", err)
 	}
 	return newErr("failed to stop instance", err)
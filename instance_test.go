@@ -22,8 +22,10 @@ import (
 	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 	"testing"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	computeBeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
 )
@@ -535,6 +537,7 @@ func TestInstanceValidateDisks(t *testing.T) {
 		testDisk: {link: fmt.Sprintf("projects/%s/zones/%s/disks/%s", w.Project, w.Zone, testDisk)},
 	}
 	m := defaultDiskMode
+	localSSD := fmt.Sprintf("projects/%s/zones/%s/diskTypes/local-ssd", w.Project, w.Zone)
 
 	tests := []struct {
 		desc      string
@@ -549,6 +552,9 @@ func TestInstanceValidateDisks(t *testing.T) {
 		{desc: "error no disks case", i: &Instance{Instance: compute.Instance{}}, shouldErr: true},
 		{desc: "error disk mode case", i: &Instance{Instance: compute.Instance{Disks: []*compute.AttachedDisk{{Source: testDisk, Mode: "bad mode!"}}, Zone: testZone}}, shouldErr: true},
 		{desc: "error both disks and source machine image provided", iBeta: &InstanceBeta{Instance: computeBeta.Instance{Disks: []*computeBeta.AttachedDisk{{Source: testDisk}}, Zone: testZone, SourceMachineImage: "source-machine-image"}}, shouldErr: true},
+		{desc: "success local ssd case", i: &Instance{Instance: compute.Instance{Disks: []*compute.AttachedDisk{{Source: testDisk, Mode: m}, {Mode: m, Type: scratchDiskType, Interface: "NVME", InitializeParams: &compute.AttachedDiskInitializeParams{DiskType: localSSD}}}}}, shouldErr: false},
+		{desc: "error local ssd interface case", i: &Instance{Instance: compute.Instance{Disks: []*compute.AttachedDisk{{Source: testDisk, Mode: m}, {Mode: m, Type: scratchDiskType, Interface: "IDE", InitializeParams: &compute.AttachedDiskInitializeParams{DiskType: localSSD}}}}}, shouldErr: true},
+		{desc: "error beta local ssd size case", iBeta: &InstanceBeta{Instance: computeBeta.Instance{Disks: []*computeBeta.AttachedDisk{{Source: testDisk, Mode: m}, {Mode: m, Type: scratchDiskType, InitializeParams: &computeBeta.AttachedDiskInitializeParams{DiskType: localSSD, DiskSizeGb: 100}}}}}, shouldErr: true},
 	}
 
 	for _, tt := range tests {
@@ -578,6 +584,36 @@ func TestInstanceValidateDisks(t *testing.T) {
 	}
 }
 
+func TestValidateLocalSSD(t *testing.T) {
+	localSSD := fmt.Sprintf("projects/%s/zones/%s/diskTypes/local-ssd", testProject, testZone)
+	pdStandard := fmt.Sprintf("projects/%s/zones/%s/diskTypes/pd-standard", testProject, testZone)
+
+	tests := []struct {
+		desc      string
+		d         *computeDisk
+		shouldErr bool
+	}{
+		{"default local ssd case", &computeDisk{hasInitializeParams: true, diskType: localSSD, attachType: scratchDiskType}, false},
+		{"nvme case", &computeDisk{hasInitializeParams: true, diskType: localSSD, attachType: scratchDiskType, diskInterface: "NVME"}, false},
+		{"scsi 375GB case", &computeDisk{hasInitializeParams: true, diskType: localSSD, attachType: scratchDiskType, diskInterface: "SCSI", diskSizeGb: 375}, false},
+		{"multiple of 375GB case", &computeDisk{hasInitializeParams: true, diskType: localSSD, attachType: scratchDiskType, diskSizeGb: 3000}, false},
+		{"persistent disk case", &computeDisk{hasInitializeParams: true, diskType: pdStandard, attachType: "PERSISTENT", diskSizeGb: 100}, false},
+		{"persistent type case", &computeDisk{hasInitializeParams: true, diskType: localSSD, attachType: "PERSISTENT"}, true},
+		{"bad interface case", &computeDisk{hasInitializeParams: true, diskType: localSSD, attachType: scratchDiskType, diskInterface: "IDE"}, true},
+		{"bad size case", &computeDisk{hasInitializeParams: true, diskType: localSSD, attachType: scratchDiskType, diskSizeGb: 100}, true},
+		{"negative size case", &computeDisk{hasInitializeParams: true, diskType: localSSD, attachType: scratchDiskType, diskSizeGb: -375}, true},
+		{"scratch persistent disk case", &computeDisk{hasInitializeParams: true, diskType: pdStandard, attachType: scratchDiskType}, true},
+		{"scratch source case", &computeDisk{source: testDisk, attachType: scratchDiskType}, true},
+	}
+	for _, tt := range tests {
+		if err := validateLocalSSD(tt.d); tt.shouldErr && err == nil {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if !tt.shouldErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
 func TestInstanceValidateDiskSource(t *testing.T) {
 	// Test:
 	// - good case
@@ -672,7 +708,7 @@ func TestInstanceValidateDiskInitializeParams(t *testing.T) {
 	// Check good disks were created.
 	wantCreator := w.Steps["good case"]
 	wantLink := fmt.Sprintf("projects/%s/zones/%s/disks/foo", testProject, testZone)
-	wantFoo := &Resource{RealName: "foo", link: wantLink, creator: wantCreator}
+	wantFoo := &Resource{RealName: "foo", link: wantLink, creator: wantCreator, typeName: "disk"}
 	if gotFoo, ok := w.disks.m["foo"]; !ok || !reflect.DeepEqual(gotFoo, wantFoo) {
 		t.Errorf("foo resource not added as expected: got: %+v, want: %+v", gotFoo, wantFoo)
 	}
@@ -712,6 +748,7 @@ func TestInstanceValidateMachineType(t *testing.T) {
 		{"good case", fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", testProject, testZone, testMachineType), false},
 		{"custom case", fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", testProject, testZone, "custom"), false},
 		{"bad machine type case", fmt.Sprintf("projects/%s/zones/%s/machineTypes/bad-mt", testProject, testZone), true},
+		{"bad custom machine type case", fmt.Sprintf("projects/%s/zones/%s/machineTypes/n2-custom-2-20480", testProject, testZone), true},
 		{"bad project case", fmt.Sprintf("projects/p2/zones/%s/machineTypes/%s", testZone, testMachineType), true},
 		{"bad zone case", fmt.Sprintf("projects/%s/zones/z2/machineTypes/%s", testProject, testMachineType), true},
 		{"bad zone case 2", "zones/z2/machineTypes/mt", true},
@@ -734,12 +771,90 @@ func TestInstanceValidateMachineType(t *testing.T) {
 	}
 }
 
+func TestInstanceValidateOSLogin(t *testing.T) {
+	enabled := "true"
+	tests := []struct {
+		desc        string
+		md          map[string]string
+		projectMD   *compute.Metadata
+		projErr     error
+		wantWarning bool
+	}{
+		{"no ssh-keys case", map[string]string{"enable-oslogin": "true"}, nil, nil, false},
+		{"ssh-keys case", map[string]string{"ssh-keys": "user:key"}, nil, nil, false},
+		{"instance oslogin case", map[string]string{"ssh-keys": "user:key", "enable-oslogin": "TRUE"}, nil, nil, true},
+		{"project oslogin case", map[string]string{"ssh-keys": "user:key"}, &compute.Metadata{Items: []*compute.MetadataItems{{Key: "enable-oslogin", Value: &enabled}}}, nil, true},
+		{"instance overrides project case", map[string]string{"ssh-keys": "user:key", "enable-oslogin": "false"}, &compute.Metadata{Items: []*compute.MetadataItems{{Key: "enable-oslogin", Value: &enabled}}}, nil, false},
+		{"project error case", map[string]string{"ssh-keys": "user:key"}, nil, errors.New("error"), false},
+	}
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			w := testWorkflow()
+			w.StrictOSLogin = strict
+			s, _ := w.NewStep("s")
+			var gets int
+			w.ComputeClient.(*daisyCompute.TestClient).GetProjectFn = func(project string) (*compute.Project, error) {
+				gets++
+				return &compute.Project{CommonInstanceMetadata: tt.projectMD}, tt.projErr
+			}
+
+			s.CreateInstances = &CreateInstances{}
+
+			ci := &Instance{Metadata: tt.md, InstanceBase: InstanceBase{Resource: Resource{Project: testProject}}}
+			err := (&ci.InstanceBase).validateOSLogin(ci, s)
+			if shouldErr := strict && tt.wantWarning; shouldErr && err == nil {
+				t.Errorf("%s, strict %t: should have returned an error", tt.desc, strict)
+			} else if !shouldErr && err != nil {
+				t.Errorf("%s, strict %t: unexpected error: %v", tt.desc, strict, err)
+			}
+			var warned bool
+			for _, e := range w.Logger.(*MockLogger).getEntries() {
+				// Warnings name the step.
+				if e.StepName != "s" || e.StepType != "CreateInstances" {
+					t.Errorf("%s, strict %t: log entry not attributed to the step, got step %q of type %q", tt.desc, strict, e.StepName, e.StepType)
+				}
+				warned = warned || strings.Contains(e.Message, "is ignored because OS Login is enabled")
+			}
+			if want := !strict && tt.wantWarning; warned != want {
+				t.Errorf("%s, strict %t: warned: %t, want: %t", tt.desc, strict, warned, want)
+			}
+
+			// The project's setting is only read once.
+			gets = 0
+			(&ci.InstanceBase).validateOSLogin(ci, s)
+			if gets > 0 && tt.projErr == nil {
+				t.Errorf("%s, strict %t: project read again instead of using the cache", tt.desc, strict)
+			}
+		}
+	}
+}
+
 func TestInstanceValidateNetworks(t *testing.T) {
 	w := testWorkflow()
 	acs := []*compute.AccessConfig{{Type: "ONE_TO_ONE_NAT"}}
 	acsBeta := []*computeBeta.AccessConfig{{Type: "ONE_TO_ONE_NAT"}}
-	w.networks.m = map[string]*Resource{testNetwork: {link: fmt.Sprintf("projects/%s/global/networks/%s", testProject, testNetwork)}}
-	w.subnetworks.m = map[string]*Resource{testSubnetwork: {link: fmt.Sprintf("projects/%s/global/subnetworks/%s", testProject, testSubnetwork)}}
+	cn, _ := w.NewStep("create-networks")
+	autoCreate := false
+	cn.CreateNetworks = &CreateNetworks{{AutoCreateSubnetworks: &autoCreate, Resource: Resource{daisyName: "custom-net"}}}
+	w.networks.m = map[string]*Resource{
+		testNetwork:       {link: fmt.Sprintf("projects/%s/global/networks/%s", testProject, testNetwork)},
+		"custom-net":      {daisyName: "custom-net", link: fmt.Sprintf("projects/%s/global/networks/custom-net-abcdef", testProject), creator: cn},
+		"existing-custom": {link: fmt.Sprintf("projects/%s/global/networks/existing-custom", testProject)},
+		"legacy-net":      {link: fmt.Sprintf("projects/%s/global/networks/legacy-net", testProject)},
+	}
+	var lists int
+	w.ComputeClient.(*daisyCompute.TestClient).ListNetworksFn = func(_ string, _ ...daisyCompute.ListCallOption) ([]*compute.Network, error) {
+		lists++
+		return []*compute.Network{
+			{Name: testNetwork, AutoCreateSubnetworks: true},
+			{Name: "existing-custom"},
+			{Name: "legacy-net", IPv4Range: "10.0.0.0/16"},
+		}, nil
+	}
+	w.subnetworks.m = map[string]*Resource{
+		testSubnetwork:    {link: fmt.Sprintf("projects/%s/global/subnetworks/%s", testProject, testSubnetwork)},
+		"regional-subnet": {link: fmt.Sprintf("projects/%s/regions/%s/subnetworks/regional-subnet", testProject, testRegion)},
+	}
 
 	r := Resource{Project: testProject}
 	tests := []struct {
@@ -766,6 +881,42 @@ func TestInstanceValidateNetworks(t *testing.T) {
 			&InstanceBeta{InstanceBase: InstanceBase{Resource: r}, Instance: computeBeta.Instance{NetworkInterfaces: []*computeBeta.NetworkInterface{{Network: fmt.Sprintf("projects/%s/global/networks/%s", testProject, testNetwork), AccessConfigs: acsBeta}}}},
 			false,
 		},
+		{
+			"good case subnetwork in zone region",
+			&Instance{InstanceBase: InstanceBase{Resource: r}, Instance: compute.Instance{Zone: testZone, NetworkInterfaces: []*compute.NetworkInterface{{Subnetwork: "regional-subnet", AccessConfigs: acs}}}},
+			&InstanceBeta{InstanceBase: InstanceBase{Resource: r}, Instance: computeBeta.Instance{Zone: testZone, NetworkInterfaces: []*computeBeta.NetworkInterface{{Subnetwork: "regional-subnet", AccessConfigs: acsBeta}}}},
+			false,
+		},
+		{
+			"bad case subnetwork in other region",
+			&Instance{InstanceBase: InstanceBase{Resource: r}, Instance: compute.Instance{Zone: "other-region-zone", NetworkInterfaces: []*compute.NetworkInterface{{Subnetwork: "regional-subnet", AccessConfigs: acs}}}},
+			&InstanceBeta{InstanceBase: InstanceBase{Resource: r}, Instance: computeBeta.Instance{Zone: "other-region-zone", NetworkInterfaces: []*computeBeta.NetworkInterface{{Subnetwork: "regional-subnet", AccessConfigs: acsBeta}}}},
+			true,
+		},
+		{
+			"good case legacy network",
+			&Instance{InstanceBase: InstanceBase{Resource: r}, Instance: compute.Instance{NetworkInterfaces: []*compute.NetworkInterface{{Network: "legacy-net", AccessConfigs: acs}}}},
+			&InstanceBeta{InstanceBase: InstanceBase{Resource: r}, Instance: computeBeta.Instance{NetworkInterfaces: []*computeBeta.NetworkInterface{{Network: "legacy-net", AccessConfigs: acsBeta}}}},
+			false,
+		},
+		{
+			"good case custom network with subnetwork",
+			&Instance{InstanceBase: InstanceBase{Resource: r}, Instance: compute.Instance{Zone: testZone, NetworkInterfaces: []*compute.NetworkInterface{{Network: "custom-net", Subnetwork: "regional-subnet", AccessConfigs: acs}}}},
+			&InstanceBeta{InstanceBase: InstanceBase{Resource: r}, Instance: computeBeta.Instance{Zone: testZone, NetworkInterfaces: []*computeBeta.NetworkInterface{{Network: "custom-net", Subnetwork: "regional-subnet", AccessConfigs: acsBeta}}}},
+			false,
+		},
+		{
+			"bad case created custom network without subnetwork",
+			&Instance{InstanceBase: InstanceBase{Resource: r}, Instance: compute.Instance{NetworkInterfaces: []*compute.NetworkInterface{{Network: "custom-net", AccessConfigs: acs}}}},
+			&InstanceBeta{InstanceBase: InstanceBase{Resource: r}, Instance: computeBeta.Instance{NetworkInterfaces: []*computeBeta.NetworkInterface{{Network: "custom-net", AccessConfigs: acsBeta}}}},
+			true,
+		},
+		{
+			"bad case existing custom network without subnetwork",
+			&Instance{InstanceBase: InstanceBase{Resource: r}, Instance: compute.Instance{NetworkInterfaces: []*compute.NetworkInterface{{Network: "existing-custom", AccessConfigs: acs}}}},
+			&InstanceBeta{InstanceBase: InstanceBase{Resource: r}, Instance: computeBeta.Instance{NetworkInterfaces: []*computeBeta.NetworkInterface{{Network: "existing-custom", AccessConfigs: acsBeta}}}},
+			true,
+		},
 		{
 			"bad name case",
 			&Instance{InstanceBase: InstanceBase{Resource: r}, Instance: compute.Instance{NetworkInterfaces: []*compute.NetworkInterface{{Network: fmt.Sprintf("projects/%s/global/networks/bad!", testProject), AccessConfigs: acs}}}},
@@ -789,8 +940,13 @@ func TestInstanceValidateNetworks(t *testing.T) {
 	}
 	for _, tt := range tests {
 		s, _ := w.NewStep(tt.desc)
+		w.AddDependency(s, cn)
 		s.CreateInstances = &CreateInstances{Instances: []*Instance{tt.ci}, InstancesBeta: []*InstanceBeta{tt.ciBeta}}
 		assertTest(tt.shouldErr, tt.ci.validateNetworks(s), tt.desc)
 		assertTest(tt.shouldErr, tt.ciBeta.validateNetworks(s), tt.desc+" beta")
 	}
+	// Existing networks are looked up in the workflow's network cache.
+	if lists != 1 {
+		t.Errorf("networks listed %d times, want once", lists)
+	}
 }
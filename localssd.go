@@ -0,0 +1,128 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// localSSDCounts are the numbers of local SSDs a machine type with at least
+// minCPUs vCPUs can attach.
+type localSSDCounts struct {
+	minCPUs int64
+	counts  []int
+}
+
+// localSSDMachineFamilies are the machine type families whose local SSD
+// counts are validated locally, by ascending vCPU count. Their local SSDs
+// are 375GB each. Families without counts don't support local SSDs, families
+// not listed here aren't checked.
+var localSSDMachineFamilies = map[string][]localSSDCounts{
+	"n1": {
+		{1, []int{1, 2, 3, 4, 5, 6, 7, 8, 16, 24}},
+	},
+	"n2": {
+		{1, []int{1, 2, 4, 8, 16, 24}},
+		{12, []int{2, 4, 8, 16, 24}},
+		{22, []int{4, 8, 16, 24}},
+		{42, []int{8, 16, 24}},
+		{82, []int{16, 24}},
+	},
+	"n2d": {
+		{1, []int{1, 2, 4, 8, 16, 24}},
+		{32, []int{2, 4, 8, 16, 24}},
+		{64, []int{4, 8, 16, 24}},
+		{96, []int{8, 16, 24}},
+	},
+	"c2": {
+		{1, []int{1, 2, 4, 8}},
+		{16, []int{2, 4, 8}},
+		{30, []int{4, 8}},
+		{60, []int{8}},
+	},
+	"c2d": {
+		{1, []int{1, 2, 4, 8}},
+		{32, []int{2, 4, 8}},
+		{56, []int{4, 8}},
+		{112, []int{8}},
+	},
+	"e2": nil,
+}
+
+// validLocalSSDCounts returns the numbers of local SSDs a machine type of
+// family with cpus vCPUs can attach.
+func validLocalSSDCounts(family string, cpus int64) []int {
+	var counts []int
+	for _, c := range localSSDMachineFamilies[family] {
+		if cpus >= c.minCPUs {
+			counts = append(counts[:0], c.counts...)
+		}
+	}
+	return counts
+}
+
+// validateLocalSSDCount checks the number and size of the local SSDs of an
+// instance against what its machine type family allows, GCE only rejects
+// them when the instance is inserted. The vCPU count of the machine type is
+// looked up with GetMachineTypeSpec.
+func (ib *InstanceBase) validateLocalSSDCount(ii InstanceInterface, s *Step) DError {
+	var n int
+	var sizes []int64
+	for _, d := range ii.getComputeDisks() {
+		if d.hasInitializeParams && NamedSubexp(diskTypeURLRgx, d.diskType)["disktype"] == "local-ssd" {
+			n++
+			sizes = append(sizes, d.diskSizeGb)
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	mt := NamedSubexp(machineTypeURLRegex, ii.getMachineType())
+	if mt == nil {
+		return nil
+	}
+	family := strings.SplitN(mt["machinetype"], "-", 2)[0]
+	if family == "custom" {
+		family = "n1"
+	}
+	if _, ok := localSSDMachineFamilies[family]; !ok {
+		return nil
+	}
+	pre := fmt.Sprintf("cannot create instance %q", ib.daisyName)
+
+	var errs DError
+	for _, size := range sizes {
+		if size != 0 && size != localSSDSizeGb {
+			errs = addErrs(errs, Errf("%s: local SSDs of machine type %q are %dGB each, got InitializeParams.DiskSizeGb: %d, attach more local SSDs for more space", pre, mt["machinetype"], localSSDSizeGb, size))
+			break
+		}
+	}
+
+	cpus, _, err := s.w.ComputeClient.GetMachineTypeSpec(mt["project"], mt["zone"], mt["machinetype"])
+	if err != nil {
+		return addErrs(errs, typedErr(apiError, fmt.Sprintf("%s: failed to get machine type %q", pre, mt["machinetype"]), err))
+	}
+	counts := validLocalSSDCounts(family, cpus)
+	if len(counts) == 0 {
+		return addErrs(errs, Errf("%s: machine type %q doesn't support local SSDs", pre, mt["machinetype"]))
+	}
+	for _, c := range counts {
+		if c == n {
+			return errs
+		}
+	}
+	return addErrs(errs, Errf("%s: machine type %q with %d vCPUs can't attach %d local SSDs, use one of %v local SSDs", pre, mt["machinetype"], cpus, n, counts))
+}
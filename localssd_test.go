@@ -0,0 +1,118 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestValidateLocalSSDCount(t *testing.T) {
+	localSSDs := func(n int, size int64) []*compute.AttachedDisk {
+		disks := []*compute.AttachedDisk{{Source: testDisk}}
+		for i := 0; i < n; i++ {
+			disks = append(disks, &compute.AttachedDisk{Type: scratchDiskType, InitializeParams: &compute.AttachedDiskInitializeParams{
+				DiskType:   fmt.Sprintf("projects/%s/zones/%s/diskTypes/local-ssd", testProject, testZone),
+				DiskSizeGb: size,
+			}})
+		}
+		return disks
+	}
+
+	tests := []struct {
+		desc        string
+		machineType string
+		disks       []*compute.AttachedDisk
+		wantLookup  bool
+		shouldErr   bool
+	}{
+		{"no local ssd case", "n2-standard-2", localSSDs(0, 0), false, false},
+		{"n1 case", "n1-standard-2", localSSDs(3, 0), true, false},
+		{"n1 375GB case", "n1-standard-2", localSSDs(8, 375), true, false},
+		{"n2 small case", "n2-standard-2", localSSDs(1, 0), true, false},
+		{"n2 large case", "n2-standard-96", localSSDs(24, 0), true, false},
+		{"c2 case", "c2-standard-60", localSSDs(8, 375), true, false},
+		{"unknown family case", "z3-highmem-88", localSSDs(3, 3000), false, false},
+		{"unknown machine type case", "test-machine-type", localSSDs(3, 0), false, false},
+		{"n1 bad count case", "n1-standard-2", localSSDs(9, 0), true, true},
+		{"n2 too few for vCPUs case", "n2-standard-96", localSSDs(8, 0), true, true},
+		{"n2d bad count case", "n2d-standard-2", localSSDs(3, 0), true, true},
+		{"c2 too many case", "c2-standard-4", localSSDs(16, 0), true, true},
+		{"bad size case", "n2-standard-2", localSSDs(1, 3000), true, true},
+		{"e2 case", "e2-standard-2", localSSDs(1, 0), true, true},
+		{"lookup error case", "custom-2-4096", localSSDs(1, 0), true, true},
+	}
+	for _, tt := range tests {
+		// Machine type specs are cached by the client, use a new one each time.
+		var lookups int
+		w := testWorkflow()
+		s := &Step{w: w}
+		w.ComputeClient.(*daisyCompute.TestClient).GetMachineTypeFn = func(_, _, mt string) (*compute.MachineType, error) {
+			lookups++
+			// The vCPU count is the last part of the test machine types, e.g.
+			// "n2-standard-16".
+			parts := strings.Split(mt, "-")
+			cpus, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+			if err != nil || strings.HasPrefix(mt, "custom") {
+				return nil, errors.New("bad machinetype")
+			}
+			return &compute.MachineType{Name: mt, GuestCpus: cpus}, nil
+		}
+		i := &Instance{Instance: compute.Instance{
+			MachineType: fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", testProject, testZone, tt.machineType),
+			Disks:       tt.disks,
+		}}
+		i.Project = testProject
+		i.Zone = testZone
+
+		err := i.validateLocalSSDCount(i, s)
+		if tt.shouldErr && err == nil {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if !tt.shouldErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if gotLookup := lookups > 0; gotLookup != tt.wantLookup {
+			t.Errorf("%s: machine type looked up: got %t, want %t", tt.desc, gotLookup, tt.wantLookup)
+		}
+	}
+}
+
+func TestValidLocalSSDCounts(t *testing.T) {
+	tests := []struct {
+		family string
+		cpus   int64
+		want   []int
+	}{
+		{"n2", 2, []int{1, 2, 4, 8, 16, 24}},
+		{"n2", 12, []int{2, 4, 8, 16, 24}},
+		{"n2", 128, []int{16, 24}},
+		{"n2d", 48, []int{2, 4, 8, 16, 24}},
+		{"c2d", 112, []int{8}},
+		{"e2", 2, nil},
+		{"z3", 88, nil},
+	}
+	for _, tt := range tests {
+		got := validLocalSSDCounts(tt.family, tt.cpus)
+		if diffRes := diff(got, tt.want, 0); diffRes != "" {
+			t.Errorf("%s with %d vCPUs: local SSD counts do not match expectation: (-got +want)\n%s", tt.family, tt.cpus, diffRes)
+		}
+	}
+}
@@ -120,6 +120,7 @@ func (w *Workflow) LogWorkflowInfo(format string, a ...interface{}) {
 }
 
 func (w *Workflow) logEntry(e *LogEntry) {
+	e.WorkflowID = w.id
 	//  Execute all log process hooks
 	rw := w
 	for rw != nil {
@@ -130,6 +131,7 @@ func (w *Workflow) logEntry(e *LogEntry) {
 	}
 
 	w.Logger.WriteLogEntry(e)
+	w.sendWarningEvent(e)
 }
 
 // AppendSerialPortLogs collects a segment of serial port logs for an instance.
@@ -159,6 +161,7 @@ func (l *daisyLog) WriteSerialPortLogsToCloudLogging(w *Workflow, instance strin
 		entry := &LogEntry{
 			LocalTimestamp: time.Now(),
 			WorkflowName:   getAbsoluteName(w),
+			WorkflowID:     w.id,
 			Message:        fmt.Sprintf("Serial port output for instance %q", instance),
 			SerialPort1:    string(data),
 			Type:           "Daisy",
@@ -209,11 +212,16 @@ func (l *daisyLog) Flush() {
 type LogEntry struct {
 	LocalTimestamp time.Time `json:"localTimestamp"`
 	WorkflowName   string    `json:"workflow"`
+	WorkflowID     string    `json:"workflowId,omitempty"`
 	StepName       string    `json:"stepName,omitempty"`
 	StepType       string    `json:"stepType,omitempty"`
 	SerialPort1    string    `json:"serialPort1,omitempty"`
 	Message        string    `json:"message"`
 	Type           string    `json:"type"`
+	// workflowPrefix keeps the workflow name as the text prefix of an
+	// entry with a StepName, as the workflow's own logs about its steps
+	// always had.
+	workflowPrefix bool
 }
 
 func (l *daisyLog) WriteLogEntry(e *LogEntry) {
@@ -295,7 +303,7 @@ func getAbsoluteName(w *Workflow) string {
 
 func (e *LogEntry) String() string {
 	var prefix string
-	if e.StepName != "" {
+	if e.StepName != "" && !e.workflowPrefix {
 		prefix = fmt.Sprintf("%s.%s", e.WorkflowName, e.StepName)
 	} else {
 		prefix = e.WorkflowName
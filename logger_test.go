@@ -17,12 +17,15 @@ package daisy
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
 	"testing"
 
 	"cloud.google.com/go/logging"
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -192,3 +195,74 @@ func assertLogOutput(t *testing.T, actualLogs []string, expectedLogs []string) {
 		assert.Contains(t, actualLogs, log)
 	}
 }
+
+func TestStepLogEntriesCarryContext(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, err := w.NewStep("my-step")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.testType = &mockStep{}
+	if err := s.populate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.validate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := w.Logger.(*MockLogger).getEntries()
+	if len(entries) == 0 {
+		t.Fatal("no log entries written")
+	}
+	for _, e := range entries {
+		if e.StepName != "my-step" || e.WorkflowID != w.id {
+			t.Errorf("log entry not attributed to the step, got step %q and workflow id %q: %q", e.StepName, e.WorkflowID, e.Message)
+		}
+		// The text format of the workflow's logs about its steps is unchanged.
+		if want := "[" + w.Name + "]: "; !strings.HasPrefix(e.String(), want) {
+			t.Errorf("log entry %q doesn't start with %q", e, want)
+		}
+	}
+}
+
+func TestOperationLogEntriesCarryStep(t *testing.T) {
+	w := testWorkflow()
+	creator := &Step{name: "create", w: w}
+	deleter := &Step{name: "delete", w: w}
+	w.disks.m = map[string]*Resource{
+		"d": {link: fmt.Sprintf("projects/%s/zones/%s/disks/d", testProject, testZone), creator: creator, deleter: deleter},
+	}
+	link := fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/zones/%s/disks/d", testProject, testZone)
+
+	tests := []struct {
+		op       daisyCompute.OperationProgress
+		wantStep string
+	}{
+		{daisyCompute.OperationProgress{Name: "op1", TargetLink: link, OperationType: "insert", Progress: 100}, "create"},
+		{daisyCompute.OperationProgress{Name: "op2", TargetLink: link, OperationType: "delete", Progress: 100}, "delete"},
+		{daisyCompute.OperationProgress{Name: "op3", TargetLink: link, OperationType: "setLabels", Progress: 100}, ""},
+		{daisyCompute.OperationProgress{Name: "op4", TargetLink: link + "-other", OperationType: "insert", Progress: 100}, ""},
+	}
+	for _, tt := range tests {
+		w.Logger = &MockLogger{}
+		w.operationProgress(tt.op)
+		entries := w.Logger.(*MockLogger).getEntries()
+		if len(entries) != 1 {
+			t.Fatalf("%s: want 1 log entry, got %d", tt.op.Name, len(entries))
+		}
+		if entries[0].StepName != tt.wantStep {
+			t.Errorf("%s: log entry attributed to step %q, want %q", tt.op.Name, entries[0].StepName, tt.wantStep)
+		}
+	}
+
+	// Operations in progress aren't logged.
+	w.Logger = &MockLogger{}
+	w.operationProgress(daisyCompute.OperationProgress{Name: "op", TargetLink: link, OperationType: "insert", Progress: 50})
+	if entries := w.Logger.(*MockLogger).getEntries(); len(entries) != 0 {
+		t.Errorf("want no log entries for an operation in progress, got %d", len(entries))
+	}
+}
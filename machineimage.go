@@ -18,12 +18,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"regexp"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/googleapi"
 )
 
 var (
@@ -84,6 +82,17 @@ func (mi *MachineImage) validate(ctx context.Context, s *Step) DError {
 		errs = addErrs(errs, newErr("failed to get source instance", err))
 	}
 
+	// Storage location checking, the API accepts a single region or
+	// multi-region.
+	if len(mi.StorageLocations) > 1 {
+		errs = addErrs(errs, Errf("%s: at most one storage location can be set, got %q", pre, mi.StorageLocations))
+	}
+	for _, l := range mi.StorageLocations {
+		if l == "" {
+			errs = addErrs(errs, Errf("%s: empty location in StorageLocations", pre))
+		}
+	}
+
 	// Register machine image creation.
 	errs = addErrs(errs, s.w.machineImages.regCreate(mi.daisyName, &mi.Resource, s, mi.OverWrite))
 	return errs
@@ -103,8 +112,5 @@ func newMachineImageRegistry(w *Workflow) *machineImageRegistry {
 func (ir *machineImageRegistry) deleteFn(res *Resource) DError {
 	m := NamedSubexp(machineImageURLRgx, res.link)
 	err := ir.w.ComputeClient.DeleteMachineImage(m["project"], m["machineImage"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete machine image", err)
-	}
-	return newErr("failed to delete machine image", err)
+	return deleteErr("machine image", err)
 }
@@ -66,6 +66,9 @@ func TestMachineImagesValidate(t *testing.T) {
 	}{
 		{"simple case success", &MachineImage{MachineImage: compute.MachineImage{Name: "i1", SourceInstance: "si"}}, false},
 		{"no source instance case failure", &MachineImage{MachineImage: compute.MachineImage{Name: "i2"}}, true},
+		{"storage location case success", &MachineImage{MachineImage: compute.MachineImage{Name: "i3", SourceInstance: "si", StorageLocations: []string{"us-central1"}, GuestFlush: true}}, false},
+		{"multiple storage locations case failure", &MachineImage{MachineImage: compute.MachineImage{Name: "i4", SourceInstance: "si", StorageLocations: []string{"us", "eu"}}}, true},
+		{"empty storage location case failure", &MachineImage{MachineImage: compute.MachineImage{Name: "i5", SourceInstance: "si", StorageLocations: []string{""}}}, true},
 	}
 
 	for _, tt := range tests {
@@ -17,11 +17,152 @@ package daisy
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 )
 
-var machineTypeURLRegex = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?zones/(?P<zone>%[2]s)/machineTypes/(?P<machinetype>%[2]s)$`, projectRgxStr, rfc1035))
+var (
+	machineTypeURLRegex  = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?zones/(?P<zone>%[2]s)/machineTypes/(?P<machinetype>%[2]s)$`, projectRgxStr, rfc1035))
+	customMachineTypeRgx = regexp.MustCompile(`^(?:(?P<family>[a-z0-9]+)-)?custom-(?P<cpus>\d+)-(?P<memory>\d+)(?P<ext>-ext)?$`)
+)
+
+// customMemoryStepMB is the granularity of the memory of custom machine
+// types.
+const customMemoryStepMB = 256
+
+// customMachineFamily holds the vCPU and memory constraints of the custom
+// machine types of a machine family.
+type customMachineFamily struct {
+	maxCPUs int64
+	// validCPUs reports whether a custom machine type can have cpus vCPUs.
+	validCPUs func(cpus int64) bool
+	// Memory per vCPU bounds in MB, extended memory lifts the maximum.
+	minMemPerCPU, maxMemPerCPU float64
+	// Total memory bound in MB, no limit if 0.
+	maxMem int64
+	// Total memory bound in MB with extended memory.
+	maxExtMem int64
+}
+
+// customMachineFamilies are the families whose custom machine types are
+// validated locally, custom machine types without a family are N1.
+var customMachineFamilies = map[string]customMachineFamily{
+	"n1": {
+		maxCPUs:      96,
+		validCPUs:    func(c int64) bool { return c == 1 || c%2 == 0 },
+		minMemPerCPU: 921.6,
+		maxMemPerCPU: 6656,
+		maxExtMem:    624 * 1024,
+	},
+	"n2": {
+		maxCPUs:      128,
+		validCPUs:    func(c int64) bool { return (c <= 32 && c%2 == 0) || c%4 == 0 },
+		minMemPerCPU: 512,
+		maxMemPerCPU: 8192,
+		maxExtMem:    864 * 1024,
+	},
+	"n2d": {
+		maxCPUs:      96,
+		validCPUs:    func(c int64) bool { return c == 2 || c == 4 || c == 8 || c%16 == 0 },
+		minMemPerCPU: 512,
+		maxMemPerCPU: 8192,
+		maxExtMem:    768 * 1024,
+	},
+	"e2": {
+		maxCPUs:      32,
+		validCPUs:    func(c int64) bool { return c >= 2 && c%2 == 0 },
+		minMemPerCPU: 512,
+		maxMemPerCPU: 8192,
+		maxMem:       128 * 1024,
+		maxExtMem:    128 * 1024,
+	},
+}
+
+// memRange returns the valid memory range in MB of a custom machine type
+// with cpus vCPUs, rounded to the memory granularity.
+func (f customMachineFamily) memRange(cpus int64, ext bool) (min, max int64) {
+	min = roundUp(int64(f.minMemPerCPU*float64(cpus)+0.5), customMemoryStepMB)
+	max = int64(f.maxMemPerCPU*float64(cpus)) / customMemoryStepMB * customMemoryStepMB
+	if ext {
+		max = f.maxExtMem
+	} else if f.maxMem > 0 && max > f.maxMem {
+		max = f.maxMem
+	}
+	return min, max
+}
+
+func roundUp(n, step int64) int64 {
+	return (n + step - 1) / step * step
+}
+
+// validateCustomMachineType checks the vCPU count and memory of a custom
+// machine type, e.g. "custom-2-4096" or "n2-custom-4-16384-ext", which GCE
+// rejects without saying why. The error suggests the closest valid machine
+// type. Machine types that aren't custom, or of a family not known here,
+// aren't checked.
+func validateCustomMachineType(machineType string) DError {
+	m := NamedSubexp(customMachineTypeRgx, machineType)
+	if m == nil {
+		return nil
+	}
+	f, ok := customMachineFamilies[strOr(m["family"], "n1")]
+	if !ok {
+		return nil
+	}
+	cpus, err := strconv.ParseInt(m["cpus"], 10, 64)
+	if err != nil {
+		return nil
+	}
+	mem, err := strconv.ParseInt(m["memory"], 10, 64)
+	if err != nil {
+		return nil
+	}
+	ext := m["ext"] != ""
+
+	var problems []string
+	if cpus < 1 || cpus > f.maxCPUs || !f.validCPUs(cpus) {
+		problems = append(problems, fmt.Sprintf("%d vCPUs isn't a valid vCPU count", cpus))
+	}
+	if mem%customMemoryStepMB != 0 {
+		problems = append(problems, fmt.Sprintf("memory must be a multiple of %d MB", customMemoryStepMB))
+	}
+	if min, max := f.memRange(cpus, ext); cpus > 0 && mem < min {
+		problems = append(problems, fmt.Sprintf("%d MB of memory is less than the minimum of %d MB for %d vCPUs", mem, min, cpus))
+	} else if cpus > 0 && max > 0 && mem > max {
+		problems = append(problems, fmt.Sprintf("%d MB of memory is more than the maximum of %d MB for %d vCPUs", mem, max, cpus))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	// The closest valid machine type has the closest valid vCPU count and
+	// the closest valid memory for it.
+	best := int64(0)
+	for c := int64(1); c <= f.maxCPUs; c++ {
+		if f.validCPUs(c) && (best == 0 || abs64(c-cpus) <= abs64(best-cpus)) {
+			best = c
+		}
+	}
+	min, max := f.memRange(best, ext)
+	bestMem := (mem + customMemoryStepMB/2) / customMemoryStepMB * customMemoryStepMB
+	if bestMem < min {
+		bestMem = min
+	} else if max > 0 && bestMem > max {
+		bestMem = max
+	}
+	prefix := strings.TrimSuffix(machineType, m["cpus"]+"-"+m["memory"]+m["ext"])
+	suggestion := fmt.Sprintf("%s%d-%d%s", prefix, best, bestMem, m["ext"])
+	return Errf("bad custom machine type %q: %s, the closest valid machine type is %q", machineType, strings.Join(problems, ", "), suggestion)
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
 
 func (w *Workflow) machineTypeExists(project, zone, machineType string) (bool, DError) {
 	predefinedMachineTypeExists, err := w.machineTypeCache.resourceExists(func(project, zone string, opts ...daisyCompute.ListCallOption) (interface{}, error) {
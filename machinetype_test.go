@@ -0,0 +1,63 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCustomMachineType(t *testing.T) {
+	tests := []struct {
+		desc, machineType string
+		// Suggested machine type, empty if the machine type is valid.
+		wantSuggestion string
+	}{
+		{"predefined case", "n1-standard-1", ""},
+		{"n1 case", "custom-2-4096", ""},
+		{"n1 one vCPU case", "custom-1-1024", ""},
+		{"n2 case", "n2-custom-4-16384", ""},
+		{"n2d case", "n2d-custom-16-8192", ""},
+		{"e2 case", "e2-custom-2-16384", ""},
+		{"extended memory case", "n2-custom-2-32768-ext", ""},
+		{"unknown family case", "c3-custom-3-1000", ""},
+		{"n1 too much memory case", "custom-2-16384", "custom-2-13312"},
+		{"n1 too little memory case", "custom-4-2048", "custom-4-3840"},
+		{"n1 odd vCPUs case", "custom-3-4096", "custom-4-4096"},
+		{"n2 too much memory case", "n2-custom-2-20480", "n2-custom-2-16384"},
+		{"n2 vCPUs case", "n2-custom-34-65536", "n2-custom-36-65536"},
+		{"n2d vCPUs case", "n2d-custom-6-8192", "n2d-custom-8-8192"},
+		{"e2 one vCPU case", "e2-custom-1-2048", "e2-custom-2-2048"},
+		{"e2 total memory case", "e2-custom-32-262144-ext", "e2-custom-32-131072-ext"},
+		{"n1 extended memory case", "custom-96-655360-ext", "custom-96-638976-ext"},
+		{"n2 extended memory case", "n2-custom-2-901120-ext", "n2-custom-2-884736-ext"},
+		{"memory granularity case", "n2-custom-2-4000", "n2-custom-2-4096"},
+		{"too many vCPUs case", "custom-128-131072", "custom-96-131072"},
+	}
+	for _, tt := range tests {
+		err := validateCustomMachineType(tt.machineType)
+		if tt.wantSuggestion == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.desc, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		} else if want := `the closest valid machine type is "` + tt.wantSuggestion + `"`; !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %q doesn't contain %q", tt.desc, err, want)
+		}
+	}
+}
@@ -19,12 +19,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"net/http"
 	"regexp"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/googleapi"
 )
 
 var (
@@ -37,6 +35,19 @@ func (w *Workflow) networkExists(project, network string) (bool, DError) {
 	}, project, network)
 }
 
+// getNetwork returns the existing network from the workflow's network cache,
+// which is loaded with the networks of project if needed. It returns nil if
+// the network doesn't exist.
+func (w *Workflow) getNetwork(project, network string) (*compute.Network, DError) {
+	if _, err := w.networkExists(project, network); err != nil {
+		return nil, err
+	}
+	w.networkCache.mu.Lock()
+	defer w.networkCache.mu.Unlock()
+	n, _ := w.networkCache.exists[project][network].(*compute.Network)
+	return n, nil
+}
+
 // Network is used to create a GCE network.
 type Network struct {
 	compute.Network
@@ -104,10 +115,7 @@ func newNetworkRegistry(w *Workflow) *networkRegistry {
 func (nr *networkRegistry) deleteFn(res *Resource) DError {
 	m := NamedSubexp(networkURLRegex, res.link)
 	err := nr.w.ComputeClient.DeleteNetwork(m["project"], m["network"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete network", err)
-	}
-	return newErr("failed to delete network", err)
+	return deleteErr("network", err)
 }
 
 func (nr *networkRegistry) disconnectHelper(nName, iName string, s *Step) DError {
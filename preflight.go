@@ -0,0 +1,135 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// preflightRefs collects the GCE resource URLs referenced by a workflow, and
+// the links of the resources the workflow creates itself.
+type preflightRefs struct {
+	// URL -> names of the steps referencing it.
+	refs    map[string][]string
+	created map[string]bool
+}
+
+// preflightReferences checks, in a single pass before any step is validated,
+// that the existing images, machine types, networks and subnetworks referenced
+// by the workflow, its included and its sub workflows exist. Lookups go
+// through the workflow's resource caches, which list each project, zone or
+// region once. All missing references are reported together.
+func (w *Workflow) preflightReferences() DError {
+	p := &preflightRefs{refs: map[string][]string{}, created: map[string]bool{}}
+	p.collect(w, "")
+
+	var urls []string
+	for url := range p.refs {
+		if !p.created[url] {
+			urls = append(urls, url)
+		}
+	}
+	sort.Strings(urls)
+
+	var errs DError
+	for _, url := range urls {
+		sort.Strings(p.refs[url])
+		steps := strings.Join(p.refs[url], ", ")
+		if exists, err := w.resourceExists(url); err != nil {
+			errs = addErrs(errs, Errf("bad lookup of %q referenced by %s: %v", url, steps, err))
+		} else if !exists {
+			errs = addErrs(errs, Errf("resource %q referenced by %s does not exist", url, steps))
+		}
+	}
+	return errs
+}
+
+func (p *preflightRefs) collect(w *Workflow, prefix string) {
+	for name, s := range w.Steps {
+		name = prefix + name
+		switch {
+		case s.IncludeWorkflow != nil && s.IncludeWorkflow.Workflow != nil:
+			p.collect(s.IncludeWorkflow.Workflow, name+".")
+		case s.SubWorkflow != nil && s.SubWorkflow.Workflow != nil:
+			p.collect(s.SubWorkflow.Workflow, name+".")
+		case s.CreateDisks != nil:
+			for _, d := range *s.CreateDisks {
+				p.created[d.link] = true
+				p.add(name, d.SourceImage)
+			}
+		case s.CreateImages != nil:
+			for _, i := range s.CreateImages.Images {
+				p.created[i.link] = true
+			}
+			for _, i := range s.CreateImages.ImagesBeta {
+				p.created[i.link] = true
+			}
+			for _, i := range s.CreateImages.ImagesAlpha {
+				p.created[i.link] = true
+			}
+		case s.CreateInstances != nil:
+			for _, i := range s.CreateInstances.Instances {
+				p.add(name, i.MachineType)
+				for _, d := range i.Disks {
+					if d.InitializeParams != nil {
+						p.add(name, d.InitializeParams.SourceImage)
+					}
+				}
+				for _, n := range i.NetworkInterfaces {
+					p.add(name, n.Network)
+					p.add(name, n.Subnetwork)
+				}
+			}
+			for _, i := range s.CreateInstances.InstancesBeta {
+				p.add(name, i.MachineType)
+				for _, d := range i.Disks {
+					if d.InitializeParams != nil {
+						p.add(name, d.InitializeParams.SourceImage)
+					}
+				}
+				for _, n := range i.NetworkInterfaces {
+					p.add(name, n.Network)
+					p.add(name, n.Subnetwork)
+				}
+			}
+		case s.CreateNetworks != nil:
+			for _, n := range *s.CreateNetworks {
+				p.created[n.link] = true
+			}
+		case s.CreateSubnetworks != nil:
+			for _, sn := range *s.CreateSubnetworks {
+				p.created[sn.link] = true
+			}
+		}
+	}
+}
+
+// add records url as referenced by step, if url is a full URL of a resource
+// type checked by preflightReferences. Other values, e.g. names of workflow
+// resources, are left to the validation of the step.
+func (p *preflightRefs) add(step, url string) {
+	if !strings.HasPrefix(url, "projects/") {
+		return
+	}
+	for _, rgx := range []*regexp.Regexp{imageURLRgx, machineTypeURLRegex, networkURLRegex, subnetworkURLRegex} {
+		if rgx.MatchString(url) {
+			p.refs[url] = append(p.refs[url], fmt.Sprintf("step %q", step))
+			return
+		}
+	}
+}
@@ -0,0 +1,123 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestPreflightReferences(t *testing.T) {
+	w := testWorkflow()
+	listCalls := map[string]int{}
+	w.ComputeClient = &daisyCompute.TestClient{
+		ListImagesFn: func(_ string, _ ...daisyCompute.ListCallOption) ([]*compute.Image, error) {
+			listCalls["images"]++
+			return []*compute.Image{{Name: "image"}}, nil
+		},
+		ListMachineTypesFn: func(_, _ string, _ ...daisyCompute.ListCallOption) ([]*compute.MachineType, error) {
+			listCalls["machineTypes"]++
+			return []*compute.MachineType{{Name: "mt"}}, nil
+		},
+		GetMachineTypeFn: func(_, _, mt string) (*compute.MachineType, error) {
+			return nil, Errf("machine type %q not found", mt)
+		},
+		ListNetworksFn: func(_ string, _ ...daisyCompute.ListCallOption) ([]*compute.Network, error) {
+			listCalls["networks"]++
+			return []*compute.Network{{Name: "network"}}, nil
+		},
+		ListSubnetworksFn: func(_, _ string, _ ...daisyCompute.ListCallOption) ([]*compute.Subnetwork, error) {
+			listCalls["subnetworks"]++
+			return nil, nil
+		},
+	}
+
+	image := fmt.Sprintf("projects/%s/global/images/image", testProject)
+	createdImage := fmt.Sprintf("projects/%s/global/images/created", testProject)
+	missingImage := fmt.Sprintf("projects/%s/global/images/missing", testProject)
+	network := fmt.Sprintf("projects/%s/global/networks/network", testProject)
+	missingSubnet := fmt.Sprintf("projects/%s/regions/r/subnetworks/missing", testProject)
+	ci := &Image{}
+	ci.link = createdImage
+	w.Steps = map[string]*Step{
+		"create-image": {w: w, CreateImages: &CreateImages{Images: []*Image{ci}}},
+		"create-disks": {w: w, CreateDisks: &CreateDisks{
+			{Disk: compute.Disk{SourceImage: image}},
+			{Disk: compute.Disk{SourceImage: createdImage}},
+			{Disk: compute.Disk{SourceImage: missingImage}},
+			{Disk: compute.Disk{SourceImage: "workflow-image"}},
+		}},
+		"create-instances": {w: w, CreateInstances: &CreateInstances{Instances: []*Instance{
+			{Instance: compute.Instance{
+				MachineType:       fmt.Sprintf("projects/%s/zones/%s/machineTypes/mt", testProject, testZone),
+				NetworkInterfaces: []*compute.NetworkInterface{{Network: network}},
+			}},
+			{Instance: compute.Instance{
+				MachineType:       fmt.Sprintf("projects/%s/zones/%s/machineTypes/missing", testProject, testZone),
+				Disks:             []*compute.AttachedDisk{{InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: missingImage}}},
+				NetworkInterfaces: []*compute.NetworkInterface{{Network: network, Subnetwork: missingSubnet}},
+			}},
+		}}},
+	}
+
+	err := w.preflightReferences()
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	wantErrs := []string{
+		fmt.Sprintf(`resource %q referenced by step "create-disks", step "create-instances" does not exist`, missingImage),
+		`machineTypes/missing" referenced by step "create-instances": APIError: machine type "missing" not found`,
+		fmt.Sprintf(`resource %q referenced by step "create-instances" does not exist`, missingSubnet),
+	}
+	for _, want := range wantErrs {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), createdImage) || strings.Contains(err.Error(), "workflow-image") {
+		t.Errorf("error reports resources created by the workflow: %v", err)
+	}
+	for typ, n := range listCalls {
+		if n != 1 {
+			t.Errorf("%s listed %d times, want once", typ, n)
+		}
+	}
+	if len(listCalls) != 4 {
+		t.Errorf("unexpected list calls: %v", listCalls)
+	}
+}
+
+func TestPreflightReferencesValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	w.PreflightReferences = true
+	w.ComputeClient = &daisyCompute.TestClient{
+		ListImagesFn: func(_ string, _ ...daisyCompute.ListCallOption) ([]*compute.Image, error) {
+			return nil, nil
+		},
+	}
+	s, _ := w.NewStep("create-disks")
+	s.CreateDisks = &CreateDisks{{Disk: compute.Disk{SourceImage: fmt.Sprintf("projects/%s/global/images/missing", testProject)}}}
+
+	err := w.validate(ctx)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected missing reference error, got: %v", err)
+	}
+}
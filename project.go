@@ -15,11 +15,9 @@
 package daisy
 
 import (
-	"net/http"
 	"sync"
 
 	"github.com/GoogleCloudPlatform/compute-daisy/compute"
-	"google.golang.org/api/googleapi"
 )
 
 var projectCache struct {
@@ -34,7 +32,7 @@ func projectExists(client compute.Client, project string) (bool, DError) {
 		return true, nil
 	}
 	if _, err := client.GetProject(project); err != nil {
-		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+		if compute.IsNotFound(err) {
 			return false, nil
 		}
 		return false, typedErr(apiError, "failed to get project", err)
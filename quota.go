@@ -0,0 +1,210 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/api/compute/v1"
+)
+
+const localSSDSizeGb = 375
+
+// plannedDisk holds the quota relevant fields of a disk a workflow will create.
+type plannedDisk struct {
+	diskType string
+	sizeGb   int64
+}
+
+// plannedInstance holds the quota relevant fields shared by GA and Beta
+// instances a workflow will create.
+type plannedInstance struct {
+	name        string
+	zone        string
+	machineType string
+	preemptible bool
+	disks       []plannedDisk
+	externalIPs int
+}
+
+// newPlannedInstance returns the planned instance of the GA instance i,
+// named name in the workflow.
+func newPlannedInstance(name string, i *compute.Instance) plannedInstance {
+	pi := plannedInstance{name: name, zone: i.Zone, machineType: i.MachineType}
+	if i.Scheduling != nil {
+		pi.preemptible = isPreemptible(i.Scheduling.Preemptible, i.Scheduling.ProvisioningModel)
+	}
+	for _, d := range i.Disks {
+		if p := d.InitializeParams; p != nil {
+			pi.disks = append(pi.disks, plannedDisk{diskType: p.DiskType, sizeGb: p.DiskSizeGb})
+		}
+	}
+	for _, n := range i.NetworkInterfaces {
+		for _, ac := range n.AccessConfigs {
+			// Configs with a NatIP use an already reserved address.
+			if ac.NatIP == "" {
+				pi.externalIPs++
+			}
+		}
+	}
+	return pi
+}
+
+// newPlannedInstanceBeta returns the planned instance of the Beta instance
+// i. The quota relevant fields of a Beta instance are also GA fields, so it's
+// read as a GA instance.
+func newPlannedInstanceBeta(i *InstanceBeta) (plannedInstance, DError) {
+	b, err := json.Marshal(&i.Instance)
+	if err != nil {
+		return plannedInstance{}, newErr("cannot compute planned quota for instance "+i.daisyName, err)
+	}
+	var ga compute.Instance
+	if err := json.Unmarshal(b, &ga); err != nil {
+		return plannedInstance{}, newErr("cannot compute planned quota for instance "+i.daisyName, err)
+	}
+	return newPlannedInstance(i.daisyName, &ga), nil
+}
+
+// cpuQuotaMetric returns the CPU quota metric consumed by a machine type.
+// N1, E2, F1 and G1 machine types share the CPUS metric, other machine
+// families have their own metric, e.g. N2_CPUS.
+func cpuQuotaMetric(machineType string, preemptible bool) string {
+	if preemptible {
+		return "PREEMPTIBLE_CPUS"
+	}
+	family := strings.SplitN(machineType, "-", 2)[0]
+	switch family {
+	case "n1", "e2", "f1", "g1", "custom":
+		return "CPUS"
+	}
+	return strings.ToUpper(family) + "_CPUS"
+}
+
+// diskQuotaMetric returns the disk quota metric consumed by a disk type.
+func diskQuotaMetric(diskType string) string {
+	switch diskType {
+	case "pd-ssd", "pd-balanced":
+		return "SSD_TOTAL_GB"
+	case "local-ssd":
+		return "LOCAL_SSD_TOTAL_GB"
+	}
+	return "DISKS_TOTAL_GB"
+}
+
+type plannedQuota map[string]map[string]float64
+
+func (q plannedQuota) add(region, metric string, units float64) {
+	if units == 0 {
+		return
+	}
+	if q[region] == nil {
+		q[region] = map[string]float64{}
+	}
+	q[region][metric] += units
+}
+
+func (q plannedQuota) addDisk(zone string, d plannedDisk) {
+	dt := NamedSubexp(diskTypeURLRgx, d.diskType)["disktype"]
+	size := d.sizeGb
+	if dt == "local-ssd" && size == 0 {
+		size = localSSDSizeGb
+	}
+	q.add(getRegionFromZone(zone), diskQuotaMetric(dt), float64(size))
+}
+
+func (w *Workflow) addInstanceQuota(ctx context.Context, q plannedQuota, pi plannedInstance) DError {
+	region := getRegionFromZone(pi.zone)
+	pre := "cannot compute planned quota for instance " + pi.name
+	if err := ctx.Err(); err != nil {
+		return typedErr(err.Error(), pre, err)
+	}
+
+	// Instances created from a machine image without a MachineType
+	// inherit it from the image.
+	if pi.machineType != "" {
+		if !machineTypeURLRegex.MatchString(pi.machineType) {
+			return Errf("%s: bad MachineType: %q", pre, pi.machineType)
+		}
+		mt := NamedSubexp(machineTypeURLRegex, pi.machineType)
+		vCPUs, _, err := w.ComputeClient.GetMachineTypeSpec(mt["project"], mt["zone"], mt["machinetype"])
+		if err != nil {
+			return typedErr(apiError, pre+": failed to get machine type spec", err)
+		}
+		q.add(region, cpuQuotaMetric(mt["machinetype"], pi.preemptible), float64(vCPUs))
+	}
+	q.add(region, "INSTANCES", 1)
+	q.add(region, "IN_USE_ADDRESSES", float64(pi.externalIPs))
+	for _, d := range pi.disks {
+		q.addDisk(pi.zone, d)
+	}
+	return nil
+}
+
+func (w *Workflow) addPlannedQuota(ctx context.Context, q plannedQuota) DError {
+	for _, s := range w.Steps {
+		switch {
+		case s.IncludeWorkflow != nil && s.IncludeWorkflow.Workflow != nil:
+			if err := s.IncludeWorkflow.Workflow.addPlannedQuota(ctx, q); err != nil {
+				return err
+			}
+		case s.SubWorkflow != nil && s.SubWorkflow.Workflow != nil:
+			if err := s.SubWorkflow.Workflow.addPlannedQuota(ctx, q); err != nil {
+				return err
+			}
+		case s.CreateInstances != nil:
+			ci := s.CreateInstances
+			var pis []plannedInstance
+			if ci.instanceUsesBetaFeatures() {
+				for _, i := range ci.InstancesBeta {
+					pi, err := newPlannedInstanceBeta(i)
+					if err != nil {
+						return err
+					}
+					pis = append(pis, pi)
+				}
+			} else {
+				for _, i := range ci.Instances {
+					pis = append(pis, newPlannedInstance(i.daisyName, &i.Instance))
+				}
+			}
+			for _, pi := range pis {
+				if err := w.addInstanceQuota(ctx, q, pi); err != nil {
+					return err
+				}
+			}
+		case s.CreateDisks != nil:
+			for _, d := range *s.CreateDisks {
+				q.addDisk(d.Zone, plannedDisk{diskType: d.Type, sizeGb: d.Disk.SizeGb})
+			}
+		}
+	}
+	return nil
+}
+
+// ComputePlannedQuota returns the quota the workflow, including its included
+// workflows and sub workflows, will consume by creating instances and disks.
+// The result is keyed by region and then by quota metric, e.g. CPUS,
+// SSD_TOTAL_GB or IN_USE_ADDRESSES. The workflow must be populated, which is
+// done by Validate. Disks sized by their source image or snapshot are not
+// counted. Machine type specs are looked up until ctx is done.
+func (w *Workflow) ComputePlannedQuota(ctx context.Context) (map[string]map[string]float64, error) {
+	q := plannedQuota{}
+	if err := w.addPlannedQuota(ctx, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
@@ -0,0 +1,168 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	computeBeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestComputePlannedQuota(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	w.ComputeClient.(*daisyCompute.TestClient).GetMachineTypeSpecFn = func(_, _, mt string) (int64, int64, error) {
+		switch mt {
+		case "n1-standard-4":
+			return 4, 15360, nil
+		case "n2-standard-8":
+			return 8, 32768, nil
+		}
+		return 0, 0, fmt.Errorf("unknown machine type %q", mt)
+	}
+
+	mt := func(t string) string {
+		return fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", testProject, testZone, t)
+	}
+	dt := func(t string) string {
+		return fmt.Sprintf("projects/%s/zones/%s/diskTypes/%s", testProject, testZone, t)
+	}
+	newInstance := func(machineType string, disks []*compute.AttachedDisk, acs []*compute.AccessConfig) *Instance {
+		return &Instance{Instance: compute.Instance{
+			Zone:              testZone,
+			MachineType:       machineType,
+			Disks:             disks,
+			NetworkInterfaces: []*compute.NetworkInterface{{AccessConfigs: acs}},
+		}}
+	}
+
+	sw := w.NewSubWorkflow()
+	sw.Steps = map[string]*Step{
+		"create-disks": {w: sw, CreateDisks: &CreateDisks{
+			{Disk: compute.Disk{Zone: testZone, Type: dt("pd-ssd"), SizeGb: 100}},
+			{Disk: compute.Disk{Zone: testZone, Type: dt("pd-standard"), SourceImage: "image"}},
+		}},
+	}
+	w.Steps = map[string]*Step{
+		"create-instances": {w: w, CreateInstances: &CreateInstances{Instances: []*Instance{
+			newInstance(mt("n1-standard-4"),
+				[]*compute.AttachedDisk{
+					{InitializeParams: &compute.AttachedDiskInitializeParams{DiskType: dt("pd-standard"), DiskSizeGb: 20}},
+					{InitializeParams: &compute.AttachedDiskInitializeParams{DiskType: dt("local-ssd")}},
+					{Source: "zones/z/disks/existing"},
+				},
+				[]*compute.AccessConfig{{Type: defaultAccessConfigType}}),
+			newInstance(mt("n2-standard-8"),
+				[]*compute.AttachedDisk{
+					{InitializeParams: &compute.AttachedDiskInitializeParams{DiskType: dt("pd-balanced"), DiskSizeGb: 50}},
+				},
+				[]*compute.AccessConfig{{Type: defaultAccessConfigType, NatIP: "1.2.3.4"}}),
+		}}},
+		"sub": {w: w, SubWorkflow: &SubWorkflow{Workflow: sw}},
+	}
+
+	got, err := w.ComputePlannedQuota(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]map[string]float64{
+		testRegion: {
+			"CPUS":               4,
+			"N2_CPUS":            8,
+			"INSTANCES":          2,
+			"IN_USE_ADDRESSES":   1,
+			"DISKS_TOTAL_GB":     20,
+			"SSD_TOTAL_GB":       150,
+			"LOCAL_SSD_TOTAL_GB": 375,
+		},
+	}
+	if diffRes := diff(got, want, 0); diffRes != "" {
+		t.Errorf("planned quota does not match expectation: (-got +want)\n%s", diffRes)
+	}
+
+	// Bad machine type case.
+	w.Steps["create-instances"].CreateInstances.Instances[0].MachineType = "bad"
+	if _, err := w.ComputePlannedQuota(ctx); err == nil {
+		t.Error("should have returned an error for a bad machine type")
+	}
+
+	// Machine type spec lookup error case.
+	w.Steps["create-instances"].CreateInstances.Instances[0].MachineType = mt("dne")
+	if _, err := w.ComputePlannedQuota(ctx); err == nil {
+		t.Error("should have returned an error for a failed machine type lookup")
+	}
+
+	// Canceled context case.
+	w.Steps["create-instances"].CreateInstances.Instances[0].MachineType = mt("n1-standard-4")
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := w.ComputePlannedQuota(cctx); err == nil {
+		t.Error("should have returned an error for a canceled context")
+	}
+}
+
+func TestNewPlannedInstanceBeta(t *testing.T) {
+	ib := &InstanceBeta{Instance: computeBeta.Instance{
+		Zone:        testZone,
+		MachineType: "machineTypes/n1-standard-4",
+		Scheduling:  &computeBeta.Scheduling{ProvisioningModel: provisioningModelSpot},
+		Disks: []*computeBeta.AttachedDisk{
+			{InitializeParams: &computeBeta.AttachedDiskInitializeParams{DiskType: "diskTypes/pd-ssd", DiskSizeGb: 10}},
+			{Source: "disks/existing"},
+		},
+		NetworkInterfaces: []*computeBeta.NetworkInterface{{AccessConfigs: []*computeBeta.AccessConfig{{}, {NatIP: "1.2.3.4"}}}},
+	}}
+	ib.daisyName = "beta"
+
+	got, err := newPlannedInstanceBeta(ib)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := plannedInstance{
+		name:        "beta",
+		zone:        testZone,
+		machineType: "machineTypes/n1-standard-4",
+		preemptible: true,
+		disks:       []plannedDisk{{diskType: "diskTypes/pd-ssd", sizeGb: 10}},
+		externalIPs: 1,
+	}
+	if diffRes := diff(got, want, 0); diffRes != "" {
+		t.Errorf("planned instance does not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestCPUQuotaMetric(t *testing.T) {
+	tests := []struct {
+		machineType string
+		preemptible bool
+		want        string
+	}{
+		{"n1-standard-1", false, "CPUS"},
+		{"e2-medium", false, "CPUS"},
+		{"custom-2-4096", false, "CPUS"},
+		{"n2d-highmem-4", false, "N2D_CPUS"},
+		{"c2-standard-4", false, "C2_CPUS"},
+		{"n1-standard-1", true, "PREEMPTIBLE_CPUS"},
+	}
+	for _, tt := range tests {
+		if got := cpuQuotaMetric(tt.machineType, tt.preemptible); got != tt.want {
+			t.Errorf("cpuQuotaMetric(%q, %t) = %q, want %q", tt.machineType, tt.preemptible, got, tt.want)
+		}
+	}
+}
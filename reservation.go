@@ -0,0 +1,146 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"regexp"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+const (
+	specificReservationType = "SPECIFIC_RESERVATION"
+	reservationNameKey      = "compute.googleapis.com/reservation-name"
+)
+
+// Shared reservations are referenced as projects/PROJECT/reservations/NAME.
+var reservationURLRgx = regexp.MustCompile(fmt.Sprintf(`^projects/(?P<project>%[1]s)/reservations/(?P<reservation>%[2]s)$`, projectRgxStr, rfc1035))
+
+// specificReservation is a reservation targeted by an instance.
+type specificReservation struct {
+	project, zone, name string
+}
+
+func (r specificReservation) String() string {
+	return fmt.Sprintf("projects/%s/zones/%s/reservations/%s", r.project, r.zone, r.name)
+}
+
+// specificReservations returns the reservations targeted by a
+// SPECIFIC_RESERVATION affinity, GCE will use any of them.
+func specificReservations(ra *compute.ReservationAffinity, project, zone string) ([]specificReservation, DError) {
+	if ra == nil || ra.ConsumeReservationType != specificReservationType {
+		return nil, nil
+	}
+	if ra.Key != reservationNameKey || len(ra.Values) == 0 {
+		return nil, Errf("%s reservation affinity requires Key %q and at least one reservation name in Values", specificReservationType, reservationNameKey)
+	}
+	var rs []specificReservation
+	for _, v := range ra.Values {
+		if m := NamedSubexp(reservationURLRgx, v); m != nil {
+			rs = append(rs, specificReservation{project: m["project"], zone: zone, name: m["reservation"]})
+		} else {
+			rs = append(rs, specificReservation{project: project, zone: zone, name: v})
+		}
+	}
+	return rs, nil
+}
+
+// reservationCapacity returns the number of instances of machineType the
+// reservation can still accommodate.
+func (w *Workflow) reservationCapacity(r specificReservation, machineType string) (int64, DError) {
+	res, err := w.ComputeClient.GetReservation(r.project, r.zone, r.name)
+	if err != nil {
+		if daisyCompute.IsNotFound(err) {
+			return 0, Errf("reservation %q does not exist", r)
+		}
+		return 0, typedErr(apiError, fmt.Sprintf("failed to get reservation %q", r), err)
+	}
+	sr := res.SpecificReservation
+	if sr == nil {
+		return 0, Errf("reservation %q has no specific reservation properties", r)
+	}
+	if machineType != "" && sr.InstanceProperties != nil && sr.InstanceProperties.MachineType != machineType {
+		return 0, Errf("reservation %q is for machine type %q, not %q", r, sr.InstanceProperties.MachineType, machineType)
+	}
+	return sr.Count - sr.InUseCount, nil
+}
+
+// validateReservationAffinity checks that at least one of the reservations
+// targeted by the instance exists, matches its machine type and has capacity
+// for it, taking other instances of the workflow targeting it into account.
+func (ib *InstanceBase) validateReservationAffinity(ii InstanceInterface, s *Step) DError {
+	pre := fmt.Sprintf("cannot create instance %q", ib.daisyName)
+	rs, err := specificReservations(ii.getReservationAffinity(), ib.Project, ii.getZone())
+	if err != nil {
+		return Errf("%s: %v", pre, err)
+	}
+	if len(rs) == 0 {
+		return nil
+	}
+	mt := NamedSubexp(machineTypeURLRegex, ii.getMachineType())["machinetype"]
+
+	w := s.w
+	w.reservationUseMx.Lock()
+	defer w.reservationUseMx.Unlock()
+	if w.reservationUse == nil {
+		w.reservationUse = map[string]int64{}
+	}
+	var errs DError
+	for _, r := range rs {
+		free, err := w.reservationCapacity(r, mt)
+		if err != nil {
+			errs = addErrs(errs, Errf("%s: %v", pre, err))
+			continue
+		}
+		if planned := w.reservationUse[r.String()]; free > planned {
+			w.reservationUse[r.String()]++
+			return nil
+		}
+		errs = addErrs(errs, Errf("%s: reservation %q can't accommodate the instance: %d instances available, %d already planned by the workflow", pre, r, free, w.reservationUse[r.String()]))
+	}
+	return errs
+}
+
+// checkReservationAffinity checks that at least one of the reservations
+// targeted by the instance can still accommodate it. This is called right
+// before creating the instance to fail fast instead of GCE failing the
+// creation.
+func (ib *InstanceBase) checkReservationAffinity(ii InstanceInterface, w *Workflow) DError {
+	pre := fmt.Sprintf("cannot create instance %q", ib.daisyName)
+	rs, err := specificReservations(ii.getReservationAffinity(), ib.Project, ii.getZone())
+	if err != nil {
+		return Errf("%s: %v", pre, err)
+	}
+	if len(rs) == 0 {
+		return nil
+	}
+	mt := NamedSubexp(machineTypeURLRegex, ii.getMachineType())["machinetype"]
+
+	var errs DError
+	for _, r := range rs {
+		free, err := w.reservationCapacity(r, mt)
+		if err != nil {
+			errs = addErrs(errs, Errf("%s: %v", pre, err))
+			continue
+		}
+		if free > 0 {
+			return nil
+		}
+		errs = addErrs(errs, Errf("%s: reservation %q has no capacity left", pre, r))
+	}
+	return errs
+}
@@ -0,0 +1,104 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestValidateReservationAffinity(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{w: w}
+	var gotProjects []string
+	w.ComputeClient.(*daisyCompute.TestClient).GetReservationFn = func(project, zone, name string) (*compute.Reservation, error) {
+		gotProjects = append(gotProjects, project)
+		if name == DNE {
+			return nil, &googleapi.Error{Code: http.StatusNotFound}
+		}
+		return &compute.Reservation{Name: name, SpecificReservation: &compute.AllocationSpecificSKUReservation{
+			Count:              3,
+			InUseCount:         2,
+			InstanceProperties: &compute.AllocationSpecificSKUAllocationReservedInstanceProperties{MachineType: "n1-standard-4"},
+		}}, nil
+	}
+
+	newInstance := func(machineType string, ra *compute.ReservationAffinity) *Instance {
+		i := &Instance{Instance: compute.Instance{
+			Zone:                testZone,
+			MachineType:         fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", testProject, testZone, machineType),
+			ReservationAffinity: ra,
+		}}
+		i.Project = testProject
+		return i
+	}
+	specific := func(values ...string) *compute.ReservationAffinity {
+		return &compute.ReservationAffinity{ConsumeReservationType: specificReservationType, Key: reservationNameKey, Values: values}
+	}
+
+	tests := []struct {
+		desc      string
+		i         *Instance
+		shouldErr bool
+	}{
+		{"no affinity case", newInstance("n1-standard-4", nil), false},
+		{"any reservation case", newInstance("n1-standard-4", &compute.ReservationAffinity{ConsumeReservationType: "ANY_RESERVATION"}), false},
+		{"specific reservation case", newInstance("n1-standard-4", specific("res")), false},
+		{"reservation full case", newInstance("n1-standard-4", specific("res")), true},
+		{"fall back to second reservation case", newInstance("n1-standard-4", specific("res", "res2")), false},
+		{"shared reservation case", newInstance("n1-standard-4", specific("projects/other/reservations/shared")), false},
+		{"machine type mismatch case", newInstance("n1-standard-8", specific("res3")), true},
+		{"reservation does not exist case", newInstance("n1-standard-4", specific(DNE)), true},
+		{"no values case", newInstance("n1-standard-4", specific()), true},
+		{"bad key case", newInstance("n1-standard-4", &compute.ReservationAffinity{ConsumeReservationType: specificReservationType, Key: "foo", Values: []string{"res"}}), true},
+	}
+	for _, tt := range tests {
+		gotProjects = nil
+		err := tt.i.validateReservationAffinity(tt.i, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.desc == "shared reservation case" && (len(gotProjects) != 1 || gotProjects[0] != "other") {
+			t.Errorf("%s: reservation looked up in unexpected projects: %v", tt.desc, gotProjects)
+		}
+	}
+}
+
+func TestCheckReservationAffinity(t *testing.T) {
+	w := testWorkflow()
+	var inUse int64
+	w.ComputeClient.(*daisyCompute.TestClient).GetReservationFn = func(project, zone, name string) (*compute.Reservation, error) {
+		return &compute.Reservation{Name: name, SpecificReservation: &compute.AllocationSpecificSKUReservation{Count: 1, InUseCount: inUse}}, nil
+	}
+	i := &Instance{Instance: compute.Instance{
+		Zone:                testZone,
+		ReservationAffinity: &compute.ReservationAffinity{ConsumeReservationType: specificReservationType, Key: reservationNameKey, Values: []string{"res"}},
+	}}
+
+	if err := i.checkReservationAffinity(i, w); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	inUse = 1
+	if err := i.checkReservationAffinity(i, w); err == nil {
+		t.Error("should have returned an error for a full reservation")
+	}
+}
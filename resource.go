@@ -16,12 +16,17 @@ package daisy
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/googleapi"
 )
 
 // Resource is the base struct for Daisy representation structs for GCE resources.
@@ -35,6 +40,10 @@ type Resource struct {
 	RealName string `json:",omitempty"`
 	// If set, Daisy will use the exact name as specified by the user instead of generating a name. Mutually exclusive with RealName.
 	ExactName bool `json:",omitempty"`
+	// If set and the resource already exists, Daisy adopts the existing resource instead of failing, provided
+	// its configuration matches. Requires a name which doesn't change across runs: ExactName, RealName or
+	// Workflow.NameSeed. Adopted resources are never cleaned up, even with ForceCleanupOnError.
+	ExistsOk bool `json:",omitempty"`
 
 	// The name of the disk as known to Daisy and the Daisy user.
 	daisyName string
@@ -45,9 +54,45 @@ type Resource struct {
 	startedByWf bool
 	deleteMx    *sync.Mutex
 
-	creator, deleter  *Step
+	creator, deleter *Step
+	// Registry type of the resource, set when it's registered as created.
+	typeName          string
 	createdInWorkflow bool
-	users             []*Step
+	createdAt         time.Time
+	// Set when the resource already existed and was adopted because of
+	// ExistsOk.
+	adopted bool
+	// Set for resources owned outside the workflow and registered by an
+	// AdoptResources step, these are never deleted by the workflow.
+	external bool
+	users    []*Step
+}
+
+// ResourceRef describes a GCE resource created by a workflow.
+type ResourceRef struct {
+	// Type is the registry type of the resource, e.g. "disk" or "instance".
+	Type string
+	// Name is the name of the resource as known to the workflow.
+	Name string
+	// RealName is the name of the resource in GCE.
+	RealName string
+	Project  string
+	// Link is the partial URL of the resource, e.g. projects/p/zones/z/disks/d.
+	Link string
+	// Created is when the creating step finished creating the resource.
+	Created time.Time
+	// Deleted reports whether the workflow has since deleted the resource.
+	Deleted bool
+}
+
+// markCreated records that the workflow created the resource, or adopted it
+// because of ExistsOk.
+func (r *Resource) markCreated() {
+	r.createdInWorkflow = true
+	r.createdAt = time.Now()
+	if r.creator != nil {
+		r.creator.w.sendResourceEvent(EventResourceCreated, r.creator.name, r.typeName, r.daisyName, r)
+	}
 }
 
 func (r *Resource) populateWithGlobal(ctx context.Context, s *Step, name string) (string, DError) {
@@ -72,6 +117,9 @@ func (r *Resource) populateHelper(ctx context.Context, s *Step, name string) DEr
 	} else if r.ExactName {
 		r.RealName = name
 	} else if r.RealName == "" {
+		if r.ExistsOk && s.w.nameSeed() == "" {
+			errs = addErrs(errs, Errf("ExistsOk requires ExactName, RealName or the workflow NameSeed to be set"))
+		}
 		r.RealName = s.w.genName(name)
 	}
 	r.daisyName = name
@@ -120,6 +168,102 @@ func (r *Resource) validateWithRegion(ctx context.Context, s *Step, re, errPrefi
 	return errs
 }
 
+// configIgnoredFields are fields which can differ between an existing resource
+// and the configuration it was created from.
+var configIgnoredFields = []string{"creationTimestamp", "description", "id", "kind", "selfLink", "status"}
+
+// configMismatches compares the top level scalar fields set in desired with
+// existing and describes the ones which differ. Partial URLs in desired match
+// the full URLs returned by the API.
+func configMismatches(desired, existing interface{}) ([]string, error) {
+	toMap := func(v interface{}) (map[string]interface{}, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		m := map[string]interface{}{}
+		return m, json.Unmarshal(b, &m)
+	}
+	dm, err := toMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	em, err := toMap(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []string
+	for k, dv := range dm {
+		if strIn(k, configIgnoredFields) {
+			continue
+		}
+		switch dv := dv.(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		case string:
+			// Image families resolve to the latest image at creation time.
+			if strings.Contains(dv, "/images/family/") {
+				continue
+			}
+			if ev, ok := em[k].(string); ok && (ev == dv || strings.HasSuffix(ev, "/"+dv)) {
+				continue
+			}
+		default:
+			if reflect.DeepEqual(dv, em[k]) {
+				continue
+			}
+		}
+		mismatches = append(mismatches, fmt.Sprintf("%s: want %v, got %v", k, dv, em[k]))
+	}
+	sort.Strings(mismatches)
+	return mismatches, nil
+}
+
+// createOrAdopt creates the resource using create, once the PreCreateHook of
+// w accepted desired, and passes the created resource, fetched using get, to
+// the PostCreateHook of w. If the resource already exists and ExistsOk is
+// set, the existing resource is adopted instead when its configuration
+// matches desired, and desired is refreshed from it. Adopted resources aren't
+// cleaned up. It returns whether the resource was adopted.
+func (r *Resource) createOrAdopt(w *Workflow, resourceType string, desired interface{}, create func() error, get func() (interface{}, error)) (bool, error) {
+	if err := w.preCreate(resourceType, desired); err != nil {
+		return false, err
+	}
+	err := create()
+	if err == nil {
+		if err := w.postCreate(resourceType, get); err != nil {
+			// The resource exists, make sure it's cleaned up.
+			r.markCreated()
+			return false, err
+		}
+		return false, nil
+	}
+	if apiErr, ok := err.(*googleapi.Error); !ok || apiErr.Code != http.StatusConflict || !r.ExistsOk {
+		return false, err
+	}
+	existing, gErr := get()
+	if gErr != nil {
+		return false, fmt.Errorf("%v, error getting existing resource: %v", err, gErr)
+	}
+	mismatches, cErr := configMismatches(desired, existing)
+	if cErr != nil {
+		return false, fmt.Errorf("%v, error comparing existing resource: %v", err, cErr)
+	}
+	if len(mismatches) > 0 {
+		return false, fmt.Errorf("resource already exists with a different configuration: %s", strings.Join(mismatches, "; "))
+	}
+	b, mErr := json.Marshal(existing)
+	if mErr == nil {
+		mErr = json.Unmarshal(b, desired)
+	}
+	if mErr != nil {
+		return false, fmt.Errorf("%v, error reading existing resource: %v", err, mErr)
+	}
+	r.adopted = true
+	return true, nil
+}
+
 func defaultDescription(resourceTypeName, wfName, user string) string {
 	return fmt.Sprintf("%s created by Daisy in workflow %q on behalf of %s.", resourceTypeName, wfName, user)
 }
@@ -160,6 +304,9 @@ func (w *Workflow) resourceExists(url string) (bool, DError) {
 	case targetInstanceURLRegex.MatchString(url):
 		result := NamedSubexp(targetInstanceURLRegex, url)
 		return w.targetInstanceExists(result["project"], result["zone"], result["targetInstance"])
+	case addressURLRegex.MatchString(url):
+		result := NamedSubexp(addressURLRegex, url)
+		return w.addressExists(result["project"], result["region"], result["address"])
 	case forwardingRuleURLRegex.MatchString(url):
 		result := NamedSubexp(forwardingRuleURLRegex, url)
 		return w.forwardingRuleExists(result["project"], result["region"], result["forwardingRule"])
@@ -16,9 +16,97 @@ package daisy
 
 import (
 	"fmt"
+	"path"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+)
+
+// cleanupDependents maps a resource type to the resource types that can
+// reference it, and so must be deleted before it during cleanup. It mirrors
+// the registries' attachment and connection tracking: instances attach disks
+// and connect to networks and subnetworks, target instances point at
+// instances, forwarding rules point at target instances and instances use
+// the IPs of addresses reserved in subnetworks.
+var cleanupDependents = map[string][]string{
+	"address":        {"instance"},
+	"disk":           {"instance"},
+	"instance":       {"targetInstance"},
+	"network":        {"firewallRule", "forwardingRule", "instance", "subnetwork"},
+	"subnetwork":     {"address", "forwardingRule", "instance"},
+	"targetInstance": {"forwardingRule"},
+}
+
+// resourceCleanupOrder is the order in which the resource registries are
+// cleaned up, dependent resources are deleted first.
+var resourceCleanupOrder = mustCleanupOrder([]string{
+	"forwardingRule", "targetInstance", "instance", "address", "image", "machineImage", "disk",
+	"firewallRule", "subnetwork", "network", "snapshot",
+}, cleanupDependents)
+
+// cleanupOrder topologically sorts types so that every type comes after its
+// dependents. Ties are broken by the order of types.
+func cleanupOrder(types []string, dependents map[string][]string) ([]string, error) {
+	known := map[string]bool{}
+	for _, t := range types {
+		known[t] = true
+	}
+	// pending counts the dependents of a type that are not yet in the order.
+	pending := map[string]int{}
+	for t, ds := range dependents {
+		if !known[t] {
+			return nil, fmt.Errorf("unknown resource type %q", t)
+		}
+		for _, d := range ds {
+			if !known[d] {
+				return nil, fmt.Errorf("unknown resource type %q, dependent of %q", d, t)
+			}
+			pending[t]++
+		}
+	}
+	var order []string
+	done := map[string]bool{}
+	for len(order) < len(types) {
+		next := ""
+		for _, t := range types {
+			if !done[t] && pending[t] == 0 {
+				next = t
+				break
+			}
+		}
+		if next == "" {
+			return nil, fmt.Errorf("resource type dependency cycle, ordered %v of %v", order, types)
+		}
+		done[next] = true
+		order = append(order, next)
+		for t, ds := range dependents {
+			for _, d := range ds {
+				if d == next {
+					pending[t]--
+				}
+			}
+		}
+	}
+	return order, nil
+}
+
+func mustCleanupOrder(types []string, dependents map[string][]string) []string {
+	order, err := cleanupOrder(types, dependents)
+	if err != nil {
+		panic(err)
+	}
+	return order
+}
+
+// deleteInUseRetries is how many times a delete failing because the resource
+// is in use is retried, waiting deleteInUseBackoff, doubled on each retry,
+// before each retry.
+const (
+	deleteInUseRetries = 5
+	deleteInUseBackoff = 2 * time.Second
 )
 
 type baseResourceRegistry struct {
@@ -41,6 +129,8 @@ func (r *baseResourceRegistry) cleanup() {
 	var wg sync.WaitGroup
 	for name, res := range r.m {
 		if res.creator == nil || // placeholder resource
+			res.external || // resource is owned outside the workflow
+			res.adopted || // resource existed before the workflow
 			(res.creator != nil && !res.createdInWorkflow) || // resource isn‘t created successfully
 			(res.NoCleanup && !r.w.forceCleanup) || // resource is flagged to avoid cleanup
 			res.deleted { // resource has been deleted
@@ -57,6 +147,29 @@ func (r *baseResourceRegistry) cleanup() {
 	wg.Wait()
 }
 
+// createdResources returns references to the resources in the registry that
+// were created by the workflow. Adopted resources are not included.
+func (r *baseResourceRegistry) createdResources() []ResourceRef {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	var refs []ResourceRef
+	for name, res := range r.m {
+		if res.external || res.adopted || !res.createdInWorkflow {
+			continue
+		}
+		refs = append(refs, ResourceRef{
+			Type:     r.typeName,
+			Name:     name,
+			RealName: res.RealName,
+			Project:  res.Project,
+			Link:     res.link,
+			Created:  res.createdAt,
+			Deleted:  res.deleted,
+		})
+	}
+	return refs
+}
+
 func (r *baseResourceRegistry) delete(name string) DError {
 	res, ok := r.get(name)
 	if !ok {
@@ -76,13 +189,29 @@ func (r *baseResourceRegistry) delete(name string) DError {
 	if res.deleted {
 		return Errf("cannot delete %q; already deleted", name)
 	}
-	if err := r.deleteFn(res); err != nil {
-		return err
+	err := r.deleteFn(res)
+	// A dependent resource deleted just before may not be released yet.
+	for i := 1; i <= deleteInUseRetries && isResourceInUseErr(err); i++ {
+		SleepFn(deleteInUseBackoff * time.Duration(1<<(i-1)))
+		err = r.deleteFn(res)
+	}
+	if err != nil {
+		return resourceErr(name, err)
 	}
 	res.deleted = true
+	r.w.sendResourceEvent(EventResourceDeleted, "", r.typeName, name, res)
 	return nil
 }
 
+// deleteErr returns the error of a failed delete of a resource of kind, typed
+// resourceDNEError if the resource doesn't exist.
+func deleteErr(kind string, err error) DError {
+	if daisyCompute.IsNotFound(err) {
+		return typedErr(resourceDNEError, "failed to delete "+kind, err)
+	}
+	return newErr("failed to delete "+kind, err)
+}
+
 func (r *baseResourceRegistry) start(name string) DError {
 	res, ok := r.get(name)
 	if !ok {
@@ -93,7 +222,7 @@ func (r *baseResourceRegistry) start(name string) DError {
 		return Errf("cannot start %q; already started", name)
 	}
 	if err := r.startFn(res); err != nil {
-		return err
+		return resourceErr(name, err)
 	}
 	res.stoppedByWf = false
 	res.startedByWf = true
@@ -110,7 +239,7 @@ func (r *baseResourceRegistry) stop(name string) DError {
 		return Errf("cannot stop %q; already stopped", name)
 	}
 	if err := r.stopFn(res); err != nil {
-		return err
+		return resourceErr(name, err)
 	}
 	res.startedByWf = false
 	res.stoppedByWf = true
@@ -124,29 +253,81 @@ func (r *baseResourceRegistry) get(name string) (*Resource, bool) {
 	return res, ok
 }
 
+// rename records that the resource known by name was renamed to realName, so
+// later references to name resolve to the renamed resource.
+func (r *baseResourceRegistry) rename(name, realName string) DError {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	res, ok := r.m[name]
+	if !ok {
+		return Errf("cannot rename %s %q; does not exist in registry", r.typeName, name)
+	}
+	res.RealName = realName
+	res.link = path.Join(path.Dir(res.link), realName)
+	return nil
+}
+
 // regCreate registers a Step s as the creator of a resource, res, and identifies the resource by name.
 func (r *baseResourceRegistry) regCreate(name string, res *Resource, s *Step, overWrite bool) DError {
 	// Check:
 	// - no duplicates known by name
+	// - no other name for the same resource, unless that one is deleted before s
 	r.mx.Lock()
 	defer r.mx.Unlock()
 	if res, ok := r.m[name]; ok {
 		return Errf("cannot create %s %q; already created by step %q", r.typeName, name, res.creator.name)
 	}
+	for n, other := range r.m {
+		if other.creator == nil || other.link == "" || other.link != res.link {
+			continue
+		}
+		if other.deleter == nil || !s.nestedDepends(other.deleter) {
+			return Errf("cannot create %s %q; %s %q created by step %q is the same resource: %s", r.typeName, name, r.typeName, n, other.creator.name, res.link)
+		}
+	}
 
+	if overWrite && res.ExistsOk {
+		return Errf("cannot create %s %q; OverWrite and ExistsOk are mutually exclusive", r.typeName, name)
+	}
 	if !overWrite {
 		if exists, err := r.w.resourceExists(res.link); err != nil {
 			return Errf("cannot create %s %q; resource lookup error: %v", r.typeName, name, err)
-		} else if exists {
+		} else if exists && !res.ExistsOk {
 			return Errf("cannot create %s %q; resource already exists", r.typeName, name)
 		}
 	}
 
 	res.creator = s
+	res.typeName = r.typeName
 	r.m[name] = res
 	return nil
 }
 
+// regAdopt registers an existing resource, identified by a fully qualified resource URL, under name. Step s is
+// registered as its creator so that users of name depend on s, but the resource is never deleted by the workflow.
+func (r *baseResourceRegistry) regAdopt(name, url string, s *Step) DError {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.urlRgx != nil && !r.urlRgx.MatchString(url) {
+		return Errf("cannot adopt %s %q; bad resource URL: %q", r.typeName, name, url)
+	}
+	if res, ok := r.m[name]; ok {
+		if res.creator != nil {
+			return Errf("cannot adopt %s %q; already created by step %q", r.typeName, name, res.creator.name)
+		}
+		return Errf("cannot adopt %s %q; name already in use", r.typeName, name)
+	}
+	if exists, err := r.w.resourceExists(url); err != nil {
+		return Errf("cannot adopt %s %q; resource lookup error: %v", r.typeName, name, err)
+	} else if !exists {
+		return typedErrf(r.typeName+resourceDNEError, "cannot adopt %s %q; %s does not exist", r.typeName, name, url)
+	}
+
+	parts := strings.Split(url, "/")
+	r.m[name] = &Resource{RealName: parts[len(parts)-1], link: url, NoCleanup: true, daisyName: name, creator: s, external: true}
+	return nil
+}
+
 // regDelete registers a Step s as the deleter of a resource.
 // The name argument can be a Daisy internal name, or a fully qualified resource URL, e.g. projects/p/global/images/i.
 func (r *baseResourceRegistry) regDelete(name string, s *Step) DError {
@@ -167,6 +348,9 @@ func (r *baseResourceRegistry) regDelete(name string, s *Step) DError {
 		return Errf("missing reference for %s %q", r.typeName, name)
 	}
 
+	if res.external {
+		return Errf("cannot delete %s %q: it is adopted by step %q and owned outside the workflow", r.typeName, name, res.creator.name)
+	}
 	if res.deleter != nil {
 		return Errf("cannot delete %s %q: already deleted by step %q", r.typeName, name, res.deleter.name)
 	}
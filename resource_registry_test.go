@@ -15,11 +15,16 @@
 package daisy
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"google.golang.org/api/googleapi"
 )
 
 func TestPlaceholderResourceRegistryCleanup(t *testing.T) {
@@ -87,7 +92,8 @@ func TestResourceRegistryCleanup(t *testing.T) {
 	in2 := &Resource{RealName: "in2", link: "link", NoCleanup: true, creator: s, createdInWorkflow: true}
 	mi1 := &Resource{RealName: "mi1", link: "link", NoCleanup: false, creator: s, createdInWorkflow: true}
 	mi2 := &Resource{RealName: "mi2", link: "link", NoCleanup: true, creator: s, createdInWorkflow: true}
-	w.disks.m = map[string]*Resource{"d1": d1, "d2": d2}
+	adopted := &Resource{RealName: "adopted", link: "link", ExistsOk: true, creator: s, createdInWorkflow: true, adopted: true}
+	w.disks.m = map[string]*Resource{"d1": d1, "d2": d2, "adopted": adopted}
 	w.images.m = map[string]*Resource{"im1": im1, "im2": im2}
 	w.machineImages.m = map[string]*Resource{"mi1": mi1, "mi2": mi2}
 	w.instances.m = map[string]*Resource{"in1": in1, "in2": in2}
@@ -101,6 +107,74 @@ func TestResourceRegistryCleanup(t *testing.T) {
 			t.Errorf("cleanup didn't delete %q", r.RealName)
 		}
 	}
+	if adopted.deleted {
+		t.Error("cleanup deleted an adopted resource")
+	}
+}
+
+func TestResourceRegistryCleanupOrder(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{}
+
+	var mx sync.Mutex
+	var got []string
+	regs := w.resourceRegistries()
+	for typeName, r := range regs {
+		typeName := typeName
+		r.m = map[string]*Resource{typeName: {RealName: typeName, link: "link", creator: s, createdInWorkflow: true}}
+		r.deleteFn = func(res *Resource) DError {
+			mx.Lock()
+			defer mx.Unlock()
+			got = append(got, typeName)
+			return nil
+		}
+	}
+
+	w.cleanup()
+
+	if diffRes := diff(got, resourceCleanupOrder, 0); diffRes != "" {
+		t.Errorf("unexpected cleanup order: (-got +want)\n%s", diffRes)
+	}
+	if len(resourceCleanupOrder) != len(regs) {
+		t.Errorf("cleanup order %v doesn't cover all %d registries", resourceCleanupOrder, len(regs))
+	}
+	pos := map[string]int{}
+	for i, typeName := range resourceCleanupOrder {
+		pos[typeName] = i
+	}
+	for typeName, dependents := range cleanupDependents {
+		for _, d := range dependents {
+			if pos[d] > pos[typeName] {
+				t.Errorf("%s is cleaned up before its dependent %s", typeName, d)
+			}
+		}
+	}
+}
+
+func TestCleanupOrder(t *testing.T) {
+	tests := []struct {
+		desc       string
+		types      []string
+		dependents map[string][]string
+		want       []string
+		wantErr    bool
+	}{
+		{"no dependents case", []string{"a", "b", "c"}, nil, []string{"a", "b", "c"}, false},
+		{"dependents first case", []string{"a", "b", "c"}, map[string][]string{"a": {"c"}, "b": {"c"}}, []string{"c", "a", "b"}, false},
+		{"transitive case", []string{"a", "b", "c"}, map[string][]string{"a": {"b"}, "b": {"c"}}, []string{"c", "b", "a"}, false},
+		{"cycle case", []string{"a", "b", "c"}, map[string][]string{"a": {"b"}, "b": {"a"}}, nil, true},
+		{"unknown type case", []string{"a"}, map[string][]string{"a": {"b"}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := cleanupOrder(tt.types, tt.dependents)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("%s: unexpected error, got: %v, wantErr: %t", tt.desc, err, tt.wantErr)
+		}
+		if diffRes := diff(got, tt.want, 0); diffRes != "" {
+			t.Errorf("%s: unexpected order: (-got +want)\n%s", tt.desc, diffRes)
+		}
+	}
 }
 
 func TestResourceRegistryForcedCleanup(t *testing.T) {
@@ -170,7 +244,7 @@ func TestResourceRegistryConcurrency(t *testing.T) {
 
 func TestResourceRegistryDelete(t *testing.T) {
 	var deleteFnErr DError
-	r := &baseResourceRegistry{m: map[string]*Resource{}}
+	r := &baseResourceRegistry{w: testWorkflow(), m: map[string]*Resource{}}
 	r.deleteFn = func(r *Resource) DError {
 		return deleteFnErr
 	}
@@ -199,6 +273,12 @@ func TestResourceRegistryDelete(t *testing.T) {
 		}
 	}
 
+	// Delete errors name the resource, and keep their type.
+	deleteFnErr = typedErr(resourceDNEError, "error", errors.New("error"))
+	if err := r.delete("baz"); err == nil || err.etype() != resourceDNEError || !strings.Contains(err.Error(), `resource "baz": `) {
+		t.Errorf("delete error should name the resource and keep its type, got: %v", err)
+	}
+
 	wantM := map[string]*Resource{
 		"foo": {deleted: true, deleteMx: r.m["foo"].deleteMx},
 		"baz": {deleted: false, deleteMx: r.m["baz"].deleteMx},
@@ -208,6 +288,80 @@ func TestResourceRegistryDelete(t *testing.T) {
 	}
 }
 
+func TestDeleteErr(t *testing.T) {
+	notFound := &googleapi.Error{Code: http.StatusNotFound}
+	tests := []struct {
+		desc      string
+		err       error
+		wantType  string
+		shouldErr bool
+	}{
+		{"nil case", nil, "", false},
+		{"not found case", notFound, resourceDNEError, true},
+		{"wrapped not found case", fmt.Errorf("error: %w", notFound), resourceDNEError, true},
+		{"other error case", &googleapi.Error{Code: http.StatusForbidden}, "", true},
+	}
+	for _, tt := range tests {
+		err := deleteErr("disk", tt.err)
+		if !tt.shouldErr {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.desc, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if err.etype() != tt.wantType {
+			t.Errorf("%s: got error type %q, want %q", tt.desc, err.etype(), tt.wantType)
+		}
+	}
+}
+
+func TestResourceRegistryDeleteInUseRetry(t *testing.T) {
+	defer func(f func(time.Duration)) { SleepFn = f }(SleepFn)
+	var sleeps []time.Duration
+	SleepFn = func(d time.Duration) { sleeps = append(sleeps, d) }
+	inUseErr := newErr("failed to delete disk", &googleapi.Error{Code: 400, Errors: []googleapi.ErrorItem{{Reason: "resourceInUseByAnotherResource"}}})
+
+	tests := []struct {
+		desc       string
+		errs       []DError
+		wantSleeps []time.Duration
+		shouldErr  bool
+	}{
+		{"no retry case", nil, nil, false},
+		{"in use then deleted case", []DError{inUseErr, inUseErr}, []time.Duration{2 * time.Second, 4 * time.Second}, false},
+		{"other error case", []DError{Errf("error")}, nil, true},
+		{"in use then other error case", []DError{inUseErr, Errf("error")}, []time.Duration{2 * time.Second}, true},
+		{"always in use case", []DError{inUseErr, inUseErr, inUseErr, inUseErr, inUseErr, inUseErr}, []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		sleeps = nil
+		calls := 0
+		r := &baseResourceRegistry{w: testWorkflow(), m: map[string]*Resource{"foo": {}}}
+		r.deleteFn = func(res *Resource) DError {
+			defer func() { calls++ }()
+			if calls < len(tt.errs) {
+				return tt.errs[calls]
+			}
+			return nil
+		}
+		err := r.delete("foo")
+		if tt.shouldErr && err == nil {
+			t.Errorf("%s: should have erred but didn't", tt.desc)
+		} else if !tt.shouldErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if diffRes := diff(sleeps, tt.wantSleeps, 0); diffRes != "" {
+			t.Errorf("%s: unexpected backoff: (-got,+want)\n%s", tt.desc, diffRes)
+		}
+		if r.m["foo"].deleted == tt.shouldErr {
+			t.Errorf("%s: unexpected deleted state: %t", tt.desc, r.m["foo"].deleted)
+		}
+	}
+}
+
 func TestResourceRegistryStart(t *testing.T) {
 	var startFnErr DError
 	var stopFnErr DError
@@ -346,6 +500,58 @@ func TestResourceRegistryRegCreate(t *testing.T) {
 	if err := rr.regCreate("foo", r, nil, true); err == nil {
 		t.Fatalf("unexpected error registering creation of foo: %v", err)
 	}
+
+	// Test create of an existing resource.
+	existing := &Resource{link: fmt.Sprintf("projects/%s/global/images/%s", testProject, testImage)}
+	if err := rr.regCreate("existing", existing, s, false); err == nil {
+		t.Error("should have returned an error for an existing resource, but didn't")
+	}
+	existing.ExistsOk = true
+	if err := rr.regCreate("existing", existing, s, true); err == nil {
+		t.Error("should have returned an error for OverWrite and ExistsOk, but didn't")
+	}
+	if err := rr.regCreate("existing", existing, s, false); err != nil {
+		t.Errorf("unexpected error registering creation of an existing resource with ExistsOk: %v", err)
+	}
+}
+
+func TestResourceRegistryRegCreateCollision(t *testing.T) {
+	w := testWorkflow()
+	creator := &Step{name: "creator", w: w}
+	deleter := &Step{name: "deleter", w: w}
+	other := &Step{name: "other", w: w}
+	recreator := &Step{name: "recreator", w: w}
+	w.Steps = map[string]*Step{"creator": creator, "deleter": deleter, "other": other, "recreator": recreator}
+	w.Dependencies = map[string][]string{
+		"deleter":   {"creator"},
+		"recreator": {"deleter"},
+	}
+	rr := &baseResourceRegistry{w: w, typeName: "image"}
+	rr.init()
+	link := "projects/foo/global/images/bar"
+	if err := rr.regCreate("foo", &Resource{link: link}, creator, false); err != nil {
+		t.Fatalf("unexpected error registering creation of foo: %v", err)
+	}
+
+	// Another name resolving to the same resource.
+	err := rr.regCreate("foo2", &Resource{link: link}, other, false)
+	if err == nil {
+		t.Fatal("should have returned an error, but didn't")
+	}
+	for _, want := range []string{`"foo"`, `"foo2"`, link} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %s", err, want)
+		}
+	}
+
+	// Recreating the resource after it is deleted is fine.
+	rr.m["foo"].deleter = deleter
+	if err := rr.regCreate("foo2", &Resource{link: link}, other, false); err == nil {
+		t.Error("should have returned an error for a step not depending on the deleter, but didn't")
+	}
+	if err := rr.regCreate("foo2", &Resource{link: link}, recreator, false); err != nil {
+		t.Errorf("unexpected error registering creation after deletion: %v", err)
+	}
 }
 
 func TestResourceRegistryRegDelete(t *testing.T) {
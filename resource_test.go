@@ -16,7 +16,15 @@ package daisy
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
 	"testing"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
 func TestExtendPartialURL(t *testing.T) {
@@ -30,6 +38,45 @@ func TestExtendPartialURL(t *testing.T) {
 	}
 }
 
+func TestPartialURLProjectInference(t *testing.T) {
+	tests := []struct {
+		desc    string
+		rgx     *regexp.Regexp
+		partial string
+		field   string
+	}{
+		{"disk", diskURLRgx, "zones/z/disks/d", "disk"},
+		{"disk type", diskTypeURLRgx, "zones/z/diskTypes/pd-ssd", "disktype"},
+		{"firewall rule", firewallRuleURLRegex, "global/firewalls/f", "firewallRule"},
+		{"forwarding rule", forwardingRuleURLRegex, "regions/r/forwardingRules/fr", "forwardingRule"},
+		{"image", imageURLRgx, "global/images/i", "image"},
+		{"image family", imageURLRgx, "global/images/family/f", "family"},
+		{"instance", instanceURLRgx, "zones/z/instances/i", "instance"},
+		{"license", licenseURLRegex, "global/licenses/l", "license"},
+		{"machine image", machineImageURLRgx, "global/machineImages/mi", "machineImage"},
+		{"machine type", machineTypeURLRegex, "zones/z/machineTypes/n1-standard-1", "machinetype"},
+		{"network", networkURLRegex, "global/networks/n", "network"},
+		{"snapshot", snapshotURLRgx, "global/snapshots/s", "snapshot"},
+		{"subnetwork", subnetworkURLRegex, "regions/r/subnetworks/sn", "subnetwork"},
+		{"target instance", targetInstanceURLRegex, "zones/z/targetInstances/ti", "targetInstance"},
+	}
+	for _, tt := range tests {
+		if !tt.rgx.MatchString(tt.partial) {
+			t.Errorf("%s: partial URL %q should have matched", tt.desc, tt.partial)
+			continue
+		}
+		want := tt.partial[strings.LastIndex(tt.partial, "/")+1:]
+		// Partial URLs resolve against the workflow project, full URLs keep theirs.
+		for project, url := range map[string]string{"wf-project": tt.partial, "other": "projects/other/" + tt.partial} {
+			url = extendPartialURL(url, "wf-project")
+			m := NamedSubexp(tt.rgx, url)
+			if m["project"] != project || m[tt.field] != want {
+				t.Errorf("%s: %q resolved to project %q and %s %q, want: %q and %q", tt.desc, url, m["project"], tt.field, m[tt.field], project, want)
+			}
+		}
+	}
+}
+
 func TestResourcePopulate(t *testing.T) {
 	w := testWorkflow()
 	s, _ := w.NewStep("foo")
@@ -47,6 +94,8 @@ func TestResourcePopulate(t *testing.T) {
 		{"ExactName case", Resource{ExactName: true}, Resource{daisyName: name, RealName: name, Project: w.Project, ExactName: true}, "", name, w.Zone, false},
 		{"RealName case", Resource{RealName: "foo"}, Resource{daisyName: name, RealName: "foo", Project: w.Project}, "", "foo", w.Zone, false},
 		{"RealName and ExactName error case", Resource{RealName: "foo", ExactName: true}, Resource{}, "", "", "", true},
+		{"ExistsOk with ExactName case", Resource{ExistsOk: true, ExactName: true}, Resource{daisyName: name, RealName: name, Project: w.Project, ExactName: true, ExistsOk: true}, "", name, w.Zone, false},
+		{"ExistsOk with generated name error case", Resource{ExistsOk: true}, Resource{}, "", "", "", true},
 	}
 
 	for _, tt := range tests {
@@ -132,3 +181,176 @@ func TestResourceValidateWithZone(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigMismatches(t *testing.T) {
+	desired := &compute.Disk{
+		Name:        "disk",
+		Description: "created by daisy",
+		Zone:        testZone,
+		Type:        fmt.Sprintf("projects/%s/zones/%s/diskTypes/pd-ssd", testProject, testZone),
+		SizeGb:      10,
+		SourceImage: "projects/debian-cloud/global/images/family/debian-12",
+		Labels:      map[string]string{"foo": "bar"},
+	}
+	existing := &compute.Disk{
+		Name:        "disk",
+		Description: "other description",
+		Zone:        fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/zones/%s", testProject, testZone),
+		Type:        fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/zones/%s/diskTypes/pd-ssd", testProject, testZone),
+		SizeGb:      10,
+		SourceImage: "https://www.googleapis.com/compute/v1/projects/debian-cloud/global/images/debian-12-v20240101",
+		Status:      "READY",
+	}
+
+	got, err := configMismatches(desired, existing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("unexpected mismatches for matching config: %q", got)
+	}
+
+	existing.SizeGb = 20
+	existing.Type = "https://www.googleapis.com/compute/v1/projects/p/zones/z/diskTypes/pd-standard"
+	got, err = configMismatches(desired, existing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"sizeGb: want 10, got 20", fmt.Sprintf("type: want %s, got %s", desired.Type, existing.Type)}
+	if diffRes := diff(got, want, 0); diffRes != "" {
+		t.Errorf("mismatches do not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestCreateOrAdopt(t *testing.T) {
+	exists := &googleapi.Error{Code: http.StatusConflict}
+
+	tests := []struct {
+		desc        string
+		existsOk    bool
+		createErr   error
+		existing    *compute.Network
+		wantAdopted bool
+		shouldErr   bool
+	}{
+		{"created case", true, nil, nil, false, false},
+		{"create error case", true, errors.New("error"), nil, false, true},
+		{"exists case", false, exists, nil, false, true},
+		{"exists ok case", true, exists, &compute.Network{Name: "net", AutoCreateSubnetworks: true, SelfLink: "link"}, true, false},
+		{"exists ok mismatch case", true, exists, &compute.Network{Name: "net"}, false, true},
+	}
+	for _, tt := range tests {
+		desired := &compute.Network{Name: "net", AutoCreateSubnetworks: true}
+		r := &Resource{ExistsOk: tt.existsOk}
+		adopted, err := r.createOrAdopt(testWorkflow(), "network", desired, func() error {
+			return tt.createErr
+		}, func() (interface{}, error) {
+			return tt.existing, nil
+		})
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if adopted != tt.wantAdopted {
+			t.Errorf("%s: unexpected adopted, got: %t, want: %t", tt.desc, adopted, tt.wantAdopted)
+		}
+		if adopted != r.adopted {
+			t.Errorf("%s: resource adopted is %t, want: %t", tt.desc, r.adopted, adopted)
+		}
+		if adopted && desired.SelfLink != tt.existing.SelfLink {
+			t.Errorf("%s: desired not refreshed from the existing resource, got SelfLink: %q, want: %q", tt.desc, desired.SelfLink, tt.existing.SelfLink)
+		}
+	}
+}
+
+func TestCreateOrAdoptPreCreateHook(t *testing.T) {
+	w := testWorkflow()
+	sw := w.NewSubWorkflow()
+	var gotType string
+	w.PreCreateHook = func(resourceType string, resource interface{}) error {
+		gotType = resourceType
+		d := resource.(*compute.Disk)
+		if d.Name == "rejected" {
+			return errors.New("missing required labels")
+		}
+		d.Labels = map[string]string{"team": "platform"}
+		return nil
+	}
+
+	// The hook of the top level workflow applies to sub workflows.
+	d := &compute.Disk{Name: "disk"}
+	var created *compute.Disk
+	if _, err := (&Resource{}).createOrAdopt(sw, "disk", d, func() error {
+		created = d
+		return nil
+	}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotType != "disk" {
+		t.Errorf("unexpected resource type, got: %q, want: %q", gotType, "disk")
+	}
+	if created == nil || created.Labels["team"] != "platform" {
+		t.Errorf("the hook's changes should have been created, got: %+v", created)
+	}
+
+	called := false
+	if _, err := (&Resource{}).createOrAdopt(w, "disk", &compute.Disk{Name: "rejected"}, func() error {
+		called = true
+		return nil
+	}, nil); err == nil {
+		t.Error("should have returned the hook's error, but didn't")
+	}
+	if called {
+		t.Error("a resource rejected by the hook should not have been created")
+	}
+}
+
+func TestCreateOrAdoptPostCreateHook(t *testing.T) {
+	w := testWorkflow()
+	selfLink := fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/zones/%s/instances/i", testProject, testZone)
+	get := func() (interface{}, error) {
+		return &compute.Instance{Name: "i", Id: 123, SelfLink: selfLink}, nil
+	}
+	var got interface{}
+	var hookErr error
+	w.PostCreateHook = func(resourceType string, resource interface{}) error {
+		if resourceType != "instance" {
+			t.Errorf("unexpected resource type, got: %q, want: %q", resourceType, "instance")
+		}
+		got = resource
+		return hookErr
+	}
+
+	// The hook of the top level workflow applies to sub workflows.
+	r := &Resource{}
+	if _, err := r.createOrAdopt(w.NewSubWorkflow(), "instance", &compute.Instance{Name: "i"}, func() error { return nil }, get); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inst, ok := got.(*compute.Instance); !ok || inst.SelfLink != selfLink || inst.Id != 123 {
+		t.Errorf("the hook should have received the created instance, got: %+v", got)
+	}
+
+	// Hook errors are only logged by default.
+	hookErr = errors.New("inventory unavailable")
+	if _, err := r.createOrAdopt(w, "instance", &compute.Instance{Name: "i"}, func() error { return nil }, get); err != nil {
+		t.Errorf("hook errors should only have been logged, got: %v", err)
+	}
+
+	w.FailOnPostCreateHookError = true
+	if _, err := r.createOrAdopt(w, "instance", &compute.Instance{Name: "i"}, func() error { return nil }, get); err == nil {
+		t.Error("should have returned the hook's error, but didn't")
+	}
+	if !r.createdInWorkflow {
+		t.Error("a resource failing the hook should still be cleaned up")
+	}
+
+	// Resources failing to be created aren't passed to the hook.
+	got = nil
+	if _, err := (&Resource{}).createOrAdopt(w, "instance", &compute.Instance{Name: "i"}, func() error { return errors.New("error") }, get); err == nil {
+		t.Error("should have returned the create error, but didn't")
+	}
+	if got != nil {
+		t.Errorf("the hook should not have been called, got: %+v", got)
+	}
+}
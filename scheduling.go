@@ -0,0 +1,110 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	provisioningModelSpot     = "SPOT"
+	provisioningModelStandard = "STANDARD"
+	terminationActionStop     = "STOP"
+	onHostMaintenanceMigrate  = "MIGRATE"
+	onHostMaintenanceStop     = "TERMINATE"
+)
+
+// SpotScheduling returns the scheduling of a Spot VM, which is stopped when
+// preempted. Set InstanceTerminationAction to "DELETE" to delete it instead.
+func SpotScheduling() *compute.Scheduling {
+	return &compute.Scheduling{
+		ProvisioningModel:         provisioningModelSpot,
+		InstanceTerminationAction: terminationActionStop,
+		AutomaticRestart:          googleapi.Bool(false),
+		OnHostMaintenance:         onHostMaintenanceStop,
+	}
+}
+
+// StandardScheduling returns the scheduling of a standard VM, which is live
+// migrated on host maintenance and restarted if it crashes.
+func StandardScheduling() *compute.Scheduling {
+	return &compute.Scheduling{
+		ProvisioningModel: provisioningModelStandard,
+		AutomaticRestart:  googleapi.Bool(true),
+		OnHostMaintenance: onHostMaintenanceMigrate,
+	}
+}
+
+// PreemptibleScheduling returns the scheduling of a legacy preemptible VM,
+// prefer SpotScheduling for new workflows.
+func PreemptibleScheduling() *compute.Scheduling {
+	return &compute.Scheduling{
+		Preemptible:       true,
+		AutomaticRestart:  googleapi.Bool(false),
+		OnHostMaintenance: onHostMaintenanceStop,
+	}
+}
+
+// isPreemptible reports whether an instance with this scheduling can be
+// preempted, Spot and preemptible VMs both use preemptible quota.
+func isPreemptible(preemptible bool, provisioningModel string) bool {
+	return preemptible || provisioningModel == provisioningModelSpot
+}
+
+// validateScheduling checks that the preemptibility fields of an instance's
+// scheduling are consistent.
+func validateScheduling(preemptible bool, provisioningModel, terminationAction string, automaticRestart *bool, onHostMaintenance string) DError {
+	var errs DError
+	if preemptible && provisioningModel == provisioningModelStandard {
+		errs = addErrs(errs, Errf("Preemptible can't be set with ProvisioningModel %q, use ProvisioningModel %q", provisioningModelStandard, provisioningModelSpot))
+	}
+	if !isPreemptible(preemptible, provisioningModel) {
+		if terminationAction != "" {
+			errs = addErrs(errs, Errf("InstanceTerminationAction %q requires ProvisioningModel %q", terminationAction, provisioningModelSpot))
+		}
+		return errs
+	}
+	if automaticRestart != nil && *automaticRestart {
+		errs = addErrs(errs, Errf("AutomaticRestart can't be set for Spot or preemptible instances"))
+	}
+	if onHostMaintenance == onHostMaintenanceMigrate {
+		errs = addErrs(errs, Errf("OnHostMaintenance must be %q for Spot or preemptible instances", onHostMaintenanceStop))
+	}
+	return errs
+}
+
+func (ib *InstanceBase) validateScheduling(ii InstanceInterface) DError {
+	if err := ii.validateScheduling(); err != nil {
+		return Errf("cannot create instance %q: bad Scheduling: %v", ib.daisyName, err)
+	}
+	return nil
+}
+
+func (i *Instance) validateScheduling() DError {
+	if i.Scheduling == nil {
+		return nil
+	}
+	sc := i.Scheduling
+	return validateScheduling(sc.Preemptible, sc.ProvisioningModel, sc.InstanceTerminationAction, sc.AutomaticRestart, sc.OnHostMaintenance)
+}
+
+func (i *InstanceBeta) validateScheduling() DError {
+	if i.Scheduling == nil {
+		return nil
+	}
+	sc := i.Scheduling
+	return validateScheduling(sc.Preemptible, sc.ProvisioningModel, sc.InstanceTerminationAction, sc.AutomaticRestart, sc.OnHostMaintenance)
+}
@@ -0,0 +1,67 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"testing"
+
+	computeBeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestValidateScheduling(t *testing.T) {
+	tests := []struct {
+		desc    string
+		sc      *compute.Scheduling
+		wantErr bool
+	}{
+		{"no scheduling case", nil, false},
+		{"spot case", SpotScheduling(), false},
+		{"standard case", StandardScheduling(), false},
+		{"preemptible case", PreemptibleScheduling(), false},
+		{"spot delete case", &compute.Scheduling{ProvisioningModel: provisioningModelSpot, InstanceTerminationAction: "DELETE"}, false},
+		{"preemptible spot case", &compute.Scheduling{Preemptible: true, ProvisioningModel: provisioningModelSpot}, false},
+		{"preemptible standard case", &compute.Scheduling{Preemptible: true, ProvisioningModel: provisioningModelStandard}, true},
+		{"standard termination action case", &compute.Scheduling{ProvisioningModel: provisioningModelStandard, InstanceTerminationAction: terminationActionStop}, true},
+		{"spot automatic restart case", &compute.Scheduling{ProvisioningModel: provisioningModelSpot, AutomaticRestart: googleapi.Bool(true)}, true},
+		{"preemptible migrate case", &compute.Scheduling{Preemptible: true, OnHostMaintenance: onHostMaintenanceMigrate}, true},
+	}
+	for _, tt := range tests {
+		i := &Instance{Instance: compute.Instance{Scheduling: tt.sc}}
+		err := i.validateScheduling()
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		} else if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+
+	ib := &InstanceBeta{Instance: computeBeta.Instance{Scheduling: &computeBeta.Scheduling{Preemptible: true, ProvisioningModel: provisioningModelStandard}}}
+	if err := ib.validateScheduling(); err == nil {
+		t.Error("beta instance: expected error, got none")
+	}
+}
+
+func TestPlannedInstancePreemptible(t *testing.T) {
+	for _, sc := range []*compute.Scheduling{SpotScheduling(), PreemptibleScheduling()} {
+		if pi := newPlannedInstance("", &compute.Instance{Scheduling: sc}); !pi.preemptible {
+			t.Errorf("instance with scheduling %+v isn't planned as preemptible", sc)
+		}
+	}
+	if pi := newPlannedInstance("", &compute.Instance{Scheduling: StandardScheduling()}); pi.preemptible {
+		t.Error("standard instance is planned as preemptible")
+	}
+}
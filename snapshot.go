@@ -18,12 +18,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"regexp"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/googleapi"
 )
 
 var (
@@ -98,8 +96,5 @@ func newSnapshotRegistry(w *Workflow) *snapshotRegistry {
 func (sr *snapshotRegistry) deleteFn(res *Resource) DError {
 	m := NamedSubexp(snapshotURLRgx, res.link)
 	err := sr.w.ComputeClient.DeleteSnapshot(m["project"], m["snapshot"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete snapshot", err)
-	}
-	return newErr("failed to delete snapshot", err)
+	return deleteErr("snapshot", err)
 }
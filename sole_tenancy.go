@@ -0,0 +1,93 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+)
+
+const (
+	nodeAffinityOperatorIn = "IN"
+	nodeGroupNameKey       = "compute.googleapis.com/node-group-name"
+)
+
+// Node groups reference their template by URL.
+var nodeTemplateURLRgx = regexp.MustCompile(fmt.Sprintf(`regions/(?P<region>%[1]s)/nodeTemplates/(?P<template>%[1]s)$`, rfc1035))
+
+// validateNodeAffinities checks that the node groups targeted by the
+// instance's "compute.googleapis.com/node-group-name" node affinities exist,
+// and that the node type of each fits the instance's machine type. Node
+// affinities on custom node labels aren't checked.
+func (ib *InstanceBase) validateNodeAffinities(ii InstanceInterface, s *Step) DError {
+	pre := fmt.Sprintf("cannot create instance %q", ib.daisyName)
+	var errs DError
+	for _, na := range ii.getNodeAffinities() {
+		if na.Key != nodeGroupNameKey || na.Operator != nodeAffinityOperatorIn {
+			continue
+		}
+		if len(na.Values) == 0 {
+			errs = addErrs(errs, Errf("%s: node affinity %q with operator %s requires at least one node group name in Values", pre, nodeGroupNameKey, nodeAffinityOperatorIn))
+			continue
+		}
+		for _, ng := range na.Values {
+			if err := s.w.nodeGroupFits(ib.Project, ii.getZone(), ng, ii.getMachineType()); err != nil {
+				errs = addErrs(errs, Errf("%s: %v", pre, err))
+			}
+		}
+	}
+	return errs
+}
+
+// nodeGroupFits checks that the node group exists, and that an instance of
+// machineType, a machine type URL, fits its node type. The fit isn't checked
+// if machineType isn't a valid URL, validateMachineType reports that.
+func (w *Workflow) nodeGroupFits(project, zone, nodeGroup, machineType string) DError {
+	ng, err := w.ComputeClient.GetNodeGroup(project, zone, nodeGroup)
+	if err != nil {
+		if daisyCompute.IsNotFound(err) {
+			return Errf("node group %q does not exist in zone %q", nodeGroup, zone)
+		}
+		return typedErr(apiError, fmt.Sprintf("failed to get node group %q", nodeGroup), err)
+	}
+	mt := NamedSubexp(machineTypeURLRegex, machineType)
+	if mt == nil {
+		return nil
+	}
+
+	region, template := getRegionFromZone(zone), path.Base(ng.NodeTemplate)
+	if m := NamedSubexp(nodeTemplateURLRgx, ng.NodeTemplate); m != nil {
+		region, template = m["region"], m["template"]
+	}
+	nt, err := w.ComputeClient.GetNodeTemplate(project, region, template)
+	if err != nil {
+		return typedErr(apiError, fmt.Sprintf("failed to get node template %q of node group %q", template, nodeGroup), err)
+	}
+	nodeType, err := w.ComputeClient.GetNodeType(project, zone, nt.NodeType)
+	if err != nil {
+		return typedErr(apiError, fmt.Sprintf("failed to get node type %q of node group %q", nt.NodeType, nodeGroup), err)
+	}
+	vCPUs, memoryMb, err := w.ComputeClient.GetMachineTypeSpec(mt["project"], mt["zone"], mt["machinetype"])
+	if err != nil {
+		return typedErr(apiError, fmt.Sprintf("failed to get machine type %q", mt["machinetype"]), err)
+	}
+	if vCPUs > nodeType.GuestCpus || memoryMb > nodeType.MemoryMb {
+		return Errf("machine type %q (%d vCPUs, %d MB) doesn't fit node type %q (%d vCPUs, %d MB) of node group %q", mt["machinetype"], vCPUs, memoryMb, nodeType.Name, nodeType.GuestCpus, nodeType.MemoryMb, nodeGroup)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestValidateNodeAffinities(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{w: w}
+	var gotTemplateRegion string
+	tc := w.ComputeClient.(*daisyCompute.TestClient)
+	tc.GetNodeGroupFn = func(_, _, name string) (*compute.NodeGroup, error) {
+		if name == DNE {
+			return nil, &googleapi.Error{Code: http.StatusNotFound}
+		}
+		return &compute.NodeGroup{Name: name, NodeTemplate: fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/regions/other-region/nodeTemplates/nt", testProject)}, nil
+	}
+	tc.GetNodeTemplateFn = func(_, region, name string) (*compute.NodeTemplate, error) {
+		gotTemplateRegion = region
+		return &compute.NodeTemplate{Name: name, NodeType: "n1-node-96-624"}, nil
+	}
+	tc.GetNodeTypeFn = func(_, _, name string) (*compute.NodeType, error) {
+		return &compute.NodeType{Name: name, GuestCpus: 96, MemoryMb: 638976}, nil
+	}
+	tc.GetMachineTypeFn = func(_, _, mt string) (*compute.MachineType, error) {
+		if mt == "n1-standard-4" {
+			return &compute.MachineType{Name: mt, GuestCpus: 4, MemoryMb: 15360}, nil
+		}
+		return &compute.MachineType{Name: mt, GuestCpus: 160, MemoryMb: 3844096}, nil
+	}
+
+	newInstance := func(machineType string, nas ...*compute.SchedulingNodeAffinity) *Instance {
+		i := &Instance{Instance: compute.Instance{
+			Zone:        testZone,
+			MachineType: fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", testProject, testZone, machineType),
+			Scheduling:  &compute.Scheduling{NodeAffinities: nas},
+		}}
+		i.Project = testProject
+		return i
+	}
+	nodeGroups := func(values ...string) *compute.SchedulingNodeAffinity {
+		return &compute.SchedulingNodeAffinity{Key: nodeGroupNameKey, Operator: nodeAffinityOperatorIn, Values: values}
+	}
+
+	tests := []struct {
+		desc      string
+		i         *Instance
+		shouldErr bool
+	}{
+		{"no affinity case", newInstance("n1-standard-4"), false},
+		{"node group case", newInstance("n1-standard-4", nodeGroups("ng")), false},
+		{"custom label case", newInstance("n1-standard-4", &compute.SchedulingNodeAffinity{Key: "workload", Operator: nodeAffinityOperatorIn, Values: []string{"frontend"}}), false},
+		{"not in node group case", newInstance("n1-standard-4", &compute.SchedulingNodeAffinity{Key: nodeGroupNameKey, Operator: "NOT_IN", Values: []string{DNE}}), false},
+		{"node group does not exist case", newInstance("n1-standard-4", nodeGroups("ng", DNE)), true},
+		{"machine type doesn't fit case", newInstance("m1-ultramem-160", nodeGroups("ng")), true},
+		{"no values case", newInstance("n1-standard-4", nodeGroups()), true},
+	}
+	for _, tt := range tests {
+		err := tt.i.validateNodeAffinities(tt.i, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+	if gotTemplateRegion != "other-region" {
+		t.Errorf("node template looked up in unexpected region: %q", gotTemplateRegion)
+	}
+}
@@ -33,6 +33,15 @@ type stepImpl interface {
 	run(ctx context.Context, s *Step) DError
 }
 
+// destructiveStep is implemented by step types which can have a broad blast
+// radius, such as deleting resources not created by the workflow. These steps
+// only run if the workflow's ConfirmDestructive is set.
+type destructiveStep interface {
+	// destructive describes what the step changes, or returns "" if the step
+	// as configured isn't destructive.
+	destructive() string
+}
+
 // Step is a single daisy workflow step.
 type Step struct {
 	name string
@@ -44,10 +53,16 @@ type Step struct {
 	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
 	Timeout string `json:",omitempty"`
 	timeout time.Duration
+	// Resources to wait for before running this step. A WaitForResourceStatus
+	// step, named "<step>-wait-for-resources", is inserted during populate and
+	// this step depends on it.
+	WaitFor []*ResourceStatus `json:",omitempty"`
 	// Only one of the below fields should exist for each instance of Step.
 	AttachDisks               *AttachDisks               `json:",omitempty"`
 	DetachDisks               *DetachDisks               `json:",omitempty"`
+	BulkInsertDisks           *BulkInsertDisks           `json:",omitempty"`
 	CreateDisks               *CreateDisks               `json:",omitempty"`
+	CreateAddresses           *CreateAddresses           `json:",omitempty"`
 	CreateForwardingRules     *CreateForwardingRules     `json:",omitempty"`
 	CreateFirewallRules       *CreateFirewallRules       `json:",omitempty"`
 	CreateImages              *CreateImages              `json:",omitempty"`
@@ -58,10 +73,19 @@ type Step struct {
 	CreateSubnetworks         *CreateSubnetworks         `json:",omitempty"`
 	CreateTargetInstances     *CreateTargetInstances     `json:",omitempty"`
 	CopyGCSObjects            *CopyGCSObjects            `json:",omitempty"`
+	ExportImage               *ExportImage               `json:",omitempty"`
+	SmokeTestImage            *SmokeTestImage            `json:",omitempty"`
+	ReplicateImages           *ReplicateImages           `json:",omitempty"`
+	CaptureImages             *CaptureImages             `json:",omitempty"`
 	ResizeDisks               *ResizeDisks               `json:",omitempty"`
+	UpdateDisks               *UpdateDisks               `json:",omitempty"`
+	UpdateNetworks            *UpdateNetworks            `json:",omitempty"`
+	SetTags                   *SetTags                   `json:",omitempty"`
 	StartInstances            *StartInstances            `json:",omitempty"`
 	StopInstances             *StopInstances             `json:",omitempty"`
+	SendDiagnosticInterrupts  *SendDiagnosticInterrupts  `json:",omitempty"`
 	DeleteResources           *DeleteResources           `json:",omitempty"`
+	AdoptResources            *AdoptResources            `json:",omitempty"`
 	DeprecateImages           *DeprecateImages           `json:",omitempty"`
 	IncludeWorkflow           *IncludeWorkflow           `json:",omitempty"`
 	SubWorkflow               *SubWorkflow               `json:",omitempty"`
@@ -70,7 +94,13 @@ type Step struct {
 	WaitForInstancesSignal    *WaitForInstancesSignal    `json:",omitempty"`
 	WaitForAnyInstancesSignal *WaitForAnyInstancesSignal `json:",omitempty"`
 	WaitForAvailableQuotas    *WaitForAvailableQuotas    `json:",omitempty"`
+	WaitForBackendHealthy     *WaitForBackendHealthy     `json:",omitempty"`
+	WaitForResourceStatus     *WaitForResourceStatus     `json:",omitempty"`
+	WaitForSignals            *WaitForSignals            `json:",omitempty"`
 	UpdateInstancesMetadata   *UpdateInstancesMetadata   `json:",omitempty"`
+	UpdateResourcePolicies    *UpdateResourcePolicies    `json:",omitempty"`
+	SetInstanceName           *SetInstanceName           `json:",omitempty"`
+	SaveScreenshot            *SaveScreenshot            `json:",omitempty"`
 	// Used for unit tests.
 	testType stepImpl
 }
@@ -100,10 +130,18 @@ func (s *Step) stepImpl() (stepImpl, DError) {
 		matchCount++
 		result = s.DetachDisks
 	}
+	if s.BulkInsertDisks != nil {
+		matchCount++
+		result = s.BulkInsertDisks
+	}
 	if s.CreateDisks != nil {
 		matchCount++
 		result = s.CreateDisks
 	}
+	if s.CreateAddresses != nil {
+		matchCount++
+		result = s.CreateAddresses
+	}
 	if s.CreateForwardingRules != nil {
 		matchCount++
 		result = s.CreateForwardingRules
@@ -144,10 +182,38 @@ func (s *Step) stepImpl() (stepImpl, DError) {
 		matchCount++
 		result = s.CopyGCSObjects
 	}
+	if s.ExportImage != nil {
+		matchCount++
+		result = s.ExportImage
+	}
+	if s.SmokeTestImage != nil {
+		matchCount++
+		result = s.SmokeTestImage
+	}
+	if s.ReplicateImages != nil {
+		matchCount++
+		result = s.ReplicateImages
+	}
+	if s.CaptureImages != nil {
+		matchCount++
+		result = s.CaptureImages
+	}
 	if s.ResizeDisks != nil {
 		matchCount++
 		result = s.ResizeDisks
 	}
+	if s.UpdateDisks != nil {
+		matchCount++
+		result = s.UpdateDisks
+	}
+	if s.UpdateNetworks != nil {
+		matchCount++
+		result = s.UpdateNetworks
+	}
+	if s.SetTags != nil {
+		matchCount++
+		result = s.SetTags
+	}
 	if s.StartInstances != nil {
 		matchCount++
 		result = s.StartInstances
@@ -156,10 +222,18 @@ func (s *Step) stepImpl() (stepImpl, DError) {
 		matchCount++
 		result = s.StopInstances
 	}
+	if s.SendDiagnosticInterrupts != nil {
+		matchCount++
+		result = s.SendDiagnosticInterrupts
+	}
 	if s.DeleteResources != nil {
 		matchCount++
 		result = s.DeleteResources
 	}
+	if s.AdoptResources != nil {
+		matchCount++
+		result = s.AdoptResources
+	}
 	if s.DeprecateImages != nil {
 		matchCount++
 		result = s.DeprecateImages
@@ -184,10 +258,34 @@ func (s *Step) stepImpl() (stepImpl, DError) {
 		matchCount++
 		result = s.WaitForAvailableQuotas
 	}
+	if s.WaitForBackendHealthy != nil {
+		matchCount++
+		result = s.WaitForBackendHealthy
+	}
+	if s.WaitForResourceStatus != nil {
+		matchCount++
+		result = s.WaitForResourceStatus
+	}
+	if s.WaitForSignals != nil {
+		matchCount++
+		result = s.WaitForSignals
+	}
 	if s.UpdateInstancesMetadata != nil {
 		matchCount++
 		result = s.UpdateInstancesMetadata
 	}
+	if s.UpdateResourcePolicies != nil {
+		matchCount++
+		result = s.UpdateResourcePolicies
+	}
+	if s.SetInstanceName != nil {
+		matchCount++
+		result = s.SetInstanceName
+	}
+	if s.SaveScreenshot != nil {
+		matchCount++
+		result = s.SaveScreenshot
+	}
 	if s.testType != nil {
 		matchCount++
 		result = s.testType
@@ -286,7 +384,7 @@ func (s *Step) getChain() []*Step {
 }
 
 func (s *Step) populate(ctx context.Context) DError {
-	s.w.LogWorkflowInfo("Populating step %q", s.name)
+	s.logInfo("Populating step %q", s.name)
 	impl, err := s.stepImpl()
 	if err != nil {
 		return s.wrapPopulateError(err)
@@ -309,14 +407,40 @@ func (s *Step) run(ctx context.Context) DError {
 	if err != nil {
 		return s.wrapRunError(err)
 	}
-	var st string
-	if t := reflect.TypeOf(impl); t.Kind() == reflect.Ptr {
-		st = t.Elem().Name()
-	} else {
-		st = t.Name()
+	st := stepTypeName(impl)
+	if d, ok := impl.(destructiveStep); ok && !s.w.destructiveConfirmed() {
+		if what := d.destructive(); what != "" {
+			return s.wrapRunError(Errf("step changes %s, set ConfirmDestructive on the workflow to run it", what))
+		}
+	}
+	s.w.sendEvent(Event{Type: EventStepStarted, StepName: s.name, StepType: st})
+	err = s.runImpl(ctx, impl, st)
+	s.w.sendEvent(Event{Type: EventStepFinished, StepName: s.name, StepType: st, Err: err})
+	return err
+}
+
+// stepTypeName returns the name of the type of a step implementation, e.g.
+// CreateInstances.
+func stepTypeName(impl stepImpl) string {
+	t := reflect.TypeOf(impl)
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().Name()
+	}
+	return t.Name()
+}
+
+// typeName returns the name of the type of the step, "" if it has none.
+func (s *Step) typeName() string {
+	impl, err := s.stepImpl()
+	if err != nil {
+		return ""
 	}
-	s.w.LogWorkflowInfo("Running step %q (%s)", s.name, st)
-	if err = impl.run(ctx, s); err != nil {
+	return stepTypeName(impl)
+}
+
+func (s *Step) runImpl(ctx context.Context, impl stepImpl, st string) DError {
+	s.logInfo("Running step %q (%s)", s.name, st)
+	if err := impl.run(ctx, s); err != nil {
 		return s.wrapRunError(err)
 	}
 	select {
@@ -324,13 +448,13 @@ func (s *Step) run(ctx context.Context) DError {
 		// return an error to indicate a canceled workflow is not 'success'
 		return s.w.onStepCancel(s, st)
 	default:
-		s.w.LogWorkflowInfo("Step %q (%s) successfully finished.", s.name, st)
+		s.logInfo("Step %q (%s) successfully finished.", s.name, st)
 	}
 	return nil
 }
 
 func (s *Step) validate(ctx context.Context) DError {
-	s.w.LogWorkflowInfo("Validating step %q", s.name)
+	s.logInfo("Validating step %q", s.name)
 	if !rfc1035Rgx.MatchString(strings.ToLower(s.name)) {
 		return s.wrapValidateError(Errf("step name must start with a letter and only contain letters, numbers, and hyphens"))
 	}
@@ -344,6 +468,19 @@ func (s *Step) validate(ctx context.Context) DError {
 	return nil
 }
 
+// logInfo logs information about the step itself. The entry is attributed
+// to the step so that the structured logs of parallel steps can be told
+// apart, its text keeps the workflow prefix.
+func (s *Step) logInfo(format string, a ...interface{}) {
+	s.w.logEntry(&LogEntry{
+		LocalTimestamp: time.Now(),
+		WorkflowName:   getAbsoluteName(s.w),
+		StepName:       s.name,
+		Message:        fmt.Sprintf(format, a...),
+		workflowPrefix: true,
+	})
+}
+
 func (s *Step) wrapPopulateError(e DError) DError {
 	return wrapErrf(e, "step %q populate error", s.name)
 }
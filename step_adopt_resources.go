@@ -0,0 +1,88 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"regexp"
+	"sort"
+)
+
+// AdoptResources registers GCE resources owned outside the workflow under
+// workflow names, so that later steps can reference them by name. Each field
+// maps a name to the partial URL of an existing resource. Adopted resources
+// are never deleted by the workflow.
+type AdoptResources struct {
+	Disks         map[string]string `json:",omitempty"`
+	Images        map[string]string `json:",omitempty"`
+	MachineImages map[string]string `json:",omitempty"`
+	Instances     map[string]string `json:",omitempty"`
+	Networks      map[string]string `json:",omitempty"`
+	Subnetworks   map[string]string `json:",omitempty"`
+	Firewalls     map[string]string `json:",omitempty"`
+}
+
+type adoptedResources struct {
+	m   map[string]string
+	rgx *regexp.Regexp
+	r   *baseResourceRegistry
+}
+
+func (a *AdoptResources) resources(w *Workflow) []adoptedResources {
+	return []adoptedResources{
+		{a.Disks, diskURLRgx, &w.disks.baseResourceRegistry},
+		{a.Images, imageURLRgx, &w.images.baseResourceRegistry},
+		{a.MachineImages, machineImageURLRgx, &w.machineImages.baseResourceRegistry},
+		{a.Instances, instanceURLRgx, &w.instances.baseResourceRegistry},
+		{a.Networks, networkURLRegex, &w.networks.baseResourceRegistry},
+		{a.Subnetworks, subnetworkURLRegex, &w.subnetworks.baseResourceRegistry},
+		{a.Firewalls, firewallRuleURLRegex, &w.firewallRules.baseResourceRegistry},
+	}
+}
+
+func (a *AdoptResources) populate(ctx context.Context, s *Step) DError {
+	for _, ar := range a.resources(s.w) {
+		for name, url := range ar.m {
+			if ar.rgx.MatchString(url) {
+				ar.m[name] = extendPartialURL(url, s.w.Project)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *AdoptResources) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, ar := range a.resources(s.w) {
+		var names []string
+		for name := range ar.m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			errs = addErrs(errs, ar.r.regAdopt(name, ar.m[name], s))
+		}
+	}
+	return errs
+}
+
+func (a *AdoptResources) run(ctx context.Context, s *Step) DError {
+	for _, ar := range a.resources(s.w) {
+		for name, url := range ar.m {
+			s.w.LogStepInfo(s.name, "AdoptResources", "Adopted %s %q as %q.", ar.r.typeName, url, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestAdoptResourcesPopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	a := &AdoptResources{
+		Disks:    map[string]string{"d": fmt.Sprintf("zones/%s/disks/%s", testZone, testDisk)},
+		Networks: map[string]string{"n": "projects/other/global/networks/shared"},
+	}
+	if err := a.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &AdoptResources{
+		Disks:    map[string]string{"d": fmt.Sprintf("projects/%s/zones/%s/disks/%s", testProject, testZone, testDisk)},
+		Networks: map[string]string{"n": "projects/other/global/networks/shared"},
+	}
+	if diffRes := diff(a, want, 0); diffRes != "" {
+		t.Errorf("populated AdoptResources does not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestAdoptResourcesValidate(t *testing.T) {
+	ctx := context.Background()
+	disk := fmt.Sprintf("projects/%s/zones/%s/disks/%s", testProject, testZone, testDisk)
+	network := fmt.Sprintf("projects/%s/global/networks/%s", testProject, testNetwork)
+
+	tests := []struct {
+		desc      string
+		a         *AdoptResources
+		shouldErr bool
+	}{
+		{"normal case", &AdoptResources{Disks: map[string]string{"d": disk}, Networks: map[string]string{"n": network}}, false},
+		{"resource dne case", &AdoptResources{Disks: map[string]string{"d": fmt.Sprintf("projects/%s/zones/%s/disks/%s", testProject, testZone, DNE)}}, true},
+		{"bad url case", &AdoptResources{Disks: map[string]string{"d": network}}, true},
+		{"name in use case", &AdoptResources{Disks: map[string]string{"created": disk}}, true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s, _ := w.NewStep("adopt")
+		s.AdoptResources = tt.a
+		w.disks.m["created"] = &Resource{creator: &Step{name: "create"}}
+		if err := tt.a.validate(ctx, s); err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestAdoptResourcesUse(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	adopt, _ := w.NewStep("adopt")
+	adopt.AdoptResources = &AdoptResources{Networks: map[string]string{"shared": fmt.Sprintf("projects/%s/global/networks/%s", testProject, testNetwork)}}
+	user, _ := w.NewStep("user")
+	other, _ := w.NewStep("other")
+	w.AddDependency(user, adopt)
+
+	if err := adopt.AdoptResources.validate(ctx, adopt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, err := w.networks.regUse("shared", user)
+	if err != nil {
+		t.Fatalf("unexpected error using adopted network: %v", err)
+	}
+	if want := fmt.Sprintf("projects/%s/global/networks/%s", testProject, testNetwork); r.link != want {
+		t.Errorf("unexpected link, got: %q, want: %q", r.link, want)
+	}
+	if _, err := w.networks.regUse("shared", other); err == nil {
+		t.Error("using an adopted network without depending on the adopting step should have returned an error")
+	}
+	if err := w.networks.regDelete("shared", user); err == nil {
+		t.Error("deleting an adopted network should have returned an error")
+	}
+
+	// Adopted resources aren't cleaned up, even when forced.
+	w.forceCleanup = true
+	r.createdInWorkflow = true
+	w.networks.cleanup()
+	if r.deleted {
+		t.Error("adopted network was cleaned up")
+	}
+}
@@ -17,8 +17,10 @@ package daisy
 import (
 	"context"
 	"fmt"
+	"path"
 	"testing"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -75,6 +77,76 @@ func TestAttachDisksPopulateAndValidate(t *testing.T) {
 	}
 }
 
+func TestAttachDisksValidateShared(t *testing.T) {
+	ctx := context.Background()
+	diskTypes := map[string]string{
+		"pd":        "pd-balanced",
+		"hyperdisk": "hyperdisk-balanced",
+		"ml":        "hyperdisk-ml",
+	}
+	attach := func(disk, mode string, instances ...string) *AttachDisks {
+		var ads AttachDisks
+		for _, i := range instances {
+			ads = append(ads, &AttachDisk{Instance: i, AttachedDisk: compute.AttachedDisk{Mode: mode, Source: disk, DeviceName: disk}})
+		}
+		return &ads
+	}
+
+	tests := []struct {
+		desc    string
+		ads     *AttachDisks
+		wantErr bool
+	}{
+		{"single RW attachment case", attach("pd", diskModeRW, "i1"), false},
+		{"shared RO case", attach("pd", diskModeRO, "i1", "i2"), false},
+		{"shared RO hyperdisk ml case", attach("ml", diskModeRO, "i1", "i2"), false},
+		{"shared RO created disk case", attach("created", diskModeRO, "i1", "i2"), false},
+		{"shared RW case", attach("pd", diskModeRW, "i1", "i2"), true},
+		{"shared RO hyperdisk case", attach("hyperdisk", diskModeRO, "i1", "i2"), true},
+		{"shared RO created hyperdisk case", attach("created-hyperdisk", diskModeRO, "i1", "i2"), true},
+		{"disk lookup error case", attach(DNE, diskModeRO, "i1", "i2"), true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		cs, _ := w.NewStep("create")
+		cs.CreateDisks = &CreateDisks{
+			{Disk: compute.Disk{Type: "projects/p/zones/z/diskTypes/pd-ssd"}, Resource: Resource{link: "projects/p/zones/z/disks/created"}},
+			{Disk: compute.Disk{Type: "projects/p/zones/z/diskTypes/hyperdisk-extreme"}, Resource: Resource{link: "projects/p/zones/z/disks/created-hyperdisk"}},
+		}
+		s, _ := w.NewStep("attach")
+		w.AddDependency(s, cs)
+		w.instances.m = map[string]*Resource{}
+		for _, i := range []string{"i1", "i2"} {
+			w.instances.m[i] = &Resource{RealName: i, link: fmt.Sprintf("projects/p/zones/z/instances/%s", i)}
+		}
+		w.disks.m = map[string]*Resource{}
+		for _, d := range []string{"pd", "hyperdisk", "ml", DNE} {
+			w.disks.m[d] = &Resource{RealName: d, link: fmt.Sprintf("projects/p/zones/z/disks/%s", d)}
+		}
+		for _, d := range []string{"created", "created-hyperdisk"} {
+			w.disks.m[d] = &Resource{RealName: d, link: fmt.Sprintf("projects/p/zones/z/disks/%s", d), creator: cs}
+		}
+		w.ComputeClient.(*daisyCompute.TestClient).GetDiskFn = func(_, _, d string) (*compute.Disk, error) {
+			t, ok := diskTypes[d]
+			if !ok {
+				return nil, Errf("disk %q not found", d)
+			}
+			return &compute.Disk{Name: d, Type: path.Join("projects/p/zones/z/diskTypes", t)}, nil
+		}
+
+		var err error
+		if err = tt.ads.populate(ctx, s); err == nil {
+			err = tt.ads.validate(ctx, s)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+	}
+}
+
 func TestAttachDisksRun(t *testing.T) {
 	ctx := context.Background()
 	w := testWorkflow()
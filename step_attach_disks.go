@@ -16,7 +16,10 @@ package daisy
 
 import (
 	"context"
+	"fmt"
 	"path"
+	"sort"
+	"strings"
 	"sync"
 
 	"google.golang.org/api/compute/v1"
@@ -49,6 +52,8 @@ func (a *AttachDisks) populate(ctx context.Context, s *Step) DError {
 }
 
 func (a *AttachDisks) validate(ctx context.Context, s *Step) (errs DError) {
+	// Disk link -> instances the disk is attached to by this step.
+	shared := map[string]*sharedDisk{}
 	for _, ad := range *a {
 		if !checkDiskMode(ad.Mode) {
 			errs = addErrs(errs, Errf("cannot attach disk: bad disk mode: %q", ad.Mode))
@@ -86,6 +91,56 @@ func (a *AttachDisks) validate(ctx context.Context, s *Step) (errs DError) {
 
 		// Register disk attachments.
 		errs = addErrs(errs, s.w.instances.w.disks.regAttach(ad.DeviceName, ad.Source, ad.Instance, ad.Mode, s))
+
+		sd, ok := shared[dr.link]
+		if !ok {
+			sd = &sharedDisk{res: dr, instances: map[string]bool{}}
+			shared[dr.link] = sd
+		}
+		sd.instances[ir.link] = true
+		if ad.Mode != diskModeRO {
+			sd.rw = true
+		}
+	}
+	return addErrs(errs, validateSharedDisks(s.w, shared))
+}
+
+type sharedDisk struct {
+	res       *Resource
+	instances map[string]bool
+	// Whether the disk is attached in a mode other than READ_ONLY.
+	rw bool
+}
+
+// validateSharedDisks checks that the disks attached to several instances
+// are attached in READ_ONLY mode and support multiple readers.
+func validateSharedDisks(w *Workflow, shared map[string]*sharedDisk) DError {
+	var links []string
+	for link, sd := range shared {
+		if len(sd.instances) > 1 {
+			links = append(links, link)
+		}
+	}
+	sort.Strings(links)
+
+	var errs DError
+	for _, link := range links {
+		sd := shared[link]
+		var instances []string
+		for i := range sd.instances {
+			instances = append(instances, i)
+		}
+		sort.Strings(instances)
+		pre := fmt.Sprintf("cannot attach disk %q to instances %s", link, strings.Join(instances, ", "))
+		if sd.rw {
+			errs = addErrs(errs, Errf("%s: a disk attached to several instances must be attached in %s mode", pre, diskModeRO))
+			continue
+		}
+		if ok, err := w.diskSupportsMultiReader(sd.res); err != nil {
+			errs = addErrs(errs, Errf("%s: %v", pre, err))
+		} else if !ok {
+			errs = addErrs(errs, Errf("%s: disk type doesn't support %s attachment to several instances", pre, diskModeRO))
+		}
 	}
 	return errs
 }
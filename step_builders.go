@@ -0,0 +1,131 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+)
+
+// The step builders below add a step of a given type to a workflow built in
+// Go. Required fields are checked when the step is built, the step isn't
+// added to the workflow if they're missing. The step is populated and
+// validated with the rest of the workflow.
+
+// NewCreateDisksStep adds a CreateDisks step creating disks to this workflow.
+// Each disk must have a Name.
+func (w *Workflow) NewCreateDisksStep(name string, disks ...*Disk) (*Step, error) {
+	if len(disks) == 0 {
+		return nil, fmt.Errorf("can't create step %q: no disks given", name)
+	}
+	for _, d := range disks {
+		if d == nil || d.Name == "" {
+			return nil, fmt.Errorf("can't create step %q: disk has no Name", name)
+		}
+	}
+	s, err := w.NewStep(name)
+	if err != nil {
+		return nil, err
+	}
+	cd := CreateDisks(disks)
+	s.CreateDisks = &cd
+	return s, nil
+}
+
+// NewCreateInstancesStep adds a CreateInstances step creating instances to
+// this workflow. Each instance must have a Name.
+func (w *Workflow) NewCreateInstancesStep(name string, instances ...*Instance) (*Step, error) {
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("can't create step %q: no instances given", name)
+	}
+	for _, i := range instances {
+		if i == nil || i.Name == "" {
+			return nil, fmt.Errorf("can't create step %q: instance has no Name", name)
+		}
+	}
+	s, err := w.NewStep(name)
+	if err != nil {
+		return nil, err
+	}
+	s.CreateInstances = &CreateInstances{Instances: instances}
+	return s, nil
+}
+
+// NewStartInstancesStep adds a StartInstances step starting instances, either
+// workflow instance names or partial URLs, to this workflow.
+func (w *Workflow) NewStartInstancesStep(name string, instances ...string) (*Step, error) {
+	if err := checkInstanceNames(name, instances); err != nil {
+		return nil, err
+	}
+	s, err := w.NewStep(name)
+	if err != nil {
+		return nil, err
+	}
+	s.StartInstances = &StartInstances{Instances: instances}
+	return s, nil
+}
+
+// NewStopInstancesStep adds a StopInstances step stopping instances, either
+// workflow instance names or partial URLs, to this workflow.
+func (w *Workflow) NewStopInstancesStep(name string, instances ...string) (*Step, error) {
+	if err := checkInstanceNames(name, instances); err != nil {
+		return nil, err
+	}
+	s, err := w.NewStep(name)
+	if err != nil {
+		return nil, err
+	}
+	s.StopInstances = &StopInstances{Instances: instances}
+	return s, nil
+}
+
+// NewSuspendStep adds a Suspend step suspending instance to this workflow,
+// the instance is in the workflow's project and zone.
+func (w *Workflow) NewSuspendStep(name, instance string) (*Step, error) {
+	if err := checkInstanceNames(name, []string{instance}); err != nil {
+		return nil, err
+	}
+	s, err := w.NewStep(name)
+	if err != nil {
+		return nil, err
+	}
+	s.Suspend = &Suspend{Instance: instance}
+	return s, nil
+}
+
+// NewResumeStep adds a Resume step resuming instance to this workflow, the
+// instance is in the workflow's project and zone.
+func (w *Workflow) NewResumeStep(name, instance string) (*Step, error) {
+	if err := checkInstanceNames(name, []string{instance}); err != nil {
+		return nil, err
+	}
+	s, err := w.NewStep(name)
+	if err != nil {
+		return nil, err
+	}
+	s.Resume = &Resume{Instance: instance}
+	return s, nil
+}
+
+func checkInstanceNames(step string, instances []string) error {
+	if len(instances) == 0 {
+		return fmt.Errorf("can't create step %q: no instances given", step)
+	}
+	for _, i := range instances {
+		if i == "" {
+			return fmt.Errorf("can't create step %q: empty instance name", step)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/api/compute/v1"
+)
+
+func TestStepBuilders(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+
+	cd, err := w.NewCreateDisksStep("create-disk", &Disk{Disk: compute.Disk{Name: "disk", SourceImage: fmt.Sprintf("projects/%s/global/images/%s", testProject, testImage)}})
+	if err != nil {
+		t.Fatalf("error building CreateDisks step: %v", err)
+	}
+	ci, err := w.NewCreateInstancesStep("create-instance", &Instance{Instance: compute.Instance{
+		Name:              "instance",
+		MachineType:       testMachineType,
+		Disks:             []*compute.AttachedDisk{{Source: "disk"}},
+		NetworkInterfaces: []*compute.NetworkInterface{{Network: fmt.Sprintf("projects/%s/global/networks/%s", testProject, testNetwork)}},
+	}})
+	if err != nil {
+		t.Fatalf("error building CreateInstances step: %v", err)
+	}
+	st, err := w.NewStopInstancesStep("stop-instance", "instance")
+	if err != nil {
+		t.Fatalf("error building StopInstances step: %v", err)
+	}
+	if err := w.AddDependency(ci, cd); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddDependency(st, ci); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.populate(ctx); err != nil {
+		t.Fatalf("error populating workflow: %v", err)
+	}
+	if err := w.validate(ctx); err != nil {
+		t.Fatalf("error validating workflow: %v", err)
+	}
+	if r, ok := w.instances.get("instance"); !ok || r.creator != ci {
+		t.Error("instance is not registered as created by the CreateInstances step")
+	}
+	if r, ok := w.disks.get("disk"); !ok || r.creator != cd {
+		t.Error("disk is not registered as created by the CreateDisks step")
+	}
+}
+
+func TestStepBuildersErrors(t *testing.T) {
+	w := testWorkflow()
+	if _, err := w.NewStep("taken"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc  string
+		build func() (*Step, error)
+	}{
+		{"no disks case", func() (*Step, error) { return w.NewCreateDisksStep("s") }},
+		{"disk without name case", func() (*Step, error) { return w.NewCreateDisksStep("s", &Disk{}) }},
+		{"no instances case", func() (*Step, error) { return w.NewCreateInstancesStep("s") }},
+		{"instance without name case", func() (*Step, error) { return w.NewCreateInstancesStep("s", &Instance{}) }},
+		{"no instances to start case", func() (*Step, error) { return w.NewStartInstancesStep("s") }},
+		{"empty instance to stop case", func() (*Step, error) { return w.NewStopInstancesStep("s", "") }},
+		{"empty instance to suspend case", func() (*Step, error) { return w.NewSuspendStep("s", "") }},
+		{"empty instance to resume case", func() (*Step, error) { return w.NewResumeStep("s", "") }},
+		{"step name taken case", func() (*Step, error) { return w.NewResumeStep("taken", "instance") }},
+	}
+	for _, tt := range tests {
+		if _, err := tt.build(); err == nil {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		}
+	}
+	if _, ok := w.Steps["s"]; ok {
+		t.Error("step was added to the workflow despite an error")
+	}
+}
@@ -0,0 +1,106 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// BulkInsertDisks is a Daisy BulkInsertDisks workflow step.
+type BulkInsertDisks []*BulkInsertDisk
+
+// BulkInsertDisk is used to clone the disks of a consistency group with a
+// single disks.bulkInsert request, which is all GCE can bulk insert disks
+// from, use CreateDisks for identical disks. Disks created this way are named
+// by GCE and are not tracked by the workflow, they will not be cleaned up.
+type BulkInsertDisk struct {
+	compute.BulkInsertDiskResource
+
+	// If this is unset Workflow.Project is used.
+	Project string `json:",omitempty"`
+	// If this is unset Workflow.Zone is used.
+	Zone string `json:",omitempty"`
+}
+
+func (b *BulkInsertDisks) populate(ctx context.Context, s *Step) DError {
+	for _, bd := range *b {
+		bd.Project = strOr(bd.Project, s.w.Project)
+		bd.Zone = strOr(bd.Zone, s.w.Zone)
+		if bd.SourceConsistencyGroupPolicy != "" {
+			bd.SourceConsistencyGroupPolicy = extendPartialURL(bd.SourceConsistencyGroupPolicy, bd.Project)
+		}
+	}
+	return nil
+}
+
+func (b *BulkInsertDisks) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, bd := range *b {
+		pre := fmt.Sprintf("cannot bulk insert disks in zone %q", bd.Zone)
+		if bd.SourceConsistencyGroupPolicy == "" {
+			errs = addErrs(errs, Errf("%s: SourceConsistencyGroupPolicy not set", pre))
+		}
+		if exists, err := projectExists(s.w.ComputeClient, bd.Project); err != nil {
+			errs = addErrs(errs, Errf("%s: bad project lookup: %q, error: %v", pre, bd.Project, err))
+		} else if !exists {
+			errs = addErrs(errs, Errf("%s: project does not exist: %q", pre, bd.Project))
+		}
+		if exists, err := s.w.zoneExists(bd.Project, bd.Zone); err != nil {
+			errs = addErrs(errs, Errf("%s: bad zone lookup: %q, error: %v", pre, bd.Zone, err))
+		} else if !exists {
+			errs = addErrs(errs, Errf("%s: zone does not exist: %q", pre, bd.Zone))
+		}
+	}
+	return errs
+}
+
+func (b *BulkInsertDisks) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, bd := range *b {
+		wg.Add(1)
+		go func(bd *BulkInsertDisk) {
+			defer wg.Done()
+
+			w.LogStepInfo(s.name, "BulkInsertDisks", "Bulk inserting disks in zone %q from %q.", bd.Zone, bd.SourceConsistencyGroupPolicy)
+			if err := w.preCreate("bulkInsertDisk", &bd.BulkInsertDiskResource); err != nil {
+				e <- newErr("failed to bulk insert disks", err)
+				return
+			}
+			if err := w.ComputeClient.BulkInsertDisk(bd.Project, bd.Zone, &bd.BulkInsertDiskResource); err != nil {
+				e <- newErr("failed to bulk insert disks", err)
+				return
+			}
+		}(bd)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		wg.Wait()
+		return nil
+	}
+}
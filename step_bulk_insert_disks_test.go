@@ -0,0 +1,115 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestBulkInsertDisksPopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	bds := &BulkInsertDisks{
+		{BulkInsertDiskResource: compute.BulkInsertDiskResource{SourceConsistencyGroupPolicy: "regions/r/resourcePolicies/p"}},
+		{BulkInsertDiskResource: compute.BulkInsertDiskResource{SourceConsistencyGroupPolicy: "projects/foo/regions/r/resourcePolicies/p"}, Project: "foo", Zone: "bar"},
+	}
+	if err := bds.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &BulkInsertDisks{
+		{BulkInsertDiskResource: compute.BulkInsertDiskResource{SourceConsistencyGroupPolicy: fmt.Sprintf("projects/%s/regions/r/resourcePolicies/p", testProject)}, Project: testProject, Zone: testZone},
+		{BulkInsertDiskResource: compute.BulkInsertDiskResource{SourceConsistencyGroupPolicy: "projects/foo/regions/r/resourcePolicies/p"}, Project: "foo", Zone: "bar"},
+	}
+	if diffRes := diff(bds, want, 0); diffRes != "" {
+		t.Errorf("populated BulkInsertDisks does not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestBulkInsertDisksValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	tests := []struct {
+		desc      string
+		bd        *BulkInsertDisk
+		shouldErr bool
+	}{
+		{"normal case", &BulkInsertDisk{BulkInsertDiskResource: compute.BulkInsertDiskResource{SourceConsistencyGroupPolicy: "p"}, Project: testProject, Zone: testZone}, false},
+		{"no policy case", &BulkInsertDisk{Project: testProject, Zone: testZone}, true},
+		{"bad project case", &BulkInsertDisk{BulkInsertDiskResource: compute.BulkInsertDiskResource{SourceConsistencyGroupPolicy: "p"}, Project: DNE, Zone: testZone}, true},
+		{"bad zone case", &BulkInsertDisk{BulkInsertDiskResource: compute.BulkInsertDiskResource{SourceConsistencyGroupPolicy: "p"}, Project: testProject, Zone: DNE}, true},
+	}
+	for _, tt := range tests {
+		bds := &BulkInsertDisks{tt.bd}
+		if err := bds.validate(ctx, s); err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestBulkInsertDisksRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	e := Errf("error")
+	tests := []struct {
+		desc      string
+		clientErr error
+		wantErr   DError
+	}{
+		{"normal case", nil, nil},
+		{"client error case", e, e},
+	}
+	for _, tt := range tests {
+		var gotProject, gotZone string
+		var gotReq compute.BulkInsertDiskResource
+		fake := func(p, z string, req *compute.BulkInsertDiskResource) error {
+			gotProject, gotZone, gotReq = p, z, *req
+			return tt.clientErr
+		}
+		w.ComputeClient = &daisyCompute.TestClient{BulkInsertDiskFn: fake}
+		var gotType string
+		w.PreCreateHook = func(resourceType string, _ interface{}) error {
+			gotType = resourceType
+			return nil
+		}
+		req := compute.BulkInsertDiskResource{SourceConsistencyGroupPolicy: "policy"}
+		bds := &BulkInsertDisks{{BulkInsertDiskResource: req, Project: testProject, Zone: testZone}}
+		if err := bds.run(ctx, s); err != tt.wantErr {
+			t.Errorf("%s: unexpected error returned, got: %v, want: %v", tt.desc, err, tt.wantErr)
+		}
+		if gotProject != testProject || gotZone != testZone {
+			t.Errorf("%s: client got incorrect project/zone, got: %s/%s, want: %s/%s", tt.desc, gotProject, gotZone, testProject, testZone)
+		}
+		if diffRes := diff(gotReq, req, 0); diffRes != "" {
+			t.Errorf("%s: client got incorrect request, got: %v, want: %v", tt.desc, gotReq, req)
+		}
+		if gotType != "bulkInsertDisk" {
+			t.Errorf("%s: PreCreateHook got incorrect resource type, got: %q, want: %q", tt.desc, gotType, "bulkInsertDisk")
+		}
+	}
+}
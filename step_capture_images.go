@@ -0,0 +1,203 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+// CaptureImages is a Daisy CaptureImages workflow step.
+type CaptureImages []*CaptureImage
+
+// CaptureImage creates an image from the boot disk of an instance. The disk
+// is made consistent first, either by stopping the instance or by flushing
+// its guest file systems.
+type CaptureImage struct {
+	// Name of the image.
+	Name string
+	// Family of the image.
+	Family string `json:",omitempty"`
+	// Instance whose boot disk is captured.
+	Instance string
+	// Stop the instance before creating the image, the instance is left
+	// stopped.
+	StopInstance bool `json:",omitempty"`
+	// Snapshot the boot disk with guest flush and create the image from the
+	// snapshot, which is deleted once the image is created.
+	GuestFlush bool `json:",omitempty"`
+	// Should the image be kept after the workflow?
+	NoCleanup bool `json:",omitempty"`
+	// If set, the image name is used as is instead of generating a name.
+	ExactName bool `json:",omitempty"`
+
+	image                       *Image
+	project, zone, instanceName string
+}
+
+func (ci *CaptureImage) populate(ctx context.Context, s *Step) DError {
+	if instanceURLRgx.MatchString(ci.Instance) {
+		ci.Instance = extendPartialURL(ci.Instance, s.w.Project)
+	}
+	ci.image = &Image{
+		ImageBase: ImageBase{Resource: Resource{NoCleanup: ci.NoCleanup, ExactName: ci.ExactName}},
+		Image:     compute.Image{Name: ci.Name, Family: ci.Family},
+	}
+	return (&ci.image.ImageBase).populate(ctx, ci.image, s)
+}
+
+func (ci *CaptureImage) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot capture image %q", ci.Name)
+	errs := ci.image.Resource.validate(ctx, s, pre)
+	if ci.StopInstance && ci.GuestFlush {
+		errs = addErrs(errs, Errf("%s: StopInstance and GuestFlush are mutually exclusive", pre))
+	}
+
+	ir, err := s.w.instances.regUse(ci.Instance, s)
+	if ir == nil {
+		// Return now, the rest of this function can't be run without ir.
+		return addErrs(errs, Errf("%s: %v", pre, err))
+	}
+	errs = addErrs(errs, err)
+	m := NamedSubexp(instanceURLRgx, ir.link)
+	ci.project, ci.zone, ci.instanceName = m["project"], m["zone"], m["instance"]
+
+	// Register image creation.
+	return addErrs(errs, s.w.images.regCreate(ci.image.daisyName, &ci.image.Resource, s, false))
+}
+
+// bootDisk returns the URL of the boot disk of the instance, the disk is in
+// the zone of the instance.
+func (ci *CaptureImage) bootDisk(w *Workflow) (string, DError) {
+	inst, err := w.ComputeClient.GetInstance(ci.project, ci.zone, ci.instanceName)
+	if err != nil {
+		return "", newErr("failed to get instance data", err)
+	}
+	for _, d := range inst.Disks {
+		if d.Boot {
+			return d.Source, nil
+		}
+	}
+	return "", Errf("instance %q has no boot disk", ci.instanceName)
+}
+
+func (ci *CaptureImage) run(ctx context.Context, s *Step) DError {
+	w := s.w
+	i := ci.image
+	// The instance may have been renamed since validation.
+	if ir, ok := w.instances.get(ci.Instance); ok {
+		ci.instanceName = NamedSubexp(instanceURLRgx, ir.link)["instance"]
+	}
+
+	if ci.StopInstance {
+		w.LogStepInfo(s.name, "CaptureImages", "Stopping instance %q.", ci.Instance)
+		if err := w.instances.stop(ci.Instance); err != nil {
+			return err
+		}
+	}
+	disk, err := ci.bootDisk(w)
+	if err != nil {
+		return err
+	}
+
+	if ci.GuestFlush {
+		ss := &compute.Snapshot{Name: w.genName(ci.Name + "-flush")}
+		w.LogStepInfo(s.name, "CaptureImages", "Creating snapshot %q of disk %q with guest flush.", ss.Name, disk)
+		if err := w.preCreate("snapshot", ss); err != nil {
+			return newErr("failed to create guest flush snapshot", err)
+		}
+		if err := w.ComputeClient.CreateSnapshotWithGuestFlush(ci.project, ci.zone, path.Base(disk), ss); err != nil {
+			return newErr("failed to create guest flush snapshot", err)
+		}
+		defer func() {
+			if err := w.ComputeClient.DeleteSnapshot(ci.project, ss.Name); err != nil && !daisyCompute.IsNotFound(err) {
+				w.LogStepInfo(s.name, "CaptureImages", "WARNING: failed to delete snapshot %q: %v", ss.Name, err)
+			}
+		}()
+		i.SourceSnapshot = fmt.Sprintf("projects/%s/global/snapshots/%s", ci.project, ss.Name)
+	} else {
+		i.SourceDisk = disk
+	}
+	// The disk of a running instance can only be captured when forced.
+	force := !ci.StopInstance && !ci.GuestFlush
+
+	w.LogStepInfo(s.name, "CaptureImages", "Creating image %q from instance %q.", i.Name, ci.Instance)
+	adopted, cErr := i.createOrAdopt(w, "image", &i.Image, func() error {
+		if force {
+			return w.ComputeClient.ForceCreateImage(i.Project, &i.Image)
+		}
+		return i.create(w.ComputeClient)
+	}, func() (interface{}, error) {
+		return w.ComputeClient.GetImage(i.Project, i.Name)
+	})
+	if cErr != nil {
+		return resourceErr(i.daisyName, newErr("failed to capture image", cErr))
+	}
+	if adopted {
+		w.LogStepInfo(s.name, "CaptureImages", "Image %q already exists, adopted it.", i.Name)
+	}
+	i.markCreatedInWorkflow()
+	return nil
+}
+
+func (c *CaptureImages) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, ci := range *c {
+		errs = addErrs(errs, ci.populate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CaptureImages) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, ci := range *c {
+		errs = addErrs(errs, ci.validate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CaptureImages) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, ci := range *c {
+		wg.Add(1)
+		go func(ci *CaptureImage) {
+			defer wg.Done()
+			if err := ci.run(ctx, s); err != nil {
+				e <- err
+			}
+		}(ci)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		// Wait so images being created now can be deleted.
+		wg.Wait()
+		return nil
+	}
+}
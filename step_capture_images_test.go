@@ -0,0 +1,142 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestCaptureImagesValidate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc      string
+		ci        *CaptureImage
+		shouldErr bool
+	}{
+		{"guest flush case", &CaptureImage{Name: "golden", Instance: "instance", GuestFlush: true}, false},
+		{"stop case", &CaptureImage{Name: "golden", Instance: "instance", StopInstance: true}, false},
+		{"running instance case", &CaptureImage{Name: "golden", Instance: "instance"}, false},
+		{"stop and guest flush case", &CaptureImage{Name: "golden", Instance: "instance", StopInstance: true, GuestFlush: true}, true},
+		{"bad instance case", &CaptureImage{Name: "golden", Instance: "bad"}, true},
+		{"bad name case", &CaptureImage{Name: "Bad_Name", Instance: "instance", ExactName: true}, true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{w: w}
+		w.instances.m = map[string]*Resource{"instance": {RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+		cis := &CaptureImages{tt.ci}
+		if err := cis.populate(ctx, s); err != nil {
+			t.Errorf("%s: populate error: %v", tt.desc, err)
+		}
+		if err := cis.validate(ctx, s); err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestCaptureImagesRun(t *testing.T) {
+	ctx := context.Background()
+	bootDisk := fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/zones/%s/disks/boot", testProject, testZone)
+
+	tests := []struct {
+		desc  string
+		ci    *CaptureImage
+		calls []string
+	}{
+		{
+			"guest flush case",
+			&CaptureImage{Name: "golden", Instance: "instance", GuestFlush: true, ExactName: true},
+			[]string{
+				"hook snapshot",
+				"snapshot boot golden-flush-test-wf-abcdef",
+				"hook image",
+				fmt.Sprintf("image golden from projects/%s/global/snapshots/golden-flush-test-wf-abcdef force=false", testProject),
+				"delete snapshot golden-flush-test-wf-abcdef",
+			},
+		},
+		{
+			"stop case",
+			&CaptureImage{Name: "golden", Instance: "instance", StopInstance: true, ExactName: true},
+			[]string{"stop " + testInstance, "hook image", fmt.Sprintf("image golden from %s force=false", bootDisk)},
+		},
+		{
+			"running instance case",
+			&CaptureImage{Name: "golden", Instance: "instance", ExactName: true},
+			[]string{"hook image", fmt.Sprintf("image golden from %s force=true", bootDisk)},
+		},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{name: "capture", w: w}
+		w.instances.m = map[string]*Resource{"instance": {RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+
+		cis := &CaptureImages{tt.ci}
+		if err := cis.populate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected populate error: %v", tt.desc, err)
+		}
+		if err := cis.validate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected validate error: %v", tt.desc, err)
+		}
+
+		var calls []string
+		w.PreCreateHook = func(resourceType string, _ interface{}) error {
+			calls = append(calls, "hook "+resourceType)
+			return nil
+		}
+		w.ComputeClient = &daisyCompute.TestClient{
+			GetInstanceFn: func(project, zone, name string) (*compute.Instance, error) {
+				return &compute.Instance{Disks: []*compute.AttachedDisk{{Source: "data"}, {Boot: true, Source: bootDisk}}}, nil
+			},
+			StopInstanceFn: func(project, zone, name string) error {
+				calls = append(calls, "stop "+name)
+				return nil
+			},
+			CreateSnapshotWithGuestFlushFn: func(project, zone, disk string, ss *compute.Snapshot) error {
+				calls = append(calls, fmt.Sprintf("snapshot %s %s", disk, ss.Name))
+				return nil
+			},
+			CreateImageFn: func(project string, i *compute.Image) error {
+				calls = append(calls, fmt.Sprintf("image %s from %s%s force=false", i.Name, i.SourceSnapshot, i.SourceDisk))
+				return nil
+			},
+			ForceCreateImageFn: func(project string, i *compute.Image) error {
+				calls = append(calls, fmt.Sprintf("image %s from %s%s force=true", i.Name, i.SourceSnapshot, i.SourceDisk))
+				return nil
+			},
+			DeleteSnapshotFn: func(project, name string) error {
+				calls = append(calls, "delete snapshot "+name)
+				return nil
+			},
+		}
+
+		if err := cis.run(ctx, s); err != nil {
+			t.Errorf("%s: unexpected run error: %v", tt.desc, err)
+		}
+		if diffRes := diff(calls, tt.calls, 0); diffRes != "" {
+			t.Errorf("%s: API calls do not match expectation: (-got +want)\n%s", tt.desc, diffRes)
+		}
+		if !tt.ci.image.createdInWorkflow {
+			t.Errorf("%s: image not marked as created in workflow", tt.desc)
+		}
+	}
+}
@@ -69,28 +69,10 @@ func (c *CopyGCSObjects) validate(ctx context.Context, s *Step) DError {
 		}
 		readableBkts.mx.Unlock()
 
-		// Check if destination bucket exists and is readable.
-		writableBkts.mx.Lock()
-		if !strIn(dBkt, writableBkts.bkts) {
-			if _, err := s.w.StorageClient.Bucket(dBkt).Attrs(ctx); err != nil {
-				return Errf("error reading bucket %q: %v", dBkt, err)
-			}
-
-			// Check if destination bucket is writable.
-			tObj := s.w.StorageClient.Bucket(dBkt).Object(fmt.Sprintf("daisy-validate-%s-%s", s.name, s.w.id))
-			w := tObj.NewWriter(ctx)
-			if _, err := w.Write(nil); err != nil {
-				return newErr("failed to ", err)
-			}
-			if err := w.Close(); err != nil {
-				return Errf("error writing to bucket %q: %v", dBkt, err)
-			}
-			if err := tObj.Delete(ctx); err != nil {
-				return Errf("error deleting file %+v after write validation: %v", tObj, err)
-			}
-			writableBkts.bkts = append(writableBkts.bkts, dBkt)
+		// Check if destination bucket exists and is writable.
+		if err := checkBucketWritable(ctx, s, dBkt); err != nil {
+			return err
 		}
-		writableBkts.mx.Unlock()
 
 		// Check each ACLRule
 		for _, acl := range co.ACLRules {
@@ -0,0 +1,94 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+)
+
+// CreateAddresses is a Daisy CreateAddresses workflow step. It reserves
+// static internal IPs, instances of later steps use a reserved IP by setting
+// the NetworkIP of a network interface to the address name.
+type CreateAddresses []*Address
+
+func (c *CreateAddresses) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, a := range *c {
+		errs = addErrs(errs, a.populate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateAddresses) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, a := range *c {
+		errs = addErrs(errs, a.validate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateAddresses) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, a := range *c {
+		wg.Add(1)
+		go func(a *Address) {
+			defer wg.Done()
+
+			if subnetworkRes, ok := w.subnetworks.get(a.Subnetwork); ok {
+				a.Subnetwork = subnetworkRes.link
+			}
+
+			w.LogStepInfo(s.name, "CreateAddresses", "Creating address %q.", a.Name)
+			adopted, err := a.createOrAdopt(w, "address", &a.Address, func() error {
+				return w.ComputeClient.CreateAddress(a.Project, a.Region, &a.Address)
+			}, func() (interface{}, error) {
+				return w.ComputeClient.GetAddress(a.Project, a.Region, a.Name)
+			})
+			if err != nil {
+				e <- resourceErr(a.daisyName, newErr("failed to create addresses", err))
+				return
+			}
+			a.markCreated()
+			if adopted {
+				w.LogStepInfo(s.name, "CreateAddresses", "Address %q already exists, adopted it.", a.Name)
+				existing, err := w.ComputeClient.GetAddress(a.Project, a.Region, a.Name)
+				if err != nil {
+					e <- resourceErr(a.daisyName, newErr("failed to get adopted address", err))
+					return
+				}
+				a.Address = *existing
+			}
+			w.addresses.setIP(a.daisyName, a.Address.Address)
+			w.LogStepInfo(s.name, "CreateAddresses", "Address %q reserved IP %s.", a.Name, a.Address.Address)
+		}(a)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		// Wait so addresses being created now can be deleted.
+		wg.Wait()
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestCreateAddressesRun(t *testing.T) {
+	ctx := context.Background()
+	e := Errf("error")
+
+	tests := []struct {
+		desc      string
+		ip        string
+		clientErr error
+		wantErr   DError
+		wantIP    string
+	}{
+		{"auto-allocated case", "", nil, nil, "10.128.0.2"},
+		{"specified IP case", "10.128.0.5", nil, nil, "10.128.0.5"},
+		{"client error case", "", e, resourceErr("address", e), ""},
+	}
+
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{w: w}
+		w.subnetworks.m = map[string]*Resource{"subnet": {link: fmt.Sprintf("projects/%s/regions/%s/subnetworks/real-subnet", testProject, testRegion)}}
+
+		var gotA compute.Address
+		w.ComputeClient = &daisyCompute.TestClient{
+			CreateAddressFn: func(_, _ string, a *compute.Address) error {
+				gotA = *a
+				if tt.clientErr != nil {
+					return tt.clientErr
+				}
+				if a.Address == "" {
+					a.Address = "10.128.0.2"
+				}
+				return nil
+			},
+		}
+		ca := &CreateAddresses{{Address: compute.Address{Name: "address", Subnetwork: "subnet", Address: tt.ip}}}
+		if err := ca.populate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected populate error: %v", tt.desc, err)
+		}
+		if err := ca.run(ctx, s); fmt.Sprint(err) != fmt.Sprint(tt.wantErr) {
+			t.Errorf("%s: unexpected error returned, got: %v, want: %v", tt.desc, err, tt.wantErr)
+		}
+		if gotA.AddressType != addressTypeInternal || gotA.Subnetwork != fmt.Sprintf("projects/%s/regions/%s/subnetworks/real-subnet", testProject, testRegion) || gotA.Address != tt.ip {
+			t.Errorf("%s: client got incorrect address: %+v", tt.desc, gotA)
+		}
+
+		ip, ok := w.addresses.ip("address")
+		if ok != (tt.wantIP != "") || ip != tt.wantIP {
+			t.Errorf("%s: unexpected reserved IP, got: %q %t, want: %q", tt.desc, ip, ok, tt.wantIP)
+		}
+
+		// Instances created later use the reserved IP.
+		i := &Instance{Instance: compute.Instance{NetworkInterfaces: []*compute.NetworkInterface{{NetworkIP: "address"}}}}
+		i.updateDisksAndNetworksBeforeCreate(w)
+		if want := strOr(tt.wantIP, "address"); i.NetworkInterfaces[0].NetworkIP != want {
+			t.Errorf("%s: unexpected instance NetworkIP, got: %q, want: %q", tt.desc, i.NetworkInterfaces[0].NetworkIP, want)
+		}
+	}
+}
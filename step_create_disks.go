@@ -16,8 +16,6 @@ package daisy
 
 import (
 	"context"
-	"fmt"
-	"regexp"
 	"sync"
 
 	"github.com/GoogleCloudPlatform/compute-daisy/compute"
@@ -66,11 +64,19 @@ func (c *CreateDisks) run(ctx context.Context, s *Step) DError {
 			}
 
 			w.LogStepInfo(s.name, "CreateDisks", "Creating disk %q.", cd.Name)
-			if err := w.ComputeClient.CreateDisk(cd.Project, cd.Zone, &cd.Disk); err != nil {
-				e <- newErr("failed to create disk", err)
+			adopted, err := cd.createOrAdopt(w, "disk", &cd.Disk, func() error {
+				return w.ComputeClient.CreateDisk(cd.Project, cd.Zone, &cd.Disk)
+			}, func() (interface{}, error) {
+				return w.ComputeClient.GetDisk(cd.Project, cd.Zone, cd.Name)
+			})
+			if err != nil {
+				e <- resourceErr(cd.daisyName, newErr("failed to create disk", err))
 				return
 			}
-			cd.createdInWorkflow = true
+			if adopted {
+				w.LogStepInfo(s.name, "CreateDisks", "Disk %q already exists, adopted it.", cd.Name)
+			}
+			cd.markCreated()
 		}(d)
 	}
 
@@ -89,8 +95,6 @@ func (c *CreateDisks) run(ctx context.Context, s *Step) DError {
 	}
 }
 
-var operationErrorCodeRegex = regexp.MustCompile(fmt.Sprintf("(?m)^"+compute.OperationErrorCodeFormat+"$", "QUOTA_EXCEEDED"))
-
 func isQuotaExceeded(err error) bool {
-	return operationErrorCodeRegex.FindIndex([]byte(err.Error())) != nil
+	return compute.HasOperationErrorCode(err, "QUOTA_EXCEEDED")
 }
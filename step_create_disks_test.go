@@ -16,10 +16,13 @@ package daisy
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"testing"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
 func TestCreateDisksRun(t *testing.T) {
@@ -64,3 +67,82 @@ func TestCreateDisksRun(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateDisksRunErrorBreadcrumb(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	w.ComputeClient = &daisyCompute.TestClient{CreateDiskFn: func(_, _ string, _ *compute.Disk) error {
+		return errors.New("quota exceeded")
+	}}
+	s, err := w.NewStep("create-disks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Disk{Disk: compute.Disk{Name: "real-name"}}
+	d.daisyName = "my-disk"
+	s.CreateDisks = &CreateDisks{d}
+
+	err = s.run(ctx)
+	if err == nil {
+		t.Fatal("should have returned an error")
+	}
+	if want := `step "create-disks" run error: resource "my-disk": quota exceeded`; err.Error() != want {
+		t.Errorf("error does not contain the step and resource breadcrumb, got: %q, want: %q", err.Error(), want)
+	}
+}
+
+func TestCreateDisksRunAPIError(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	w.ComputeClient = &daisyCompute.TestClient{CreateDiskFn: func(_, _ string, _ *compute.Disk) error {
+		return &googleapi.Error{Code: http.StatusForbidden, Message: "permission denied"}
+	}}
+	s, err := w.NewStep("create-disks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Disk{Disk: compute.Disk{Name: "real-name"}}
+	d.daisyName = "my-disk"
+	s.CreateDisks = &CreateDisks{d}
+
+	var gErr *googleapi.Error
+	if err := s.run(ctx); !errors.As(err, &gErr) {
+		t.Fatalf("*googleapi.Error not found in %v", err)
+	}
+	if gErr.Code != http.StatusForbidden {
+		t.Errorf("unexpected HTTP code, got: %d, want: %d", gErr.Code, http.StatusForbidden)
+	}
+}
+
+func TestCreateDisksRunExistsOk(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	existing := &compute.Disk{Name: "disk", SizeGb: 10}
+	w.ComputeClient = &daisyCompute.TestClient{
+		CreateDiskFn: func(_, _ string, _ *compute.Disk) error {
+			return &googleapi.Error{Code: http.StatusConflict}
+		},
+		GetDiskFn: func(_, _, _ string) (*compute.Disk, error) {
+			return existing, nil
+		},
+	}
+
+	d := &Disk{Disk: compute.Disk{Name: "disk", SizeGb: 10}}
+	d.ExistsOk = true
+	if err := (&CreateDisks{d}).run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.createdInWorkflow || !d.adopted {
+		t.Error("adopted disk not marked as created in workflow and adopted")
+	}
+
+	// Config mismatch case.
+	existing.SizeGb = 20
+	d = &Disk{Disk: compute.Disk{Name: "disk", SizeGb: 10}}
+	d.ExistsOk = true
+	if err := (&CreateDisks{d}).run(ctx, s); err == nil {
+		t.Error("should have returned an error for a config mismatch, but didn't")
+	}
+}
@@ -52,11 +52,19 @@ func (c *CreateFirewallRules) run(ctx context.Context, s *Step) DError {
 			}
 
 			w.LogStepInfo(s.name, "CreateFirewallRules", "Creating firewall rule %q.", fir.Name)
-			if err := w.ComputeClient.CreateFirewallRule(fir.Project, &fir.Firewall); err != nil {
-				e <- newErr("failed to create firewall", err)
+			adopted, err := fir.createOrAdopt(w, "firewallRule", &fir.Firewall, func() error {
+				return w.ComputeClient.CreateFirewallRule(fir.Project, &fir.Firewall)
+			}, func() (interface{}, error) {
+				return w.ComputeClient.GetFirewallRule(fir.Project, fir.Name)
+			})
+			if err != nil {
+				e <- resourceErr(fir.daisyName, newErr("failed to create firewall", err))
 				return
 			}
-			fir.createdInWorkflow = true
+			if adopted {
+				w.LogStepInfo(s.name, "CreateFirewallRules", "Firewall rule %q already exists, adopted it.", fir.Name)
+			}
+			fir.markCreated()
 		}(fir)
 	}
 
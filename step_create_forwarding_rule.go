@@ -48,11 +48,19 @@ func (c *CreateForwardingRules) run(ctx context.Context, s *Step) DError {
 			defer wg.Done()
 
 			w.LogStepInfo(s.name, "CreateForwardingRules", "Creating forwarding-rule %q.", fr.Name)
-			if err := w.ComputeClient.CreateForwardingRule(fr.Project, fr.Region, &fr.ForwardingRule); err != nil {
-				e <- newErr("failed to create forwarding rules", err)
+			adopted, err := fr.createOrAdopt(w, "forwardingRule", &fr.ForwardingRule, func() error {
+				return w.ComputeClient.CreateForwardingRule(fr.Project, fr.Region, &fr.ForwardingRule)
+			}, func() (interface{}, error) {
+				return w.ComputeClient.GetForwardingRule(fr.Project, fr.Region, fr.Name)
+			})
+			if err != nil {
+				e <- resourceErr(fr.daisyName, newErr("failed to create forwarding rules", err))
 				return
 			}
-			fr.createdInWorkflow = true
+			if adopted {
+				w.LogStepInfo(s.name, "CreateForwardingRules", "Forwarding-rule %q already exists, adopted it.", fr.Name)
+			}
+			fr.markCreated()
 		}(fr)
 	}
 
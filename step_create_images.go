@@ -19,7 +19,7 @@ import (
 	"encoding/json"
 	"sync"
 
-	"google.golang.org/api/googleapi"
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 )
 
 // CreateImages is a Daisy CreateImages workflow step.
@@ -115,7 +115,7 @@ func (ci *CreateImages) run(ctx context.Context, s *Step) DError {
 	w := s.w
 	e := make(chan DError)
 
-	createImage := func(ci ImageInterface, overwrite bool) {
+	createImage := func(ci ImageInterface, ib *ImageBase, desired interface{}) {
 		defer wg.Done()
 		// Get source disk link if SourceDisk is a daisy reference to a disk.
 		if d, ok := w.disks.get(ci.getSourceDisk()); ok {
@@ -123,38 +123,44 @@ func (ci *CreateImages) run(ctx context.Context, s *Step) DError {
 		}
 
 		// Delete existing if OverWrite is true.
-		if overwrite {
+		if ib.OverWrite {
 			// Just try to delete it, a 404 here indicates the image doesn't exist.
-			if err := ci.delete(w.ComputeClient); err != nil {
-				if apiErr, ok := err.(*googleapi.Error); !ok || apiErr.Code != 404 {
-					e <- Errf("error deleting existing image: %v", err)
-					return
-				}
+			if err := ci.delete(w.ComputeClient); err != nil && !daisyCompute.IsNotFound(err) {
+				e <- resourceErr(ib.daisyName, Errf("error deleting existing image: %v", err))
+				return
 			}
 		}
 
 		w.LogStepInfo(s.name, "CreateImages", "Creating image %q.", ci.getName())
-		if err := ci.create(w.ComputeClient); err != nil {
-			e <- newErr("failed to create images", err)
+		adopted, err := ib.createOrAdopt(w, "image", desired, func() error {
+			return ci.create(w.ComputeClient)
+		}, func() (interface{}, error) {
+			return w.ComputeClient.GetImage(ib.Project, ci.getName())
+		})
+		if err != nil {
+			e <- resourceErr(ib.daisyName, newErr("failed to create images", err))
 			return
 		}
+		if adopted {
+			w.LogStepInfo(s.name, "CreateImages", "Image %q already exists, adopted it.", ci.getName())
+		}
 		ci.markCreatedInWorkflow()
 	}
 
 	if imageUsesAlphaFeatures(ci.ImagesAlpha) {
 		for _, i := range ci.ImagesAlpha {
 			wg.Add(1)
-			go createImage(i, i.OverWrite)
+			go createImage(i, &i.ImageBase, &i.Image)
 		}
 	} else if imageUsesBetaFeatures(ci.ImagesBeta) {
 		for _, i := range ci.ImagesBeta {
 			wg.Add(1)
-			go createImage(i, i.OverWrite)
+			go createImage(i, &i.ImageBase, &i.Image)
 		}
 	} else {
 		for _, i := range ci.Images {
 			wg.Add(1)
-			go createImage(i, i.OverWrite)
+			go createImage(i, &i.ImageBase, &i.Image)
 		}
 	}
 
@@ -25,6 +25,7 @@ import (
 	"sync"
 	"time"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/googleapi"
 )
 
@@ -159,14 +160,12 @@ func (ci *CreateInstances) run(ctx context.Context, s *Step) DError {
 	var wg sync.WaitGroup
 	w := s.w
 	eChan := make(chan DError)
-	createInstance := func(ii InstanceInterface, ib *InstanceBase) {
+	createInstance := func(ii InstanceInterface, ib *InstanceBase, desired interface{}) {
 		// Just try to delete it, a 404 here indicates the instance doesn't exist.
 		if ib.OverWrite {
-			if err := ii.delete(w.ComputeClient, true); err != nil {
-				if apiErr, ok := err.(*googleapi.Error); !ok || apiErr.Code != 404 {
-					eChan <- Errf("error deleting existing instance: %v", err)
-					return
-				}
+			if err := ii.delete(w.ComputeClient, true); err != nil && !daisyCompute.IsNotFound(err) {
+				eChan <- resourceErr(ib.daisyName, Errf("error deleting existing instance: %v", err))
+				return
 			}
 		}
 
@@ -179,25 +178,40 @@ func (ci *CreateInstances) run(ctx context.Context, s *Step) DError {
 		defer wg.Done()
 		ii.updateDisksAndNetworksBeforeCreate(w)
 
+		if err := ib.checkReservationAffinity(ii, w); err != nil {
+			eChan <- resourceErr(ib.daisyName, err)
+			return
+		}
+
 		w.LogStepInfo(s.name, "CreateInstances", "Creating instance %q.", ii.getName())
 
-		if err := ii.create(w.ComputeClient); err != nil {
+		adopted, err := ib.createOrAdopt(w, "instance", desired, func() error {
+			err := ii.create(w.ComputeClient)
 			// Fallback to no-external-ip mode to workaround organization policy.
-			if ib.RetryWhenExternalIPDenied && isExternalIPDeniedByOrganizationPolicy(err) {
+			if err != nil && ib.RetryWhenExternalIPDenied && isExternalIPDeniedByOrganizationPolicy(err) {
 				w.LogStepInfo(s.name, "CreateInstances", "Falling back to no-external-ip mode "+
 					"for creating instance %v due to the fact that external IP is denied by organization policy.", ii.getName())
 
 				UpdateInstanceNoExternalIP(s)
 				err = ii.create(w.ComputeClient)
 			}
-
-			if err != nil {
-				eChan <- newErr("failed to create instances", err)
-				return
-			}
+			return err
+		}, func() (interface{}, error) {
+			return w.ComputeClient.GetInstance(ib.Project, ii.getZone(), ii.getName())
+		})
+		if err != nil {
+			eChan <- resourceErr(ib.daisyName, newErr("failed to create instances", err))
+			return
+		}
+		if adopted {
+			w.LogStepInfo(s.name, "CreateInstances", "Instance %q already exists, adopted it.", ii.getName())
 		}
 
-		ib.createdInWorkflow = true
+		// The instance is read back by create, or when adopted, with the IPs
+		// assigned to it.
+		w.addInstanceIPs(ib.daisyName, ii.getIPs())
+
+		ib.markCreated()
 		for _, port := range ib.SerialPortsToLog {
 			go logSerialOutput(ctx, s, ii, ib, port, 3*time.Second)
 		}
@@ -206,12 +220,12 @@ func (ci *CreateInstances) run(ctx context.Context, s *Step) DError {
 	if ci.instanceUsesBetaFeatures() {
 		for _, i := range ci.InstancesBeta {
 			wg.Add(1)
-			go createInstance(i, &i.InstanceBase)
+			go createInstance(i, &i.InstanceBase, &i.Instance)
 		}
 	} else {
 		for _, i := range ci.Instances {
 			wg.Add(1)
-			go createInstance(i, &i.InstanceBase)
+			go createInstance(i, &i.InstanceBase, &i.Instance)
 		}
 	}
 
@@ -235,6 +249,10 @@ func (ci *CreateInstances) instanceUsesBetaFeatures() bool {
 		if instanceBeta != nil && instanceBeta.SourceMachineImage != "" {
 			return true
 		}
+		// ConfidentialInstanceType is only available in the Beta API.
+		if instanceBeta != nil && instanceBeta.ConfidentialInstanceConfig != nil && instanceBeta.ConfidentialInstanceConfig.ConfidentialInstanceType != "" {
+			return true
+		}
 	}
 	// if GA instances collection is empty, switch to Beta
 	return len(ci.Instances) == 0
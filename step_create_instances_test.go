@@ -195,7 +195,7 @@ func TestCreateInstancesRun(t *testing.T) {
 			{InstanceBase: InstanceBase{Resource: Resource{daisyName: "i0"}}, Instance: compute.Instance{Name: "realI0", MachineType: "foo-type", Disks: []*compute.AttachedDisk{{Source: "d0"}}}},
 		},
 	}
-	if err := ci.run(ctx, s); err != createErr {
+	if err := ci.run(ctx, s); err == nil || err.Error() != `resource "i0": client error` {
 		t.Errorf("CreateInstances.run() should have return compute client error: %v != %v", err, createErr)
 	}
 	ci = &CreateInstances{
@@ -203,7 +203,59 @@ func TestCreateInstancesRun(t *testing.T) {
 			{InstanceBase: InstanceBase{Resource: Resource{daisyName: "i0"}}, Instance: computeBeta.Instance{Name: "realI0", MachineType: "foo-type", Disks: []*computeBeta.AttachedDisk{{Source: "d0"}}}},
 		},
 	}
-	if err := ci.run(ctx, s); err != createErr {
+	if err := ci.run(ctx, s); err == nil || err.Error() != `resource "i0": client error` {
 		t.Errorf("CreateInstances.run() should have return compute client error: %v != %v", err, createErr)
 	}
 }
+
+func TestCreateInstancesRunInstanceIPs(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	// Like the real client, create reads back the instance with its IPs.
+	w.ComputeClient.(*daisyCompute.TestClient).CreateInstanceFn = func(_, _ string, i *compute.Instance) error {
+		switch i.Name {
+		case "real-external":
+			i.NetworkInterfaces = []*compute.NetworkInterface{
+				{NetworkIP: "10.0.0.2", AccessConfigs: []*compute.AccessConfig{{NatIP: "203.0.113.5"}}},
+				{NetworkIP: "10.1.0.2"},
+			}
+		case "real-internal":
+			i.NetworkInterfaces = []*compute.NetworkInterface{{NetworkIP: "10.0.0.3"}}
+		}
+		return nil
+	}
+	w.ComputeClient.(*daisyCompute.TestClient).GetInstanceFn = func(_, _, _ string) (*compute.Instance, error) {
+		t.Error("instance read again after create")
+		return nil, errors.New("error")
+	}
+
+	ci := &CreateInstances{Instances: []*Instance{
+		{InstanceBase: InstanceBase{Resource: Resource{daisyName: "external"}}, Instance: compute.Instance{Name: "real-external"}},
+		{InstanceBase: InstanceBase{Resource: Resource{daisyName: "internal"}}, Instance: compute.Instance{Name: "real-internal"}},
+		{InstanceBase: InstanceBase{Resource: Resource{daisyName: "none"}}, Instance: compute.Instance{Name: "real-none"}},
+	}}
+	if err := ci.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		want   InstanceIPs
+		wantOk bool
+	}{
+		{"external", InstanceIPs{Internal: []string{"10.0.0.2", "10.1.0.2"}, External: []string{"203.0.113.5"}}, true},
+		{"internal", InstanceIPs{Internal: []string{"10.0.0.3"}}, true},
+		{"none", InstanceIPs{}, true},
+		{"dne", InstanceIPs{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := w.GetInstanceIPs(tt.name)
+		if ok != tt.wantOk {
+			t.Errorf("%s: unexpected ok, got: %t, want: %t", tt.name, ok, tt.wantOk)
+		}
+		if diffRes := diff(got, tt.want, 0); diffRes != "" {
+			t.Errorf("%s: IPs do not match expectation: (-got +want)\n%s", tt.name, diffRes)
+		}
+	}
+}
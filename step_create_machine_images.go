@@ -18,7 +18,7 @@ import (
 	"context"
 	"sync"
 
-	"google.golang.org/api/googleapi"
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 )
 
 // CreateMachineImages is a Daisy workflow step for creating machine images.
@@ -60,21 +60,27 @@ func (c *CreateMachineImages) run(ctx context.Context, s *Step) DError {
 			// Delete existing machine image if OverWrite is true.
 			if mi.OverWrite {
 				// Just try to delete it, a 404 here indicates the machine image doesn't exist.
-				if err := w.ComputeClient.DeleteMachineImage(mi.Project, mi.Name); err != nil {
-					if apiErr, ok := err.(*googleapi.Error); !ok || apiErr.Code != 404 {
-						eChan <- Errf("error deleting existing machine image: %v", err)
-						return
-					}
+				if err := w.ComputeClient.DeleteMachineImage(mi.Project, mi.Name); err != nil && !daisyCompute.IsNotFound(err) {
+					eChan <- resourceErr(mi.daisyName, Errf("error deleting existing machine image: %v", err))
+					return
 				}
 			}
 
 			w.LogStepInfo(s.name, "CreateMachineImages", "Creating machine image %q.", mi.Name)
 
-			if err := w.ComputeClient.CreateMachineImage(mi.Project, &mi.MachineImage); err != nil {
-				eChan <- newErr("failed to create machine image", err)
+			adopted, err := mi.createOrAdopt(w, "machineImage", &mi.MachineImage, func() error {
+				return w.ComputeClient.CreateMachineImage(mi.Project, &mi.MachineImage)
+			}, func() (interface{}, error) {
+				return w.ComputeClient.GetMachineImage(mi.Project, mi.Name)
+			})
+			if err != nil {
+				eChan <- resourceErr(mi.daisyName, newErr("failed to create machine image", err))
 				return
 			}
-			mi.createdInWorkflow = true
+			if adopted {
+				w.LogStepInfo(s.name, "CreateMachineImages", "Machine image %q already exists, adopted it.", mi.Name)
+			}
+			mi.markCreated()
 		}(ci)
 	}
 
@@ -92,7 +92,7 @@ func TestCreateMachineImagesRunFailureOnComputeCreateError(t *testing.T) {
 	cmi := &CreateMachineImages{
 		{Resource: Resource{daisyName: "mi0"}, MachineImage: compute.MachineImage{Name: "realMI0", SourceInstance: "si"}},
 	}
-	if err := cmi.run(ctx, s); err != createErr {
+	if err := cmi.run(ctx, s); err == nil || err.Error() != `resource "mi0": client error` {
 		t.Errorf("CreateInstances.run() should have return compute client error: %v != %v", err, createErr)
 	}
 }
@@ -114,7 +114,7 @@ func TestCreateMachineImagesRunFailureOnComputeDeleteOnOverwriteError(t *testing
 	cmi := &CreateMachineImages{
 		{OverWrite: true, Resource: Resource{daisyName: "mi0"}, MachineImage: compute.MachineImage{Name: "realMI0", SourceInstance: "si"}},
 	}
-	expectedErrorMessage := fmt.Sprintf("error deleting existing machine image: %v", deleteErr)
+	expectedErrorMessage := fmt.Sprintf("resource \"mi0\": error deleting existing machine image: %v", deleteErr)
 	if err := cmi.run(ctx, s); fmt.Sprintf("%v", err) != expectedErrorMessage {
 		t.Errorf("CreateInstances.run() should have return compute client error: %v != %v", err, expectedErrorMessage)
 	}
@@ -48,11 +48,19 @@ func (c *CreateNetworks) run(ctx context.Context, s *Step) DError {
 			defer wg.Done()
 
 			w.LogStepInfo(s.name, "CreateNetworks", "Creating network %q.", n.Name)
-			if err := w.ComputeClient.CreateNetwork(n.Project, &n.Network); err != nil {
-				e <- newErr("failed to create networks", err)
+			adopted, err := n.createOrAdopt(w, "network", &n.Network, func() error {
+				return w.ComputeClient.CreateNetwork(n.Project, &n.Network)
+			}, func() (interface{}, error) {
+				return w.ComputeClient.GetNetwork(n.Project, n.Name)
+			})
+			if err != nil {
+				e <- resourceErr(n.daisyName, newErr("failed to create networks", err))
 				return
 			}
-			n.createdInWorkflow = true
+			if adopted {
+				w.LogStepInfo(s.name, "CreateNetworks", "Network %q already exists, adopted it.", n.Name)
+			}
+			n.markCreated()
 		}(n)
 	}
 
@@ -59,11 +59,19 @@ func (c *CreateSnapshots) run(ctx context.Context, s *Step) DError {
 
 		m := NamedSubexp(diskURLRgx, ss.SourceDisk)
 		w.LogStepInfo(s.name, "CreateSnapshots", "Creating snapshot %q.", ss.Name)
-		if err := w.ComputeClient.CreateSnapshot(m["project"], m["zone"], m["disk"], &ss.Snapshot); err != nil {
-			e <- newErr("failed to create snapshots", err)
+		adopted, err := ss.createOrAdopt(w, "snapshot", &ss.Snapshot, func() error {
+			return w.ComputeClient.CreateSnapshot(m["project"], m["zone"], m["disk"], &ss.Snapshot)
+		}, func() (interface{}, error) {
+			return w.ComputeClient.GetSnapshot(m["project"], ss.Name)
+		})
+		if err != nil {
+			e <- resourceErr(ss.daisyName, newErr("failed to create snapshots", err))
 			return
 		}
-		ss.createdInWorkflow = true
+		if adopted {
+			w.LogStepInfo(s.name, "CreateSnapshots", "Snapshot %q already exists, adopted it.", ss.Name)
+		}
+		ss.markCreated()
 	}
 
 	for _, ss := range *c {
@@ -59,7 +59,7 @@ func TestCreateSnapshotsRunFailureOnComputeCreateError(t *testing.T) {
 	css := &CreateSnapshots{
 		{Resource: Resource{daisyName: "ss0"}, Snapshot: compute.Snapshot{Name: "realSS0", SourceDisk: "sd"}},
 	}
-	if err := css.run(ctx, s); err != createErr {
+	if err := css.run(ctx, s); err == nil || err.Error() != `resource "ss0": client error` {
 		t.Errorf("CreateSnapshot.run() should have return compute client error: %v != %v", err, createErr)
 	}
 }
@@ -52,11 +52,19 @@ func (c *CreateSubnetworks) run(ctx context.Context, s *Step) DError {
 			}
 
 			w.LogStepInfo(s.name, "CreateSubnetworks", "Creating subnetwork %q.", sn.Name)
-			if err := w.ComputeClient.CreateSubnetwork(sn.Project, sn.Region, &sn.Subnetwork); err != nil {
-				e <- newErr("failed to create subnetworks", err)
+			adopted, err := sn.createOrAdopt(w, "subnetwork", &sn.Subnetwork, func() error {
+				return w.ComputeClient.CreateSubnetwork(sn.Project, sn.Region, &sn.Subnetwork)
+			}, func() (interface{}, error) {
+				return w.ComputeClient.GetSubnetwork(sn.Project, sn.Region, sn.Name)
+			})
+			if err != nil {
+				e <- resourceErr(sn.daisyName, newErr("failed to create subnetworks", err))
 				return
 			}
-			sn.createdInWorkflow = true
+			if adopted {
+				w.LogStepInfo(s.name, "CreateSubnetworks", "Subnetwork %q already exists, adopted it.", sn.Name)
+			}
+			sn.markCreated()
 		}(sn)
 	}
 
@@ -48,11 +48,19 @@ func (c *CreateTargetInstances) run(ctx context.Context, s *Step) DError {
 			defer wg.Done()
 
 			w.LogStepInfo(s.name, "CreateTargetInstances", "Creating target instance %q.", ti.Name)
-			if err := w.ComputeClient.CreateTargetInstance(ti.Project, ti.Zone, &ti.TargetInstance); err != nil {
-				e <- newErr("failed to create target instances", err)
+			adopted, err := ti.createOrAdopt(w, "targetInstance", &ti.TargetInstance, func() error {
+				return w.ComputeClient.CreateTargetInstance(ti.Project, ti.Zone, &ti.TargetInstance)
+			}, func() (interface{}, error) {
+				return w.ComputeClient.GetTargetInstance(ti.Project, ti.Zone, ti.Name)
+			})
+			if err != nil {
+				e <- resourceErr(ti.daisyName, newErr("failed to create target instances", err))
 				return
 			}
-			ti.createdInWorkflow = true
+			if adopted {
+				w.LogStepInfo(s.name, "CreateTargetInstances", "Target instance %q already exists, adopted it.", ti.Name)
+			}
+			ti.markCreated()
 		}(ti)
 	}
 
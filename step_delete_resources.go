@@ -16,8 +16,8 @@ package daisy
 
 import (
 	"context"
-	"fmt"
 	"net/http"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -36,6 +36,9 @@ type DeleteResources struct {
 	Subnetworks   []string `json:",omitempty"`
 	GCSPaths      []string `json:",omitempty"`
 	Firewalls     []string `json:",omitempty"`
+
+	// Maximum number of resources deleted at once, no limit if unset.
+	Concurrency int `json:",omitempty"`
 }
 
 func (d *DeleteResources) populate(ctx context.Context, s *Step) DError {
@@ -77,15 +80,44 @@ func (d *DeleteResources) populate(ctx context.Context, s *Step) DError {
 	return nil
 }
 
+// destructive reports the GCE resources given by partial URL, which the
+// workflow didn't create.
+func (d *DeleteResources) destructive() string {
+	var urls []string
+	for _, rs := range []struct {
+		names []string
+		rgx   *regexp.Regexp
+	}{
+		{d.Disks, diskURLRgx},
+		{d.Images, imageURLRgx},
+		{d.MachineImages, machineImageURLRgx},
+		{d.Instances, instanceURLRgx},
+		{d.Networks, networkURLRegex},
+		{d.Subnetworks, subnetworkURLRegex},
+		{d.Firewalls, firewallRuleURLRegex},
+	} {
+		for _, n := range rs.names {
+			if rs.rgx.MatchString(n) {
+				urls = append(urls, n)
+			}
+		}
+	}
+	if len(urls) == 0 {
+		return ""
+	}
+	return "resources not created by the workflow: " + strings.Join(urls, ", ")
+}
+
 func (d *DeleteResources) validateInstance(i string, s *Step) DError {
 	if err := s.w.instances.regDelete(i, s); err != nil {
 		return err
 	}
 	ir, _ := s.w.instances.get(i)
 
-	// Get the Instance that created this instance, if any.
+	// Get the Instance that created this instance, if any. Instances can
+	// also be created by SmokeTestImage.
 
-	if ir.creator != nil {
+	if ir.creator != nil && ir.creator.CreateInstances != nil {
 		//Try GA
 		for _, createI := range (*ir.creator.CreateInstances).Instances {
 			if createI.daisyName != i {
@@ -143,6 +175,10 @@ func (d *DeleteResources) checkError(err DError, s *Step) DError {
 }
 
 func (d *DeleteResources) validate(ctx context.Context, s *Step) DError {
+	if d.Concurrency < 0 {
+		return Errf("cannot delete resources: Concurrency must not be negative, got %d", d.Concurrency)
+	}
+
 	// Instance checking.
 	for _, i := range d.Instances {
 		if err := d.validateInstance(i, s); d.checkError(err, s) != nil {
@@ -193,26 +229,9 @@ func (d *DeleteResources) validate(ctx context.Context, s *Step) DError {
 		}
 
 		// Check if bucket exists and is writeable.
-		writableBkts.mx.Lock()
-		if !strIn(bkt, writableBkts.bkts) {
-			if _, err := s.w.StorageClient.Bucket(bkt).Attrs(ctx); err != nil {
-				return Errf("error reading bucket %q: %v", bkt, err)
-			}
-
-			tObj := s.w.StorageClient.Bucket(bkt).Object(fmt.Sprintf("daisy-validate-%s-%s", s.name, s.w.id))
-			w := tObj.NewWriter(ctx)
-			if _, err := w.Write(nil); err != nil {
-				return newErr("failed to write to GCS object when deleting resources", err)
-			}
-			if err := w.Close(); err != nil {
-				return Errf("error writing to bucket %q: %v", bkt, err)
-			}
-			if err := tObj.Delete(ctx); err != nil {
-				return Errf("error deleting file %+v after write validation: %v", tObj, err)
-			}
-			writableBkts.bkts = append(writableBkts.bkts, bkt)
+		if err := checkBucketWritable(ctx, s, bkt); err != nil {
+			return err
 		}
-		writableBkts.mx.Unlock()
 	}
 
 	return nil
@@ -234,172 +253,121 @@ func recursiveGCSDelete(ctx context.Context, w *Workflow, bkt, prefix string) DE
 	return nil
 }
 
-// Waits for the whole group to run. Monitors for error and cancels.
-// Returns true if error should be raised, false otherwise.
-func waitGroup(wg *sync.WaitGroup, e chan DError, w *Workflow) (bool, DError) {
-	go func() {
-		wg.Wait()
-		e <- nil
-	}()
-
-	select {
-	case err := <-e:
-		if err != nil {
-			return true, err
-		}
-	case <-w.Cancel:
-		return true, nil
-	}
-	return false, nil
-}
-
-func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
-	var wg sync.WaitGroup
-	w := s.w
-	e := make(chan DError)
-
-	for _, i := range d.Instances {
-		wg.Add(1)
-		go func(i string) {
-			defer wg.Done()
-			w.LogStepInfo(s.name, "DeleteResources", "Deleting instance %q.", i)
-			if err := w.instances.delete(i); err != nil {
+// registryDeletes returns the functions deleting names from r. Resources
+// which don't exist are logged and skipped.
+func registryDeletes(s *Step, kind string, r *baseResourceRegistry, names []string) []func() DError {
+	var fns []func() DError
+	for _, n := range names {
+		n := n
+		fns = append(fns, func() DError {
+			s.w.LogStepInfo(s.name, "DeleteResources", "Deleting %s %q.", kind, n)
+			if err := r.delete(n); err != nil {
 				if err.etype() == resourceDNEError {
-					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting instance %q: %v", i, err)
-					return
+					s.w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting %s %q: %v", kind, n, err)
+					return nil
 				}
-				e <- err
+				return err
 			}
-		}(i)
-	}
-
-	for _, i := range d.Images {
-		wg.Add(1)
-		go func(i string) {
-			defer wg.Done()
-			w.LogStepInfo(s.name, "DeleteResources", "Deleting image %q.", i)
-			if err := w.images.delete(i); err != nil {
-				if err.etype() == resourceDNEError {
-					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting image %q: %v", i, err)
-					return
-				}
-				e <- err
-			}
-		}(i)
-	}
-
-	for _, i := range d.MachineImages {
-		wg.Add(1)
-		go func(i string) {
-			defer wg.Done()
-			w.LogStepInfo(s.name, "DeleteResources", "Deleting machine image %q.", i)
-			if err := w.machineImages.delete(i); err != nil {
-				if err.etype() == resourceDNEError {
-					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting machine image %q: %v", i, err)
-					return
-				}
-				e <- err
-			}
-		}(i)
+			return nil
+		})
 	}
+	return fns
+}
 
+// gcsDeletes returns the functions deleting the GCS paths.
+func (d *DeleteResources) gcsDeletes(ctx context.Context, s *Step) []func() DError {
+	var fns []func() DError
 	for _, p := range d.GCSPaths {
-		wg.Add(1)
-		go func(p string) {
-			defer wg.Done()
+		p := p
+		fns = append(fns, func() DError {
 			bkt, obj, err := splitGCSPath(p)
 			if err != nil {
-				e <- err
-				return
+				return err
 			}
 
 			if obj == "" || strings.HasSuffix(obj, "/") {
-				if err := recursiveGCSDelete(ctx, s.w, bkt, obj); err != nil {
-					e <- err
-				}
-				return
+				return recursiveGCSDelete(ctx, s.w, bkt, obj)
 			}
 
-			if err := w.StorageClient.Bucket(bkt).Object(obj).Delete(ctx); err != nil {
+			if err := s.w.StorageClient.Bucket(bkt).Object(obj).Delete(ctx); err != nil {
 				if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting GCS Path %q: %v", p, err)
-					return
+					s.w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting GCS Path %q: %v", p, err)
+					return nil
 				}
-				e <- Errf("error deleting GCS path %q: %v", p, err)
+				return Errf("error deleting GCS path %q: %v", p, err)
 			}
-		}(p)
+			return nil
+		})
 	}
+	return fns
+}
 
-	if abort, ret := waitGroup(&wg, e, w); abort {
-		return ret
+// deleteAll runs deletes in parallel, at most Concurrency at a time if it is
+// set, and returns all of their errors. Returns true if the step should stop,
+// either because a delete failed or because the workflow was cancelled.
+func (d *DeleteResources) deleteAll(w *Workflow, deletes []func() DError) (bool, DError) {
+	var sem chan struct{}
+	if d.Concurrency > 0 {
+		sem = make(chan struct{}, d.Concurrency)
 	}
-
-	// Delete disks only after instances have been deleted.
-	e = make(chan DError)
-	for _, d := range d.Disks {
+	var wg sync.WaitGroup
+	var mx sync.Mutex
+	var errs DError
+	for _, fn := range deletes {
 		wg.Add(1)
-		go func(d string) {
+		go func(fn func() DError) {
 			defer wg.Done()
-			w.LogStepInfo(s.name, "DeleteResources", "Deleting disk %q.", d)
-			if err := w.disks.delete(d); err != nil {
-				if err.etype() == resourceDNEError {
-					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting disk %q: %v", d, err)
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-w.Cancel:
 					return
 				}
-				e <- err
 			}
-		}(d)
-	}
-
-	// Delete firewalls after instance have been deleted
-	for _, n := range d.Firewalls {
-		wg.Add(1)
-		go func(n string) {
-			defer wg.Done()
-			w.LogStepInfo(s.name, "DeleteResources", "Deleting firewall %q.", n)
-			if err := w.firewallRules.delete(n); err != nil {
-				if err.etype() == resourceDNEError {
-					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting firewall %q: %v", n, err)
-				}
-				e <- err
+			if err := fn(); err != nil {
+				mx.Lock()
+				defer mx.Unlock()
+				errs = addErrs(errs, err)
 			}
-		}(n)
+		}(fn)
 	}
 
-	// Delete subnetworks after firewalls.
-	for _, sn := range d.Subnetworks {
-		wg.Add(1)
-		go func(sn string) {
-			defer wg.Done()
-			w.LogStepInfo(s.name, "DeleteResources", "Deleting subnetwork %q.", sn)
-			if err := w.subnetworks.delete(sn); err != nil {
-				if err.etype() == resourceDNEError {
-					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting subnetwork %q: %v", sn, err)
-				}
-				e <- err
-			}
-		}(sn)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return errs != nil, errs
+	case <-w.Cancel:
+		return true, nil
 	}
+}
 
-	if abort, ret := waitGroup(&wg, e, w); abort {
-		return ret
+func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
+	w := s.w
+
+	// Instances are deleted first, as they use disks, firewalls and networks.
+	var deletes []func() DError
+	deletes = append(deletes, registryDeletes(s, "instance", &w.instances.baseResourceRegistry, d.Instances)...)
+	deletes = append(deletes, registryDeletes(s, "image", &w.images.baseResourceRegistry, d.Images)...)
+	deletes = append(deletes, registryDeletes(s, "machine image", &w.machineImages.baseResourceRegistry, d.MachineImages)...)
+	deletes = append(deletes, d.gcsDeletes(ctx, s)...)
+	if stop, err := d.deleteAll(w, deletes); stop {
+		return err
 	}
 
-	// Delete networks after subnetworks have been deleted
-	for _, n := range d.Networks {
-		wg.Add(1)
-		go func(n string) {
-			defer wg.Done()
-			w.LogStepInfo(s.name, "DeleteResources", "Deleting network %q.", n)
-			if err := w.networks.delete(n); err != nil {
-				if err.etype() == resourceDNEError {
-					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting network %q: %v", n, err)
-				}
-				e <- err
-			}
-		}(n)
+	// Delete disks and firewalls only after instances have been deleted.
+	deletes = registryDeletes(s, "disk", &w.disks.baseResourceRegistry, d.Disks)
+	deletes = append(deletes, registryDeletes(s, "firewall", &w.firewallRules.baseResourceRegistry, d.Firewalls)...)
+	deletes = append(deletes, registryDeletes(s, "subnetwork", &w.subnetworks.baseResourceRegistry, d.Subnetworks)...)
+	if stop, err := d.deleteAll(w, deletes); stop {
+		return err
 	}
 
-	_, ret := waitGroup(&wg, e, w)
-	return ret
+	// Delete networks after subnetworks have been deleted.
+	_, err := d.deleteAll(w, registryDeletes(s, "network", &w.networks.baseResourceRegistry, d.Networks))
+	return err
 }
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -54,6 +55,28 @@ func TestDeleteResourcesPopulate(t *testing.T) {
 	}
 }
 
+func TestDeleteResourcesDestructive(t *testing.T) {
+	d := &DeleteResources{Disks: []string{"d"}, Images: []string{"i"}, GCSPaths: []string{"gs://bucket/object"}}
+	if got := d.destructive(); got != "" {
+		t.Errorf("deleting resources of the workflow should not be destructive, got: %q", got)
+	}
+
+	d.Disks = append(d.Disks, "projects/p/zones/z/disks/d")
+	d.Networks = []string{"projects/p/global/networks/n"}
+	want := "resources not created by the workflow: projects/p/zones/z/disks/d, projects/p/global/networks/n"
+	if got := d.destructive(); got != want {
+		t.Errorf("unexpected destructive description, got: %q, want: %q", got, want)
+	}
+
+	// The step only runs once confirmed.
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	s.DeleteResources = d
+	if err := s.run(context.Background()); err == nil {
+		t.Error("unconfirmed DeleteResources of resources not created by the workflow should have failed")
+	}
+}
+
 func TestDeleteResourcesRun(t *testing.T) {
 	ctx := context.Background()
 	w := testWorkflow()
@@ -132,6 +155,76 @@ func TestDeleteResourcesRun(t *testing.T) {
 	}
 }
 
+func TestDeleteResourcesRunConcurrency(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	var ins, ds []string
+	w.instances.m = map[string]*Resource{}
+	w.disks.m = map[string]*Resource{}
+	for i := 0; i < 4; i++ {
+		in, d := fmt.Sprintf("in%d", i), fmt.Sprintf("d%d", i)
+		w.instances.m[in] = &Resource{RealName: in, link: "link"}
+		w.disks.m[d] = &Resource{RealName: d, link: "link"}
+		ins, ds = append(ins, in), append(ds, d)
+	}
+
+	// Each delete blocks until it's released, so that the deletes running
+	// at once are counted without depending on timing.
+	var mx sync.Mutex
+	var running, maxRunning int
+	var order []string
+	started := make(chan struct{})
+	release := make(chan struct{})
+	track := func(kind string, err error) error {
+		mx.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		order = append(order, kind)
+		mx.Unlock()
+		started <- struct{}{}
+		<-release
+		mx.Lock()
+		running--
+		mx.Unlock()
+		return err
+	}
+	tc := w.ComputeClient.(*daisyCompute.TestClient)
+	tc.DeleteInstanceFn = func(project, zone, name string) error { return track("instance", nil) }
+	tc.DeleteDiskFn = func(project, zone, name string) error { return track("disk", errors.New("disk error")) }
+
+	errc := make(chan DError, 1)
+	go func() { errc <- (&DeleteResources{Instances: ins, Disks: ds, Concurrency: 2}).run(ctx, s) }()
+	// Instances are deleted before disks. For each, two deletes start, then
+	// one more starts as each one is released.
+	for _, names := range [][]string{ins, ds} {
+		<-started
+		<-started
+		for range names[2:] {
+			release <- struct{}{}
+			<-started
+		}
+		release <- struct{}{}
+		release <- struct{}{}
+	}
+	err := <-errc
+	if err == nil {
+		t.Fatal("run should have failed with the disk errors")
+	}
+	if len(err.errors()) != len(ds) {
+		t.Errorf("all disk errors should have been returned, got: %v", err)
+	}
+	if maxRunning != 2 {
+		t.Errorf("unexpected number of concurrent deletes, got: %d, want: 2", maxRunning)
+	}
+	want := []string{"instance", "instance", "instance", "instance", "disk", "disk", "disk", "disk"}
+	if diffRes := diff(order, want, 0); diffRes != "" {
+		t.Errorf("disks should have been deleted after instances: (-got,+want)\n%s", diffRes)
+	}
+}
+
 func TestRecursiveGCSDelete(t *testing.T) {
 	w := testWorkflow()
 	ctx := context.Background()
@@ -18,8 +18,10 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	computeAlpha "google.golang.org/api/compute/v0.alpha"
+	computeBeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -34,10 +36,45 @@ type DeprecateImage struct {
 	DeprecationStatus compute.DeprecationStatus
 	// DeprecationStatus to set for image.
 	DeprecationStatusAlpha computeAlpha.DeprecationStatus
+	// DeprecationStatus to set for image, used with the beta API.
+	DeprecationStatusBeta computeBeta.DeprecationStatus
+	// API version to deprecate the image with, one of "ga", "beta" or
+	// "alpha". By default the alpha API is used if DeprecationStatusAlpha.State
+	// is set, the GA API otherwise.
+	API string `json:"Api,omitempty"`
 	// Project image is in, overrides workflow Project.
 	Project string `json:",omitempty"`
 }
 
+const (
+	apiGA    = "ga"
+	apiBeta  = "beta"
+	apiAlpha = "alpha"
+)
+
+// api returns the API version used to deprecate the image.
+func (di *DeprecateImage) api() string {
+	if di.API != "" {
+		return di.API
+	}
+	if di.DeprecationStatusAlpha.State != "" {
+		return apiAlpha
+	}
+	return apiGA
+}
+
+// validateRolloutTime checks the DefaultRolloutTime of the beta and alpha only
+// StateOverride of a deprecation status.
+func validateRolloutTime(field, defaultRolloutTime string) DError {
+	if defaultRolloutTime == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, defaultRolloutTime); err != nil {
+		return Errf("%s %q is not an RFC 3339 timestamp: %v", field, defaultRolloutTime, err)
+	}
+	return nil
+}
+
 func (d *DeprecateImages) populate(ctx context.Context, s *Step) DError {
 	for _, di := range *d {
 		di.Project = strOr(di.Project, s.w.Project)
@@ -54,12 +91,33 @@ func (d *DeprecateImages) validate(ctx context.Context, s *Step) DError {
 			return Errf("cannot deprecate image %q: project does not exist: %q", di.Image, di.Project)
 		}
 
-		// Verify State is one of the deprecated states.
-		// The Alpha check also requires the value to not be emptry string as in that case the GA API will be used.
-		if di.DeprecationStatusAlpha.State != "" && !strIn(di.DeprecationStatusAlpha.State, deprecationStates) {
-			return Errf("DeprecationStatusAlpha.State of %q not in %q", di.DeprecationStatusAlpha.State, deprecationStates)
-		} else if !strIn(di.DeprecationStatus.State, deprecationStates) {
-			return Errf("DeprecationStatus.State of %q not in %q", di.DeprecationStatus.State, deprecationStates)
+		// Verify State is one of the deprecated states, for the status of the
+		// API used.
+		switch di.api() {
+		case apiAlpha:
+			if !strIn(di.DeprecationStatusAlpha.State, deprecationStates) {
+				return Errf("DeprecationStatusAlpha.State of %q not in %q", di.DeprecationStatusAlpha.State, deprecationStates)
+			}
+			if so := di.DeprecationStatusAlpha.StateOverride; so != nil {
+				if err := validateRolloutTime("DeprecationStatusAlpha.StateOverride.DefaultRolloutTime", so.DefaultRolloutTime); err != nil {
+					return err
+				}
+			}
+		case apiBeta:
+			if !strIn(di.DeprecationStatusBeta.State, deprecationStates) {
+				return Errf("DeprecationStatusBeta.State of %q not in %q", di.DeprecationStatusBeta.State, deprecationStates)
+			}
+			if so := di.DeprecationStatusBeta.StateOverride; so != nil {
+				if err := validateRolloutTime("DeprecationStatusBeta.StateOverride.DefaultRolloutTime", so.DefaultRolloutTime); err != nil {
+					return err
+				}
+			}
+		case apiGA:
+			if !strIn(di.DeprecationStatus.State, deprecationStates) {
+				return Errf("DeprecationStatus.State of %q not in %q", di.DeprecationStatus.State, deprecationStates)
+			}
+		default:
+			return Errf("cannot deprecate image %q: unknown Api %q, must be one of %q", di.Image, di.API, []string{apiGA, apiBeta, apiAlpha})
 		}
 
 		// regUse needs the partal url of a non daisy resource.
@@ -84,10 +142,22 @@ func (d *DeprecateImages) run(ctx context.Context, s *Step) DError {
 		go func(di *DeprecateImage) {
 			defer wg.Done()
 			var err error
-			if di.DeprecationStatusAlpha.State != "" {
-				w.LogStepInfo(s.name, "DeprecateImages", "%q --> %q with DefaultRolloutTime %s.", di.Image, di.DeprecationStatusAlpha.State, di.DeprecationStatusAlpha.StateOverride.DefaultRolloutTime)
+			switch di.api() {
+			case apiAlpha:
+				if so := di.DeprecationStatusAlpha.StateOverride; so != nil {
+					w.LogStepInfo(s.name, "DeprecateImages", "%q --> %q with DefaultRolloutTime %s.", di.Image, di.DeprecationStatusAlpha.State, so.DefaultRolloutTime)
+				} else {
+					w.LogStepInfo(s.name, "DeprecateImages", "%q --> %q.", di.Image, di.DeprecationStatusAlpha.State)
+				}
 				err = w.ComputeClient.DeprecateImageAlpha(di.Project, di.Image, &di.DeprecationStatusAlpha)
-			} else {
+			case apiBeta:
+				if so := di.DeprecationStatusBeta.StateOverride; so != nil {
+					w.LogStepInfo(s.name, "DeprecateImages", "%q --> %q with DefaultRolloutTime %s.", di.Image, di.DeprecationStatusBeta.State, so.DefaultRolloutTime)
+				} else {
+					w.LogStepInfo(s.name, "DeprecateImages", "%q --> %q.", di.Image, di.DeprecationStatusBeta.State)
+				}
+				err = w.ComputeClient.DeprecateImageBeta(di.Project, di.Image, &di.DeprecationStatusBeta)
+			default:
 				w.LogStepInfo(s.name, "DeprecateImages", "%q --> %q.", di.Image, di.DeprecationStatus.State)
 				err = w.ComputeClient.DeprecateImage(di.Project, di.Image, &di.DeprecationStatus)
 			}
@@ -20,6 +20,7 @@ import (
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	computeAlpha "google.golang.org/api/compute/v0.alpha"
+	computeBeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -119,6 +120,46 @@ func TestDeprecateImagesValidate(t *testing.T) {
 			&DeprecateImage{Image: "i1", Project: testProject, DeprecationStatusAlpha: computeAlpha.DeprecationStatus{State: "BAD"}},
 			true,
 		},
+		{
+			"alpha api unDEPRECATED case",
+			&DeprecateImage{Image: "i1", Project: testProject, API: "alpha"},
+			false,
+		},
+		{
+			"alpha rollout time case",
+			&DeprecateImage{Image: "i1", Project: testProject, DeprecationStatusAlpha: computeAlpha.DeprecationStatus{State: "DEPRECATED", StateOverride: &computeAlpha.RolloutPolicy{DefaultRolloutTime: "2024-01-02T15:04:05Z"}}},
+			false,
+		},
+		{
+			"alpha bad rollout time case",
+			&DeprecateImage{Image: "i1", Project: testProject, DeprecationStatusAlpha: computeAlpha.DeprecationStatus{State: "DEPRECATED", StateOverride: &computeAlpha.RolloutPolicy{DefaultRolloutTime: "tomorrow"}}},
+			true,
+		},
+		{
+			"beta DEPRECATED case",
+			&DeprecateImage{Image: "i1", Project: testProject, API: "beta", DeprecationStatusBeta: computeBeta.DeprecationStatus{State: "DEPRECATED"}},
+			false,
+		},
+		{
+			"beta bad case",
+			&DeprecateImage{Image: "i1", Project: testProject, API: "beta", DeprecationStatusBeta: computeBeta.DeprecationStatus{State: "BAD"}},
+			true,
+		},
+		{
+			"beta bad rollout time case",
+			&DeprecateImage{Image: "i1", Project: testProject, API: "beta", DeprecationStatusBeta: computeBeta.DeprecationStatus{State: "DEPRECATED", StateOverride: &computeBeta.RolloutPolicy{DefaultRolloutTime: "tomorrow"}}},
+			true,
+		},
+		{
+			"ga api with alpha status case",
+			&DeprecateImage{Image: "i1", Project: testProject, API: "ga", DeprecationStatus: compute.DeprecationStatus{State: "DEPRECATED"}, DeprecationStatusAlpha: computeAlpha.DeprecationStatus{State: "BAD"}},
+			false,
+		},
+		{
+			"bad api case",
+			&DeprecateImage{Image: "i1", Project: testProject, API: "v2", DeprecationStatus: compute.DeprecationStatus{State: "DEPRECATED"}},
+			true,
+		},
 	}
 	for _, tt := range tests {
 		w.Steps[tt.desc] = &Step{name: tt.desc, w: w, DeprecateImages: &DeprecateImages{tt.di}}
@@ -163,3 +204,46 @@ func TestDeprecateImagesRun(t *testing.T) {
 		}
 	}
 }
+
+func TestDeprecateImagesRunAPI(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	tests := []struct {
+		desc string
+		di   *DeprecateImage
+		want string
+	}{
+		{"ga case", &DeprecateImage{Image: "i1", DeprecationStatus: compute.DeprecationStatus{State: "DEPRECATED"}}, "ga"},
+		{"alpha state case", &DeprecateImage{Image: "i1", DeprecationStatusAlpha: computeAlpha.DeprecationStatus{State: "DEPRECATED"}}, "alpha"},
+		{"alpha api case", &DeprecateImage{Image: "i1", API: "alpha"}, "alpha"},
+		{"beta api case", &DeprecateImage{Image: "i1", API: "beta", DeprecationStatusBeta: computeBeta.DeprecationStatus{State: "DEPRECATED"}}, "beta"},
+		{"ga api case", &DeprecateImage{Image: "i1", API: "ga", DeprecationStatusAlpha: computeAlpha.DeprecationStatus{State: "DEPRECATED"}}, "ga"},
+	}
+	for _, tt := range tests {
+		var got []string
+		w.ComputeClient = &daisyCompute.TestClient{
+			DeprecateImageFn: func(_, _ string, _ *compute.DeprecationStatus) error {
+				got = append(got, "ga")
+				return nil
+			},
+			DeprecateImageBetaFn: func(_, _ string, _ *computeBeta.DeprecationStatus) error {
+				got = append(got, "beta")
+				return nil
+			},
+			DeprecateImageAlphaFn: func(_, _ string, _ *computeAlpha.DeprecationStatus) error {
+				got = append(got, "alpha")
+				return nil
+			},
+		}
+
+		dis := &DeprecateImages{tt.di}
+		if err := dis.run(ctx, s); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%s: unexpected API calls, got: %q, want: %q", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+const (
+	defaultExportWorkerImage = "projects/debian-cloud/global/images/family/debian-12"
+	defaultExportMachineType = "n1-standard-4"
+	exportDeviceName         = "export-disk"
+	exportSuccessMatch       = "ExportSuccess"
+	exportFailureMatch       = "ExportFailed"
+)
+
+// exportPollInterval is how often the worker's serial port is checked.
+var exportPollInterval = 10 * time.Second
+
+// exportStartupScript streams the export disk into a gzip compressed tarball
+// containing a single disk.raw file, the format expected by
+// Image.RawDisk.Source, and uploads it to the destination in the metadata.
+var exportStartupScript = `#!/bin/bash
+DEST=$(curl -s -H "Metadata-Flavor: Google" http://metadata.google.internal/computeMetadata/v1/instance/attributes/daisy-export-destination)
+python3 - /dev/disk/by-id/google-` + exportDeviceName + ` <<'EOF' | gsutil -q cp - "${DEST}"
+import os, sys, tarfile
+dev = sys.argv[1]
+with open(dev, "rb") as f:
+    size = f.seek(0, os.SEEK_END)
+    f.seek(0)
+    info = tarfile.TarInfo("disk.raw")
+    info.size = size
+    with tarfile.open(fileobj=sys.stdout.buffer, mode="w|gz", format=tarfile.GNU_FORMAT) as t:
+        t.addfile(info, f)
+EOF
+if [[ ${PIPESTATUS[0]} -eq 0 && ${PIPESTATUS[1]} -eq 0 ]]; then
+  echo "` + exportSuccessMatch + `: exported to ${DEST}" > /dev/ttyS0
+else
+  echo "` + exportFailureMatch + `: failed to export to ${DEST}" > /dev/ttyS0
+fi
+`
+
+// ExportImage is a Daisy ExportImage workflow step. The image is exported
+// as a gzip compressed tarball by a worker instance which reads a disk
+// created from the image.
+type ExportImage struct {
+	// Image to export, either a workflow image name or a partial URL.
+	Image string
+	// GCS path of the tarball to create, must end with ".tar.gz".
+	DestinationURI string
+	// Image used to boot the worker instance.
+	WorkerImage string `json:",omitempty"`
+	// Machine type of the worker instance.
+	MachineType string `json:",omitempty"`
+	Project     string `json:",omitempty"`
+	Zone        string `json:",omitempty"`
+
+	diskName, instanceName string
+}
+
+// populate preprocesses fields: Image, WorkerImage, MachineType, Project, Zone
+// - sets defaults
+// - extends short partial URLs to include "projects/<project>"
+func (ei *ExportImage) populate(ctx context.Context, s *Step) DError {
+	if ei.Project == "" {
+		ei.Project = s.w.Project
+	}
+	if ei.Zone == "" {
+		ei.Zone = s.w.Zone
+	}
+	if ei.WorkerImage == "" {
+		ei.WorkerImage = defaultExportWorkerImage
+	}
+	if ei.MachineType == "" {
+		ei.MachineType = defaultExportMachineType
+	}
+	if machineTypeURLRegex.MatchString(ei.MachineType) {
+		ei.MachineType = extendPartialURL(ei.MachineType, ei.Project)
+	} else {
+		ei.MachineType = fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", ei.Project, ei.Zone, ei.MachineType)
+	}
+	if imageURLRgx.MatchString(ei.Image) {
+		ei.Image = extendPartialURL(ei.Image, ei.Project)
+	}
+	ei.diskName = s.w.genName(s.name + "-disk")
+	ei.instanceName = s.w.genName(s.name + "-worker")
+	return nil
+}
+
+func (ei *ExportImage) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot export image %q", ei.Image)
+	var errs DError
+	if ei.Project == "" {
+		errs = addErrs(errs, Errf("%s: must specify project", pre))
+	}
+	if ei.Zone == "" {
+		errs = addErrs(errs, Errf("%s: must specify zone", pre))
+	}
+	if ei.Image == "" {
+		errs = addErrs(errs, Errf("%s: must specify image", pre))
+	} else if _, err := s.w.images.regUse(ei.Image, s); err != nil {
+		errs = addErrs(errs, Errf("%s: %v", pre, err))
+	}
+
+	bkt, obj, err := splitGCSPath(ei.DestinationURI)
+	if err != nil {
+		return addErrs(errs, Errf("%s: %v", pre, err))
+	}
+	if obj == "" || !strings.HasSuffix(obj, ".tar.gz") {
+		return addErrs(errs, Errf("%s: DestinationURI %q must be a GCS object ending with \".tar.gz\"", pre, ei.DestinationURI))
+	}
+	if err := checkBucketWritable(ctx, s, bkt); err != nil {
+		return addErrs(errs, Errf("%s: %v", pre, err))
+	}
+	return addErrs(errs, s.w.objects.regCreate(path.Join(bkt, obj)))
+}
+
+func (ei *ExportImage) run(ctx context.Context, s *Step) DError {
+	w := s.w
+	image := ei.Image
+	if i, ok := w.images.get(image); ok {
+		image = i.link
+	}
+
+	w.LogStepInfo(s.name, "ExportImage", "Creating disk %q from image %q.", ei.diskName, image)
+	d := &compute.Disk{Name: ei.diskName, SourceImage: image}
+	if err := w.preCreate("disk", d); err != nil {
+		return newErr("failed to create export disk", err)
+	}
+	if err := w.ComputeClient.CreateDisk(ei.Project, ei.Zone, d); err != nil {
+		return newErr("failed to create export disk", err)
+	}
+	if err := w.postCreate("disk", func() (interface{}, error) {
+		return w.ComputeClient.GetDisk(ei.Project, ei.Zone, ei.diskName)
+	}); err != nil {
+		if dErr := w.ComputeClient.DeleteDisk(ei.Project, ei.Zone, ei.diskName); dErr != nil && !daisyCompute.IsNotFound(dErr) {
+			w.LogStepInfo(s.name, "ExportImage", "WARNING: failed to delete export disk %q: %v", ei.diskName, dErr)
+		}
+		return newErr("failed to create export disk", err)
+	}
+
+	w.LogStepInfo(s.name, "ExportImage", "Creating worker instance %q.", ei.instanceName)
+	dest := fmt.Sprintf("gs://%s", strings.TrimPrefix(ei.DestinationURI, "gs://"))
+	inst := &compute.Instance{
+		Name:        ei.instanceName,
+		MachineType: ei.MachineType,
+		Disks: []*compute.AttachedDisk{
+			{
+				AutoDelete:       true,
+				Boot:             true,
+				Type:             "PERSISTENT",
+				InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: ei.WorkerImage},
+			},
+			{
+				AutoDelete: true,
+				DeviceName: exportDeviceName,
+				Mode:       "READ_ONLY",
+				Source:     fmt.Sprintf("projects/%s/zones/%s/disks/%s", ei.Project, ei.Zone, ei.diskName),
+				Type:       "PERSISTENT",
+			},
+		},
+		Metadata: &compute.Metadata{Items: []*compute.MetadataItems{
+			{Key: "startup-script", Value: &exportStartupScript},
+			{Key: "daisy-export-destination", Value: &dest},
+		}},
+		NetworkInterfaces: []*compute.NetworkInterface{{
+			Network:       extendPartialURL("global/networks/default", ei.Project),
+			AccessConfigs: []*compute.AccessConfig{{Type: defaultAccessConfigType}},
+		}},
+		ServiceAccounts: []*compute.ServiceAccount{{
+			Email:  "default",
+			Scopes: []string{"https://www.googleapis.com/auth/devstorage.read_write"},
+		}},
+	}
+	err := w.preCreate("instance", inst)
+	if err == nil {
+		err = w.ComputeClient.CreateInstance(ei.Project, ei.Zone, inst)
+	}
+	if err != nil {
+		if dErr := w.ComputeClient.DeleteDisk(ei.Project, ei.Zone, ei.diskName); dErr != nil && !daisyCompute.IsNotFound(dErr) {
+			w.LogStepInfo(s.name, "ExportImage", "WARNING: failed to delete export disk %q: %v", ei.diskName, dErr)
+		}
+		return newErr("failed to create export worker instance", err)
+	}
+	defer func() {
+		w.LogStepInfo(s.name, "ExportImage", "Deleting worker instance %q.", ei.instanceName)
+		if err := w.ComputeClient.DeleteInstance(ei.Project, ei.Zone, ei.instanceName); err != nil && !daisyCompute.IsNotFound(err) {
+			w.LogStepInfo(s.name, "ExportImage", "WARNING: failed to delete worker instance %q: %v", ei.instanceName, err)
+		}
+	}()
+	if err := w.postCreate("instance", func() (interface{}, error) {
+		return w.ComputeClient.GetInstance(ei.Project, ei.Zone, ei.instanceName)
+	}); err != nil {
+		return newErr("failed to create export worker instance", err)
+	}
+
+	so := &SerialOutput{Port: 1, SuccessMatch: exportSuccessMatch, FailureMatch: FailureMatches{exportFailureMatch}}
+	return waitForSerialOutput(ctx, s, ei.Project, ei.Zone, ei.instanceName, so, exportPollInterval)
+}
@@ -0,0 +1,150 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestExportImagePopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{name: "export", w: w}
+
+	ei := &ExportImage{Image: "global/images/foo", DestinationURI: "gs://bucket/foo.tar.gz"}
+	if err := ei.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &ExportImage{
+		Image:          fmt.Sprintf("projects/%s/global/images/foo", testProject),
+		DestinationURI: "gs://bucket/foo.tar.gz",
+		WorkerImage:    defaultExportWorkerImage,
+		MachineType:    fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", testProject, testZone, defaultExportMachineType),
+		Project:        testProject,
+		Zone:           testZone,
+		diskName:       w.genName("export-disk"),
+		instanceName:   w.genName("export-worker"),
+	}
+	if diffRes := diff(ei, want, 0); diffRes != "" {
+		t.Errorf("populated ExportImage does not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestExportImageValidate(t *testing.T) {
+	ctx := context.Background()
+	image := fmt.Sprintf("projects/%s/global/images/%s", testProject, testImage)
+
+	tests := []struct {
+		desc      string
+		ei        *ExportImage
+		shouldErr bool
+	}{
+		{"normal case", &ExportImage{Image: image, DestinationURI: "gs://bucket/image.tar.gz"}, false},
+		{"no image case", &ExportImage{DestinationURI: "gs://bucket/image.tar.gz"}, true},
+		{"image dne case", &ExportImage{Image: fmt.Sprintf("projects/%s/global/images/%s", testProject, DNE), DestinationURI: "gs://bucket/image.tar.gz"}, true},
+		{"bad destination case", &ExportImage{Image: image, DestinationURI: "not/a/gcs/path"}, true},
+		{"bucket only case", &ExportImage{Image: image, DestinationURI: "gs://bucket"}, true},
+		{"not a tarball case", &ExportImage{Image: image, DestinationURI: "gs://bucket/image.raw"}, true},
+		{"bucket dne case", &ExportImage{Image: image, DestinationURI: "gs://dne/image.tar.gz"}, true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s, _ := w.NewStep("export")
+		s.ExportImage = tt.ei
+		if err := tt.ei.populate(ctx, s); err != nil {
+			t.Errorf("%s: populate error: %v", tt.desc, err)
+		}
+		if err := tt.ei.validate(ctx, s); err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestExportImageRun(t *testing.T) {
+	ctx := context.Background()
+	defer func(i time.Duration) { exportPollInterval = i }(exportPollInterval)
+	exportPollInterval = time.Millisecond
+
+	tests := []struct {
+		desc          string
+		output        string
+		instanceErr   error
+		shouldErr     bool
+		wantDeletions []string
+	}{
+		{"success case", exportSuccessMatch, nil, false, []string{"instance"}},
+		{"export failure case", exportFailureMatch, nil, true, []string{"instance"}},
+		{"instance creation failure case", "", Errf("error"), true, []string{"disk"}},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{name: "export", w: w}
+		w.images.m = map[string]*Resource{"image": {RealName: w.genName("image"), link: "projects/p/global/images/real"}}
+
+		var gotDisk *compute.Disk
+		var gotInstance *compute.Instance
+		var gotDeletions []string
+		w.ComputeClient = &daisyCompute.TestClient{
+			CreateDiskFn: func(_, _ string, d *compute.Disk) error {
+				gotDisk = d
+				return nil
+			},
+			CreateInstanceFn: func(_, _ string, i *compute.Instance) error {
+				gotInstance = i
+				return tt.instanceErr
+			},
+			GetSerialPortOutputFn: func(_, _, _ string, _, _ int64) (*compute.SerialPortOutput, error) {
+				return &compute.SerialPortOutput{Contents: tt.output + "\n"}, nil
+			},
+			DeleteDiskFn: func(_, _, _ string) error {
+				gotDeletions = append(gotDeletions, "disk")
+				return nil
+			},
+			DeleteInstanceFn: func(_, _, _ string) error {
+				gotDeletions = append(gotDeletions, "instance")
+				return nil
+			},
+		}
+
+		ei := &ExportImage{Image: "image", DestinationURI: "gs://bucket/image.tar.gz"}
+		if err := ei.populate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected populate error: %v", tt.desc, err)
+		}
+		err := ei.run(ctx, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+
+		if gotDisk == nil || gotDisk.SourceImage != "projects/p/global/images/real" {
+			t.Errorf("%s: export disk not created from the image link: %+v", tt.desc, gotDisk)
+		}
+		if gotInstance == nil || len(gotInstance.Disks) != 2 || gotInstance.Disks[1].DeviceName != exportDeviceName {
+			t.Errorf("%s: export disk not attached to the worker instance: %+v", tt.desc, gotInstance)
+		}
+		if diffRes := diff(gotDeletions, tt.wantDeletions, 0); diffRes != "" {
+			t.Errorf("%s: deleted resources do not match expectation: (-got +want)\n%s", tt.desc, diffRes)
+		}
+	}
+}
@@ -142,5 +142,9 @@ func (i *IncludeWorkflow) validate(ctx context.Context, s *Step) DError {
 }
 
 func (i *IncludeWorkflow) run(ctx context.Context, s *Step) DError {
-	return i.Workflow.run(ctx)
+	if err := i.Workflow.run(ctx); err != nil {
+		i.Workflow.runFailureHooks()
+		return err
+	}
+	return nil
 }
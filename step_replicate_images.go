@@ -0,0 +1,181 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// ReplicateImages is a Daisy ReplicateImages workflow step.
+type ReplicateImages []*ReplicateImage
+
+// ReplicateImage is used to copy an image to a set of projects and/or
+// storage locations. One image is created, using the source image, for each
+// combination of target project and storage location.
+type ReplicateImage struct {
+	// Name of the copies. If there are multiple Projects or StorageLocations
+	// the copy's project and storage location are appended to the name.
+	Name string
+	// Image to replicate, either a workflow image name or a partial URL.
+	SourceImage string
+	// Projects to create the copies in. If this is unset Workflow.Project is used.
+	Projects []string `json:",omitempty"`
+	// Storage locations to create the copies in.
+	StorageLocations []string `json:",omitempty"`
+	// Should the copies be kept after the workflow?
+	NoCleanup bool `json:",omitempty"`
+	// If set, the copy names are used as is instead of generating names.
+	ExactName bool `json:",omitempty"`
+
+	images []*Image
+}
+
+func (ri *ReplicateImage) populate(ctx context.Context, s *Step) DError {
+	if imageURLRgx.MatchString(ri.SourceImage) {
+		ri.SourceImage = extendPartialURL(ri.SourceImage, s.w.Project)
+	}
+
+	projects := ri.Projects
+	if len(projects) == 0 {
+		projects = []string{s.w.Project}
+	}
+	locations := ri.StorageLocations
+	if len(locations) == 0 {
+		locations = []string{""}
+	}
+
+	var errs DError
+	ri.images = nil
+	for _, p := range projects {
+		for _, l := range locations {
+			name := ri.Name
+			if len(projects) > 1 {
+				name = fmt.Sprintf("%s-%s", name, p)
+			}
+			if len(locations) > 1 {
+				name = fmt.Sprintf("%s-%s", name, l)
+			}
+			i := &Image{
+				ImageBase: ImageBase{Resource: Resource{Project: p, NoCleanup: ri.NoCleanup, ExactName: ri.ExactName}},
+				Image:     compute.Image{Name: name, SourceImage: ri.SourceImage},
+			}
+			if l != "" {
+				i.StorageLocations = []string{l}
+			}
+			errs = addErrs(errs, (&i.ImageBase).populate(ctx, i, s))
+			ri.images = append(ri.images, i)
+		}
+	}
+	return errs
+}
+
+func (ri *ReplicateImage) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot replicate image %q", ri.Name)
+	var errs DError
+	if ri.SourceImage == "" {
+		errs = addErrs(errs, Errf("%s: SourceImage not set", pre))
+	}
+	if len(ri.Projects) == 0 && len(ri.StorageLocations) == 0 {
+		errs = addErrs(errs, Errf("%s: at least one of Projects or StorageLocations must be set", pre))
+	}
+	for _, p := range ri.Projects {
+		if p == "" {
+			errs = addErrs(errs, Errf("%s: empty project in Projects", pre))
+		}
+	}
+	for _, l := range ri.StorageLocations {
+		if l == "" {
+			errs = addErrs(errs, Errf("%s: empty location in StorageLocations", pre))
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+
+	for _, i := range ri.images {
+		errs = addErrs(errs, (&i.ImageBase).validate(ctx, i, nil, s))
+	}
+	return errs
+}
+
+func (r *ReplicateImages) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, ri := range *r {
+		errs = addErrs(errs, ri.populate(ctx, s))
+	}
+	return errs
+}
+
+func (r *ReplicateImages) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, ri := range *r {
+		errs = addErrs(errs, ri.validate(ctx, s))
+	}
+	return errs
+}
+
+func (r *ReplicateImages) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, ri := range *r {
+		// Get the source image link if the source image was created in the workflow.
+		sourceImage := ri.SourceImage
+		if image, ok := w.images.get(sourceImage); ok {
+			sourceImage = image.link
+		}
+		for _, i := range ri.images {
+			wg.Add(1)
+			go func(i *Image) {
+				defer wg.Done()
+				i.SourceImage = sourceImage
+
+				w.LogStepInfo(s.name, "ReplicateImages", "Creating image %q in project %q from %q.", i.Name, i.Project, sourceImage)
+				if err := w.preCreate("image", &i.Image); err != nil {
+					e <- resourceErr(i.daisyName, newErr("failed to replicate image", err))
+					return
+				}
+				if err := w.ComputeClient.CreateImage(i.Project, &i.Image); err != nil {
+					e <- resourceErr(i.daisyName, newErr("failed to replicate image", err))
+					return
+				}
+				i.markCreatedInWorkflow()
+				if err := w.postCreate("image", func() (interface{}, error) {
+					return w.ComputeClient.GetImage(i.Project, i.Name)
+				}); err != nil {
+					e <- resourceErr(i.daisyName, newErr("failed to replicate image", err))
+				}
+			}(i)
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		// Wait so images being created now will complete before we try to clean them up.
+		wg.Wait()
+		return nil
+	}
+}
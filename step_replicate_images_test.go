@@ -0,0 +1,135 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestReplicateImagesPopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	ri := &ReplicateImage{Name: "copy", SourceImage: "global/images/src", Projects: []string{"p1", "p2"}, StorageLocations: []string{"us", "eu"}, ExactName: true}
+	if err := (&ReplicateImages{ri}).populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantSource := fmt.Sprintf("projects/%s/global/images/src", testProject)
+	if ri.SourceImage != wantSource {
+		t.Errorf("unexpected SourceImage, got: %q, want: %q", ri.SourceImage, wantSource)
+	}
+	var got []string
+	for _, i := range ri.images {
+		got = append(got, fmt.Sprintf("%s %s %v %s", i.Project, i.Name, i.StorageLocations, i.SourceImage))
+	}
+	want := []string{
+		fmt.Sprintf("p1 copy-p1-us [us] %s", wantSource),
+		fmt.Sprintf("p1 copy-p1-eu [eu] %s", wantSource),
+		fmt.Sprintf("p2 copy-p2-us [us] %s", wantSource),
+		fmt.Sprintf("p2 copy-p2-eu [eu] %s", wantSource),
+	}
+	if diffRes := diff(got, want, 0); diffRes != "" {
+		t.Errorf("populated copies do not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestReplicateImagesValidate(t *testing.T) {
+	ctx := context.Background()
+	src := fmt.Sprintf("projects/%s/global/images/%s", testProject, testImage)
+
+	tests := []struct {
+		desc      string
+		ri        *ReplicateImage
+		shouldErr bool
+	}{
+		{"storage locations case", &ReplicateImage{Name: "copy", SourceImage: src, StorageLocations: []string{"us", "eu"}}, false},
+		{"project case", &ReplicateImage{Name: "copy", SourceImage: src, Projects: []string{testProject}}, false},
+		{"no targets case", &ReplicateImage{Name: "copy", SourceImage: src}, true},
+		{"empty project case", &ReplicateImage{Name: "copy", SourceImage: src, Projects: []string{""}}, true},
+		{"empty location case", &ReplicateImage{Name: "copy", SourceImage: src, StorageLocations: []string{""}}, true},
+		{"no source image case", &ReplicateImage{Name: "copy", Projects: []string{testProject}}, true},
+		{"bad project case", &ReplicateImage{Name: "copy", SourceImage: src, Projects: []string{DNE}}, true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{w: w}
+		ris := &ReplicateImages{tt.ri}
+		if err := ris.populate(ctx, s); err != nil {
+			t.Errorf("%s: populate error: %v", tt.desc, err)
+		}
+		if err := ris.validate(ctx, s); err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestReplicateImagesRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.images.m = map[string]*Resource{testImage: {RealName: w.genName(testImage), link: "projects/p/global/images/real"}}
+
+	var mx sync.Mutex
+	var got []string
+	w.ComputeClient = &daisyCompute.TestClient{CreateImageFn: func(p string, i *compute.Image) error {
+		mx.Lock()
+		defer mx.Unlock()
+		got = append(got, fmt.Sprintf("%s %s %v %s", p, i.Name, i.StorageLocations, i.SourceImage))
+		return nil
+	}}
+
+	ri := &ReplicateImage{Name: "copy", SourceImage: testImage, Projects: []string{"p1", "p2"}, StorageLocations: []string{"us", "eu"}, ExactName: true}
+	ris := &ReplicateImages{ri}
+	if err := ris.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+	if err := ris.run(ctx, s); err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+
+	sort.Strings(got)
+	want := []string{
+		"p1 copy-p1-eu [eu] projects/p/global/images/real",
+		"p1 copy-p1-us [us] projects/p/global/images/real",
+		"p2 copy-p2-eu [eu] projects/p/global/images/real",
+		"p2 copy-p2-us [us] projects/p/global/images/real",
+	}
+	if diffRes := diff(got, want, 0); diffRes != "" {
+		t.Errorf("created images do not match expectation: (-got +want)\n%s", diffRes)
+	}
+	for _, i := range ri.images {
+		if !i.createdInWorkflow {
+			t.Errorf("image %q not marked as created in workflow", i.Name)
+		}
+	}
+
+	w.ComputeClient = &daisyCompute.TestClient{CreateImageFn: func(p string, i *compute.Image) error {
+		return Errf("error")
+	}}
+	if err := ris.run(ctx, s); err == nil {
+		t.Error("should have returned an error, but didn't")
+	}
+}
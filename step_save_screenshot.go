@@ -0,0 +1,110 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/base64"
+	"path"
+)
+
+// SaveScreenshot is a Daisy SaveScreenshot workflow step. It saves a
+// screenshot of an instance's display as a PNG image in GCS, which shows
+// the boot screen of instances that never produce serial port output.
+type SaveScreenshot struct {
+	// Instance to take a screenshot of, its display device must be enabled.
+	Instance string
+	// GCS path of the image, e.g. gs://bucket/boot.png.
+	Destination string
+	// Take the screenshot if the workflow fails after this step ran, before
+	// the instance is deleted, instead of when the step runs.
+	OnFailure bool `json:",omitempty"`
+
+	project, zone, name string
+	bucket, object      string
+}
+
+func (ss *SaveScreenshot) populate(ctx context.Context, s *Step) DError {
+	if instanceURLRgx.MatchString(ss.Instance) {
+		ss.Instance = extendPartialURL(ss.Instance, s.w.Project)
+	}
+	return nil
+}
+
+func (ss *SaveScreenshot) validate(ctx context.Context, s *Step) (errs DError) {
+	ir, err := s.w.instances.regUse(ss.Instance, s)
+	if ir == nil {
+		// Return now, the rest of this function can't be run without ir.
+		return addErrs(errs, Errf("cannot save screenshot: %v", err))
+	}
+	errs = addErrs(errs, err)
+
+	// Set instance project, zone and name.
+	instance := NamedSubexp(instanceURLRgx, ir.link)
+	ss.project = instance["project"]
+	ss.zone = instance["zone"]
+	ss.name = instance["instance"]
+
+	bkt, obj, err := splitGCSPath(ss.Destination)
+	if err != nil {
+		return addErrs(errs, err)
+	}
+	if obj == "" {
+		return addErrs(errs, Errf("cannot save screenshot: Destination %q is not a GCS object path", ss.Destination))
+	}
+	ss.bucket, ss.object = bkt, obj
+	errs = addErrs(errs, s.w.objects.regCreate(path.Join(bkt, obj)))
+	return addErrs(errs, checkBucketWritable(ctx, s, bkt))
+}
+
+func (ss *SaveScreenshot) run(ctx context.Context, s *Step) DError {
+	w := s.w
+	// The instance may have been renamed since validation.
+	if ir, ok := w.instances.get(ss.Instance); ok {
+		ss.name = NamedSubexp(instanceURLRgx, ir.link)["instance"]
+	}
+	if !ss.OnFailure {
+		return ss.save(ctx, s)
+	}
+	w.LogStepInfo(s.name, "SaveScreenshot", "Instance %q: saving a screenshot to %s if the workflow fails.", ss.name, ss.Destination)
+	w.addFailureHook(func() DError {
+		// The step's context is done by the time the workflow fails.
+		return ss.save(context.Background(), s)
+	})
+	return nil
+}
+
+func (ss *SaveScreenshot) save(ctx context.Context, s *Step) DError {
+	w := s.w
+	w.LogStepInfo(s.name, "SaveScreenshot", "Saving a screenshot of instance %q to %s.", ss.name, ss.Destination)
+	sc, err := w.ComputeClient.GetScreenshot(ss.project, ss.zone, ss.name)
+	if err != nil {
+		return typedErr(apiError, "failed to get instance screenshot", err)
+	}
+	img, err := base64.StdEncoding.DecodeString(sc.Contents)
+	if err != nil {
+		return newErr("failed to decode instance screenshot", err)
+	}
+	wc := w.StorageClient.Bucket(ss.bucket).Object(ss.object).NewWriter(ctx)
+	wc.ContentType = "image/png"
+	if _, err := wc.Write(img); err != nil {
+		wc.Close()
+		return typedErr(apiError, "failed to write screenshot to GCS", err)
+	}
+	if err := wc.Close(); err != nil {
+		return typedErr(apiError, "failed to write screenshot to GCS", err)
+	}
+	return nil
+}
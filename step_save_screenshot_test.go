@@ -0,0 +1,108 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestSaveScreenshotValidate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc    string
+		ss      *SaveScreenshot
+		wantErr bool
+	}{
+		{"normal case", &SaveScreenshot{Instance: testInstance, Destination: "gs://bucket/boot.png"}, false},
+		{"instance dne case", &SaveScreenshot{Instance: DNE, Destination: "gs://bucket/boot.png"}, true},
+		{"bad destination case", &SaveScreenshot{Instance: testInstance, Destination: "bucket/boot.png"}, true},
+		{"bucket destination case", &SaveScreenshot{Instance: testInstance, Destination: "gs://bucket"}, true},
+		{"bucket dne case", &SaveScreenshot{Instance: testInstance, Destination: "gs://dne/boot.png"}, true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{name: "screenshot", w: w}
+		w.instances.m = map[string]*Resource{testInstance: {Project: testProject, RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+		err := tt.ss.validate(ctx, s)
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+	}
+	if ss := tests[0].ss; ss.project != testProject || ss.zone != testZone || ss.name != testInstance || ss.bucket != "bucket" || ss.object != "boot.png" {
+		t.Errorf("unexpected project, zone, name and destination, got: %q %q %q %q %q", ss.project, ss.zone, ss.name, ss.bucket, ss.object)
+	}
+}
+
+func TestSaveScreenshotRun(t *testing.T) {
+	ctx := context.Background()
+	png := base64.StdEncoding.EncodeToString([]byte("\x89PNG"))
+
+	tests := []struct {
+		desc          string
+		onFailure     bool
+		workflowFails bool
+		contents      string
+		screenshotErr error
+		wantErr       bool
+		wantCalled    bool
+	}{
+		{"save case", false, false, png, nil, false, true},
+		{"screenshot error case", false, false, png, Errf("error"), true, true},
+		{"bad contents case", false, false, "not base64!", nil, true, true},
+		{"on failure case", true, true, png, nil, false, true},
+		{"on failure success case", true, false, png, nil, false, false},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{name: "screenshot", w: w}
+		w.instances.m = map[string]*Resource{testInstance: {Project: testProject, RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+		var gotProject, gotZone, gotInstance string
+		var called bool
+		w.ComputeClient = &daisyCompute.TestClient{
+			GetScreenshotFn: func(project, zone, instance string) (*compute.Screenshot, error) {
+				gotProject, gotZone, gotInstance, called = project, zone, instance, true
+				return &compute.Screenshot{Contents: tt.contents}, tt.screenshotErr
+			},
+		}
+
+		ss := &SaveScreenshot{Instance: testInstance, Destination: "gs://bucket/boot.png", OnFailure: tt.onFailure, project: testProject, zone: testZone, name: testInstance, bucket: "bucket", object: "boot.png"}
+		err := ss.run(ctx, s)
+		if tt.workflowFails {
+			w.runFailureHooks()
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+		if called != tt.wantCalled {
+			t.Errorf("%s: screenshot taken: got %t, want %t", tt.desc, called, tt.wantCalled)
+		}
+		if called && (gotProject != testProject || gotZone != testZone || gotInstance != testInstance) {
+			t.Errorf("%s: unexpected project, zone and instance, got: %q %q %q", tt.desc, gotProject, gotZone, gotInstance)
+		}
+	}
+}
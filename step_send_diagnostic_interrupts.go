@@ -0,0 +1,110 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+)
+
+const instanceStatusRunning = "RUNNING"
+
+// SendDiagnosticInterrupts is a Daisy SendDiagnosticInterrupts workflow step.
+// It sends a diagnostic interrupt (NMI) to running instances, which makes a
+// guest kernel configured for it panic and write a crash dump.
+type SendDiagnosticInterrupts struct {
+	Instances []string `json:",omitempty"`
+}
+
+func (sd *SendDiagnosticInterrupts) populate(ctx context.Context, s *Step) DError {
+	for i, instance := range sd.Instances {
+		if instanceURLRgx.MatchString(instance) {
+			sd.Instances[i] = extendPartialURL(instance, s.w.Project)
+		}
+	}
+	return nil
+}
+
+func (sd *SendDiagnosticInterrupts) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, i := range sd.Instances {
+		ir, err := s.w.instances.regUse(i, s)
+		if err != nil {
+			errs = addErrs(errs, err)
+			continue
+		}
+		// Instances created by the workflow are checked when the step runs.
+		if ir.creator == nil {
+			errs = addErrs(errs, checkInstanceRunning(s.w, i, ir.link))
+		}
+	}
+	return errs
+}
+
+// checkInstanceRunning checks that the instance at link is RUNNING.
+func checkInstanceRunning(w *Workflow, name, link string) DError {
+	m := NamedSubexp(instanceURLRgx, link)
+	inst, err := w.ComputeClient.GetInstance(m["project"], m["zone"], m["instance"])
+	if err != nil {
+		return typedErr(apiError, "failed to get instance", err)
+	}
+	if inst.Status != instanceStatusRunning {
+		return Errf("cannot send diagnostic interrupt to instance %q: instance is %s, not %s", name, inst.Status, instanceStatusRunning)
+	}
+	return nil
+}
+
+func (sd *SendDiagnosticInterrupts) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	// Buffered for every instance and the done signal, so that no sender
+	// blocks once run has returned.
+	e := make(chan DError, len(sd.Instances)+1)
+
+	for _, i := range sd.Instances {
+		wg.Add(1)
+		go func(i string) {
+			defer wg.Done()
+			ir, ok := w.instances.get(i)
+			if !ok {
+				e <- Errf("unresolved instance %q", i)
+				return
+			}
+			if err := checkInstanceRunning(w, i, ir.link); err != nil {
+				e <- err
+				return
+			}
+			w.LogStepInfo(s.name, "SendDiagnosticInterrupts", "Sending diagnostic interrupt to instance %q.", i)
+			m := NamedSubexp(instanceURLRgx, ir.link)
+			if err := w.ComputeClient.SendDiagnosticInterrupt(m["project"], m["zone"], m["instance"]); err != nil {
+				e <- typedErr(apiError, "failed to send diagnostic interrupt", err)
+			}
+		}(i)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		// Let the interrupts being sent finish before the step ends.
+		wg.Wait()
+		return nil
+	}
+}
@@ -0,0 +1,165 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestSendDiagnosticInterruptsPopulate(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	s.SendDiagnosticInterrupts = &SendDiagnosticInterrupts{
+		Instances: []string{"i", "zones/z/instances/i"},
+	}
+
+	if err := (s.SendDiagnosticInterrupts).populate(context.Background(), s); err != nil {
+		t.Error("err should be nil")
+	}
+
+	want := &SendDiagnosticInterrupts{
+		Instances: []string{"i", fmt.Sprintf("projects/%s/zones/z/instances/i", w.Project)},
+	}
+	if diffRes := diff(s.SendDiagnosticInterrupts, want, 0); diffRes != "" {
+		t.Errorf("SendDiagnosticInterrupts not populated as expected: (-got,+want)\n%s", diffRes)
+	}
+}
+
+func TestSendDiagnosticInterruptsValidate(t *testing.T) {
+	ctx := context.Background()
+	existing := fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)
+
+	tests := []struct {
+		desc      string
+		instance  string
+		status    string
+		shouldErr bool
+	}{
+		{"created instance case", "instance1", "", false},
+		{"running instance case", existing, "RUNNING", false},
+		{"stopped instance case", existing, "TERMINATED", true},
+		{"instance dne case", "dne", "", true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s, _ := w.NewStep("s")
+		iCreator, _ := w.NewStep("iCreator")
+		iCreator.CreateInstances = &CreateInstances{Instances: []*Instance{{}}}
+		w.AddDependency(s, iCreator)
+		tc := w.ComputeClient.(*daisyCompute.TestClient)
+		tc.ListInstancesFn = func(_, _ string, _ ...daisyCompute.ListCallOption) ([]*compute.Instance, error) {
+			return []*compute.Instance{{Name: testInstance}}, nil
+		}
+		tc.GetInstanceFn = func(_, _, _ string) (*compute.Instance, error) {
+			return &compute.Instance{Status: tt.status}, nil
+		}
+		if err := w.instances.regCreate("instance1", &Resource{link: fmt.Sprintf("projects/%s/zones/%s/instances/i", testProject, testZone)}, false, iCreator); err != nil {
+			t.Fatal(err)
+		}
+
+		err := (&SendDiagnosticInterrupts{Instances: []string{tt.instance}}).validate(ctx, s)
+		if tt.shouldErr && err == nil {
+			t.Errorf("%s: should have returned an error but didn't", tt.desc)
+		} else if !tt.shouldErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestSendDiagnosticInterruptsRun(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc         string
+		status       string
+		interruptErr error
+		wantErr      bool
+		wantSent     bool
+	}{
+		{"running case", "RUNNING", nil, false, true},
+		{"not running case", "STOPPING", nil, true, false},
+		{"interrupt error case", "RUNNING", Errf("error"), true, true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s, _ := w.NewStep("s")
+		w.instances.m = map[string]*Resource{"in0": {RealName: "real0", link: fmt.Sprintf("projects/%s/zones/%s/instances/real0", testProject, testZone)}}
+
+		var gotProject, gotZone, gotInstance string
+		var sent bool
+		w.ComputeClient = &daisyCompute.TestClient{
+			GetInstanceFn: func(_, _, _ string) (*compute.Instance, error) {
+				return &compute.Instance{Status: tt.status}, nil
+			},
+			SendDiagnosticInterruptFn: func(project, zone, instance string) error {
+				gotProject, gotZone, gotInstance, sent = project, zone, instance, true
+				return tt.interruptErr
+			},
+		}
+
+		err := (&SendDiagnosticInterrupts{Instances: []string{"in0"}}).run(ctx, s)
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: should have returned an error but didn't", tt.desc)
+		} else if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if sent != tt.wantSent {
+			t.Errorf("%s: interrupt sent: got %t, want %t", tt.desc, sent, tt.wantSent)
+		}
+		if sent && (gotProject != testProject || gotZone != testZone || gotInstance != "real0") {
+			t.Errorf("%s: unexpected project, zone and instance, got: %q %q %q", tt.desc, gotProject, gotZone, gotInstance)
+		}
+	}
+}
+
+func TestSendDiagnosticInterruptsRunCancel(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	w.instances.m = map[string]*Resource{
+		"in0": {RealName: "real0", link: fmt.Sprintf("projects/%s/zones/%s/instances/real0", testProject, testZone)},
+		"in1": {RealName: "real1", link: fmt.Sprintf("projects/%s/zones/%s/instances/real1", testProject, testZone)},
+	}
+
+	// The workflow is canceled while the interrupts are sent, the step
+	// returns once they're all sent.
+	var cancelOnce sync.Once
+	var sent int32
+	w.ComputeClient = &daisyCompute.TestClient{
+		GetInstanceFn: func(_, _, _ string) (*compute.Instance, error) {
+			return &compute.Instance{Status: "RUNNING"}, nil
+		},
+		SendDiagnosticInterruptFn: func(_, _, _ string) error {
+			cancelOnce.Do(func() { close(w.Cancel) })
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&sent, 1)
+			return nil
+		},
+	}
+
+	if err := (&SendDiagnosticInterrupts{Instances: []string{"in0", "in1"}}).run(context.Background(), s); err != nil {
+		t.Errorf("unexpected error on cancel: %v", err)
+	}
+	if got := atomic.LoadInt32(&sent); got != 2 {
+		t.Errorf("step returned on cancel with %d of 2 interrupts sent", got)
+	}
+}
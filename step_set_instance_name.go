@@ -0,0 +1,77 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// SetInstanceName is a Daisy SetInstanceName workflow step. It renames a
+// stopped instance, references to the instance by its workflow name resolve
+// to the renamed instance afterwards.
+type SetInstanceName struct {
+	// Instance to rename.
+	Instance string
+	// New name of the instance.
+	Name string
+
+	project, zone, currentName string
+}
+
+func (sn *SetInstanceName) populate(ctx context.Context, s *Step) DError {
+	if instanceURLRgx.MatchString(sn.Instance) {
+		sn.Instance = extendPartialURL(sn.Instance, s.w.Project)
+	}
+	return nil
+}
+
+func (sn *SetInstanceName) validate(ctx context.Context, s *Step) (errs DError) {
+	if !checkName(sn.Name) {
+		errs = addErrs(errs, Errf("Instance %v: bad new name: %q", sn.Instance, sn.Name))
+	}
+
+	ir, err := s.w.instances.regUse(sn.Instance, s)
+	if ir == nil {
+		// Return now, the rest of this function can't be run without ir.
+		return addErrs(errs, Errf("cannot set instance name: %v", err))
+	}
+	errs = addErrs(errs, err)
+
+	// Set instance project, zone and name.
+	instance := NamedSubexp(instanceURLRgx, ir.link)
+	sn.project = instance["project"]
+	sn.zone = instance["zone"]
+	sn.currentName = instance["instance"]
+	if sn.currentName == sn.Name {
+		errs = addErrs(errs, Errf("Instance %v: already named %q", sn.Instance, sn.Name))
+	}
+	return errs
+}
+
+func (sn *SetInstanceName) run(ctx context.Context, s *Step) DError {
+	w := s.w
+	// The instance may have been renamed since validation.
+	if ir, ok := w.instances.get(sn.Instance); ok {
+		sn.currentName = NamedSubexp(instanceURLRgx, ir.link)["instance"]
+	}
+	w.LogStepInfo(s.name, "SetInstanceName", "Renaming Instance %q to %q.", sn.Instance, sn.Name)
+	req := &compute.InstancesSetNameRequest{CurrentName: sn.currentName, Name: sn.Name}
+	if err := w.ComputeClient.SetInstanceName(sn.project, sn.zone, sn.currentName, req); err != nil {
+		return newErr("failed to set instance name", err)
+	}
+	return w.instances.rename(sn.Instance, sn.Name)
+}
@@ -0,0 +1,105 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestSetInstanceNameValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.instances.m = map[string]*Resource{testInstance: {Project: testProject, RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+
+	tests := []struct {
+		desc    string
+		sn      *SetInstanceName
+		wantErr bool
+	}{
+		{"bad instance case", &SetInstanceName{Instance: "bad", Name: "new-name"}, true},
+		{"bad name case", &SetInstanceName{Instance: testInstance, Name: "Bad_Name"}, true},
+		{"same name case", &SetInstanceName{Instance: testInstance, Name: testInstance}, true},
+		{"positive flow case", &SetInstanceName{Instance: testInstance, Name: "new-name"}, false},
+	}
+	for _, tt := range tests {
+		err := tt.sn.validate(ctx, s)
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+	}
+	if sn := tests[3].sn; sn.project != testProject || sn.zone != testZone || sn.currentName != testInstance {
+		t.Errorf("unexpected project, zone and name, got: %q %q %q", sn.project, sn.zone, sn.currentName)
+	}
+}
+
+func TestSetInstanceNameRun(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc       string
+		setNameErr error
+		wantErr    bool
+	}{
+		{"rename case", nil, false},
+		{"set name error case", Errf("error"), true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{w: w}
+		w.instances.m = map[string]*Resource{testInstance: {Project: testProject, RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+		var gotProject, gotZone, gotInstance string
+		var gotReq *compute.InstancesSetNameRequest
+		w.ComputeClient = &daisyCompute.TestClient{
+			SetInstanceNameFn: func(project, zone, instance string, req *compute.InstancesSetNameRequest) error {
+				gotProject, gotZone, gotInstance, gotReq = project, zone, instance, req
+				return tt.setNameErr
+			},
+		}
+
+		sn := &SetInstanceName{Instance: testInstance, Name: "new-name", project: testProject, zone: testZone, currentName: testInstance}
+		err := sn.run(ctx, s)
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+		wantReq := &compute.InstancesSetNameRequest{CurrentName: testInstance, Name: "new-name"}
+		if gotProject != testProject || gotZone != testZone || gotInstance != testInstance {
+			t.Errorf("%s: unexpected project, zone and instance, got: %q %q %q", tt.desc, gotProject, gotZone, gotInstance)
+		}
+		if diffRes := diff(gotReq, wantReq, 0); diffRes != "" {
+			t.Errorf("%s: request does not match expectation: (-got +want)\n%s", tt.desc, diffRes)
+		}
+
+		r := w.instances.m[testInstance]
+		wantName, wantLink := "new-name", fmt.Sprintf("projects/%s/zones/%s/instances/new-name", testProject, testZone)
+		if tt.wantErr {
+			wantName, wantLink = testInstance, fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)
+		}
+		if r.RealName != wantName || r.link != wantLink {
+			t.Errorf("%s: unexpected registry entry, got: %q %q, want: %q %q", tt.desc, r.RealName, r.link, wantName, wantLink)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// SetTags is a Daisy SetTags workflow step.
+type SetTags []*SetTag
+
+// SetTag is used to set the network tags of an instance.
+type SetTag struct {
+	// Instance to set the tags on.
+	Instance string
+	// Network tags, these replace the current tags of the instance.
+	Tags []string
+	// If set, log a warning for each tag that isn't a target tag of any
+	// firewall rule created by the workflow.
+	CheckFirewallRules bool `json:",omitempty"`
+
+	project, zone string
+}
+
+// firewallTargetTags adds the target tags of the firewall rules created by
+// the workflow, including its included workflows and sub workflows, to tags.
+func (w *Workflow) firewallTargetTags(tags map[string]bool) {
+	for _, s := range w.Steps {
+		switch {
+		case s.IncludeWorkflow != nil && s.IncludeWorkflow.Workflow != nil:
+			s.IncludeWorkflow.Workflow.firewallTargetTags(tags)
+		case s.SubWorkflow != nil && s.SubWorkflow.Workflow != nil:
+			s.SubWorkflow.Workflow.firewallTargetTags(tags)
+		case s.CreateFirewallRules != nil:
+			for _, fir := range *s.CreateFirewallRules {
+				for _, t := range fir.TargetTags {
+					tags[t] = true
+				}
+			}
+		}
+	}
+}
+
+// unmatchedFirewallTags returns the tags that aren't a target tag of any
+// firewall rule created by the top level workflow. Nothing is returned if
+// the workflow doesn't create firewall rules with target tags.
+func unmatchedFirewallTags(w *Workflow, tags []string) []string {
+	for w.parent != nil {
+		w = w.parent
+	}
+	targetTags := map[string]bool{}
+	w.firewallTargetTags(targetTags)
+	if len(targetTags) == 0 {
+		return nil
+	}
+	var unmatched []string
+	for _, t := range tags {
+		if !targetTags[t] {
+			unmatched = append(unmatched, t)
+		}
+	}
+	return unmatched
+}
+
+func (c *SetTags) populate(ctx context.Context, s *Step) DError {
+	return nil
+}
+
+func (c *SetTags) validate(ctx context.Context, s *Step) (errs DError) {
+	for _, st := range *c {
+		for _, t := range st.Tags {
+			if !checkName(t) {
+				errs = addErrs(errs, Errf("Instance %v: bad network tag: %q", st.Instance, t))
+			}
+		}
+
+		ir, err := s.w.instances.regUse(st.Instance, s)
+		if ir == nil {
+			// Return now, the rest of this function can't be run without ir.
+			return addErrs(errs, Errf("cannot set tags: %v", err))
+		}
+		errs = addErrs(errs, err)
+
+		// Set instance project and zone.
+		instance := NamedSubexp(instanceURLRgx, ir.link)
+		st.project = instance["project"]
+		st.zone = instance["zone"]
+
+		if st.CheckFirewallRules {
+			for _, t := range unmatchedFirewallTags(s.w, st.Tags) {
+				s.w.LogStepInfo(s.name, "SetTags", "WARNING: Instance %q tag %q doesn't match the target tags of any firewall rule in the workflow.", st.Instance, t)
+			}
+		}
+	}
+	return errs
+}
+
+func (c *SetTags) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, st := range *c {
+		wg.Add(1)
+		go func(st *SetTag) {
+			defer wg.Done()
+
+			inst := st.Instance
+			if instRes, ok := w.instances.get(st.Instance); ok {
+				inst = instRes.link
+				st.Instance = instRes.RealName
+			}
+
+			// The current tags fingerprint is required to set the tags.
+			resp, err := w.ComputeClient.GetInstance(st.project, st.zone, st.Instance)
+			if err != nil {
+				e <- newErr("failed to get instance data", err)
+				return
+			}
+			tags := &compute.Tags{Items: st.Tags}
+			if resp.Tags != nil {
+				tags.Fingerprint = resp.Tags.Fingerprint
+			}
+
+			w.LogStepInfo(s.name, "SetTags", "Set Instance %q tags to %q.", inst, st.Tags)
+			if err := w.ComputeClient.SetTags(st.project, st.zone, st.Instance, tags); err != nil {
+				e <- newErr("failed to set instance tags", err)
+				return
+			}
+		}(st)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		wg.Wait()
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestSetTagsValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.instances.m = map[string]*Resource{testInstance: {Project: testProject, RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+
+	tests := []struct {
+		desc    string
+		st      *SetTags
+		wantErr bool
+	}{
+		{"bad instance case", &SetTags{{Instance: "bad", Tags: []string{"tag"}}}, true},
+		{"bad tag case", &SetTags{{Instance: testInstance, Tags: []string{"Bad_Tag"}}}, true},
+		{"clear tags case", &SetTags{{Instance: testInstance}}, false},
+		{"positive flow case", &SetTags{{Instance: testInstance, Tags: []string{"tag"}}}, false},
+	}
+	for _, tt := range tests {
+		err := tt.st.validate(ctx, s)
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+	}
+	if st := (*tests[3].st)[0]; st.project != testProject || st.zone != testZone {
+		t.Errorf("unexpected project and zone, got: %q %q, want: %q %q", st.project, st.zone, testProject, testZone)
+	}
+}
+
+func TestSetTagsFirewallWarning(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	w.instances.m = map[string]*Resource{testInstance: {Project: testProject, RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+	sw := w.NewSubWorkflow()
+	sw.Steps = map[string]*Step{
+		"create-firewall": {w: sw, CreateFirewallRules: &CreateFirewallRules{
+			{Firewall: compute.Firewall{TargetTags: []string{"http-server"}}},
+		}},
+	}
+	w.Steps = map[string]*Step{
+		"sub": {w: w, SubWorkflow: &SubWorkflow{Workflow: sw}},
+	}
+
+	tests := []struct {
+		desc          string
+		tags          []string
+		wantUnmatched []string
+	}{
+		{"matching tag case", []string{"http-server"}, nil},
+		{"mistagged case", []string{"http-server", "https-server"}, []string{"https-server"}},
+	}
+	for _, tt := range tests {
+		if got := unmatchedFirewallTags(sw, tt.tags); diff(got, tt.wantUnmatched, 0) != "" {
+			t.Errorf("%s: unexpected unmatched tags, got: %v, want: %v", tt.desc, got, tt.wantUnmatched)
+		}
+	}
+
+	warned := func() bool {
+		for _, e := range w.Logger.(*MockLogger).getEntries() {
+			if strings.Contains(e.Message, "WARNING") && strings.Contains(e.Message, "https-server") {
+				return true
+			}
+		}
+		return false
+	}
+	s := &Step{name: "set-tags", w: w}
+	if err := (&SetTags{{Instance: testInstance, Tags: []string{"https-server"}}}).validate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if warned() {
+		t.Error("should not have warned when CheckFirewallRules isn't set")
+	}
+	if err := (&SetTags{{Instance: testInstance, Tags: []string{"https-server"}, CheckFirewallRules: true}}).validate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !warned() {
+		t.Error("should have warned about a tag not matching any firewall rule")
+	}
+
+	// No firewall rules in the workflow case.
+	if got := unmatchedFirewallTags(testWorkflow(), []string{"tag"}); got != nil {
+		t.Errorf("unexpected unmatched tags without firewall rules: %v", got)
+	}
+}
+
+func TestSetTagsRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.instances.m = map[string]*Resource{testInstance: {Project: testProject, RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+
+	tests := []struct {
+		desc       string
+		st         *SetTags
+		wantTags   *compute.Tags
+		wantErr    bool
+		getInstErr error
+		setTagsErr error
+	}{
+		{"set tags case", &SetTags{{Instance: testInstance, Tags: []string{"a", "b"}, project: testProject, zone: testZone}}, &compute.Tags{Items: []string{"a", "b"}, Fingerprint: "fp"}, false, nil, nil},
+		{"get instance error case", &SetTags{{Instance: testInstance, Tags: []string{"a"}}}, nil, true, Errf("error"), nil},
+		{"set tags error case", &SetTags{{Instance: testInstance, Tags: []string{"a"}}}, &compute.Tags{Items: []string{"a"}, Fingerprint: "fp"}, true, nil, Errf("error")},
+	}
+	for _, tt := range tests {
+		var gotTags *compute.Tags
+		var gotProject, gotZone string
+		w.ComputeClient = &daisyCompute.TestClient{
+			GetInstanceFn: func(_, _, _ string) (*compute.Instance, error) {
+				return &compute.Instance{Tags: &compute.Tags{Fingerprint: "fp"}}, tt.getInstErr
+			},
+			SetTagsFn: func(project, zone, _ string, tags *compute.Tags) error {
+				gotProject, gotZone, gotTags = project, zone, tags
+				return tt.setTagsErr
+			},
+		}
+		err := tt.st.run(ctx, s)
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+		if diffRes := diff(gotTags, tt.wantTags, 0); diffRes != "" {
+			t.Errorf("%s: tags do not match expectation: (-got +want)\n%s", tt.desc, diffRes)
+		}
+		if !tt.wantErr && (gotProject != testProject || gotZone != testZone) {
+			t.Errorf("%s: unexpected project and zone, got: %q %q", tt.desc, gotProject, gotZone)
+		}
+	}
+}
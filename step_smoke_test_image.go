@@ -0,0 +1,285 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+const (
+	defaultSmokeTestMachineType = "n1-standard-1"
+	defaultSmokeTestTimeout     = "10m"
+	// The OS Config agent writes the guest inventory once the guest is up.
+	defaultSmokeTestReadyKey = "guestInventory/LastUpdated"
+)
+
+// smokeTestPollInterval is how often the ready guest attribute is checked,
+// guest attributes queries are limited to 10 per minute.
+var smokeTestPollInterval = 6 * time.Second
+
+// defaultSmokeTestFacts are the facts collected when none are given.
+var defaultSmokeTestFacts = map[string]string{
+	"kernel-version": "guestInventory/KernelVersion",
+	"agent-version":  "guestInventory/OSConfigAgentVersion",
+}
+
+// SmokeTestImage is a Daisy SmokeTestImage workflow step. A throwaway
+// instance is booted from the image, once the guest signals it is ready the
+// facts are read from its guest attributes and recorded as serial-output
+// values of the workflow, then the instance is deleted. The instance is
+// registered as created by the step, so workflow cleanup deletes it if the
+// workflow stops before the step does.
+type SmokeTestImage struct {
+	// Image to test, either a workflow image name or a partial URL.
+	Image string
+	// Guest attribute, "namespace/key", whose presence signals the guest is ready.
+	ReadyKey string `json:",omitempty"`
+	// Facts to collect, fact name -> guest attribute "namespace/key".
+	Facts map[string]string `json:",omitempty"`
+	// How long to wait for the guest to be ready.
+	Timeout string `json:",omitempty"`
+	// Machine type of the instance.
+	MachineType string `json:",omitempty"`
+	Project     string `json:",omitempty"`
+	Zone        string `json:",omitempty"`
+	// Network of the instance, either a workflow network name or a partial
+	// URL. Defaults to the default network if Subnetwork is unset.
+	Network string `json:",omitempty"`
+	// Subnetwork of the instance, either a workflow subnetwork name or a
+	// partial URL.
+	Subnetwork string `json:",omitempty"`
+	// Don't give the instance an external IP.
+	NoExternalIP bool `json:",omitempty"`
+
+	timeout      time.Duration
+	instanceName string
+	// The instance, as registered with the workflow.
+	instance *Resource
+}
+
+// populate preprocesses fields: Image, ReadyKey, Facts, Timeout, MachineType,
+// Project, Zone, Network, Subnetwork
+// - sets defaults
+// - extends short partial URLs to include "projects/<project>"
+func (st *SmokeTestImage) populate(ctx context.Context, s *Step) DError {
+	if st.Project == "" {
+		st.Project = s.w.Project
+	}
+	if st.Zone == "" {
+		st.Zone = s.w.Zone
+	}
+	if st.ReadyKey == "" {
+		st.ReadyKey = defaultSmokeTestReadyKey
+	}
+	if st.Facts == nil {
+		st.Facts = map[string]string{}
+		for k, v := range defaultSmokeTestFacts {
+			st.Facts[k] = v
+		}
+	}
+	if st.Timeout == "" {
+		st.Timeout = defaultSmokeTestTimeout
+	}
+	if st.MachineType == "" {
+		st.MachineType = defaultSmokeTestMachineType
+	}
+	if machineTypeURLRegex.MatchString(st.MachineType) {
+		st.MachineType = extendPartialURL(st.MachineType, st.Project)
+	} else {
+		st.MachineType = fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", st.Project, st.Zone, st.MachineType)
+	}
+	if imageURLRgx.MatchString(st.Image) {
+		st.Image = extendPartialURL(st.Image, st.Project)
+	}
+	if st.Subnetwork == "" {
+		st.Network = strOr(st.Network, "global/networks/default")
+	}
+	if networkURLRegex.MatchString(st.Network) {
+		st.Network = extendPartialURL(st.Network, st.Project)
+	}
+	if subnetworkURLRegex.MatchString(st.Subnetwork) {
+		st.Subnetwork = extendPartialURL(st.Subnetwork, st.Project)
+	}
+	st.instanceName = s.w.genName(s.name + "-smoke")
+	st.instance = &Resource{
+		Project:   st.Project,
+		RealName:  st.instanceName,
+		daisyName: s.name + "-smoke",
+		link:      fmt.Sprintf("projects/%s/zones/%s/instances/%s", st.Project, st.Zone, st.instanceName),
+	}
+	return nil
+}
+
+func (st *SmokeTestImage) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot smoke test image %q", st.Image)
+	var errs DError
+	if st.Project == "" {
+		errs = addErrs(errs, Errf("%s: must specify project", pre))
+	}
+	if st.Zone == "" {
+		errs = addErrs(errs, Errf("%s: must specify zone", pre))
+	}
+	if st.Image == "" {
+		errs = addErrs(errs, Errf("%s: must specify image", pre))
+	} else if _, err := s.w.images.regUse(st.Image, s); err != nil {
+		errs = addErrs(errs, Errf("%s: %v", pre, err))
+	}
+
+	d, err := time.ParseDuration(st.Timeout)
+	if err != nil {
+		errs = addErrs(errs, Errf("%s: bad Timeout %q: %v", pre, st.Timeout, err))
+	} else if d <= 0 {
+		errs = addErrs(errs, Errf("%s: Timeout must be positive: %q", pre, st.Timeout))
+	}
+	st.timeout = d
+
+	for name, key := range st.Facts {
+		if name == "" || key == "" {
+			errs = addErrs(errs, Errf("%s: bad fact %q: %q", pre, name, key))
+		}
+	}
+
+	if st.Subnetwork != "" {
+		if sr, err := s.w.subnetworks.regUse(st.Subnetwork, s); err != nil {
+			errs = addErrs(errs, Errf("%s: %v", pre, err))
+		} else {
+			errs = addErrs(errs, checkSubnetworkRegion(st.Subnetwork, sr.link, st.Zone))
+		}
+	}
+	if st.Network != "" {
+		if _, err := s.w.networks.regUse(st.Network, s); err != nil {
+			errs = addErrs(errs, Errf("%s: %v", pre, err))
+		}
+	}
+	return addErrs(errs, s.w.instances.baseResourceRegistry.regCreate(st.instance.daisyName, st.instance, s, false))
+}
+
+func (st *SmokeTestImage) run(ctx context.Context, s *Step) DError {
+	w := s.w
+	image := st.Image
+	if i, ok := w.images.get(image); ok {
+		image = i.link
+	}
+
+	nic := &compute.NetworkInterface{Network: st.Network, Subnetwork: st.Subnetwork}
+	if n, ok := w.networks.get(st.Network); ok {
+		nic.Network = n.link
+	}
+	if sn, ok := w.subnetworks.get(st.Subnetwork); ok {
+		nic.Subnetwork = sn.link
+	}
+	if !st.NoExternalIP {
+		nic.AccessConfigs = []*compute.AccessConfig{{Type: defaultAccessConfigType}}
+	}
+
+	w.LogStepInfo(s.name, "SmokeTestImage", "Creating instance %q from image %q.", st.instanceName, image)
+	enabled := "TRUE"
+	inst := &compute.Instance{
+		Name:        st.instanceName,
+		MachineType: st.MachineType,
+		Disks: []*compute.AttachedDisk{{
+			AutoDelete:       true,
+			Boot:             true,
+			Type:             "PERSISTENT",
+			InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: image},
+		}},
+		Metadata: &compute.Metadata{Items: []*compute.MetadataItems{
+			{Key: "enable-guest-attributes", Value: &enabled},
+			{Key: "enable-osconfig", Value: &enabled},
+		}},
+		NetworkInterfaces: []*compute.NetworkInterface{nic},
+	}
+	err := w.preCreate("instance", inst)
+	if err == nil {
+		err = w.ComputeClient.CreateInstance(st.Project, st.Zone, inst)
+	}
+	if err != nil {
+		return newErr("failed to create smoke test instance", err)
+	}
+	st.instance.markCreated()
+	defer func() {
+		w.LogStepInfo(s.name, "SmokeTestImage", "Deleting instance %q.", st.instanceName)
+		if err := w.instances.delete(st.instance.daisyName); err != nil && err.etype() != resourceDNEError {
+			w.LogStepInfo(s.name, "SmokeTestImage", "WARNING: failed to delete instance %q: %v", st.instanceName, err)
+		}
+	}()
+	if err := w.postCreate("instance", func() (interface{}, error) {
+		return w.ComputeClient.GetInstance(st.Project, st.Zone, st.instanceName)
+	}); err != nil {
+		return newErr("failed to create smoke test instance", err)
+	}
+
+	if ready, err := st.waitReady(ctx, s); err != nil || !ready {
+		return err
+	}
+
+	var names []string
+	for name := range st.Facts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ga, err := w.ComputeClient.GetGuestAttributes(st.Project, st.Zone, st.instanceName, "", st.Facts[name])
+		if err != nil {
+			return Errf("SmokeTestImage: instance %q: error getting fact %q from guest attribute %q: %v", st.instanceName, name, st.Facts[name], err)
+		}
+		w.LogStepInfo(s.name, "SmokeTestImage", "Image %q: %s: %q.", st.Image, name, ga.VariableValue)
+		w.AddSerialConsoleOutputValue(s.name+"."+name, ga.VariableValue)
+	}
+	return nil
+}
+
+// waitReady polls the ready guest attribute until it is set, the timeout
+// expires or the workflow is canceled. It returns false without an error on
+// cancellation.
+func (st *SmokeTestImage) waitReady(ctx context.Context, s *Step) (bool, DError) {
+	w := s.w
+	w.LogStepInfo(s.name, "SmokeTestImage", "Instance %q: waiting up to %s for key %s.", st.instanceName, st.timeout, st.ReadyKey)
+	timeout := time.After(st.timeout)
+	tick := time.Tick(smokeTestPollInterval)
+	var errs int
+	for {
+		select {
+		case <-w.Cancel:
+			return false, nil
+		case <-ctx.Done():
+			return false, nil
+		case <-timeout:
+			return false, Errf("SmokeTestImage: instance %q: key %s not set after %s", st.instanceName, st.ReadyKey, st.timeout)
+		case <-tick:
+			_, err := w.ComputeClient.GetGuestAttributes(st.Project, st.Zone, st.instanceName, "", st.ReadyKey)
+			if err == nil {
+				w.LogStepInfo(s.name, "SmokeTestImage", "Instance %q: ready.", st.instanceName)
+				return true, nil
+			}
+			if daisyCompute.IsNotFound(err) {
+				// 404 is OK, that means the key isn't present yet.
+				errs = 0
+				continue
+			}
+			// Permit up to 3 consecutive non-404 errors.
+			if errs++; errs < 3 {
+				continue
+			}
+			return false, Errf("SmokeTestImage: instance %q: error getting guest attribute: %v", st.instanceName, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestSmokeTestImagePopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{name: "smoke", w: w}
+
+	st := &SmokeTestImage{Image: "global/images/foo"}
+	if err := st.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &SmokeTestImage{
+		Image:        fmt.Sprintf("projects/%s/global/images/foo", testProject),
+		ReadyKey:     defaultSmokeTestReadyKey,
+		Facts:        defaultSmokeTestFacts,
+		Timeout:      defaultSmokeTestTimeout,
+		MachineType:  fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", testProject, testZone, defaultSmokeTestMachineType),
+		Project:      testProject,
+		Zone:         testZone,
+		Network:      fmt.Sprintf("projects/%s/global/networks/default", testProject),
+		instanceName: w.genName("smoke-smoke"),
+		instance: &Resource{
+			Project:   testProject,
+			RealName:  w.genName("smoke-smoke"),
+			daisyName: "smoke-smoke",
+			link:      fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, w.genName("smoke-smoke")),
+		},
+	}
+	if diffRes := diff(st, want, 0); diffRes != "" {
+		t.Errorf("populated SmokeTestImage does not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestSmokeTestImageValidate(t *testing.T) {
+	ctx := context.Background()
+	image := fmt.Sprintf("projects/%s/global/images/%s", testProject, testImage)
+	network := fmt.Sprintf("projects/%s/global/networks/%s", testProject, testNetwork)
+
+	tests := []struct {
+		desc      string
+		st        *SmokeTestImage
+		shouldErr bool
+	}{
+		{"normal case", &SmokeTestImage{Image: image, Network: network}, false},
+		{"timeout case", &SmokeTestImage{Image: image, Network: network, Timeout: "5m"}, false},
+		{"facts case", &SmokeTestImage{Image: image, Network: network, Facts: map[string]string{"os": "guestInventory/ShortName"}}, false},
+		{"no image case", &SmokeTestImage{}, true},
+		{"image dne case", &SmokeTestImage{Image: fmt.Sprintf("projects/%s/global/images/%s", testProject, DNE)}, true},
+		{"bad timeout case", &SmokeTestImage{Image: image, Timeout: "5 minutes"}, true},
+		{"negative timeout case", &SmokeTestImage{Image: image, Timeout: "-5m"}, true},
+		{"empty fact key case", &SmokeTestImage{Image: image, Facts: map[string]string{"os": ""}}, true},
+		{"network dne case", &SmokeTestImage{Image: image, Network: fmt.Sprintf("projects/%s/global/networks/%s", testProject, DNE)}, true},
+		{"subnetwork dne case", &SmokeTestImage{Image: image, Subnetwork: DNE}, true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s, _ := w.NewStep("smoke")
+		s.SmokeTestImage = tt.st
+		if err := tt.st.populate(ctx, s); err != nil {
+			t.Errorf("%s: populate error: %v", tt.desc, err)
+		}
+		err := tt.st.validate(ctx, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if r, ok := w.instances.get("smoke-smoke"); err == nil && (!ok || r.creator != s) {
+			t.Errorf("%s: instance not registered as created by the step", tt.desc)
+		}
+	}
+}
+
+func TestSmokeTestImageRun(t *testing.T) {
+	ctx := context.Background()
+	defer func(i time.Duration) { smokeTestPollInterval = i }(smokeTestPollInterval)
+	smokeTestPollInterval = time.Millisecond
+
+	tests := []struct {
+		desc        string
+		readyAfter  int
+		attrs       map[string]string
+		timeout     time.Duration
+		instanceErr error
+		shouldErr   bool
+		wantValues  map[string]string
+		wantDeleted bool
+	}{
+		{
+			"success case", 2,
+			map[string]string{"guestInventory/KernelVersion": "6.1.0", "guestInventory/OSConfigAgentVersion": "20240101.00"},
+			time.Minute, nil, false,
+			map[string]string{"smoke.kernel-version": "6.1.0", "smoke.agent-version": "20240101.00"},
+			true,
+		},
+		{"missing fact case", 0, map[string]string{"guestInventory/KernelVersion": "6.1.0"}, time.Minute, nil, true, map[string]string{}, true},
+		{"timeout case", -1, nil, 10 * time.Millisecond, nil, true, map[string]string{}, true},
+		{"instance creation failure case", 0, nil, time.Minute, Errf("error"), true, map[string]string{}, false},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{name: "smoke", w: w}
+		w.images.m = map[string]*Resource{"image": {RealName: w.genName("image"), link: "projects/p/global/images/real"}}
+
+		var gotInstance *compute.Instance
+		var gotDeleted bool
+		var readyCalls int
+		w.ComputeClient = &daisyCompute.TestClient{
+			GetInstanceFn: func(_, _, _ string) (*compute.Instance, error) {
+				return gotInstance, nil
+			},
+			CreateInstanceFn: func(_, _ string, i *compute.Instance) error {
+				gotInstance = i
+				return tt.instanceErr
+			},
+			GetGuestAttributesFn: func(_, _, _, _, key string) (*compute.GuestAttributes, error) {
+				if key == defaultSmokeTestReadyKey {
+					readyCalls++
+					if tt.readyAfter < 0 || readyCalls <= tt.readyAfter {
+						return nil, &googleapi.Error{Code: 404}
+					}
+					return &compute.GuestAttributes{VariableKey: key, VariableValue: "2024-01-01T00:00:00Z"}, nil
+				}
+				if v, ok := tt.attrs[key]; ok {
+					return &compute.GuestAttributes{VariableKey: key, VariableValue: v}, nil
+				}
+				return nil, &googleapi.Error{Code: 404}
+			},
+			DeleteInstanceFn: func(_, _, _ string) error {
+				gotDeleted = true
+				return nil
+			},
+		}
+
+		st := &SmokeTestImage{Image: "image", NoExternalIP: true}
+		if err := st.populate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected populate error: %v", tt.desc, err)
+		}
+		st.instance.creator = s
+		w.instances.m = map[string]*Resource{st.instance.daisyName: st.instance}
+		st.timeout = tt.timeout
+		err := st.run(ctx, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+
+		if gotInstance == nil || len(gotInstance.Disks) != 1 || gotInstance.Disks[0].InitializeParams.SourceImage != "projects/p/global/images/real" {
+			t.Errorf("%s: instance not booted from the image link: %+v", tt.desc, gotInstance)
+		}
+		if gotInstance != nil && (len(gotInstance.NetworkInterfaces) != 1 || len(gotInstance.NetworkInterfaces[0].AccessConfigs) != 0) {
+			t.Errorf("%s: instance should have one network interface without external IP: %+v", tt.desc, gotInstance.NetworkInterfaces)
+		}
+		if gotDeleted != tt.wantDeleted {
+			t.Errorf("%s: instance deleted: got %t, want %t", tt.desc, gotDeleted, tt.wantDeleted)
+		}
+		if st.instance.createdInWorkflow != tt.wantDeleted || st.instance.deleted != tt.wantDeleted {
+			t.Errorf("%s: instance registration not updated, created: %t, deleted: %t", tt.desc, st.instance.createdInWorkflow, st.instance.deleted)
+		}
+		gotValues := map[string]string{}
+		for k := range tt.wantValues {
+			gotValues[k] = w.GetSerialConsoleOutputValue(k)
+		}
+		if diffRes := diff(gotValues, tt.wantValues, 0); diffRes != "" {
+			t.Errorf("%s: recorded facts do not match expectation: (-got +want)\n%s", tt.desc, diffRes)
+		}
+	}
+}
@@ -100,6 +100,7 @@ func (s *SubWorkflow) run(ctx context.Context, st *Step) DError {
 	st.w.LogStepInfo(st.name, "SubWorkflow", "Running subworkflow %q", s.Workflow.Name)
 	if err := s.Workflow.run(ctx); err != nil {
 		s.Workflow.LogStepInfo(st.name, "SubWorkflow", "Error running subworkflow %q: %v", s.Workflow.Name, err)
+		s.Workflow.runFailureHooks()
 		return err
 	}
 	return nil
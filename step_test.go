@@ -15,6 +15,7 @@
 package daisy
 
 import (
+	"context"
 	"reflect"
 	"testing"
 )
@@ -162,6 +163,10 @@ func TestStepImpl(t *testing.T) {
 		step     Step
 		stepType reflect.Type
 	}{
+		{
+			Step{BulkInsertDisks: &BulkInsertDisks{}},
+			reflect.TypeOf(&BulkInsertDisks{}),
+		},
 		{
 			Step{CreateDisks: &CreateDisks{}},
 			reflect.TypeOf(&CreateDisks{}),
@@ -170,6 +175,10 @@ func TestStepImpl(t *testing.T) {
 			Step{CreateImages: &CreateImages{}},
 			reflect.TypeOf(&CreateImages{}),
 		},
+		{
+			Step{ReplicateImages: &ReplicateImages{}},
+			reflect.TypeOf(&ReplicateImages{}),
+		},
 		{
 			Step{CreateMachineImages: &CreateMachineImages{}},
 			reflect.TypeOf(&CreateMachineImages{}),
@@ -186,6 +195,18 @@ func TestStepImpl(t *testing.T) {
 			Step{CopyGCSObjects: &CopyGCSObjects{}},
 			reflect.TypeOf(&CopyGCSObjects{}),
 		},
+		{
+			Step{ExportImage: &ExportImage{}},
+			reflect.TypeOf(&ExportImage{}),
+		},
+		{
+			Step{SmokeTestImage: &SmokeTestImage{}},
+			reflect.TypeOf(&SmokeTestImage{}),
+		},
+		{
+			Step{SetTags: &SetTags{}},
+			reflect.TypeOf(&SetTags{}),
+		},
 		{
 			Step{StartInstances: &StartInstances{}},
 			reflect.TypeOf(&StartInstances{}),
@@ -194,10 +215,18 @@ func TestStepImpl(t *testing.T) {
 			Step{StopInstances: &StopInstances{}},
 			reflect.TypeOf(&StopInstances{}),
 		},
+		{
+			Step{SendDiagnosticInterrupts: &SendDiagnosticInterrupts{}},
+			reflect.TypeOf(&SendDiagnosticInterrupts{}),
+		},
 		{
 			Step{DeleteResources: &DeleteResources{}},
 			reflect.TypeOf(&DeleteResources{}),
 		},
+		{
+			Step{AdoptResources: &AdoptResources{}},
+			reflect.TypeOf(&AdoptResources{}),
+		},
 		{
 			Step{IncludeWorkflow: &IncludeWorkflow{}},
 			reflect.TypeOf(&IncludeWorkflow{}),
@@ -210,6 +239,46 @@ func TestStepImpl(t *testing.T) {
 			Step{WaitForInstancesSignal: &WaitForInstancesSignal{}},
 			reflect.TypeOf(&WaitForInstancesSignal{}),
 		},
+		{
+			Step{WaitForBackendHealthy: &WaitForBackendHealthy{}},
+			reflect.TypeOf(&WaitForBackendHealthy{}),
+		},
+		{
+			Step{WaitForResourceStatus: &WaitForResourceStatus{}},
+			reflect.TypeOf(&WaitForResourceStatus{}),
+		},
+		{
+			Step{WaitForSignals: &WaitForSignals{}},
+			reflect.TypeOf(&WaitForSignals{}),
+		},
+		{
+			Step{UpdateResourcePolicies: &UpdateResourcePolicies{}},
+			reflect.TypeOf(&UpdateResourcePolicies{}),
+		},
+		{
+			Step{CaptureImages: &CaptureImages{}},
+			reflect.TypeOf(&CaptureImages{}),
+		},
+		{
+			Step{SetInstanceName: &SetInstanceName{}},
+			reflect.TypeOf(&SetInstanceName{}),
+		},
+		{
+			Step{SaveScreenshot: &SaveScreenshot{}},
+			reflect.TypeOf(&SaveScreenshot{}),
+		},
+		{
+			Step{CreateAddresses: &CreateAddresses{}},
+			reflect.TypeOf(&CreateAddresses{}),
+		},
+		{
+			Step{UpdateDisks: &UpdateDisks{}},
+			reflect.TypeOf(&UpdateDisks{}),
+		},
+		{
+			Step{UpdateNetworks: &UpdateNetworks{}},
+			reflect.TypeOf(&UpdateNetworks{}),
+		},
 	}
 
 	for _, tt := range tests {
@@ -237,3 +306,63 @@ func TestStepImpl(t *testing.T) {
 		t.Fatal("malformed step should have thrown an error")
 	}
 }
+
+type mockDestructiveStep struct {
+	mockStep
+}
+
+func (m *mockDestructiveStep) destructive() string {
+	return "project metadata"
+}
+
+func TestStepRunDestructive(t *testing.T) {
+	ctx := context.Background()
+	for _, confirm := range []bool{false, true} {
+		w := testWorkflow()
+		w.ConfirmDestructive = confirm
+		s, _ := w.NewStep("s")
+		ran := false
+		s.testType = &mockDestructiveStep{mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+			ran = true
+			return nil
+		}}}
+
+		err := s.run(ctx)
+		if confirm && (err != nil || !ran) {
+			t.Errorf("confirmed destructive step should have run, ran: %t, err: %v", ran, err)
+		} else if !confirm && (err == nil || ran) {
+			t.Errorf("unconfirmed destructive step should have failed without running, ran: %t, err: %v", ran, err)
+		}
+	}
+
+	// Confirmation is taken from the top level workflow.
+	w := testWorkflow()
+	w.ConfirmDestructive = true
+	iw := New()
+	w.includeWorkflow(iw)
+	iw.Logger = w.Logger
+	s, _ := iw.NewStep("s")
+	s.testType = &mockDestructiveStep{}
+	if err := s.run(ctx); err != nil {
+		t.Errorf("destructive step of an included workflow should have run, got error: %v", err)
+	}
+}
+
+func TestDestructiveSteps(t *testing.T) {
+	w := testWorkflow()
+	a, _ := w.NewStep("a")
+	a.testType = &mockDestructiveStep{}
+	b, _ := w.NewStep("b")
+	b.testType = &mockStep{}
+	iw := New()
+	w.includeWorkflow(iw)
+	inc, _ := w.NewStep("include")
+	inc.IncludeWorkflow = &IncludeWorkflow{Workflow: iw}
+	c, _ := iw.NewStep("c")
+	c.testType = &mockDestructiveStep{}
+
+	want := []string{"a (project metadata)", "include.c (project metadata)"}
+	if got := w.DestructiveSteps(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected destructive steps, got: %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// UpdateDisks is a Daisy UpdateDisks workflow step.
+type UpdateDisks []*UpdateDisk
+
+// UpdateDisk is used to update the provisioned performance of a GCE disk,
+// only the fields set are updated.
+type UpdateDisk struct {
+	// Name of the disk to be updated.
+	Name string
+	// New provisioned IOPS of the disk.
+	ProvisionedIops int64 `json:",omitempty,string"`
+	// New provisioned throughput of the disk, in MB per second.
+	ProvisionedThroughput int64 `json:",omitempty,string"`
+
+	project, zone, realName string
+}
+
+func (u *UpdateDisks) populate(ctx context.Context, s *Step) DError {
+	for _, ud := range *u {
+		if diskURLRgx.MatchString(ud.Name) {
+			ud.Name = extendPartialURL(ud.Name, s.w.Project)
+		}
+	}
+	return nil
+}
+
+func (u *UpdateDisks) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, ud := range *u {
+		dr, err := s.w.disks.regUse(ud.Name, s)
+		if dr == nil {
+			// Return now, the rest of this function can't be run without dr.
+			return addErrs(errs, Errf("cannot update disk: %v", err))
+		}
+		errs = addErrs(errs, err)
+
+		disk := NamedSubexp(diskURLRgx, dr.link)
+		ud.project, ud.zone, ud.realName = disk["project"], disk["zone"], dr.RealName
+
+		pre := fmt.Sprintf("cannot update disk %q", ud.Name)
+		if len(ud.paths()) == 0 {
+			errs = addErrs(errs, Errf("%s: ProvisionedIops or ProvisionedThroughput must be set", pre))
+		}
+		if ud.ProvisionedIops < 0 {
+			errs = addErrs(errs, Errf("%s: ProvisionedIops can't be negative: %d", pre, ud.ProvisionedIops))
+		}
+		if ud.ProvisionedThroughput < 0 {
+			errs = addErrs(errs, Errf("%s: ProvisionedThroughput can't be negative: %d", pre, ud.ProvisionedThroughput))
+		}
+	}
+	return errs
+}
+
+// paths returns the update mask of the fields set.
+func (ud *UpdateDisk) paths() []string {
+	var paths []string
+	if ud.ProvisionedIops != 0 {
+		paths = append(paths, "provisionedIops")
+	}
+	if ud.ProvisionedThroughput != 0 {
+		paths = append(paths, "provisionedThroughput")
+	}
+	return paths
+}
+
+func (u *UpdateDisks) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, ud := range *u {
+		wg.Add(1)
+		go func(ud *UpdateDisk) {
+			defer wg.Done()
+
+			w.LogStepInfo(s.name, "UpdateDisks", "Updating disk %q: provisioned IOPS %d, provisioned throughput %d.", ud.realName, ud.ProvisionedIops, ud.ProvisionedThroughput)
+			d := &compute.Disk{ProvisionedIops: ud.ProvisionedIops, ProvisionedThroughput: ud.ProvisionedThroughput}
+			if err := w.ComputeClient.UpdateDisk(ud.project, ud.zone, ud.realName, d, ud.paths()); err != nil {
+				e <- newErr("failed to update disk", err)
+				return
+			}
+		}(ud)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		wg.Wait()
+		return nil
+	}
+}
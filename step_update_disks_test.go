@@ -0,0 +1,138 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestUpdateDisksValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	sCreateDisk, _ := w.NewStep("step-create-disk")
+	w.disks.m = map[string]*Resource{"disk1": {RealName: "disk1-abcdef", link: fmt.Sprintf("projects/%s/zones/%s/disks/disk1-abcdef", testProject, testZone), creator: sCreateDisk}}
+
+	s, _ := w.NewStep("test")
+	w.AddDependency(s, sCreateDisk)
+
+	tests := []struct {
+		desc    string
+		uds     *UpdateDisks
+		wantErr bool
+	}{
+		{"update IOPS", &UpdateDisks{{Name: "disk1", ProvisionedIops: 5000}}, false},
+		{"update throughput", &UpdateDisks{{Name: "disk1", ProvisionedThroughput: 400}}, false},
+		{"update inexisting disk", &UpdateDisks{{Name: "foo", ProvisionedIops: 5000}}, true},
+		{"update nothing", &UpdateDisks{{Name: "disk1"}}, true},
+		{"negative IOPS", &UpdateDisks{{Name: "disk1", ProvisionedIops: -1}}, true},
+		{"negative throughput", &UpdateDisks{{Name: "disk1", ProvisionedIops: 5000, ProvisionedThroughput: -1}}, true},
+	}
+	for _, tt := range tests {
+		err := tt.uds.validate(ctx, s)
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+	}
+	if ud := (*tests[0].uds)[0]; ud.project != testProject || ud.zone != testZone || ud.realName != "disk1-abcdef" {
+		t.Errorf("unexpected project, zone and name, got: %q %q %q", ud.project, ud.zone, ud.realName)
+	}
+}
+
+func TestUpdateDisksRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	var uds UpdateDisks
+	if err := json.Unmarshal([]byte(`[{"Name": "disk1", "ProvisionedIops": "5000", "ProvisionedThroughput": "400"}, {"Name": "disk2", "ProvisionedIops": "3000"}]`), &uds); err != nil {
+		t.Fatalf("error unmarshalling UpdateDisks: %v", err)
+	}
+	for _, ud := range uds {
+		ud.project, ud.zone, ud.realName = testProject, testZone, ud.Name
+	}
+
+	type update struct {
+		disk  *compute.Disk
+		paths []string
+	}
+	got := make(chan update, len(uds))
+	w.ComputeClient = &daisyCompute.TestClient{UpdateDiskFn: func(project, zone, disk string, d *compute.Disk, paths []string) error {
+		if project != testProject || zone != testZone {
+			t.Errorf("unexpected project and zone, got: %q %q", project, zone)
+		}
+		got <- update{d, paths}
+		return nil
+	}}
+	if err := uds.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(got)
+
+	want := map[int64]update{
+		5000: {&compute.Disk{ProvisionedIops: 5000, ProvisionedThroughput: 400}, []string{"provisionedIops", "provisionedThroughput"}},
+		3000: {&compute.Disk{ProvisionedIops: 3000}, []string{"provisionedIops"}},
+	}
+	for u := range got {
+		if diffRes := diff(u, want[u.disk.ProvisionedIops], 0); diffRes != "" {
+			t.Errorf("client got incorrect update: (-got +want)\n%s", diffRes)
+		}
+	}
+
+	// Client error.
+	w.ComputeClient = &daisyCompute.TestClient{UpdateDiskFn: func(_, _, _ string, _ *compute.Disk, _ []string) error { return Errf("error") }}
+	if err := uds.run(ctx, s); err == nil {
+		t.Error("expected error, got none")
+	}
+}
+
+func TestUpdateDisksRunUpdateMask(t *testing.T) {
+	var gotMask string
+	svr, c, err := daisyCompute.NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" && r.URL.Path == fmt.Sprintf("/projects/%s/zones/%s/disks/%s", testProject, testZone, testDisk) {
+			gotMask = r.URL.Query().Get("updateMask")
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone) {
+			fmt.Fprint(w, `{"Status": "DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	w := testWorkflow()
+	w.ComputeClient = c
+	s := &Step{w: w}
+	uds := UpdateDisks{{Name: testDisk, ProvisionedIops: 5000, ProvisionedThroughput: 400, project: testProject, zone: testZone, realName: testDisk}}
+	if err := uds.run(context.Background(), s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "provisionedIops,provisionedThroughput"; gotMask != want {
+		t.Errorf("unexpected updateMask, got: %q, want: %q", gotMask, want)
+	}
+}
@@ -17,8 +17,6 @@ package daisy
 import (
 	"context"
 	"sync"
-
-	"google.golang.org/api/compute/v1"
 )
 
 // UpdateInstancesMetadata is a Daisy UpdateInstancesMetadata workflow step.
@@ -75,30 +73,11 @@ func (c *UpdateInstancesMetadata) run(ctx context.Context, s *Step) DError {
 				sm.Instance = instRes.RealName
 			}
 
-			// Get metadata fingerprint and original metadata
-			resp, err := w.ComputeClient.GetInstance(sm.project, sm.zone, sm.Instance)
-			if err != nil {
-				e <- newErr("failed to get instance data", err)
-				return
-			}
-			metadata := compute.Metadata{}
-			metadata.Fingerprint = resp.Metadata.Fingerprint
-			for k, v := range sm.Metadata {
-				vCopy := v
-				metadata.Items = append(metadata.Items, &compute.MetadataItems{Key: k, Value: &vCopy})
-			}
-
-			for _, item := range resp.Metadata.Items {
-				// Put only keys that were not updated
-				if _, ok := sm.Metadata[item.Key]; !ok {
-					metadata.Items = append(metadata.Items, item)
-				}
-			}
-
 			w.LogStepInfo(s.name, "UpdateInstancesMetadata", "Set Instance %q metadata to %q.", inst, sm.Metadata)
-			if err := w.ComputeClient.SetInstanceMetadata(sm.project, sm.zone, sm.Instance, &metadata); err != nil {
+			// The metadata is re-read and the update retried if the metadata
+			// changed since it was read.
+			if err := w.ComputeClient.MergeInstanceMetadata(sm.project, sm.zone, sm.Instance, sm.Metadata, nil); err != nil {
 				e <- newErr("failed to set instance metadata", err)
-				return
 			}
 		}(sm)
 	}
@@ -7,7 +7,6 @@ import (
 	"testing"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
-	"google.golang.org/api/compute/v1"
 )
 
 func TestUpdateInstancesMetadataValidate(t *testing.T) {
@@ -36,23 +35,6 @@ func TestUpdateInstancesMetadataValidate(t *testing.T) {
 	}
 }
 
-func computeMetataToMap(md compute.Metadata) map[string]string {
-	mdMap := map[string]string{}
-	for _, item := range md.Items {
-		mdMap[item.Key] = *item.Value
-	}
-	return mdMap
-}
-
-func mapToComputeMetadata(md map[string]string) compute.Metadata {
-	mdComp := compute.Metadata{}
-	for k, v := range md {
-		vCopy := v
-		mdComp.Items = append(mdComp.Items, &compute.MetadataItems{Key: k, Value: &vCopy})
-	}
-	return mdComp
-}
-
 func TestUpdateInstancesMetadataRun(t *testing.T) {
 	ctx := context.Background()
 	w := testWorkflow()
@@ -60,28 +42,31 @@ func TestUpdateInstancesMetadataRun(t *testing.T) {
 	w.instances.m = map[string]*Resource{testInstance: {Project: testProject, RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
 
 	tests := []struct {
-		desc             string
-		originalMetadata map[string]string
-		expectedMetadata map[string]string
-		sm               *UpdateInstancesMetadata
-		wantErr          bool
-		getInstErr       error
-		setMetaErr       error
+		desc     string
+		sm       *UpdateInstancesMetadata
+		wantAdd  map[string]string
+		wantErr  bool
+		mergeErr error
 	}{
-		{"blank case", map[string]string{}, map[string]string{}, &UpdateInstancesMetadata{}, false, nil, nil},
-		{"Add metadata case", map[string]string{"orig1": "value1"}, map[string]string{"orig1": "value1", "new1": "value2"}, &UpdateInstancesMetadata{{Instance: testInstance, Metadata: map[string]string{"new1": "value2"}}}, false, nil, nil},
-		{"override metadata case", map[string]string{"key1": "value1"}, map[string]string{"key1": "value2"}, &UpdateInstancesMetadata{{Instance: testInstance, Metadata: map[string]string{"key1": "value2"}}}, false, nil, nil},
-		{"get instance error case", map[string]string{}, map[string]string{}, &UpdateInstancesMetadata{{Instance: testInstance, Metadata: map[string]string{"key1": "value1"}}}, true, Errf("error"), nil},
-		{"set metadata error case", map[string]string{}, map[string]string{"key1": "value1"}, &UpdateInstancesMetadata{{Instance: testInstance, Metadata: map[string]string{"key1": "value1"}}}, true, nil, Errf("error")},
+		{"blank case", &UpdateInstancesMetadata{}, nil, false, nil},
+		{"add metadata case", &UpdateInstancesMetadata{{Instance: testInstance, Metadata: map[string]string{"new1": "value2"}}}, map[string]string{"new1": "value2"}, false, nil},
+		{"merge metadata error case", &UpdateInstancesMetadata{{Instance: testInstance, Metadata: map[string]string{"key1": "value1"}}}, map[string]string{"key1": "value1"}, true, Errf("error")},
 	}
 	for _, tt := range tests {
-		originalCompMetadata := mapToComputeMetadata(tt.originalMetadata)
-		instance := compute.Instance{Metadata: &originalCompMetadata}
-		mockGetInstance := func(_ string, _ string, _ string) (*compute.Instance, error) { return &instance, tt.getInstErr }
-
-		var gotM compute.Metadata
-		mockSetInstanceMetadata := func(_ string, _ string, _ string, md *compute.Metadata) error { gotM = *md; return tt.setMetaErr }
-		w.ComputeClient = &daisyCompute.TestClient{GetInstanceFn: mockGetInstance, SetInstanceMetadataFn: mockSetInstanceMetadata}
+		var gotAdd map[string]string
+		var gotRemove []string
+		w.ComputeClient = &daisyCompute.TestClient{
+			MergeInstanceMetadataFn: func(project, zone, name string, add map[string]string, remove []string) error {
+				if project != testProject || zone != testZone || name != testInstance {
+					t.Errorf("%s: unexpected instance %s/%s/%s", tt.desc, project, zone, name)
+				}
+				gotAdd, gotRemove = add, remove
+				return tt.mergeErr
+			},
+		}
+		for _, sm := range *tt.sm {
+			sm.project, sm.zone = testProject, testZone
+		}
 		err := tt.sm.run(ctx, s)
 		if !tt.wantErr && err != nil {
 			t.Errorf("%s: unexpected error: %v", tt.desc, err)
@@ -89,9 +74,8 @@ func TestUpdateInstancesMetadataRun(t *testing.T) {
 		if tt.wantErr && err == nil {
 			t.Errorf("%s: expected error, got none", tt.desc)
 		}
-		resMetadata := computeMetataToMap(gotM)
-		if !reflect.DeepEqual(tt.expectedMetadata, resMetadata) {
-			t.Errorf("%s: expected metadata %v, got %v", tt.desc, tt.expectedMetadata, resMetadata)
+		if !reflect.DeepEqual(gotAdd, tt.wantAdd) || gotRemove != nil {
+			t.Errorf("%s: unexpected metadata merge, got: add %v, remove %v, want: add %v", tt.desc, gotAdd, gotRemove, tt.wantAdd)
 		}
 	}
 }
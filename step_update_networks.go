@@ -0,0 +1,116 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/api/compute/v1"
+)
+
+var validRoutingModes = []string{"GLOBAL", "REGIONAL"}
+
+// UpdateNetworks is a Daisy UpdateNetworks workflow step.
+type UpdateNetworks []*UpdateNetwork
+
+// UpdateNetwork is used to switch a GCE network to custom subnet mode and to
+// update its routing mode. The network is switched before its routing mode
+// is updated.
+type UpdateNetwork struct {
+	// Name of the network to be updated.
+	Name string
+	// Switch the auto mode network to custom subnet mode.
+	SwitchToCustomMode bool `json:",omitempty"`
+	// New dynamic routing mode of the network, "GLOBAL" or "REGIONAL".
+	RoutingMode string `json:",omitempty"`
+
+	project, realName string
+}
+
+func (u *UpdateNetworks) populate(ctx context.Context, s *Step) DError {
+	for _, un := range *u {
+		if networkURLRegex.MatchString(un.Name) {
+			un.Name = extendPartialURL(un.Name, s.w.Project)
+		}
+	}
+	return nil
+}
+
+func (u *UpdateNetworks) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, un := range *u {
+		nr, err := s.w.networks.regUse(un.Name, s)
+		if nr == nil {
+			// Return now, the rest of this function can't be run without nr.
+			return addErrs(errs, Errf("cannot update network: %v", err))
+		}
+		errs = addErrs(errs, err)
+
+		network := NamedSubexp(networkURLRegex, nr.link)
+		un.project, un.realName = network["project"], nr.RealName
+
+		pre := fmt.Sprintf("cannot update network %q", un.Name)
+		if !un.SwitchToCustomMode && un.RoutingMode == "" {
+			errs = addErrs(errs, Errf("%s: SwitchToCustomMode or RoutingMode must be set", pre))
+		}
+		if un.RoutingMode != "" && !strIn(un.RoutingMode, validRoutingModes) {
+			errs = addErrs(errs, Errf("%s: RoutingMode must be one of %v: %q", pre, validRoutingModes, un.RoutingMode))
+		}
+	}
+	return errs
+}
+
+func (u *UpdateNetworks) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, un := range *u {
+		wg.Add(1)
+		go func(un *UpdateNetwork) {
+			defer wg.Done()
+
+			if un.SwitchToCustomMode {
+				w.LogStepInfo(s.name, "UpdateNetworks", "Switching network %q to custom subnet mode.", un.realName)
+				if err := w.ComputeClient.SwitchNetworkToCustomMode(un.project, un.realName); err != nil {
+					e <- newErr("failed to switch network to custom mode", err)
+					return
+				}
+			}
+			if un.RoutingMode != "" {
+				w.LogStepInfo(s.name, "UpdateNetworks", "Updating network %q: routing mode %s.", un.realName, un.RoutingMode)
+				n := &compute.Network{RoutingConfig: &compute.NetworkRoutingConfig{RoutingMode: un.RoutingMode}}
+				if err := w.ComputeClient.PatchNetwork(un.project, un.realName, n); err != nil {
+					e <- newErr("failed to update network", err)
+					return
+				}
+			}
+		}(un)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		wg.Wait()
+		return nil
+	}
+}
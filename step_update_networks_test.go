@@ -0,0 +1,123 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestUpdateNetworksValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	sCreateNetwork, _ := w.NewStep("step-create-network")
+	w.networks.m = map[string]*Resource{"network1": {RealName: "network1-abcdef", link: fmt.Sprintf("projects/%s/global/networks/network1-abcdef", testProject), creator: sCreateNetwork}}
+
+	s, _ := w.NewStep("test")
+	w.AddDependency(s, sCreateNetwork)
+
+	tests := []struct {
+		desc    string
+		uns     *UpdateNetworks
+		wantErr bool
+	}{
+		{"switch to custom mode", &UpdateNetworks{{Name: "network1", SwitchToCustomMode: true}}, false},
+		{"update routing mode", &UpdateNetworks{{Name: "network1", RoutingMode: "GLOBAL"}}, false},
+		{"update inexisting network", &UpdateNetworks{{Name: "foo", SwitchToCustomMode: true}}, true},
+		{"update nothing", &UpdateNetworks{{Name: "network1"}}, true},
+		{"bad routing mode", &UpdateNetworks{{Name: "network1", RoutingMode: "LOCAL"}}, true},
+	}
+	for _, tt := range tests {
+		err := tt.uns.validate(ctx, s)
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+	}
+	if un := (*tests[0].uns)[0]; un.project != testProject || un.realName != "network1-abcdef" {
+		t.Errorf("unexpected project and name, got: %q %q", un.project, un.realName)
+	}
+}
+
+func TestUpdateNetworksRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	uns := UpdateNetworks{
+		{Name: "network1", SwitchToCustomMode: true, RoutingMode: "GLOBAL"},
+		{Name: "network2", SwitchToCustomMode: true},
+		{Name: "network3", RoutingMode: "REGIONAL"},
+	}
+	for _, un := range uns {
+		un.project, un.realName = testProject, un.Name
+	}
+
+	var mx sync.Mutex
+	var calls []string
+	w.ComputeClient = &daisyCompute.TestClient{
+		SwitchNetworkToCustomModeFn: func(project, name string) error {
+			mx.Lock()
+			defer mx.Unlock()
+			calls = append(calls, "switch "+name)
+			return nil
+		},
+		PatchNetworkFn: func(project, name string, n *compute.Network) error {
+			mx.Lock()
+			defer mx.Unlock()
+			calls = append(calls, fmt.Sprintf("patch %s %s", name, n.RoutingConfig.RoutingMode))
+			return nil
+		},
+	}
+	if err := uns.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]bool{}
+	for _, c := range calls {
+		got[c] = true
+	}
+	want := map[string]bool{"switch network1": true, "patch network1 GLOBAL": true, "switch network2": true, "patch network3 REGIONAL": true}
+	if diffRes := diff(got, want, 0); diffRes != "" {
+		t.Errorf("client got incorrect updates: (-got +want)\n%s", diffRes)
+	}
+	// The network is switched before its routing mode is updated.
+	for i, c := range calls {
+		if c == "patch network1 GLOBAL" {
+			for _, c := range calls[i:] {
+				if c == "switch network1" {
+					t.Error("network1 was patched before it was switched to custom mode")
+				}
+			}
+		}
+	}
+
+	// Client errors.
+	w.ComputeClient = &daisyCompute.TestClient{SwitchNetworkToCustomModeFn: func(_, _ string) error { return Errf("error") }}
+	if err := (&UpdateNetworks{uns[1]}).run(ctx, s); err == nil {
+		t.Error("switch error: expected error, got none")
+	}
+	w.ComputeClient = &daisyCompute.TestClient{PatchNetworkFn: func(_, _ string, _ *compute.Network) error { return Errf("error") }}
+	if err := (&UpdateNetworks{uns[2]}).run(ctx, s); err == nil {
+		t.Error("patch error: expected error, got none")
+	}
+}
@@ -0,0 +1,139 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"google.golang.org/api/compute/v1"
+)
+
+var resourcePolicyURLRgx = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?regions/(?P<region>%[2]s)/resourcePolicies/(?P<resourcePolicy>%[2]s)$`, projectRgxStr, rfc1035))
+
+// UpdateResourcePolicies is a Daisy UpdateResourcePolicies workflow step.
+type UpdateResourcePolicies []*UpdateInstanceResourcePolicies
+
+// UpdateInstanceResourcePolicies is used to add resource policies to, or
+// remove them from, an existing instance.
+type UpdateInstanceResourcePolicies struct {
+	// Instance to update.
+	Instance string
+	// Resource policies to add, and to remove. Removals are done first, so a
+	// policy can be replaced. Policies are either names of policies in the
+	// instance's region or partial URLs.
+	Add    []string `json:",omitempty"`
+	Remove []string `json:",omitempty"`
+
+	project, zone, name string
+}
+
+// resourcePolicyURLs returns the URLs of policies, names are resolved
+// against the region of zone.
+func resourcePolicyURLs(policies []string, project, zone string) []string {
+	var urls []string
+	for _, p := range policies {
+		if resourcePolicyURLRgx.MatchString(p) {
+			urls = append(urls, extendPartialURL(p, project))
+		} else {
+			urls = append(urls, fmt.Sprintf("projects/%s/regions/%s/resourcePolicies/%s", project, getRegionFromZone(zone), p))
+		}
+	}
+	return urls
+}
+
+func (c *UpdateResourcePolicies) populate(ctx context.Context, s *Step) DError {
+	return nil
+}
+
+func (c *UpdateResourcePolicies) validate(ctx context.Context, s *Step) (errs DError) {
+	for _, up := range *c {
+		if len(up.Add) == 0 && len(up.Remove) == 0 {
+			errs = addErrs(errs, Errf("Instance %v: at least one of Add or Remove must be set", up.Instance))
+		}
+		for _, p := range append(up.Add, up.Remove...) {
+			if !resourcePolicyURLRgx.MatchString(p) && !checkName(p) {
+				errs = addErrs(errs, Errf("Instance %v: bad resource policy: %q", up.Instance, p))
+			}
+		}
+
+		ir, err := s.w.instances.regUse(up.Instance, s)
+		if ir == nil {
+			// Return now, the rest of this function can't be run without ir.
+			return addErrs(errs, Errf("cannot update resource policies: %v", err))
+		}
+		errs = addErrs(errs, err)
+
+		// Set instance project, zone and name.
+		instance := NamedSubexp(instanceURLRgx, ir.link)
+		up.project = instance["project"]
+		up.zone = instance["zone"]
+		up.name = instance["instance"]
+	}
+	return errs
+}
+
+func (c *UpdateResourcePolicies) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, up := range *c {
+		wg.Add(1)
+		go func(up *UpdateInstanceResourcePolicies) {
+			defer wg.Done()
+
+			inst := up.Instance
+			// The instance may have been renamed since validation.
+			if instRes, ok := w.instances.get(up.Instance); ok {
+				inst = instRes.link
+				up.name = NamedSubexp(instanceURLRgx, instRes.link)["instance"]
+			}
+
+			if len(up.Remove) > 0 {
+				policies := resourcePolicyURLs(up.Remove, up.project, up.zone)
+				w.LogStepInfo(s.name, "UpdateResourcePolicies", "Removing resource policies %q from Instance %q.", policies, inst)
+				req := &compute.InstancesRemoveResourcePoliciesRequest{ResourcePolicies: policies}
+				if err := w.ComputeClient.RemoveInstanceResourcePolicies(up.project, up.zone, up.name, req); err != nil {
+					e <- newErr("failed to remove instance resource policies", err)
+					return
+				}
+			}
+			if len(up.Add) > 0 {
+				policies := resourcePolicyURLs(up.Add, up.project, up.zone)
+				w.LogStepInfo(s.name, "UpdateResourcePolicies", "Adding resource policies %q to Instance %q.", policies, inst)
+				req := &compute.InstancesAddResourcePoliciesRequest{ResourcePolicies: policies}
+				if err := w.ComputeClient.AddInstanceResourcePolicies(up.project, up.zone, up.name, req); err != nil {
+					e <- newErr("failed to add instance resource policies", err)
+					return
+				}
+			}
+		}(up)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		wg.Wait()
+		return nil
+	}
+}
@@ -0,0 +1,137 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestUpdateResourcePoliciesValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.instances.m = map[string]*Resource{"instance": {Project: testProject, RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+
+	tests := []struct {
+		desc    string
+		up      *UpdateResourcePolicies
+		wantErr bool
+	}{
+		{"bad instance case", &UpdateResourcePolicies{{Instance: "bad", Add: []string{"policy"}}}, true},
+		{"bad policy case", &UpdateResourcePolicies{{Instance: "instance", Add: []string{"Bad_Policy"}}}, true},
+		{"nothing to update case", &UpdateResourcePolicies{{Instance: "instance"}}, true},
+		{"partial URL case", &UpdateResourcePolicies{{Instance: "instance", Remove: []string{"regions/r/resourcePolicies/policy"}}}, false},
+		{"positive flow case", &UpdateResourcePolicies{{Instance: "instance", Add: []string{"policy"}}}, false},
+	}
+	for _, tt := range tests {
+		err := tt.up.validate(ctx, s)
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+	}
+	if up := (*tests[4].up)[0]; up.project != testProject || up.zone != testZone || up.name != testInstance {
+		t.Errorf("unexpected project, zone and name, got: %q %q %q, want: %q %q %q", up.project, up.zone, up.name, testProject, testZone, testInstance)
+	}
+}
+
+func TestUpdateResourcePoliciesRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	policy := func(name string) string {
+		return fmt.Sprintf("projects/%s/regions/%s/resourcePolicies/%s", testProject, getRegionFromZone(testZone), name)
+	}
+
+	tests := []struct {
+		desc                   string
+		up                     *UpdateResourcePolicies
+		wantAdded, wantRemoved []string
+		wantErr                bool
+		addErr, removeErr      error
+	}{
+		{"add case", &UpdateResourcePolicies{{Add: []string{"a", "projects/other/regions/r/resourcePolicies/b"}, project: testProject, zone: testZone, name: testInstance}}, []string{policy("a"), "projects/other/regions/r/resourcePolicies/b"}, nil, false, nil, nil},
+		{"replace case", &UpdateResourcePolicies{{Add: []string{"a"}, Remove: []string{"b"}, project: testProject, zone: testZone, name: testInstance}}, []string{policy("a")}, []string{policy("b")}, false, nil, nil},
+		{"add error case", &UpdateResourcePolicies{{Add: []string{"a"}, project: testProject, zone: testZone, name: testInstance}}, []string{policy("a")}, nil, true, Errf("error"), nil},
+		{"remove error case", &UpdateResourcePolicies{{Add: []string{"a"}, Remove: []string{"b"}, project: testProject, zone: testZone, name: testInstance}}, nil, []string{policy("b")}, true, nil, Errf("error")},
+	}
+	for _, tt := range tests {
+		var gotAdded, gotRemoved []string
+		w.ComputeClient = &daisyCompute.TestClient{
+			AddInstanceResourcePoliciesFn: func(project, zone, instance string, req *compute.InstancesAddResourcePoliciesRequest) error {
+				if project != testProject || zone != testZone || instance != testInstance {
+					t.Errorf("%s: unexpected instance, got: %q %q %q", tt.desc, project, zone, instance)
+				}
+				gotAdded = req.ResourcePolicies
+				return tt.addErr
+			},
+			RemoveInstanceResourcePoliciesFn: func(project, zone, instance string, req *compute.InstancesRemoveResourcePoliciesRequest) error {
+				gotRemoved = req.ResourcePolicies
+				return tt.removeErr
+			},
+		}
+		err := tt.up.run(ctx, s)
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		}
+		if diffRes := diff(gotAdded, tt.wantAdded, 0); diffRes != "" {
+			t.Errorf("%s: added policies do not match expectation: (-got +want)\n%s", tt.desc, diffRes)
+		}
+		if diffRes := diff(gotRemoved, tt.wantRemoved, 0); diffRes != "" {
+			t.Errorf("%s: removed policies do not match expectation: (-got +want)\n%s", tt.desc, diffRes)
+		}
+	}
+}
+
+func TestUpdateResourcePoliciesRunRenamedInstance(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.instances.m = map[string]*Resource{"instance": {RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+
+	up := &UpdateResourcePolicies{{Instance: "instance", Add: []string{"a"}}}
+	if err := up.validate(ctx, s); err != nil {
+		t.Fatalf("unexpected validate error: %v", err)
+	}
+	// The instance is renamed, e.g. by a SetInstanceName step, after
+	// validation.
+	if err := w.instances.rename("instance", "renamed"); err != nil {
+		t.Fatalf("unexpected rename error: %v", err)
+	}
+
+	var got string
+	w.ComputeClient = &daisyCompute.TestClient{
+		AddInstanceResourcePoliciesFn: func(_, _, instance string, _ *compute.InstancesAddResourcePoliciesRequest) error {
+			got = instance
+			return nil
+		},
+	}
+	if err := up.run(ctx, s); err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+	if got != "renamed" {
+		t.Errorf("resource policies added to the wrong instance, got: %q, want: %q", got, "renamed")
+	}
+}
@@ -0,0 +1,216 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+// healthyState is the health state of a healthy backend endpoint.
+const healthyState = "HEALTHY"
+
+// WaitForBackendHealthy is a Daisy workflow step to wait for all the
+// endpoints of backends of backend services to be healthy, e.g. before
+// moving traffic to them.
+type WaitForBackendHealthy struct {
+	// Interval to check the health of the backends (default is 10s).
+	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
+	Interval       string `json:",omitempty"`
+	parsedInterval time.Duration
+	// Maximum time to wait for the backends, the step fails once it's
+	// reached. Waits until the step times out if unset.
+	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
+	Timeout       string `json:",omitempty"`
+	parsedTimeout time.Duration
+	Backends      []*BackendHealthy
+}
+
+// BackendHealthy waits for all the endpoints of a backend of a backend
+// service to be healthy.
+type BackendHealthy struct {
+	// Project of the backend service, defaults to the workflow project.
+	Project string `json:",omitempty"`
+	// Region of a regional backend service, the backend service is global if
+	// unset.
+	Region string `json:",omitempty"`
+	// Name of the backend service.
+	BackendService string
+	// Group of the backend, the partial URL of an instance group or network
+	// endpoint group which is a backend of BackendService.
+	Group string
+}
+
+func (bh *BackendHealthy) String() string {
+	return fmt.Sprintf("group %q of backend service %q", bh.Group, bh.BackendService)
+}
+
+// backendService gets the backend service of bh.
+func (bh *BackendHealthy) backendService(w *Workflow) (*compute.BackendService, error) {
+	if bh.Region == "" {
+		return w.ComputeClient.GetBackendService(bh.Project, bh.BackendService)
+	}
+	return w.ComputeClient.GetRegionBackendService(bh.Project, bh.Region, bh.BackendService)
+}
+
+// health returns the number of healthy endpoints of the backend, and the
+// total number of endpoints.
+func (bh *BackendHealthy) health(w *Workflow) (int, int, error) {
+	group := &compute.ResourceGroupReference{Group: bh.Group}
+	var h *compute.BackendServiceGroupHealth
+	var err error
+	if bh.Region == "" {
+		h, err = w.ComputeClient.GetGlobalBackendServiceHealth(bh.Project, bh.BackendService, group)
+	} else {
+		h, err = w.ComputeClient.GetBackendServiceHealth(bh.Project, bh.Region, bh.BackendService, group)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	var healthy int
+	for _, hs := range h.HealthStatus {
+		if hs.HealthState == healthyState {
+			healthy++
+		}
+	}
+	return healthy, len(h.HealthStatus), nil
+}
+
+func (wb *WaitForBackendHealthy) populate(ctx context.Context, s *Step) DError {
+	if wb.Interval == "" {
+		wb.Interval = defaultInterval
+	}
+	var err error
+	wb.parsedInterval, err = time.ParseDuration(wb.Interval)
+	if err != nil {
+		return typedErr(invalidInputError, fmt.Sprintf("failed to parse duration for step %v", s.name), err)
+	}
+	if wb.Timeout != "" {
+		wb.parsedTimeout, err = time.ParseDuration(wb.Timeout)
+		if err != nil {
+			return typedErr(invalidInputError, fmt.Sprintf("failed to parse timeout for step %v", s.name), err)
+		}
+	}
+	for _, bh := range wb.Backends {
+		if bh.Project == "" {
+			bh.Project = s.w.Project
+		}
+		if bh.Group != "" {
+			bh.Group = extendPartialURL(bh.Group, bh.Project)
+		}
+	}
+	return nil
+}
+
+func (wb *WaitForBackendHealthy) validate(ctx context.Context, s *Step) DError {
+	if wb.parsedInterval == 0*time.Second {
+		return Errf("No interval given for step %s", s.name)
+	}
+	if wb.Timeout != "" && wb.parsedTimeout <= 0 {
+		err := fmt.Errorf("Timeout must be positive for step %s", s.name)
+		return typedErr(invalidInputError, err.Error(), err)
+	}
+	if len(wb.Backends) == 0 {
+		err := fmt.Errorf("No backends given for step %s", s.name)
+		return typedErr(invalidInputError, err.Error(), err)
+	}
+	for _, bh := range wb.Backends {
+		if bh.BackendService == "" || bh.Group == "" {
+			err := fmt.Errorf("BackendService and Group must be given for step %s", s.name)
+			return typedErr(invalidInputError, err.Error(), err)
+		}
+		// The group of a backend exists, so checking that the group is a
+		// backend of the backend service checks both.
+		bs, err := bh.backendService(s.w)
+		if daisyCompute.IsNotFound(err) {
+			return Errf("cannot wait for %s: backend service does not exist", bh)
+		} else if err != nil {
+			return typedErr(apiError, fmt.Sprintf("cannot wait for %s: failed to get backend service", bh), err)
+		}
+		var found bool
+		for _, b := range bs.Backends {
+			if strings.HasSuffix(b.Group, "/"+bh.Group) || b.Group == bh.Group {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return Errf("cannot wait for %s: group is not a backend of the backend service", bh)
+		}
+	}
+	return nil
+}
+
+func (wb *WaitForBackendHealthy) run(ctx context.Context, s *Step) DError {
+	for _, bh := range wb.Backends {
+		s.w.LogStepInfo(s.name, "WaitForBackendHealthy", "Waiting for the endpoints of %s to be healthy.", bh)
+	}
+	var timeout <-chan time.Time
+	if wb.parsedTimeout != 0 {
+		t := time.NewTimer(wb.parsedTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	// Healthy endpoints at the last check, by backend.
+	last := map[*BackendHealthy]int{}
+	for {
+		tick := time.NewTimer(wb.parsedInterval)
+		select {
+		case <-s.w.Cancel:
+			tick.Stop()
+			s.w.LogStepInfo(s.name, "WaitForBackendHealthy", "Workflow canceled, stopped waiting for backends.")
+			return nil
+		case <-ctx.Done():
+			tick.Stop()
+			err := fmt.Errorf("context expired before backends were healthy in step %s", s.name)
+			return typedErr(ctx.Err().Error(), err.Error(), err)
+		case <-timeout:
+			tick.Stop()
+			err := fmt.Errorf("backends were not healthy within the timeout of %s in step %s", wb.Timeout, s.name)
+			return typedErr(context.DeadlineExceeded.Error(), err.Error(), err)
+		case <-tick.C:
+			ok, err := wb.checkBackends(s, last)
+			if err != nil || ok {
+				return err
+			}
+		}
+	}
+}
+
+// checkBackends returns whether all the endpoints of all backends are
+// healthy. Changes of the number of healthy endpoints of a backend since the
+// last check are logged. A backend without endpoints isn't healthy.
+func (wb *WaitForBackendHealthy) checkBackends(s *Step, last map[*BackendHealthy]int) (bool, DError) {
+	allHealthy := true
+	for _, bh := range wb.Backends {
+		healthy, total, err := bh.health(s.w)
+		if err != nil {
+			return false, typedErr(apiError, fmt.Sprintf("failed to get health of %s", bh), err)
+		}
+		if prev, ok := last[bh]; !ok || prev != healthy {
+			s.w.LogStepInfo(s.name, "WaitForBackendHealthy", "%d of %d endpoints of %s are healthy.", healthy, total, bh)
+		}
+		last[bh] = healthy
+		if total == 0 || healthy != total {
+			allHealthy = false
+		}
+	}
+	return allHealthy, nil
+}
@@ -0,0 +1,177 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+var (
+	testBackendService = "test-backend-service"
+	testBackendGroup   = fmt.Sprintf("zones/%s/instanceGroups/test-group", testZone)
+)
+
+// testBackendServiceClient sets up the test client of w with a global and a
+// regional backend service, both with the backend testBackendGroup.
+func testBackendServiceClient(w *Workflow) *daisyCompute.TestClient {
+	c := w.ComputeClient.(*daisyCompute.TestClient)
+	bs := &compute.BackendService{Name: testBackendService, Backends: []*compute.Backend{
+		{Group: fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/%s", testProject, testBackendGroup)},
+	}}
+	get := func(project, name string) (*compute.BackendService, error) {
+		if project != testProject || name != testBackendService {
+			return nil, daisyCompute.ErrResourceNotFound
+		}
+		return bs, nil
+	}
+	c.GetBackendServiceFn = get
+	c.GetRegionBackendServiceFn = func(project, region, name string) (*compute.BackendService, error) {
+		if region != testRegion {
+			return nil, daisyCompute.ErrResourceNotFound
+		}
+		return get(project, name)
+	}
+	return c
+}
+
+func TestWaitForBackendHealthyValidate(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{name: "foo", w: w}
+	c := testBackendServiceClient(w)
+	get := c.GetBackendServiceFn
+	c.GetBackendServiceFn = func(project, name string) (*compute.BackendService, error) {
+		if name == "error" {
+			return nil, errors.New("error")
+		}
+		return get(project, name)
+	}
+
+	tests := []struct {
+		desc      string
+		bh        *BackendHealthy
+		shouldErr bool
+	}{
+		{"global case", &BackendHealthy{BackendService: testBackendService, Group: testBackendGroup}, false},
+		{"regional case", &BackendHealthy{Region: testRegion, BackendService: testBackendService, Group: testBackendGroup}, false},
+		{"group url case", &BackendHealthy{BackendService: testBackendService, Group: fmt.Sprintf("projects/%s/%s", testProject, testBackendGroup)}, false},
+		{"no backend service case", &BackendHealthy{Group: testBackendGroup}, true},
+		{"no group case", &BackendHealthy{BackendService: testBackendService}, true},
+		{"backend service dne case", &BackendHealthy{BackendService: "dne", Group: testBackendGroup}, true},
+		{"regional backend service dne case", &BackendHealthy{Region: "dne", BackendService: testBackendService, Group: testBackendGroup}, true},
+		{"other project case", &BackendHealthy{Project: "dne", BackendService: testBackendService, Group: testBackendGroup}, true},
+		{"group not a backend case", &BackendHealthy{BackendService: testBackendService, Group: fmt.Sprintf("zones/%s/instanceGroups/dne", testZone)}, true},
+		{"api error case", &BackendHealthy{BackendService: "error", Group: testBackendGroup}, true},
+	}
+	for _, tt := range tests {
+		wb := &WaitForBackendHealthy{Backends: []*BackendHealthy{tt.bh}}
+		if err := wb.populate(context.Background(), s); err != nil {
+			t.Fatalf("%s: unexpected populate error: %v", tt.desc, err)
+		}
+		err := wb.validate(context.Background(), s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+
+	for _, wb := range []*WaitForBackendHealthy{
+		{Interval: "1 minute", Backends: []*BackendHealthy{{BackendService: testBackendService, Group: testBackendGroup}}},
+		{Timeout: "1 minute", Backends: []*BackendHealthy{{BackendService: testBackendService, Group: testBackendGroup}}},
+	} {
+		if err := wb.populate(context.Background(), s); !err.CausedByErrType(invalidInputError) {
+			t.Errorf("unexpected error type for bad durations, want %v, got %v", invalidInputError, err)
+		}
+	}
+	wb := &WaitForBackendHealthy{}
+	if err := wb.populate(context.Background(), s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+	if err := wb.validate(context.Background(), s); !err.CausedByErrType(invalidInputError) {
+		t.Errorf("unexpected error type for no backends, want %v, got %v", invalidInputError, err)
+	}
+}
+
+func TestWaitForBackendHealthyRun(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{name: "foo", w: w}
+	c := testBackendServiceClient(w)
+
+	// The global backend gets an endpoint which becomes healthy, the regional
+	// backend is always healthy.
+	states := [][]string{nil, {"UNHEALTHY"}, {"HEALTHY"}}
+	var calls int
+	c.GetGlobalBackendServiceHealthFn = func(project, bs string, group *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error) {
+		if want := fmt.Sprintf("projects/%s/%s", testProject, testBackendGroup); project != testProject || bs != testBackendService || group.Group != want {
+			t.Errorf("unexpected GetGlobalBackendServiceHealth call for project %q, backend service %q, group %q", project, bs, group.Group)
+		}
+		h := &compute.BackendServiceGroupHealth{}
+		for _, st := range states[min(calls, len(states)-1)] {
+			h.HealthStatus = append(h.HealthStatus, &compute.HealthStatus{HealthState: st})
+		}
+		calls++
+		return h, nil
+	}
+	c.GetBackendServiceHealthFn = func(_, region, _ string, _ *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error) {
+		if region != testRegion {
+			t.Errorf("unexpected GetBackendServiceHealth call for region %q", region)
+		}
+		return &compute.BackendServiceGroupHealth{HealthStatus: []*compute.HealthStatus{{HealthState: "HEALTHY"}, {HealthState: "HEALTHY"}}}, nil
+	}
+
+	wb := &WaitForBackendHealthy{
+		Interval: "1ms",
+		Backends: []*BackendHealthy{
+			{BackendService: testBackendService, Group: testBackendGroup},
+			{Region: testRegion, BackendService: testBackendService, Group: testBackendGroup},
+		},
+	}
+	ctx := context.Background()
+	if err := wb.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+	if err := wb.validate(ctx, s); err != nil {
+		t.Fatalf("unexpected validate error: %v", err)
+	}
+	if err := wb.run(ctx, s); err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+	if calls != len(states) {
+		t.Errorf("unexpected number of health checks, got: %d, want: %d", calls, len(states))
+	}
+
+	// Backends which never get healthy time out.
+	wb.Timeout = "50ms"
+	states = [][]string{{"UNHEALTHY"}}
+	if err := wb.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+	if err := wb.run(ctx, s); !err.CausedByErrType(context.DeadlineExceeded.Error()) {
+		t.Errorf("unexpected error type from timeout, want %v, got %v", context.DeadlineExceeded.Error(), err)
+	}
+
+	c.GetGlobalBackendServiceHealthFn = func(_, _ string, _ *compute.ResourceGroupReference) (*compute.BackendServiceGroupHealth, error) {
+		return nil, errors.New("error")
+	}
+	if err := wb.run(ctx, s); !err.CausedByErrType(apiError) {
+		t.Errorf("unexpected error type from health check error, want %v, got %v", apiError, err)
+	}
+}
@@ -23,17 +23,49 @@ import (
 	"sync"
 	"time"
 
-	"google.golang.org/api/googleapi"
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
 )
 
 const (
 	defaultInterval = "10s"
+	// maxInstanceSignalPolls bounds the API calls made at the same time by
+	// the instance signal watchers of a workflow, there is one watcher per
+	// signal and instance.
+	maxInstanceSignalPolls = 20
 )
 
 var (
 	serialOutputValueRegex = regexp.MustCompile(".*<serial-output key:'(.*)' value:'(.*)'>")
 )
 
+// pollInstanceSignal runs the API calls of a single poll, f, once fewer than
+// maxInstanceSignalPolls polls of the top level workflow of w are running.
+// It returns false without running f if ctx is done or w is canceled first.
+func pollInstanceSignal(ctx context.Context, w *Workflow, f func()) bool {
+	root := w
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.instanceSignalPollsMx.Lock()
+	if root.instanceSignalPolls == nil {
+		root.instanceSignalPolls = make(chan struct{}, maxInstanceSignalPolls)
+	}
+	polls := root.instanceSignalPolls
+	root.instanceSignalPollsMx.Unlock()
+
+	select {
+	case polls <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	case <-w.Cancel:
+		return false
+	}
+	defer func() { <-polls }()
+	f()
+	return true
+}
+
 // WaitForInstancesSignal is a Daisy WaitForInstancesSignal workflow step.
 type WaitForInstancesSignal []*InstanceSignal
 
@@ -104,7 +136,7 @@ type InstanceSignal struct {
 	Status []string `json:",omitempty"`
 }
 
-func waitForInstanceStopped(s *Step, project, zone, name string, interval time.Duration) DError {
+func waitForInstanceStopped(ctx context.Context, s *Step, project, zone, name string, interval time.Duration) DError {
 	w := s.w
 	w.LogStepInfo(s.name, "WaitForInstancesSignal", "Waiting for instance %q to stop.", name)
 	tick := time.Tick(interval)
@@ -112,10 +144,16 @@ func waitForInstanceStopped(s *Step, project, zone, name string, interval time.D
 		select {
 		case <-s.w.Cancel:
 			return nil
+		case <-ctx.Done():
+			return nil
 		case <-tick:
-			stopped, err := s.w.ComputeClient.InstanceStopped(project, zone, name)
+			var stopped bool
+			var err error
+			if !pollInstanceSignal(ctx, w, func() { stopped, err = s.w.ComputeClient.InstanceStopped(project, zone, name) }) {
+				return nil
+			}
 			if err != nil {
-				return typedErr(apiError, "failed to check whether instance is stopped", err)
+				return typedErr(apiError, fmt.Sprintf("failed to check whether instance %s is stopped", name), err)
 			}
 			if stopped {
 				w.LogStepInfo(s.name, "WaitForInstancesSignal", "Instance %q stopped.", name)
@@ -125,7 +163,7 @@ func waitForInstanceStopped(s *Step, project, zone, name string, interval time.D
 	}
 }
 
-func waitForInstanceStatus(s *Step, project, zone, name string, interval time.Duration, target []string) DError {
+func waitForInstanceStatus(ctx context.Context, s *Step, project, zone, name string, interval time.Duration, target []string) DError {
 	w := s.w
 	w.LogStepInfo(s.name, "WaitForInstancesSignal", "Waiting for instance %q to have status one of %v.", name, target)
 	tick := time.Tick(interval)
@@ -133,8 +171,14 @@ func waitForInstanceStatus(s *Step, project, zone, name string, interval time.Du
 		select {
 		case <-s.w.Cancel:
 			return nil
+		case <-ctx.Done():
+			return nil
 		case <-tick:
-			status, err := s.w.ComputeClient.InstanceStatus(project, zone, name)
+			var status string
+			var err error
+			if !pollInstanceSignal(ctx, w, func() { status, err = s.w.ComputeClient.InstanceStatus(project, zone, name) }) {
+				return nil
+			}
 			if err != nil {
 				return typedErr(apiError, fmt.Sprintf("failed to check instance %s status", name), err)
 			}
@@ -148,7 +192,7 @@ func waitForInstanceStatus(s *Step, project, zone, name string, interval time.Du
 	}
 }
 
-func waitForSerialOutput(s *Step, project, zone, name string, so *SerialOutput, interval time.Duration) DError {
+func waitForSerialOutput(ctx context.Context, s *Step, project, zone, name string, so *SerialOutput, interval time.Duration) DError {
 	w := s.w
 	msg := fmt.Sprintf("Instance %q: watching serial port %d", name, so.Port)
 	if so.SuccessMatch != "" {
@@ -169,10 +213,22 @@ func waitForSerialOutput(s *Step, project, zone, name string, so *SerialOutput,
 		select {
 		case <-s.w.Cancel:
 			return nil
+		case <-ctx.Done():
+			return nil
 		case <-tick:
-			resp, err := w.ComputeClient.GetSerialPortOutput(project, zone, name, so.Port, start)
+			var resp *compute.SerialPortOutput
+			var err, sErr error
+			var status string
+			polled := pollInstanceSignal(ctx, w, func() {
+				resp, err = w.ComputeClient.GetSerialPortOutput(project, zone, name, so.Port, start)
+				if err != nil {
+					status, sErr = w.ComputeClient.InstanceStatus(project, zone, name)
+				}
+			})
+			if !polled {
+				return nil
+			}
 			if err != nil {
-				status, sErr := w.ComputeClient.InstanceStatus(project, zone, name)
 				if sErr != nil {
 					err = fmt.Errorf("%v, error getting InstanceStatus: %v", err, sErr)
 				} else {
@@ -234,7 +290,7 @@ func waitForSerialOutput(s *Step, project, zone, name string, so *SerialOutput,
 	}
 }
 
-func waitForGuestAttribute(s *Step, project, zone, name string, ga *GuestAttribute, interval time.Duration) DError {
+func waitForGuestAttribute(ctx context.Context, s *Step, project, zone, name string, ga *GuestAttribute, interval time.Duration) DError {
 	var keyTokens []string
 	if ga.Namespace != "" {
 		keyTokens = append(keyTokens, ga.Namespace)
@@ -259,14 +315,24 @@ func waitForGuestAttribute(s *Step, project, zone, name string, ga *GuestAttribu
 		select {
 		case <-s.w.Cancel:
 			return nil
+		case <-ctx.Done():
+			return nil
 		case <-tick:
-			resp, err := w.ComputeClient.GetGuestAttributes(project, zone, name, "", varkey)
+			var resp *compute.GuestAttributes
+			var err error
+			if !pollInstanceSignal(ctx, w, func() { resp, err = w.ComputeClient.GetGuestAttributes(project, zone, name, "", varkey) }) {
+				return nil
+			}
 			if err != nil {
-				if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == 404 {
+				if daisyCompute.IsNotFound(err) {
 					// 404 is OK, that means the key isn't present yet. Retry until timeout.
 					continue
 				}
-				status, sErr := w.ComputeClient.InstanceStatus(project, zone, name)
+				var status string
+				var sErr error
+				if !pollInstanceSignal(ctx, w, func() { status, sErr = w.ComputeClient.InstanceStatus(project, zone, name) }) {
+					return nil
+				}
 				if sErr != nil {
 					err = fmt.Errorf("%v, error getting InstanceStatus: %v", err, sErr)
 					errs++
@@ -338,24 +404,37 @@ func populateForWaitForInstancesSignal(w *[]*InstanceSignal, sn string) DError {
 
 func (w *WaitForInstancesSignal) run(ctx context.Context, s *Step) DError {
 	is := (*[]*InstanceSignal)(w)
-	return runForWaitForInstancesSignal(is, s, true)
+	return runForWaitForInstancesSignal(ctx, is, s, true)
 }
 
 func (w *WaitForAnyInstancesSignal) run(ctx context.Context, s *Step) DError {
 	is := (*[]*InstanceSignal)(w)
-	return runForWaitForInstancesSignal(is, s, false)
+	return runForWaitForInstancesSignal(ctx, is, s, false)
 }
 
-func runForWaitForInstancesSignal(w *[]*InstanceSignal, s *Step, waitAll bool) DError {
+// runForWaitForInstancesSignal watches all instance signals concurrently. It
+// returns the first error, or once all signals are received, or with waitAll
+// unset once any signal is received. The remaining watchers are stopped when
+// it returns.
+func runForWaitForInstancesSignal(ctx context.Context, w *[]*InstanceSignal, s *Step, waitAll bool) DError {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	var wg sync.WaitGroup
 	e := make(chan DError)
+	// send reports err, unless the step already returned.
+	send := func(err DError) {
+		select {
+		case e <- err:
+		case <-ctx.Done():
+		}
+	}
 	for _, is := range *w {
 		wg.Add(1)
 		go func(is *InstanceSignal) {
 			defer wg.Done()
 			i, ok := s.w.instances.get(is.Name)
 			if !ok {
-				e <- Errf("unresolved instance %q", is.Name)
+				send(Errf("unresolved instance %q", is.Name))
 				return
 			}
 			m := NamedSubexp(instanceURLRgx, i.link)
@@ -364,33 +443,33 @@ func runForWaitForInstancesSignal(w *[]*InstanceSignal, s *Step, waitAll bool) D
 			statusSig := make(chan struct{})
 			if is.Stopped {
 				go func() {
-					if err := waitForInstanceStopped(s, m["project"], m["zone"], m["instance"], is.interval); err != nil {
-						e <- err
+					if err := waitForInstanceStopped(ctx, s, m["project"], m["zone"], m["instance"], is.interval); err != nil {
+						send(err)
 					}
 					close(statusSig)
 				}()
 			} else if len(is.Status) > 0 {
 				go func() {
-					if err := waitForInstanceStatus(s, m["project"], m["zone"], m["instance"], is.interval, is.Status); err != nil {
-						e <- err
+					if err := waitForInstanceStatus(ctx, s, m["project"], m["zone"], m["instance"], is.interval, is.Status); err != nil {
+						send(err)
 					}
 					close(statusSig)
 				}()
 			}
 			if is.SerialOutput != nil {
 				go func() {
-					if err := waitForSerialOutput(s, m["project"], m["zone"], m["instance"], is.SerialOutput, is.interval); err != nil || !waitAll {
+					if err := waitForSerialOutput(ctx, s, m["project"], m["zone"], m["instance"], is.SerialOutput, is.interval); err != nil || !waitAll {
 						// send a signal to end other waiting instances
-						e <- err
+						send(err)
 					}
 					close(serialSig)
 				}()
 			}
 			if is.GuestAttribute != nil {
 				go func() {
-					if err := waitForGuestAttribute(s, m["project"], m["zone"], m["instance"], is.GuestAttribute, is.interval); err != nil || !waitAll {
+					if err := waitForGuestAttribute(ctx, s, m["project"], m["zone"], m["instance"], is.GuestAttribute, is.interval); err != nil || !waitAll {
 						// send a signal to end other waiting instances
-						e <- err
+						send(err)
 					}
 					close(guestSig)
 				}()
@@ -407,13 +486,15 @@ func runForWaitForInstancesSignal(w *[]*InstanceSignal, s *Step, waitAll bool) D
 	}
 	go func() {
 		wg.Wait()
-		e <- nil
+		send(nil)
 	}()
 	select {
 	case err := <-e:
 		return err
 	case <-s.w.Cancel:
 		return nil
+	case <-ctx.Done():
+		return nil
 	}
 }
 
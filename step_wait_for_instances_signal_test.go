@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -46,7 +48,7 @@ func TestWaitForInstanceStopped(t *testing.T) {
 
 	w.ComputeClient = c
 	s := &Step{name: "foo", w: w}
-	if err := waitForInstanceStopped(s, testProject, testZone, "foo", 1*time.Microsecond); err != nil {
+	if err := waitForInstanceStopped(context.Background(), s, testProject, testZone, "foo", 1*time.Microsecond); err != nil {
 		t.Fatalf("error running waitForInstanceStopped: %v", err)
 	}
 }
@@ -69,11 +71,46 @@ func TestWaitForInstanceStatus(t *testing.T) {
 
 	w.ComputeClient = c
 	s := &Step{name: "foo", w: w}
-	if err := waitForInstanceStatus(s, testProject, testZone, "foo", 1*time.Microsecond, []string{"SUSPENDING"}); err != nil {
+	if err := waitForInstanceStatus(context.Background(), s, testProject, testZone, "foo", 1*time.Microsecond, []string{"SUSPENDING"}); err != nil {
 		t.Fatalf("error running waitForInstanceStatus: %v", err)
 	}
 }
 
+func TestPollInstanceSignal(t *testing.T) {
+	w := testWorkflow()
+	child := testWorkflow()
+	child.parent = w
+
+	// Fill all slots of w from its child workflow.
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < maxInstanceSignalPolls; i++ {
+		wg.Add(1)
+		go pollInstanceSignal(context.Background(), child, func() {
+			wg.Done()
+			<-release
+		})
+	}
+	wg.Wait()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if pollInstanceSignal(ctx, w, func() { t.Error("poll ran without a free slot") }) {
+		t.Error("pollInstanceSignal should have returned false once ctx is done")
+	}
+	w.CancelWorkflow()
+	if pollInstanceSignal(context.Background(), w, func() { t.Error("poll ran without a free slot") }) {
+		t.Error("pollInstanceSignal should have returned false once the workflow is canceled")
+	}
+
+	// Other workflows have their own slots.
+	var ran bool
+	if !pollInstanceSignal(context.Background(), testWorkflow(), func() { ran = true }) || !ran {
+		t.Error("poll of another workflow should have run")
+	}
+}
+
 func TestWaitForInstancesSignalPopulate(t *testing.T) {
 	testWaitForSignalPopulate(t, false)
 }
@@ -345,6 +382,59 @@ func getStep(waitAny bool, iss []*InstanceSignal) stepImpl {
 	return &si
 }
 
+func TestWaitForInstancesSignalRunConcurrent(t *testing.T) {
+	w := testWorkflow()
+	var mx sync.Mutex
+	polls := 0
+	w.ComputeClient.(*daisyCompute.TestClient).GetSerialPortOutputFn = func(_, _, n string, _, start int64) (*compute.SerialPortOutput, error) {
+		mx.Lock()
+		defer mx.Unlock()
+		polls++
+		if n == w.genName("i3") && start > 0 {
+			return &compute.SerialPortOutput{Contents: "boot failed\n", Next: start + 1}, nil
+		}
+		return &compute.SerialPortOutput{Contents: "booting\n", Next: start + 1}, nil
+	}
+	s := &Step{w: w}
+	w.instances.m = map[string]*Resource{}
+	var iss []*InstanceSignal
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("i%d", i)
+		w.instances.m[name] = &Resource{link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, w.genName(name))}
+		iss = append(iss, &InstanceSignal{Name: name, interval: 1 * time.Millisecond, SerialOutput: &SerialOutput{Port: 1, SuccessMatch: "booted", FailureMatch: []string{"failed"}}})
+	}
+	stopped := func() bool {
+		// Watchers may finish a poll in flight after run returned.
+		time.Sleep(20 * time.Millisecond)
+		mx.Lock()
+		before := polls
+		mx.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mx.Lock()
+		defer mx.Unlock()
+		return polls == before
+	}
+
+	// Failure on one instance fails the step right away.
+	err := getStep(false, iss).run(context.Background(), s)
+	if err == nil || !strings.Contains(err.Error(), w.genName("i3")) {
+		t.Errorf("expected failure of instance %q, got: %v", w.genName("i3"), err)
+	}
+	if !stopped() {
+		t.Error("watchers still polling after the step failed")
+	}
+
+	// Canceling the context stops all watchers.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := getStep(false, iss[:2]).run(ctx, s); err != nil {
+		t.Errorf("unexpected error after cancellation: %v", err)
+	}
+	if !stopped() {
+		t.Error("watchers still polling after the context was canceled")
+	}
+}
+
 // The output is split to 3 sections. Test the loop can concat them together correctly.
 func TestWaitForSignalGetSplitOutput(t *testing.T) {
 	ctx := context.Background()
@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"google.golang.org/api/compute/v1"
 )
 
 const defaultQuotaInterval = "5s"
@@ -28,7 +30,17 @@ type WaitForAvailableQuotas struct {
 	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
 	Interval       string `json:",omitempty"`
 	parsedInterval time.Duration
-	Quotas         []*QuotaAvailable
+	// Maximum interval to check for signal. If set, the checks are backed
+	// off exponentially from Interval up to MaxInterval.
+	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
+	MaxInterval       string `json:",omitempty"`
+	parsedMaxInterval time.Duration
+	// Maximum time to wait for the quotas, the step fails once it's reached.
+	// Waits until the step times out if unset.
+	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
+	Timeout       string `json:",omitempty"`
+	parsedTimeout time.Duration
+	Quotas        []*QuotaAvailable
 }
 
 // QuotaAvailable waits for some units of quota to be available in a given region. The individual items to wait for in the workflow step.
@@ -39,6 +51,42 @@ type QuotaAvailable struct {
 	Region string
 	// Units of quota which must be available.
 	Units float64
+	// Number of planned resources to wait for quota for, used instead of Units.
+	// Units is computed as Count times the vCPUs of MachineType, Count times
+	// DiskSizeGb, or Count if neither is set, e.g. for IN_USE_ADDRESSES.
+	Count int64 `json:",omitempty"`
+	// Machine type of the planned instances, the vCPUs of which are counted.
+	// If Metric is unset it defaults to the CPU metric of the machine family.
+	MachineType string `json:",omitempty"`
+	// Whether the planned instances are Spot or preemptible VMs, which use
+	// the PREEMPTIBLE_CPUS metric.
+	Preemptible bool `json:",omitempty"`
+	// Size of the planned disks.
+	DiskSizeGb int64 `json:",omitempty"`
+}
+
+// resolveUnits computes Units from Count for quotas referencing planned
+// resources. The vCPUs of planned instances are counted as by
+// ComputePlannedQuota.
+func (q *QuotaAvailable) resolveUnits(ctx context.Context, w *Workflow) DError {
+	if q.Count == 0 {
+		return nil
+	}
+	switch {
+	case q.MachineType != "":
+		mt := NamedSubexp(machineTypeURLRegex, q.MachineType)
+		pi := plannedInstance{name: q.MachineType, zone: mt["zone"], machineType: q.MachineType, preemptible: q.Preemptible}
+		pq := plannedQuota{}
+		if err := w.addInstanceQuota(ctx, pq, pi); err != nil {
+			return err
+		}
+		q.Units = float64(q.Count) * pq[getRegionFromZone(pi.zone)][cpuQuotaMetric(mt["machinetype"], pi.preemptible)]
+	case q.DiskSizeGb != 0:
+		q.Units = float64(q.Count * q.DiskSizeGb)
+	default:
+		q.Units = float64(q.Count)
+	}
+	return nil
 }
 
 func (aq *WaitForAvailableQuotas) populate(ctx context.Context, s *Step) DError {
@@ -50,6 +98,31 @@ func (aq *WaitForAvailableQuotas) populate(ctx context.Context, s *Step) DError
 	if err != nil {
 		return typedErr(invalidInputError, fmt.Sprintf("failed to parse duration for step %v", s.name), err)
 	}
+	if aq.MaxInterval != "" {
+		aq.parsedMaxInterval, err = time.ParseDuration(aq.MaxInterval)
+		if err != nil {
+			return typedErr(invalidInputError, fmt.Sprintf("failed to parse max interval for step %v", s.name), err)
+		}
+	}
+	if aq.Timeout != "" {
+		aq.parsedTimeout, err = time.ParseDuration(aq.Timeout)
+		if err != nil {
+			return typedErr(invalidInputError, fmt.Sprintf("failed to parse timeout for step %v", s.name), err)
+		}
+	}
+	for _, q := range aq.Quotas {
+		if q.MachineType == "" {
+			continue
+		}
+		if machineTypeURLRegex.MatchString(q.MachineType) {
+			q.MachineType = extendPartialURL(q.MachineType, s.w.Project)
+		} else {
+			q.MachineType = fmt.Sprintf("projects/%s/zones/%s/machineTypes/%s", s.w.Project, s.w.Zone, q.MachineType)
+		}
+		if q.Metric == "" {
+			q.Metric = cpuQuotaMetric(NamedSubexp(machineTypeURLRegex, q.MachineType)["machinetype"], q.Preemptible)
+		}
+	}
 	return nil
 }
 
@@ -57,6 +130,14 @@ func (aq *WaitForAvailableQuotas) validate(ctx context.Context, s *Step) DError
 	if aq.parsedInterval == 0*time.Second {
 		return Errf("No interval given for step %s", s.name)
 	}
+	if aq.MaxInterval != "" && aq.parsedMaxInterval < aq.parsedInterval {
+		err := fmt.Errorf("MaxInterval must not be less than Interval for step %s", s.name)
+		return typedErr(invalidInputError, err.Error(), err)
+	}
+	if aq.Timeout != "" && aq.parsedTimeout <= 0 {
+		err := fmt.Errorf("Timeout must be positive for step %s", s.name)
+		return typedErr(invalidInputError, err.Error(), err)
+	}
 	for _, q := range aq.Quotas {
 		if q.Metric == "" {
 			err := fmt.Errorf("No metric given for step %s", s.name)
@@ -70,41 +151,117 @@ func (aq *WaitForAvailableQuotas) validate(ctx context.Context, s *Step) DError
 			err := fmt.Errorf("Units must be a positive int, got %.2f for step %s", q.Units, s.name)
 			return typedErr(invalidInputError, err.Error(), err)
 		}
+		if q.Count < 0 {
+			err := fmt.Errorf("Count must be a positive int, got %d for step %s", q.Count, s.name)
+			return typedErr(invalidInputError, err.Error(), err)
+		}
+		if q.Count != 0 && q.Units != 0 {
+			err := fmt.Errorf("Only one of Units or Count can be given for step %s", s.name)
+			return typedErr(invalidInputError, err.Error(), err)
+		}
+		if q.Count == 0 && (q.MachineType != "" || q.DiskSizeGb != 0) {
+			err := fmt.Errorf("MachineType and DiskSizeGb require Count for step %s", s.name)
+			return typedErr(invalidInputError, err.Error(), err)
+		}
+		if q.Preemptible && q.MachineType == "" {
+			err := fmt.Errorf("Preemptible requires MachineType for step %s", s.name)
+			return typedErr(invalidInputError, err.Error(), err)
+		}
+		if q.MachineType != "" && q.DiskSizeGb != 0 {
+			err := fmt.Errorf("Only one of MachineType or DiskSizeGb can be given for step %s", s.name)
+			return typedErr(invalidInputError, err.Error(), err)
+		}
+		if q.MachineType != "" && !machineTypeURLRegex.MatchString(q.MachineType) {
+			err := fmt.Errorf("Bad MachineType %q for step %s", q.MachineType, s.name)
+			return typedErr(invalidInputError, err.Error(), err)
+		}
 	}
 	return nil
 }
 
 func (aq *WaitForAvailableQuotas) run(ctx context.Context, s *Step) DError {
 	for _, a := range aq.Quotas {
+		if err := a.resolveUnits(ctx, s.w); err != nil {
+			return err
+		}
 		s.w.LogStepInfo(s.name, "WaitForAvailableQuotas", "Waiting for %.2f units of %s to be available in %s", a.Units, a.Metric, a.Region)
 	}
-	tick := time.Tick(aq.parsedInterval)
+	interval := func() time.Duration { return aq.parsedInterval }
+	if aq.parsedMaxInterval != 0 {
+		b := &Backoff{Base: aq.parsedInterval, Max: aq.parsedMaxInterval}
+		interval = b.Next
+	}
+	var timeout <-chan time.Time
+	if aq.parsedTimeout != 0 {
+		t := time.NewTimer(aq.parsedTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	// Units available at the last check, by region and metric.
+	last := map[string]float64{}
 	for {
+		tick := time.NewTimer(interval())
 		select {
 		case <-s.w.Cancel:
+			tick.Stop()
+			s.w.LogStepInfo(s.name, "WaitForAvailableQuotas", "Workflow canceled, stopped waiting for quotas.")
 			return nil
 		case <-ctx.Done():
+			tick.Stop()
 			err := fmt.Errorf("context expired before quota was available in step %s", s.name)
 			return typedErr(ctx.Err().Error(), err.Error(), err)
-		case <-tick:
-			var successmsgs []string
-			for _, a := range aq.Quotas {
-				r, err := s.w.ComputeClient.GetRegion(s.w.Project, a.Region)
-				if err != nil {
-					return typedErr(apiError, "failed to get region "+a.Region, err)
-				}
-				for _, q := range r.Quotas {
-					if q.Metric == a.Metric && ((q.Limit - q.Usage) >= a.Units) {
-						successmsgs = append(successmsgs, fmt.Sprintf("Region %s has %.2f units of %s available", a.Region, (q.Limit-q.Usage), a.Metric))
-					}
-				}
+		case <-timeout:
+			tick.Stop()
+			err := fmt.Errorf("quotas were not available within the timeout of %s in step %s", aq.Timeout, s.name)
+			return typedErr(context.DeadlineExceeded.Error(), err.Error(), err)
+		case <-tick.C:
+			ok, err := aq.checkQuotas(s, last)
+			if err != nil || ok {
+				return err
+			}
+		}
+	}
+}
+
+// checkQuotas returns whether all quotas are available. The quotas of each
+// region are read once, and changes of the available units since the last
+// check are logged.
+func (aq *WaitForAvailableQuotas) checkQuotas(s *Step, last map[string]float64) (bool, DError) {
+	regions := map[string]*compute.Region{}
+	var successmsgs []string
+	for _, a := range aq.Quotas {
+		r, ok := regions[a.Region]
+		if !ok {
+			var err error
+			if r, err = s.w.ComputeClient.GetRegion(s.w.Project, a.Region); err != nil {
+				return false, typedErr(apiError, "failed to get region "+a.Region, err)
+			}
+			regions[a.Region] = r
+		}
+		for _, q := range r.Quotas {
+			if q.Metric != a.Metric {
+				continue
 			}
-			if len(successmsgs) == len(aq.Quotas) {
-				for _, m := range successmsgs {
-					s.w.LogStepInfo(s.name, "WaitForAvailableQuotas", m)
+			available := q.Limit - q.Usage
+			key := a.Region + "/" + a.Metric
+			if prev, ok := last[key]; ok && prev != available {
+				change := "rose"
+				if available < prev {
+					change = "fell"
 				}
-				return nil
+				s.w.LogStepInfo(s.name, "WaitForAvailableQuotas", "%s in region %s %s from %.2f to %.2f units available", a.Metric, a.Region, change, prev, available)
+			}
+			last[key] = available
+			if available >= a.Units {
+				successmsgs = append(successmsgs, fmt.Sprintf("Region %s has %.2f units of %s available", a.Region, available, a.Metric))
 			}
 		}
 	}
+	if len(successmsgs) != len(aq.Quotas) {
+		return false, nil
+	}
+	for _, m := range successmsgs {
+		s.w.LogStepInfo(s.name, "WaitForAvailableQuotas", m)
+	}
+	return true, nil
 }
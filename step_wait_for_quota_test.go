@@ -18,10 +18,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
 )
 
 func TestWaitForAvailableQuotas(t *testing.T) {
@@ -40,6 +42,9 @@ func TestWaitForAvailableQuotas(t *testing.T) {
 	}
 	defer svr.Close()
 
+	c.GetMachineTypeSpecFn = func(_, _, _ string) (int64, int64, error) {
+		return 2, 8192, nil
+	}
 	w.ComputeClient = c
 	w.Project = testProject
 	s := &Step{name: "foo", w: w}
@@ -64,6 +69,25 @@ func TestWaitForAvailableQuotas(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "backed off checks",
+			input: WaitForAvailableQuotas{
+				Interval:    "0.1s",
+				MaxInterval: "1s",
+				Quotas: []*QuotaAvailable{
+					&QuotaAvailable{Metric: "A", Region: testRegion, Units: 1.0},
+				},
+			},
+		},
+		{
+			name: "planned resources",
+			input: WaitForAvailableQuotas{
+				Quotas: []*QuotaAvailable{
+					&QuotaAvailable{Metric: "A", Region: testRegion, Count: 2, MachineType: "n1-standard-2"},
+					&QuotaAvailable{Metric: "C", Region: testRegion, Count: 2, DiskSizeGb: 3},
+				},
+			},
+		},
 	}
 	for _, test := range tc {
 		t.Run(test.name, func(t *testing.T) {
@@ -101,6 +125,9 @@ func TestWaitForAvailableQuotasError(t *testing.T) {
 	}
 	defer svr.Close()
 
+	c.GetMachineTypeSpecFn = func(_, _, _ string) (int64, int64, error) {
+		return 2, 8192, nil
+	}
 	w.ComputeClient = c
 	w.Project = testProject
 	s := &Step{name: "foo", w: w}
@@ -120,6 +147,16 @@ func TestWaitForAvailableQuotasError(t *testing.T) {
 			},
 			output: context.DeadlineExceeded.Error(),
 		},
+		{
+			name: "unavailable quota for planned resources",
+			input: WaitForAvailableQuotas{
+				Interval: "0.1s",
+				Quotas: []*QuotaAvailable{
+					&QuotaAvailable{Metric: "A", Region: testRegion, Count: 3, MachineType: "n1-standard-2"},
+				},
+			},
+			output: context.DeadlineExceeded.Error(),
+		},
 	}
 	for _, test := range tc {
 		t.Run(test.name, func(t *testing.T) {
@@ -179,6 +216,56 @@ func TestValidateWaitForAvailableQuotasError(t *testing.T) {
 			},
 			output: invalidInputError,
 		},
+		{
+			name: "negative count",
+			input: WaitForAvailableQuotas{
+				Interval: "0.1s",
+				Quotas: []*QuotaAvailable{
+					&QuotaAvailable{Metric: "A", Region: testRegion, Count: -1},
+				},
+			},
+			output: invalidInputError,
+		},
+		{
+			name: "units and count",
+			input: WaitForAvailableQuotas{
+				Interval: "0.1s",
+				Quotas: []*QuotaAvailable{
+					&QuotaAvailable{Metric: "A", Region: testRegion, Units: 5.0, Count: 1},
+				},
+			},
+			output: invalidInputError,
+		},
+		{
+			name: "disk size without count",
+			input: WaitForAvailableQuotas{
+				Interval: "0.1s",
+				Quotas: []*QuotaAvailable{
+					&QuotaAvailable{Metric: "A", Region: testRegion, DiskSizeGb: 10},
+				},
+			},
+			output: invalidInputError,
+		},
+		{
+			name: "preemptible without machine type",
+			input: WaitForAvailableQuotas{
+				Interval: "0.1s",
+				Quotas: []*QuotaAvailable{
+					&QuotaAvailable{Metric: "A", Region: testRegion, Count: 1, Preemptible: true},
+				},
+			},
+			output: invalidInputError,
+		},
+		{
+			name: "machine type and disk size",
+			input: WaitForAvailableQuotas{
+				Interval: "0.1s",
+				Quotas: []*QuotaAvailable{
+					&QuotaAvailable{Metric: "A", Region: testRegion, Count: 1, MachineType: "n1-standard-2", DiskSizeGb: 10},
+				},
+			},
+			output: invalidInputError,
+		},
 	}
 	for _, test := range tc {
 		t.Run(test.name, func(t *testing.T) {
@@ -223,3 +310,169 @@ func TestPopulateWaitForAvailableQuotasError(t *testing.T) {
 		})
 	}
 }
+
+func TestQuotaAvailableResolveUnits(t *testing.T) {
+	w := testWorkflow()
+	w.ComputeClient.(*daisyCompute.TestClient).GetMachineTypeSpecFn = func(_, _, mt string) (int64, int64, error) {
+		if mt != "n2-standard-4" {
+			return 0, 0, fmt.Errorf("unexpected machine type %q", mt)
+		}
+		return 4, 16384, nil
+	}
+	s := &Step{name: "foo", w: w}
+	aq := &WaitForAvailableQuotas{
+		Quotas: []*QuotaAvailable{
+			{Region: testRegion, Count: 3, MachineType: "n2-standard-4"},
+			{Region: testRegion, Count: 2, MachineType: "n2-standard-4", Preemptible: true},
+			{Metric: "SSD_TOTAL_GB", Region: testRegion, Count: 2, DiskSizeGb: 500},
+			{Metric: "IN_USE_ADDRESSES", Region: testRegion, Count: 5},
+			{Metric: "CPUS", Region: testRegion, Units: 1.5},
+		},
+	}
+	if err := aq.populate(context.Background(), s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+	if err := aq.validate(context.Background(), s); err != nil {
+		t.Fatalf("unexpected validate error: %v", err)
+	}
+	if want := fmt.Sprintf("projects/%s/zones/%s/machineTypes/n2-standard-4", testProject, testZone); aq.Quotas[0].MachineType != want {
+		t.Errorf("unexpected MachineType, got: %q, want: %q", aq.Quotas[0].MachineType, want)
+	}
+	if aq.Quotas[0].Metric != "N2_CPUS" {
+		t.Errorf("unexpected default Metric, got: %q, want: %q", aq.Quotas[0].Metric, "N2_CPUS")
+	}
+	if aq.Quotas[1].Metric != "PREEMPTIBLE_CPUS" {
+		t.Errorf("unexpected default Metric, got: %q, want: %q", aq.Quotas[1].Metric, "PREEMPTIBLE_CPUS")
+	}
+
+	want := []float64{12, 8, 1000, 5, 1.5}
+	for i, q := range aq.Quotas {
+		if err := q.resolveUnits(context.Background(), w); err != nil {
+			t.Fatalf("unexpected resolveUnits error: %v", err)
+		}
+		if q.Units != want[i] {
+			t.Errorf("quota %d: unexpected Units, got: %.2f, want: %.2f", i, q.Units, want[i])
+		}
+	}
+
+	bad := &QuotaAvailable{Metric: "CPUS", Region: testRegion, Count: 1, MachineType: fmt.Sprintf("projects/%s/zones/%s/machineTypes/dne", testProject, testZone)}
+	if err := bad.resolveUnits(context.Background(), w); err == nil {
+		t.Error("should have returned an error for a failed machine type lookup")
+	}
+}
+
+func TestWaitForAvailableQuotasMaxInterval(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{name: "foo", w: w}
+	tests := []struct {
+		desc      string
+		aq        *WaitForAvailableQuotas
+		shouldErr bool
+	}{
+		{"max interval case", &WaitForAvailableQuotas{Interval: "1s", MaxInterval: "1m"}, false},
+		{"max interval equal to interval case", &WaitForAvailableQuotas{Interval: "1s", MaxInterval: "1s"}, false},
+		{"max interval below interval case", &WaitForAvailableQuotas{Interval: "1m", MaxInterval: "1s"}, true},
+		{"bad max interval case", &WaitForAvailableQuotas{Interval: "1s", MaxInterval: "1 minute"}, true},
+	}
+	for _, tt := range tests {
+		err := tt.aq.populate(context.Background(), s)
+		if err == nil {
+			err = tt.aq.validate(context.Background(), s)
+		}
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestWaitForAvailableQuotasDeltas(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{name: "foo", w: w}
+
+	// Usage of metric A decreases until 6 units are available, metric B is
+	// always available.
+	usages := []float64{7, 7, 4}
+	var calls int
+	w.ComputeClient.(*daisyCompute.TestClient).GetRegionFn = func(_, _ string) (*compute.Region, error) {
+		u := usages[len(usages)-1]
+		if calls < len(usages) {
+			u = usages[calls]
+		}
+		calls++
+		return &compute.Region{Quotas: []*compute.Quota{{Metric: "A", Usage: u, Limit: 10}, {Metric: "B", Usage: 0, Limit: 10}}}, nil
+	}
+	aq := &WaitForAvailableQuotas{
+		Interval: "1ms",
+		Quotas: []*QuotaAvailable{
+			{Metric: "A", Region: testRegion, Units: 6},
+			{Metric: "B", Region: testRegion, Units: 1},
+		},
+	}
+	ctx := context.Background()
+	if err := aq.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+	if err := aq.run(ctx, s); err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+
+	// The region is read once per check, for both quotas.
+	if calls != len(usages) {
+		t.Errorf("region read %d times, want %d", calls, len(usages))
+	}
+	var deltas []string
+	for _, e := range w.Logger.(*MockLogger).getEntries() {
+		if strings.Contains(e.Message, " from ") {
+			deltas = append(deltas, e.Message)
+		}
+	}
+	want := []string{fmt.Sprintf("A in region %s rose from 3.00 to 6.00 units available", testRegion)}
+	if diffRes := diff(deltas, want, 0); diffRes != "" {
+		t.Errorf("logged deltas do not match expectation: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestWaitForAvailableQuotasStop(t *testing.T) {
+	tests := []struct {
+		desc    string
+		timeout string
+		cancel  bool
+		wantErr string
+	}{
+		{"cancel case", "", true, ""},
+		{"timeout case", "50ms", false, context.DeadlineExceeded.Error()},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{name: "foo", w: w}
+		w.ComputeClient.(*daisyCompute.TestClient).GetRegionFn = func(_, _ string) (*compute.Region, error) {
+			return &compute.Region{Quotas: []*compute.Quota{{Metric: "A", Usage: 10, Limit: 10}}}, nil
+		}
+		aq := &WaitForAvailableQuotas{Interval: "1ms", Timeout: tt.timeout, Quotas: []*QuotaAvailable{{Metric: "A", Region: testRegion, Units: 1}}}
+		// The context outlives the step, it must stop on its own.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := aq.populate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected populate error: %v", tt.desc, err)
+		}
+		if err := aq.validate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected validate error: %v", tt.desc, err)
+		}
+		if tt.cancel {
+			time.AfterFunc(50*time.Millisecond, w.CancelWorkflow)
+		}
+
+		start := time.Now()
+		err := aq.run(ctx, s)
+		cancel()
+		if time.Since(start) > time.Second {
+			t.Errorf("%s: step didn't stop early, took %v", tt.desc, time.Since(start))
+		}
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		} else if tt.wantErr != "" && (err == nil || !err.CausedByErrType(tt.wantErr)) {
+			t.Errorf("%s: unexpected error type: want %v, got %v", tt.desc, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	resourceTypeInstance = "Instance"
+	resourceTypeDisk     = "Disk"
+	resourceTypeImage    = "Image"
+)
+
+// failedResourceStatus is the status of disks and images which failed to be
+// created, these will never reach any other status.
+const failedResourceStatus = "FAILED"
+
+// WaitForResourceStatus is a Daisy WaitForResourceStatus workflow step.
+type WaitForResourceStatus []*ResourceStatus
+
+// ResourceStatus waits for a resource to have a given status.
+type ResourceStatus struct {
+	// Type of the resource, one of "Instance", "Disk" or "Image".
+	Type string
+	// Name of the resource, either a workflow resource name or a partial URL.
+	Name string
+	// Status to wait for, e.g. "READY" for disks and images or "RUNNING" for
+	// instances.
+	Status string
+	// Interval to check the status (default is 10s).
+	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
+	Interval string `json:",omitempty"`
+	interval time.Duration
+}
+
+// status returns the current status of the resource at link.
+func (rs *ResourceStatus) status(w *Workflow, link string) (string, error) {
+	switch rs.Type {
+	case resourceTypeInstance:
+		m := NamedSubexp(instanceURLRgx, link)
+		return w.ComputeClient.InstanceStatus(m["project"], m["zone"], m["instance"])
+	case resourceTypeDisk:
+		m := NamedSubexp(diskURLRgx, link)
+		d, err := w.ComputeClient.GetDisk(m["project"], m["zone"], m["disk"])
+		if err != nil {
+			return "", err
+		}
+		return d.Status, nil
+	case resourceTypeImage:
+		m := NamedSubexp(imageURLRgx, link)
+		if m["family"] != "" {
+			i, err := w.ComputeClient.GetImageFromFamily(m["project"], m["family"])
+			if err != nil {
+				return "", err
+			}
+			return i.Status, nil
+		}
+		i, err := w.ComputeClient.GetImage(m["project"], m["image"])
+		if err != nil {
+			return "", err
+		}
+		return i.Status, nil
+	}
+	return "", fmt.Errorf("unknown resource type %q", rs.Type)
+}
+
+// resource returns the registered resource to wait for.
+func (rs *ResourceStatus) resource(w *Workflow) (*Resource, bool) {
+	switch rs.Type {
+	case resourceTypeInstance:
+		return w.instances.get(rs.Name)
+	case resourceTypeDisk:
+		return w.disks.get(rs.Name)
+	case resourceTypeImage:
+		return w.images.get(rs.Name)
+	}
+	return nil, false
+}
+
+func (rs *ResourceStatus) wait(s *Step) DError {
+	w := s.w
+	r, ok := rs.resource(w)
+	if !ok {
+		return Errf("unresolved %s %q", rs.Type, rs.Name)
+	}
+	w.LogStepInfo(s.name, "WaitForResourceStatus", "Waiting for %s %q to have status %q.", rs.Type, rs.Name, rs.Status)
+	tick := time.Tick(rs.interval)
+	for {
+		select {
+		case <-w.Cancel:
+			return nil
+		case <-tick:
+			status, err := rs.status(w, r.link)
+			if err != nil {
+				return typedErr(apiError, fmt.Sprintf("failed to check %s %q status", rs.Type, rs.Name), err)
+			}
+			if status == rs.Status {
+				w.LogStepInfo(s.name, "WaitForResourceStatus", "%s %q is %s, done waiting for status.", rs.Type, rs.Name, status)
+				return nil
+			}
+			if status == failedResourceStatus {
+				return Errf("%s %q has status %q, it will never be %q", rs.Type, rs.Name, status, rs.Status)
+			}
+		}
+	}
+}
+
+func (ws *WaitForResourceStatus) populate(ctx context.Context, s *Step) DError {
+	for _, rs := range *ws {
+		if rs.Interval == "" {
+			rs.Interval = defaultInterval
+		}
+		var err error
+		rs.interval, err = time.ParseDuration(rs.Interval)
+		if err != nil {
+			return newErr("failed to parse duration for step wait_for_resource_status", err)
+		}
+	}
+	return nil
+}
+
+func (ws *WaitForResourceStatus) validate(ctx context.Context, s *Step) DError {
+	for _, rs := range *ws {
+		if rs.Status == "" {
+			return Errf("%s %q: cannot wait for resource status, no Status given", rs.Type, rs.Name)
+		}
+		if rs.interval == 0*time.Second {
+			return Errf("%s %q: cannot wait for resource status, no interval given", rs.Type, rs.Name)
+		}
+		var err DError
+		switch rs.Type {
+		case resourceTypeInstance:
+			_, err = s.w.instances.regUse(rs.Name, s)
+		case resourceTypeDisk:
+			_, err = s.w.disks.regUse(rs.Name, s)
+		case resourceTypeImage:
+			_, err = s.w.images.regUse(rs.Name, s)
+		default:
+			err = Errf("%q: unknown resource type %q, must be one of %q", rs.Name, rs.Type, []string{resourceTypeInstance, resourceTypeDisk, resourceTypeImage})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ws *WaitForResourceStatus) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	// Buffered for every waiter and the done signal, so that none of them
+	// blocks once run has returned.
+	e := make(chan DError, len(*ws)+1)
+	for _, rs := range *ws {
+		wg.Add(1)
+		go func(rs *ResourceStatus) {
+			defer wg.Done()
+			if err := rs.wait(s); err != nil {
+				e <- err
+			}
+		}(rs)
+	}
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+	select {
+	case err := <-e:
+		return err
+	case <-s.w.Cancel:
+		// The waiters return on cancel, don't leave them polling.
+		wg.Wait()
+		return nil
+	}
+}
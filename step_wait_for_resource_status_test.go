@@ -0,0 +1,150 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestWaitForResourceStatusPopulate(t *testing.T) {
+	ctx := context.Background()
+	ws := &WaitForResourceStatus{{Type: resourceTypeImage, Name: "i", Status: "READY"}, {Type: resourceTypeDisk, Name: "d", Status: "READY", Interval: "1s"}}
+	if err := ws.populate(ctx, &Step{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := (*ws)[0].interval; got != 10*time.Second {
+		t.Errorf("unexpected default interval, got: %v, want: %v", got, 10*time.Second)
+	}
+	if got := (*ws)[1].interval; got != time.Second {
+		t.Errorf("unexpected interval, got: %v, want: %v", got, time.Second)
+	}
+
+	ws = &WaitForResourceStatus{{Type: resourceTypeImage, Name: "i", Status: "READY", Interval: "bad"}}
+	if err := ws.populate(ctx, &Step{}); err == nil {
+		t.Error("should have returned an error for a bad interval")
+	}
+}
+
+func TestWaitForResourceStatusValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("wait")
+	w.images.m = map[string]*Resource{"image": {RealName: "image", link: fmt.Sprintf("projects/%s/global/images/image", testProject)}}
+	w.disks.m = map[string]*Resource{"disk": {RealName: "disk", link: fmt.Sprintf("projects/%s/zones/%s/disks/disk", testProject, testZone)}}
+	w.instances.m = map[string]*Resource{"instance": {RealName: "instance", link: fmt.Sprintf("projects/%s/zones/%s/instances/instance", testProject, testZone)}}
+
+	tests := []struct {
+		desc      string
+		rs        *ResourceStatus
+		shouldErr bool
+	}{
+		{"image case", &ResourceStatus{Type: resourceTypeImage, Name: "image", Status: "READY"}, false},
+		{"disk case", &ResourceStatus{Type: resourceTypeDisk, Name: "disk", Status: "READY"}, false},
+		{"instance case", &ResourceStatus{Type: resourceTypeInstance, Name: "instance", Status: "RUNNING"}, false},
+		{"unknown resource case", &ResourceStatus{Type: resourceTypeImage, Name: "dne", Status: "READY"}, true},
+		{"bad type case", &ResourceStatus{Type: "Network", Name: "image", Status: "READY"}, true},
+		{"no status case", &ResourceStatus{Type: resourceTypeImage, Name: "image"}, true},
+	}
+	for _, tt := range tests {
+		ws := &WaitForResourceStatus{tt.rs}
+		if err := ws.populate(ctx, s); err != nil {
+			t.Errorf("%s: populate error: %v", tt.desc, err)
+		}
+		if err := ws.validate(ctx, s); err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestWaitForResourceStatusRun(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc      string
+		statuses  []string
+		getErr    error
+		shouldErr bool
+	}{
+		{"pending to ready case", []string{"PENDING", "PENDING", "READY"}, nil, false},
+		{"failed case", []string{"PENDING", "FAILED"}, nil, true},
+		{"get error case", nil, errors.New("error"), true},
+	}
+	for _, tt := range tests {
+		w := testWorkflow()
+		s := &Step{name: "wait", w: w}
+		w.images.m = map[string]*Resource{"image": {RealName: "image-abcdef", link: "projects/p/global/images/image-abcdef"}}
+
+		calls := 0
+		w.ComputeClient.(*daisyCompute.TestClient).GetImageFn = func(project, name string) (*compute.Image, error) {
+			if project != "p" || name != "image-abcdef" {
+				t.Errorf("%s: unexpected image: %s/%s", tt.desc, project, name)
+			}
+			if tt.getErr != nil {
+				return nil, tt.getErr
+			}
+			status := tt.statuses[calls]
+			calls++
+			return &compute.Image{Status: status}, nil
+		}
+
+		ws := &WaitForResourceStatus{{Type: resourceTypeImage, Name: "image", Status: "READY", Interval: "1ms"}}
+		if err := ws.populate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected populate error: %v", tt.desc, err)
+		}
+		err := ws.run(ctx, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if calls != len(tt.statuses) {
+			t.Errorf("%s: unexpected number of status checks, got: %d, want: %d", tt.desc, calls, len(tt.statuses))
+		}
+	}
+
+	// Cancel case, the workflow is canceled during a status check which the
+	// step waits for.
+	w := testWorkflow()
+	s := &Step{name: "wait", w: w}
+	w.disks.m = map[string]*Resource{"disk": {RealName: "disk", link: fmt.Sprintf("projects/%s/zones/%s/disks/disk", testProject, testZone)}}
+	var cancelOnce sync.Once
+	var checked bool
+	w.ComputeClient.(*daisyCompute.TestClient).GetDiskFn = func(_, _, _ string) (*compute.Disk, error) {
+		cancelOnce.Do(func() { close(w.Cancel) })
+		time.Sleep(10 * time.Millisecond)
+		checked = true
+		return &compute.Disk{Status: "CREATING"}, nil
+	}
+	ws := &WaitForResourceStatus{{Type: resourceTypeDisk, Name: "disk", Status: "READY", Interval: "1ms"}}
+	if err := ws.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+	if err := ws.run(ctx, s); err != nil {
+		t.Errorf("unexpected error on cancel: %v", err)
+	}
+	if !checked {
+		t.Error("step returned on cancel before its status check finished")
+	}
+}
@@ -0,0 +1,131 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+)
+
+// WaitForSignals is a Daisy WaitForSignals workflow step. It waits for a
+// combination of instance signals, the step Timeout bounds the whole wait.
+type WaitForSignals SignalCondition
+
+// SignalCondition is a tree of instance signals to wait for. Exactly one of
+// Signal, All or Any must be set.
+type SignalCondition struct {
+	// Wait for a single instance signal.
+	Signal *InstanceSignal `json:",omitempty"`
+	// Wait for all of the conditions to be met.
+	All []*SignalCondition `json:",omitempty"`
+	// Wait for the first of the conditions to be met, or to fail.
+	Any []*SignalCondition `json:",omitempty"`
+
+	// Used for unit tests.
+	testWait func(s *Step) DError
+}
+
+// signals returns the instance signals in the tree.
+func (sc *SignalCondition) signals() []*InstanceSignal {
+	if sc.Signal != nil {
+		return []*InstanceSignal{sc.Signal}
+	}
+	var is []*InstanceSignal
+	for _, c := range append(sc.All, sc.Any...) {
+		is = append(is, c.signals()...)
+	}
+	return is
+}
+
+func (sc *SignalCondition) populate() DError {
+	set := 0
+	if sc.Signal != nil || sc.testWait != nil {
+		set++
+	}
+	if sc.All != nil {
+		set++
+	}
+	if sc.Any != nil {
+		set++
+	}
+	if set != 1 {
+		return Errf("signal condition must set exactly one of Signal, All or Any")
+	}
+	if sc.All != nil && len(sc.All) == 0 || sc.Any != nil && len(sc.Any) == 0 {
+		return Errf("signal condition has no conditions to wait for")
+	}
+	for _, c := range append(sc.All, sc.Any...) {
+		if c == nil {
+			return Errf("signal condition has an empty condition")
+		}
+		if err := c.populate(); err != nil {
+			return err
+		}
+	}
+	if sc.Signal != nil {
+		return populateForWaitForInstancesSignal(&[]*InstanceSignal{sc.Signal}, "wait_for_signals")
+	}
+	return nil
+}
+
+func (sc *SignalCondition) wait(ctx context.Context, s *Step) DError {
+	if sc.testWait != nil {
+		return sc.testWait(s)
+	}
+	if sc.Signal != nil {
+		return runForWaitForInstancesSignal(ctx, &[]*InstanceSignal{sc.Signal}, s, true)
+	}
+
+	conds, waitAll := sc.All, true
+	if sc.Any != nil {
+		conds, waitAll = sc.Any, false
+	}
+	var wg sync.WaitGroup
+	// Buffered so that conditions finishing after the result is known don't
+	// block forever.
+	e := make(chan DError, len(conds)+1)
+	for _, c := range conds {
+		wg.Add(1)
+		go func(c *SignalCondition) {
+			defer wg.Done()
+			if err := c.wait(ctx, s); err != nil || !waitAll {
+				e <- err
+			}
+		}(c)
+	}
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+	select {
+	case err := <-e:
+		return err
+	case <-s.w.Cancel:
+		return nil
+	}
+}
+
+func (ws *WaitForSignals) populate(ctx context.Context, s *Step) DError {
+	return (*SignalCondition)(ws).populate()
+}
+
+func (ws *WaitForSignals) validate(ctx context.Context, s *Step) DError {
+	is := (*SignalCondition)(ws).signals()
+	return validateForWaitForInstancesSignal(&is, s)
+}
+
+func (ws *WaitForSignals) run(ctx context.Context, s *Step) DError {
+	return (*SignalCondition)(ws).wait(ctx, s)
+}
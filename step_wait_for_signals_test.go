@@ -0,0 +1,162 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitForSignalsPopulate(t *testing.T) {
+	ctx := context.Background()
+	serial := func() *SignalCondition {
+		return &SignalCondition{Signal: &InstanceSignal{Name: "i", SerialOutput: &SerialOutput{Port: 1, SuccessMatch: "done"}}}
+	}
+
+	tests := []struct {
+		desc      string
+		ws        *WaitForSignals
+		shouldErr bool
+	}{
+		{"signal case", &WaitForSignals{Signal: serial().Signal}, false},
+		{"all case", &WaitForSignals{All: []*SignalCondition{serial(), serial()}}, false},
+		{"nested case", &WaitForSignals{Any: []*SignalCondition{serial(), {All: []*SignalCondition{serial(), serial()}}}}, false},
+		{"nothing set case", &WaitForSignals{}, true},
+		{"signal and all case", &WaitForSignals{Signal: serial().Signal, All: []*SignalCondition{serial()}}, true},
+		{"all and any case", &WaitForSignals{All: []*SignalCondition{serial()}, Any: []*SignalCondition{serial()}}, true},
+		{"empty any case", &WaitForSignals{Any: []*SignalCondition{}}, true},
+		{"nil condition case", &WaitForSignals{All: []*SignalCondition{serial(), nil}}, true},
+		{"bad nested case", &WaitForSignals{All: []*SignalCondition{serial(), {}}}, true},
+		{"bad interval case", &WaitForSignals{Signal: &InstanceSignal{Name: "i", Interval: "bad"}}, true},
+	}
+	for _, tt := range tests {
+		err := tt.ws.populate(ctx, &Step{})
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error, but didn't", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+
+	ws := &WaitForSignals{All: []*SignalCondition{serial(), {Any: []*SignalCondition{serial()}}}}
+	if err := ws.populate(ctx, &Step{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, is := range (*SignalCondition)(ws).signals() {
+		if is.interval != 10*time.Second {
+			t.Errorf("unexpected default interval, got: %v, want: %v", is.interval, 10*time.Second)
+		}
+	}
+}
+
+// fakeSignal returns a condition which is met when done is closed, recording
+// its name in met.
+func fakeSignal(name string, done chan struct{}, mx *sync.Mutex, met *[]string) *SignalCondition {
+	return &SignalCondition{testWait: func(s *Step) DError {
+		select {
+		case <-done:
+		case <-s.w.Cancel:
+			return nil
+		}
+		mx.Lock()
+		defer mx.Unlock()
+		*met = append(*met, name)
+		return nil
+	}}
+}
+
+func TestWaitForSignalsRunAll(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{name: "wait", w: w}
+	defer close(w.Cancel)
+
+	var mx sync.Mutex
+	var met []string
+	serialDone, guestDone := make(chan struct{}), make(chan struct{})
+	ws := &WaitForSignals{All: []*SignalCondition{
+		fakeSignal("serial", serialDone, &mx, &met),
+		fakeSignal("guest", guestDone, &mx, &met),
+	}}
+	if err := ws.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+
+	e := make(chan DError)
+	go func() { e <- ws.run(ctx, s) }()
+	close(serialDone)
+	select {
+	case err := <-e:
+		t.Fatalf("step finished with only one signal met, error: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(guestDone)
+	if err := <-e; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(met) != 2 {
+		t.Errorf("both signals should have been met, got: %q", met)
+	}
+}
+
+func TestWaitForSignalsRunAny(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{name: "wait", w: w}
+	defer close(w.Cancel)
+
+	var mx sync.Mutex
+	var met []string
+	serialDone, guestDone := make(chan struct{}), make(chan struct{})
+	ws := &WaitForSignals{Any: []*SignalCondition{
+		fakeSignal("serial", serialDone, &mx, &met),
+		fakeSignal("guest", guestDone, &mx, &met),
+	}}
+	if err := ws.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+
+	close(guestDone)
+	if err := ws.run(ctx, s); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	mx.Lock()
+	defer mx.Unlock()
+	if len(met) != 1 || met[0] != "guest" {
+		t.Errorf("only the guest signal should have been met, got: %q", met)
+	}
+}
+
+func TestWaitForSignalsRunAnyError(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{name: "wait", w: w}
+	defer close(w.Cancel)
+
+	var mx sync.Mutex
+	var met []string
+	ws := &WaitForSignals{Any: []*SignalCondition{
+		fakeSignal("serial", make(chan struct{}), &mx, &met),
+		{testWait: func(s *Step) DError { return Errf("guest attribute failure") }},
+	}}
+	if err := ws.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+	if err := ws.run(ctx, s); err == nil {
+		t.Error("should have returned the failing signal's error, but didn't")
+	}
+}
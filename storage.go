@@ -15,6 +15,7 @@
 package daisy
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"regexp"
@@ -77,3 +78,30 @@ type validatedBkts struct {
 
 var writableBkts validatedBkts
 var readableBkts validatedBkts
+
+// checkBucketWritable checks that bkt exists and that an object can be
+// written to it. Buckets that passed the check are remembered.
+func checkBucketWritable(ctx context.Context, s *Step, bkt string) DError {
+	writableBkts.mx.Lock()
+	defer writableBkts.mx.Unlock()
+	if strIn(bkt, writableBkts.bkts) {
+		return nil
+	}
+	if _, err := s.w.StorageClient.Bucket(bkt).Attrs(ctx); err != nil {
+		return Errf("error reading bucket %q: %v", bkt, err)
+	}
+
+	tObj := s.w.StorageClient.Bucket(bkt).Object(fmt.Sprintf("daisy-validate-%s-%s", s.name, s.w.id))
+	w := tObj.NewWriter(ctx)
+	if _, err := w.Write(nil); err != nil {
+		return newErr("failed to write to GCS object", err)
+	}
+	if err := w.Close(); err != nil {
+		return Errf("error writing to bucket %q: %v", bkt, err)
+	}
+	if err := tObj.Delete(ctx); err != nil {
+		return Errf("error deleting file %+v after write validation: %v", tObj, err)
+	}
+	writableBkts.bkts = append(writableBkts.bkts, bkt)
+	return nil
+}
@@ -19,12 +19,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"net/http"
 	"regexp"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/googleapi"
 )
 
 var (
@@ -102,10 +100,7 @@ func newSubnetworkRegistry(w *Workflow) *subnetworkRegistry {
 func (nr *subnetworkRegistry) deleteFn(res *Resource) DError {
 	m := NamedSubexp(subnetworkURLRegex, res.link)
 	err := nr.w.ComputeClient.DeleteSubnetwork(m["project"], m["region"], m["subnetwork"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete subnetwork", err)
-	}
-	return newErr("failed to delete subnetwork", err)
+	return deleteErr("subnetwork", err)
 }
 
 func (nr *subnetworkRegistry) disconnectHelper(nName, iName string, s *Step) DError {
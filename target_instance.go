@@ -18,16 +18,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"regexp"
 
 	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/googleapi"
 )
 
 var (
-	targetInstanceURLRegex = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?zones/(?P<zone>%[2]s)/TargetInstances/(?P<targetInstance>%[2]s)$`, projectRgxStr, rfc1035))
+	targetInstanceURLRegex = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?zones/(?P<zone>%[2]s)/targetInstances/(?P<targetInstance>%[2]s)$`, projectRgxStr, rfc1035))
 )
 
 func (w *Workflow) targetInstanceExists(project, zone, targetInstance string) (bool, DError) {
@@ -58,7 +56,7 @@ func (ti *TargetInstance) populate(ctx context.Context, s *Step) DError {
 	}
 
 	ti.Description = strOr(ti.Description, defaultDescription("TargetInstance", s.w.Name, s.w.username))
-	ti.link = fmt.Sprintf("projects/%s/zones/%s/TargetInstances/%s", ti.Project, ti.Zone, ti.Name)
+	ti.link = fmt.Sprintf("projects/%s/zones/%s/targetInstances/%s", ti.Project, ti.Zone, ti.Name)
 	return errs
 }
 
@@ -96,8 +94,5 @@ func newTargetInstanceRegistry(w *Workflow) *targetInstanceRegistry {
 func (tir *targetInstanceRegistry) deleteFn(res *Resource) DError {
 	m := NamedSubexp(targetInstanceURLRegex, res.link)
 	err := tir.w.ComputeClient.DeleteTargetInstance(m["project"], m["zone"], m["targetInstance"])
-	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
-		return typedErr(resourceDNEError, "failed to delete target instance", err)
-	}
-	return newErr("failed to delete target instance", err)
+	return deleteErr("target instance", err)
 }
@@ -77,6 +77,7 @@ var (
 	testProject        = "test-project"
 	testZone           = "test-region-zone"
 	testRegion         = "test-region"
+	testAddress        = "test-address"
 	testDisk           = "test-disk"
 	testForwardingRule = "test-forwarding-rule"
 	testFirewallRule   = "test-firewall-rule"
@@ -180,6 +181,9 @@ func newTestGCEClient() (*daisyCompute.TestClient, error) {
 	c.ListZonesFn = func(_ string, _ ...daisyCompute.ListCallOption) ([]*compute.Zone, error) {
 		return []*compute.Zone{{Name: testZone}}, nil
 	}
+	c.ListRegionsFn = func(_ string, _ ...daisyCompute.ListCallOption) ([]*compute.Region, error) {
+		return []*compute.Region{{Name: testRegion}}, nil
+	}
 	c.ListFirewallRulesFn = func(p string, _ ...daisyCompute.ListCallOption) ([]*compute.Firewall, error) {
 		if p == testProject {
 			return []*compute.Firewall{{Name: testFirewallRule}}, nil
@@ -229,7 +233,13 @@ func newTestGCEClient() (*daisyCompute.TestClient, error) {
 		if p != testProject {
 			return nil, errors.New("bad project: " + p)
 		}
-		return []*compute.Network{{Name: testNetwork}}, nil
+		return []*compute.Network{{Name: testNetwork, AutoCreateSubnetworks: true}}, nil
+	}
+	c.GetNetworkFn = func(p, n string) (*compute.Network, error) {
+		if p != testProject || n != testNetwork {
+			return nil, &googleapi.Error{Code: http.StatusNotFound}
+		}
+		return &compute.Network{Name: testNetwork, AutoCreateSubnetworks: true}, nil
 	}
 	c.ListSubnetworksFn = func(p, r string, _ ...daisyCompute.ListCallOption) ([]*compute.Subnetwork, error) {
 		if p != testProject {
@@ -240,6 +250,21 @@ func newTestGCEClient() (*daisyCompute.TestClient, error) {
 		}
 		return []*compute.Subnetwork{{Name: testSubnetwork}}, nil
 	}
+	c.GetSubnetworkFn = func(p, r, n string) (*compute.Subnetwork, error) {
+		if p != testProject || r != testRegion || n != testSubnetwork {
+			return nil, &googleapi.Error{Code: http.StatusNotFound}
+		}
+		return &compute.Subnetwork{Name: testSubnetwork, IpCidrRange: "10.128.0.0/20"}, nil
+	}
+	c.ListAddressesFn = func(p, r string, _ ...daisyCompute.ListCallOption) ([]*compute.Address, error) {
+		if p != testProject {
+			return nil, errors.New("bad project: " + p)
+		}
+		if r != testRegion {
+			return nil, errors.New("bad region: " + r)
+		}
+		return []*compute.Address{{Name: testAddress}}, nil
+	}
 	c.ListTargetInstancesFn = func(p, z string, _ ...daisyCompute.ListCallOption) ([]*compute.TargetInstance, error) {
 		if p != testProject {
 			return nil, errors.New("bad project: " + p)
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -66,23 +67,38 @@ func (w *Workflow) validateRequiredFields() DError {
 }
 
 func (w *Workflow) validate(ctx context.Context) DError {
+	if len(w.unusedVarNames) > 0 {
+		w.LogWorkflowInfo("WARNING: vars are declared but never used: %s", strings.Join(w.unusedVarNames, ", "))
+	}
+	if w.MaxAPICalls < 0 {
+		return Errf("MaxAPICalls can't be negative: %d", w.MaxAPICalls)
+	}
+	if w.PreflightReferences && w.parent == nil {
+		if err := w.preflightReferences(); err != nil {
+			return err
+		}
+	}
 	return w.validateDAG(ctx)
 }
 
 // Step through the step DAG, calling each step's validate().
 func (w *Workflow) validateDAG(ctx context.Context) DError {
 	// Sanitation.
+	var dangling []string
+	cleanDeps := map[string][]string{}
 	for s, deps := range w.Dependencies {
 		// Check for missing steps.
 		if _, ok := w.Steps[s]; !ok {
-			return Errf("dependencies reference non existent step %q: %q:%q", s, s, deps)
+			dangling = append(dangling, fmt.Sprintf("%q (depending on %q)", s, deps))
+			continue
 		}
 		seen := map[string]bool{}
 		var clean []string
 		for _, dep := range deps {
 			// Check for missing dependencies.
 			if _, ok := w.Steps[dep]; !ok {
-				return Errf("dependencies reference non existent step %q: %q:%q", dep, s, deps)
+				dangling = append(dangling, fmt.Sprintf("%q (dependency of %q)", dep, s))
+				continue
 			}
 			// Remove duplicate dependencies.
 			if !seen[dep] {
@@ -90,18 +106,57 @@ func (w *Workflow) validateDAG(ctx context.Context) DError {
 				clean = append(clean, dep)
 			}
 		}
-		w.Dependencies[s] = clean
+		cleanDeps[s] = clean
+	}
+	if len(dangling) > 0 {
+		sort.Strings(dangling)
+		return Errf("dependencies reference non existent steps: %s", strings.Join(dangling, ", "))
+	}
+	for s, deps := range cleanDeps {
+		w.Dependencies[s] = deps
 	}
 
-	// Check for cycles.
-	for _, s := range w.Steps {
-		if s.depends(s) {
-			return Errf("cyclic dependency on step %v", s)
-		}
+	// Check for steps that can never run, i.e. cycles.
+	if us := w.unreachableSteps(); len(us) > 0 {
+		return Errf("steps are in or depend on a dependency cycle and can never run: %s", strings.Join(us, ", "))
 	}
 	return w.traverseDAG(func(s *Step) DError { return s.validate(ctx) })
 }
 
+// unreachableSteps returns the sorted names of the steps which never run
+// because no path leads to them from the steps without dependencies.
+func (w *Workflow) unreachableSteps() []string {
+	reached := map[string]bool{}
+	for progress := true; progress; {
+		progress = false
+		for name := range w.Steps {
+			if reached[name] {
+				continue
+			}
+			ready := true
+			for _, dep := range w.Dependencies[name] {
+				if !reached[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				reached[name] = true
+				progress = true
+			}
+		}
+	}
+
+	var us []string
+	for name := range w.Steps {
+		if !reached[name] {
+			us = append(us, name)
+		}
+	}
+	sort.Strings(us)
+	return us
+}
+
 func (w *Workflow) validateVarsSubbed() DError {
 	unsubbedVarRgx := regexp.MustCompile(`\$\{([^}]+)}`)
 	return traverseData(reflect.ValueOf(w).Elem(), func(v reflect.Value) DError {
@@ -122,3 +177,36 @@ func (w *Workflow) validateVarsSubbed() DError {
 		return continueTraversal
 	})
 }
+
+// unusedVars returns the sorted names of the Vars which aren't referenced by
+// any workflow field, Vars of included and sub workflows are referenced from
+// the step which includes them. It must be called before var substitution.
+func (w *Workflow) unusedVars() []string {
+	varRefRgx := regexp.MustCompile(`\$\{([^}]+)}`)
+	used := map[string]bool{}
+	traverseData(reflect.ValueOf(w).Elem(), func(v reflect.Value) DError {
+		switch v.Interface().(type) {
+		case string:
+			for _, match := range varRefRgx.FindAllStringSubmatch(v.String(), -1) {
+				used[match[1]] = true
+			}
+		}
+		return nil
+	}, func(v reflect.Value) traverseAction {
+		switch v.Interface().(type) {
+		// Skip the Var declarations, a Var can't reference another Var.
+		case *Workflow, map[string]Var:
+			return prune
+		}
+		return continueTraversal
+	})
+
+	var unused []string
+	for k := range w.Vars {
+		if !used[k] {
+			unused = append(unused, k)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
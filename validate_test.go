@@ -178,8 +178,63 @@ func TestValidateDAG(t *testing.T) {
 	}
 
 	// Fail, cyclical deps.
+	delete(w.Dependencies, "dne")
 	w.Dependencies["s0"] = []string{"s3"}
 	if err := w.validateDAG(ctx); err == nil {
 		t.Error("validation should have failed due to dependency cycle")
 	}
 }
+
+func TestValidateDAGDanglingDependency(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	w.Steps = map[string]*Step{
+		"s0": {testType: &mockStep{}, w: w},
+		"s1": {testType: &mockStep{}, w: w},
+	}
+	w.Dependencies = map[string][]string{
+		"s1": {"s0", "s00"},
+		"s2": {"s0"},
+	}
+
+	want := `dependencies reference non existent steps: "s00" (dependency of "s1"), "s2" (depending on ["s0"])`
+	if err := w.validateDAG(ctx); err == nil || err.Error() != want {
+		t.Errorf("unexpected error, got: %v, want: %s", err, want)
+	}
+	if diffRes := diff(w.Dependencies["s1"], []string{"s0", "s00"}, 0); diffRes != "" {
+		t.Errorf("dependencies modified on error: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestValidateDAGUnreachableSteps(t *testing.T) {
+	ctx := context.Background()
+	// s0---->s1
+	// s2<--->s3---->s4
+	w := testWorkflow()
+	w.Steps = map[string]*Step{
+		"s0": {testType: &mockStep{}, w: w},
+		"s1": {testType: &mockStep{}, w: w},
+		"s2": {testType: &mockStep{}, w: w},
+		"s3": {testType: &mockStep{}, w: w},
+		"s4": {testType: &mockStep{}, w: w},
+	}
+	w.Dependencies = map[string][]string{
+		"s1": {"s0"},
+		"s2": {"s3"},
+		"s3": {"s2"},
+		"s4": {"s3"},
+	}
+
+	if diffRes := diff(w.unreachableSteps(), []string{"s2", "s3", "s4"}, 0); diffRes != "" {
+		t.Errorf("unexpected unreachable steps: (-got +want)\n%s", diffRes)
+	}
+	want := "steps are in or depend on a dependency cycle and can never run: s2, s3, s4"
+	if err := w.validateDAG(ctx); err == nil || err.Error() != want {
+		t.Errorf("unexpected error, got: %v, want: %s", err, want)
+	}
+
+	delete(w.Dependencies, "s2")
+	if us := w.unreachableSteps(); len(us) != 0 {
+		t.Errorf("unexpected unreachable steps: %q", us)
+	}
+}
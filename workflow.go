@@ -18,6 +18,8 @@ package daisy
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -25,6 +27,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -115,9 +118,45 @@ type Workflow struct {
 	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
 	DefaultTimeout string `json:",omitempty"`
 	defaultTimeout time.Duration
+	// Total time the workflow may run, unlimited if unset. A step gets at most
+	// the time left to its workflow and parent workflows, even if its own
+	// timeout is longer.
+	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
+	Timeout  string `json:",omitempty"`
+	timeout  time.Duration
+	deadline time.Time
+	// Allow steps with a broad blast radius, such as deleting resources not
+	// created by the workflow, to run. These steps are listed when the workflow is
+	// validated and fail to run unless this is set on the top level workflow.
+	ConfirmDestructive bool `json:",omitempty"`
+	// Check that all existing images, machine types, networks and subnetworks
+	// referenced by the workflow exist before validating any step, and report
+	// every missing reference at once. Only used on the top level workflow.
+	PreflightReferences bool `json:",omitempty"`
+	// Fail to populate the workflow if Vars are declared but never used,
+	// unused Vars are only logged as a warning otherwise.
+	StrictVars bool `json:",omitempty"`
+	// Fail validation if an instance sets ssh-keys metadata while OS Login is
+	// enabled for it, which makes GCE ignore the keys. This is only logged as
+	// a warning otherwise. Only used on the top level workflow.
+	StrictOSLogin bool `json:",omitempty"`
+	// Maximum number of Compute API requests made while the workflow runs,
+	// validation and cleanup aren't counted. Once it is reached further API
+	// calls fail, which fails the running steps, and the workflow cleans up.
+	// No limit if 0. Only used on the top level workflow, whose compute
+	// client must have a call budget, like the clients of the compute package.
+	MaxAPICalls int64 `json:",omitempty"`
+	// Derive the names generated for resources from this seed, e.g. an ID of
+	// the run given by the caller, instead of the random workflow ID. A
+	// resource then gets the same name each time the workflow runs with the
+	// same seed, which lets re-runs adopt it with ExistsOk, while runs with
+	// different seeds get different names. Only used on the top level
+	// workflow.
+	NameSeed string `json:",omitempty"`
 
 	// Working fields.
 	autovars              map[string]string
+	unusedVarNames        []string
 	workflowDir           string
 	parent                *Workflow
 	bucket                string
@@ -134,17 +173,56 @@ type Workflow struct {
 	Logger                Logger `json:"-"`
 	cleanupHooks          []func() DError
 	cleanupHooksMx        sync.Mutex
+	failureHooks          []func() DError
+	failureHooksMx        sync.Mutex
 	recordTimeMx          sync.Mutex
 	stepWait              sync.WaitGroup
 	logProcessHook        func(string) string
 
 	// Optional compute endpoint override.stepWait
-	ComputeEndpoint    string          `json:",omitempty"`
-	ComputeClient      compute.Client  `json:"-"`
-	StorageClient      *storage.Client `json:"-"`
-	CloudLoggingClient *logging.Client `json:"-"`
+	ComputeEndpoint string         `json:",omitempty"`
+	ComputeClient   compute.Client `json:"-"`
+	// Optional tuning of the compute client's HTTP connection pool and
+	// request timeout, used when PopulateClients creates the client.
+	ComputeHTTPSettings compute.HTTPSettings `json:"-"`
+	StorageClient       *storage.Client      `json:"-"`
+	CloudLoggingClient  *logging.Client      `json:"-"`
+	// Optional hook called, when set on the top level workflow, with every
+	// resource right before the API call creating it. The hook can modify the
+	// resource or reject it by returning an error, which fails the step. The
+	// resource types, and the resources passed for them, are:
+	//   - "address": *compute.Address
+	//   - "bulkInsertDisk": *compute.BulkInsertDiskResource
+	//   - "disk": *compute.Disk
+	//   - "firewallRule": *compute.Firewall
+	//   - "forwardingRule": *compute.ForwardingRule
+	//   - "image": *compute.Image, or the beta or alpha Image
+	//   - "instance": *compute.Instance, or the beta or alpha Instance
+	//   - "machineImage": *compute.MachineImage
+	//   - "network": *compute.Network
+	//   - "snapshot": *compute.Snapshot, including the guest flush snapshots of
+	//     CaptureImages
+	//   - "subnetwork": *compute.Subnetwork
+	//   - "targetInstance": *compute.TargetInstance
+	PreCreateHook func(resourceType string, resource interface{}) error `json:"-"`
+	// Optional hook called, when set on the top level workflow, with every
+	// resource created by the workflow as fetched after its creation, which
+	// includes its self link, IDs and IPs. The resource types are those of
+	// PreCreateHook, the resources are of the GA compute types. Hook errors
+	// are logged, unless FailOnPostCreateHookError is set on the top level
+	// workflow in which case they fail the step. Either way the resource is
+	// cleaned up as usual.
+	PostCreateHook            func(resourceType string, resource interface{}) error `json:"-"`
+	FailOnPostCreateHookError bool                                                  `json:"-"`
+	// Size of the buffer of the Events channel, 100 if 0, and what happens to
+	// events sent while it's full. Only used on the top level workflow.
+	EventBufferSize int                 `json:"-"`
+	EventOverflow   EventOverflowPolicy `json:"-"`
+	events          *eventStream
+	eventsMx        sync.Mutex
 
 	// Resource registries.
+	addresses       *addressRegistry
 	disks           *diskRegistry
 	forwardingRules *forwardingRuleRegistry
 	firewallRules   *firewallRuleRegistry
@@ -159,6 +237,7 @@ type Workflow struct {
 
 	// Cache of resources
 	machineTypeCache    twoDResourceCache
+	addressCache        twoDResourceCache
 	instanceCache       twoDResourceCache
 	diskCache           twoDResourceCache
 	subnetworkCache     twoDResourceCache
@@ -174,15 +253,57 @@ type Workflow struct {
 	licenseCache        oneDResourceCache
 	snapshotCache       oneDResourceCache
 
+	// Whether OS Login is enabled in a project by its metadata, keyed by
+	// project, only set on the top level workflow.
+	projectOSLogin   map[string]bool
+	projectOSLoginMx sync.Mutex
+
+	// Slots of the instance signal polls running at the same time, only set
+	// on the top level workflow.
+	instanceSignalPolls   chan struct{}
+	instanceSignalPollsMx sync.Mutex
+
+	// Planned consumption of specific reservations, keyed by reservation URL.
+	reservationUse   map[string]int64
+	reservationUseMx sync.Mutex
+
 	stepTimeRecords             []TimeRecord
 	serialControlOutputValues   map[string]string
 	serialControlOutputValuesMx sync.Mutex
+	// IPs of the instances created by the workflow, keyed by daisy name.
+	instanceIPs   map[string]InstanceIPs
+	instanceIPsMx sync.Mutex
 	//Forces cleanup on error of all resources, including those marked with NoCleanup
 	ForceCleanupOnError bool
 	// forceCleanup is set to true when resources should be forced clean, even when NoCleanup is set to true
 	forceCleanup bool
 	// cancelReason provides custom reason when workflow is canceled. f
 	cancelReason string
+	// cancelCause is why the workflow was canceled, only set on the root
+	// workflow as the Cancel channel is shared with child workflows.
+	cancelCause *CancelCause
+}
+
+// Kinds of workflow cancellation.
+const (
+	// CancelKindUser is a cancellation requested by the user.
+	CancelKindUser = "user"
+	// CancelKindDeadline is a cancellation caused by a step timing out.
+	CancelKindDeadline = "deadline"
+	// CancelKindStepFailure is a cancellation caused by a step failing.
+	CancelKindStepFailure = "step-failure"
+)
+
+// CancelCause describes why a workflow was canceled.
+type CancelCause struct {
+	// Kind of cancellation, e.g. CancelKindDeadline.
+	Kind string
+	// Detail describes the cancellation, e.g. which step timed out.
+	Detail string
+}
+
+func (c *CancelCause) String() string {
+	return fmt.Sprintf("%s: %s", c.Kind, c.Detail)
 }
 
 // DisableCloudLogging disables logging to Cloud Logging for this workflow.
@@ -223,12 +344,64 @@ func (w *Workflow) GetSerialConsoleOutputValue(k string) string {
 	return w.serialControlOutputValues[k]
 }
 
+// InstanceIPs are the IPs of an instance created by the workflow.
+type InstanceIPs struct {
+	// Internal IPs, one per network interface.
+	Internal []string
+	// External IPs of the network interfaces which have one.
+	External []string
+}
+
+func (w *Workflow) addInstanceIPs(name string, ips InstanceIPs) {
+	for w.parent != nil {
+		w = w.parent
+	}
+	w.instanceIPsMx.Lock()
+	defer w.instanceIPsMx.Unlock()
+	if w.instanceIPs == nil {
+		w.instanceIPs = map[string]InstanceIPs{}
+	}
+	w.instanceIPs[name] = ips
+}
+
+// GetInstanceIPs gets the IPs of an instance created by the workflow or its
+// included and sub workflows, by the instance name in the workflow.
+func (w *Workflow) GetInstanceIPs(name string) (InstanceIPs, bool) {
+	for w.parent != nil {
+		w = w.parent
+	}
+	w.instanceIPsMx.Lock()
+	defer w.instanceIPsMx.Unlock()
+	ips, ok := w.instanceIPs[name]
+	return ips, ok
+}
+
 func (w *Workflow) addCleanupHook(hook func() DError) {
 	w.cleanupHooksMx.Lock()
 	w.cleanupHooks = append(w.cleanupHooks, hook)
 	w.cleanupHooksMx.Unlock()
 }
 
+// addFailureHook adds a hook run if the workflow fails, before its resources
+// are cleaned up.
+func (w *Workflow) addFailureHook(hook func() DError) {
+	w.failureHooksMx.Lock()
+	w.failureHooks = append(w.failureHooks, hook)
+	w.failureHooksMx.Unlock()
+}
+
+func (w *Workflow) runFailureHooks() {
+	w.failureHooksMx.Lock()
+	hooks := w.failureHooks
+	w.failureHooks = nil
+	w.failureHooksMx.Unlock()
+	for _, hook := range hooks {
+		if err := hook(); err != nil {
+			w.LogWorkflowInfo("Error returned from failure hook: %s", err)
+		}
+	}
+}
+
 // SetLogProcessHook sets a hook function to process log string
 func (w *Workflow) SetLogProcessHook(hook func(string) string) {
 	w.logProcessHook = hook
@@ -257,10 +430,220 @@ func (w *Workflow) Validate(ctx context.Context) DError {
 		w.CancelWorkflow()
 		return err
 	}
+	if ds := w.DestructiveSteps(); len(ds) > 0 {
+		w.LogWorkflowInfo("Workflow has destructive steps, review before running: %s", strings.Join(ds, ", "))
+		if !w.ConfirmDestructive {
+			w.LogWorkflowInfo("WARNING: destructive steps will fail to run, ConfirmDestructive is not set.")
+		}
+	}
 	w.LogWorkflowInfo("Validation Complete")
 	return nil
 }
 
+// DestructiveSteps lists the steps of the workflow, and of its included and
+// sub workflows, which only run if ConfirmDestructive is set. Each entry is the
+// step name, prefixed with the names of the steps including it, followed by
+// what the step changes.
+func (w *Workflow) DestructiveSteps() []string {
+	var ds []string
+	for name, s := range w.Steps {
+		var child *Workflow
+		switch {
+		case s.IncludeWorkflow != nil:
+			child = s.IncludeWorkflow.Workflow
+		case s.SubWorkflow != nil:
+			child = s.SubWorkflow.Workflow
+		}
+		if child != nil {
+			for _, d := range child.DestructiveSteps() {
+				ds = append(ds, fmt.Sprintf("%s.%s", name, d))
+			}
+			continue
+		}
+		impl, err := s.stepImpl()
+		if err != nil {
+			continue
+		}
+		if d, ok := impl.(destructiveStep); ok {
+			if what := d.destructive(); what != "" {
+				ds = append(ds, fmt.Sprintf("%s (%s)", name, what))
+			}
+		}
+	}
+	sort.Strings(ds)
+	return ds
+}
+
+// CreatedResources returns the GCE resources created by the workflow, its
+// included workflows and its subworkflows, sorted by type and name. Resources
+// registered by AdoptResources are not included. It is intended to be called
+// after Run, e.g. for reporting or to verify that cleanup deleted everything.
+func (w *Workflow) CreatedResources() []ResourceRef {
+	var refs []ResourceRef
+	for _, r := range w.resourceRegistries() {
+		refs = append(refs, r.createdResources()...)
+	}
+	refs = append(refs, w.subWorkflowResources()...)
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Type != refs[j].Type {
+			return refs[i].Type < refs[j].Type
+		}
+		if refs[i].Project != refs[j].Project {
+			return refs[i].Project < refs[j].Project
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
+
+// resourceRegistries returns the workflow's GCE resource registries keyed by
+// resource type.
+func (w *Workflow) resourceRegistries() map[string]*baseResourceRegistry {
+	regs := map[string]*baseResourceRegistry{}
+	for _, r := range []*baseResourceRegistry{
+		&w.addresses.baseResourceRegistry,
+		&w.disks.baseResourceRegistry,
+		&w.firewallRules.baseResourceRegistry,
+		&w.forwardingRules.baseResourceRegistry,
+		&w.images.baseResourceRegistry,
+		&w.instances.baseResourceRegistry,
+		&w.machineImages.baseResourceRegistry,
+		&w.networks.baseResourceRegistry,
+		&w.snapshots.baseResourceRegistry,
+		&w.subnetworks.baseResourceRegistry,
+		&w.targetInstances.baseResourceRegistry,
+	} {
+		regs[r.typeName] = r
+	}
+	return regs
+}
+
+// operationProgress logs the end of the operations the workflow waits on and
+// sends their progress as events, attributed to the step creating or
+// deleting the target resource if it's in the workflow's registries.
+func (w *Workflow) operationProgress(p compute.OperationProgress) {
+	s := w.operationStep(p)
+	if p.Progress == 100 {
+		if s != nil {
+			s.w.LogStepInfo(s.name, "", "Operation %q (%s) on %s is done.", p.Name, p.OperationType, p.TargetLink)
+		} else {
+			w.LogWorkflowInfo("Operation %q (%s) on %s is done.", p.Name, p.OperationType, p.TargetLink)
+		}
+	}
+	e := Event{Type: EventOperationProgress, Operation: &p}
+	if s != nil {
+		e.StepName = s.name
+	}
+	w.sendEvent(e)
+}
+
+// operationStep returns the step which creates, for insert operations, or
+// deletes, for delete operations, the target resource of an operation, nil
+// if there is none.
+func (w *Workflow) operationStep(p compute.OperationProgress) *Step {
+	for _, r := range w.resourceRegistries() {
+		r.mx.Lock()
+		for _, res := range r.m {
+			if res.link == "" || !strings.HasSuffix(p.TargetLink, "/"+res.link) {
+				continue
+			}
+			r.mx.Unlock()
+			switch p.OperationType {
+			case "insert":
+				return res.creator
+			case "delete":
+				return res.deleter
+			}
+			return nil
+		}
+		r.mx.Unlock()
+	}
+	return nil
+}
+
+// subWorkflowResources returns the resources created by subworkflows nested
+// in w. Included workflows share registries with w, so only the subworkflows
+// nested in them are visited.
+func (w *Workflow) subWorkflowResources() []ResourceRef {
+	var refs []ResourceRef
+	for _, s := range w.Steps {
+		if s.SubWorkflow != nil && s.SubWorkflow.Workflow != nil {
+			refs = append(refs, s.SubWorkflow.Workflow.CreatedResources()...)
+		} else if s.IncludeWorkflow != nil && s.IncludeWorkflow.Workflow != nil {
+			refs = append(refs, s.IncludeWorkflow.Workflow.subWorkflowResources()...)
+		}
+	}
+	return refs
+}
+
+// destructiveConfirmed returns whether the top level workflow allows
+// destructive steps to run.
+func (w *Workflow) destructiveConfirmed() bool {
+	for w.parent != nil {
+		w = w.parent
+	}
+	return w.ConfirmDestructive
+}
+
+// preCreate calls the PreCreateHook of the top level workflow, if any, with a
+// resource about to be created.
+func (w *Workflow) preCreate(resourceType string, resource interface{}) error {
+	for w.parent != nil {
+		w = w.parent
+	}
+	if w.PreCreateHook == nil {
+		return nil
+	}
+	if err := w.PreCreateHook(resourceType, resource); err != nil {
+		return fmt.Errorf("%s rejected by PreCreateHook: %v", resourceType, err)
+	}
+	return nil
+}
+
+// postCreate calls the PostCreateHook of the top level workflow, if any, with
+// a created resource fetched using get. Errors are only returned if the top
+// level workflow sets FailOnPostCreateHookError, they are logged otherwise.
+func (w *Workflow) postCreate(resourceType string, get func() (interface{}, error)) error {
+	root := w
+	for root.parent != nil {
+		root = root.parent
+	}
+	if root.PostCreateHook == nil {
+		return nil
+	}
+	res, err := get()
+	if err == nil {
+		err = root.PostCreateHook(resourceType, res)
+	}
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("PostCreateHook failed for %s: %v", resourceType, err)
+	if root.FailOnPostCreateHookError {
+		return err
+	}
+	w.LogWorkflowInfo("WARNING: %v", err)
+	return nil
+}
+
+// The settings of a workflow run are made on the compute client through these
+// optional interfaces, which the clients created by the compute package
+// implement. Other clients are used as they are.
+type (
+	clientCopier interface {
+		Copy() (compute.Client, error)
+	}
+	callBudgeter interface {
+		SetCallBudget(n int64)
+	}
+	retryContexter interface {
+		SetRetryContext(ctx context.Context)
+	}
+	operationProgressHooker interface {
+		SetOperationProgressHook(f func(compute.OperationProgress))
+	}
+)
+
 // WorkflowModifier is a function type for functions that can modify a Workflow object.
 //
 // Deprecated: This will be removed in a future release.
@@ -268,18 +651,63 @@ type WorkflowModifier func(*Workflow)
 
 // Run runs a workflow.
 func (w *Workflow) Run(ctx context.Context) (err DError) {
+	defer w.closeEvents()
 
 	w.externalLogging = true
+	// The run's settings of the compute client are made on a copy, the
+	// client may be shared with other workflows. Included workflows and
+	// subworkflows get the copy when they're populated, and the client is
+	// put back once the run is over.
+	if c, ok := w.ComputeClient.(clientCopier); ok {
+		cc, cerr := c.Copy()
+		if cerr != nil {
+			return Errf("error copying compute client: %v", cerr)
+		}
+		defer func(c compute.Client) { w.ComputeClient = c }(w.ComputeClient)
+		w.ComputeClient = cc
+	}
 	if err = w.Validate(ctx); err != nil {
 		return err
 	}
 
+	// Removed after cleanup, so that the progress of its deletions is reported.
+	if c, ok := w.ComputeClient.(operationProgressHooker); ok {
+		c.SetOperationProgressHook(w.operationProgress)
+		defer c.SetOperationProgressHook(nil)
+	}
 	defer w.cleanup()
 	defer func() {
 		if err != nil {
+			w.runFailureHooks()
 			w.forceCleanup = w.ForceCleanupOnError
 		}
 	}()
+	if w.MaxAPICalls > 0 {
+		c, ok := w.ComputeClient.(callBudgeter)
+		if !ok {
+			return Errf("MaxAPICalls is set, but the compute client (%T) has no call budget", w.ComputeClient)
+		}
+		c.SetCallBudget(w.MaxAPICalls)
+		// Lift the budget so that cleanup can delete the workflow's resources.
+		defer c.SetCallBudget(0)
+	}
+	// Stop retrying failed API calls once the workflow is canceled, cleanup
+	// retries them as usual.
+	if c, ok := w.ComputeClient.(retryContexter); ok {
+		retryCtx, stopRetries := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-w.Cancel:
+				stopRetries()
+			case <-retryCtx.Done():
+			}
+		}()
+		c.SetRetryContext(retryCtx)
+		defer func() {
+			c.SetRetryContext(nil)
+			stopRetries()
+		}()
+	}
 
 	if os.Getenv("BUILD_ID") != "" {
 		w.LogWorkflowInfo("Cloud Build ID: %s", os.Getenv("BUILD_ID"))
@@ -300,9 +728,21 @@ func (w *Workflow) Run(ctx context.Context) (err DError) {
 		for k, v := range w.serialControlOutputValues {
 			w.LogWorkflowInfo("Serial-output value -> %v:%v", k, v)
 		}
+		w.instanceIPsMx.Lock()
+		instanceIPs := make(map[string]InstanceIPs, len(w.instanceIPs))
+		for k, v := range w.instanceIPs {
+			instanceIPs[k] = v
+		}
+		w.instanceIPsMx.Unlock()
+		for k, v := range instanceIPs {
+			w.LogWorkflowInfo("Instance IPs -> %v: internal %v, external %v", k, v.Internal, v.External)
+		}
 	}()
 	if err = w.run(ctx); err != nil {
 		w.LogWorkflowInfo("Error running workflow: %v", err)
+		if c := w.CancelCause(); c != nil {
+			w.LogWorkflowInfo("Workflow canceled (%s).", c)
+		}
 		return err
 	}
 
@@ -364,16 +804,38 @@ func (w *Workflow) genName(n string) string {
 	if n != "" {
 		prefix = fmt.Sprintf("%s-%s", n, name)
 	}
+	suffix := w.nameSuffix(prefix)
 	if len(prefix) > 57 {
 		prefix = prefix[0:56]
 	}
-	result := fmt.Sprintf("%s-%s", prefix, w.id)
+	result := fmt.Sprintf("%s-%s", prefix, suffix)
 	if len(result) > 64 {
 		result = result[0:63]
 	}
 	return strings.ToLower(result)
 }
 
+// nameSuffix returns what makes the generated name of a resource unique across
+// runs: the workflow ID, or a short hash of NameSeed and the logical name of
+// the resource if NameSeed is set.
+func (w *Workflow) nameSuffix(logicalName string) string {
+	seed := w.nameSeed()
+	if seed == "" {
+		return w.id
+	}
+	sum := sha256.Sum256([]byte(seed + "/" + logicalName))
+	return hex.EncodeToString(sum[:])[:6]
+}
+
+// nameSeed returns the NameSeed of the top level workflow.
+func (w *Workflow) nameSeed() string {
+	root := w
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root.NameSeed
+}
+
 func (w *Workflow) getSourceGCSAPIPath(s string) string {
 	return fmt.Sprintf("%s/%s", gcsAPIBase, path.Join(w.bucket, w.sourcesPath, s))
 }
@@ -390,8 +852,8 @@ func (w *Workflow) PopulateClients(ctx context.Context, options ...option.Client
 
 	if len(options) > 0 {
 		computeOptions = options
-		storageOptions = options
-		loggingOptions = options
+		storageOptions = compute.OtherAPIOptions(options...)
+		loggingOptions = compute.OtherAPIOptions(options...)
 	} else {
 		computeOptions = []option.ClientOption{option.WithCredentialsFile(w.OAuthPath)}
 		storageOptions = []option.ClientOption{option.WithCredentialsFile(w.OAuthPath)}
@@ -403,7 +865,7 @@ func (w *Workflow) PopulateClients(ctx context.Context, options ...option.Client
 	}
 
 	if w.ComputeClient == nil {
-		w.ComputeClient, err = compute.NewClient(ctx, computeOptions...)
+		w.ComputeClient, err = compute.NewClientWithHTTPSettings(ctx, w.ComputeHTTPSettings, computeOptions...)
 		if err != nil {
 			return typedErr(apiError, "failed to create compute client", err)
 		}
@@ -443,13 +905,45 @@ func (w *Workflow) populateStep(ctx context.Context, s *Step) DError {
 	return step.populate(ctx, s)
 }
 
+// populateWaitFor inserts a WaitForResourceStatus step for each step which
+// sets WaitFor. The wait step takes the dependencies of the step, and the step
+// depends on the wait step, so resources are resolved by their workflow names
+// like in any other step.
+func (w *Workflow) populateWaitFor() DError {
+	var names []string
+	for name, s := range w.Steps {
+		if len(s.WaitFor) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := w.Steps[name]
+		wn := name + "-wait-for-resources"
+		if _, ok := w.Steps[wn]; ok {
+			return Errf("cannot wait for resources of step %q: a step named %q already exists", name, wn)
+		}
+		ws := WaitForResourceStatus(s.WaitFor)
+		w.Steps[wn] = &Step{name: wn, w: w, Timeout: s.Timeout, WaitForResourceStatus: &ws}
+		if w.Dependencies == nil {
+			w.Dependencies = map[string][]string{}
+		}
+		w.Dependencies[wn] = append([]string(nil), w.Dependencies[name]...)
+		w.Dependencies[name] = append(w.Dependencies[name], wn)
+		// The wait step is in place, don't insert it again.
+		s.WaitFor = nil
+	}
+	return nil
+}
+
 // populate does the following:
-// - checks that all required Vars are set.
+// - checks that all required Vars are set, and that all Vars are used.
 // - instantiates API clients, if needed.
 // - sets generic autovars and do first round of var substitution.
 // - sets GCS path information.
 // - generates autovars from workflow fields (Name, Zone, etc) and run second round of var substitution.
 // - sets up logger.
+// - inserts the wait steps of steps with WaitFor.
 // - runs populate on each step.
 func (w *Workflow) populate(ctx context.Context) DError {
 	for k, v := range w.Vars {
@@ -458,6 +952,13 @@ func (w *Workflow) populate(ctx context.Context) DError {
 		}
 	}
 
+	// Unused Vars are found before substitution, and only logged as a warning
+	// once the workflow is validated.
+	w.unusedVarNames = w.unusedVars()
+	if len(w.unusedVarNames) > 0 && w.StrictVars {
+		return Errf("cannot populate workflow, vars are declared but never used: %s", strings.Join(w.unusedVarNames, ", "))
+	}
+
 	// Set some generic autovars and run first round of var substitution.
 	cwd, _ := os.Getwd()
 	now := time.Now().UTC()
@@ -488,6 +989,11 @@ func (w *Workflow) populate(ctx context.Context) DError {
 		return Errf("failed to parse timeout for workflow: %v", err)
 	}
 	w.defaultTimeout = timeout
+	if w.Timeout != "" {
+		if w.timeout, err = time.ParseDuration(w.Timeout); err != nil {
+			return Errf("failed to parse Timeout for workflow: %v", err)
+		}
+	}
 
 	// Set up GCS paths.
 	if w.GCSPath == "" {
@@ -528,6 +1034,10 @@ func (w *Workflow) populate(ctx context.Context) DError {
 		w.createLogger(ctx)
 	}
 
+	if err := w.populateWaitFor(); err != nil {
+		return err
+	}
+
 	// Run populate on each step.
 	for name, s := range w.Steps {
 		s.name = name
@@ -573,6 +1083,7 @@ func (w *Workflow) AddDependency(dependent *Step, dependencies ...*Step) error {
 func (w *Workflow) includeWorkflow(iw *Workflow) {
 	iw.Cancel = w.Cancel
 	iw.parent = w
+	iw.addresses = w.addresses
 	iw.disks = w.disks
 	iw.forwardingRules = w.forwardingRules
 	iw.firewallRules = w.firewallRules
@@ -656,15 +1167,40 @@ func (w *Workflow) Print(ctx context.Context) {
 }
 
 func (w *Workflow) run(ctx context.Context) DError {
+	if w.timeout > 0 {
+		w.deadline = time.Now().Add(w.timeout)
+	}
 	return w.traverseDAG(func(s *Step) DError {
 		return w.runStep(ctx, s)
 	})
 }
 
+// stepTimeout returns how long s may run, its own timeout clamped by the time
+// left until the deadline of this workflow or any parent workflow. clamped is
+// true if a workflow deadline is the limit.
+func (w *Workflow) stepTimeout(s *Step) (timeout time.Duration, clamped bool) {
+	timeout = s.timeout
+	now := time.Now()
+	for cur := w; cur != nil; cur = cur.parent {
+		if cur.deadline.IsZero() {
+			continue
+		}
+		left := cur.deadline.Sub(now)
+		if left < 0 {
+			left = 0
+		}
+		if left < timeout {
+			timeout, clamped = left, true
+		}
+	}
+	return timeout, clamped
+}
+
 func (w *Workflow) runStep(ctx context.Context, s *Step) DError {
+	d, clamped := w.stepTimeout(s)
 	timeout := make(chan struct{})
 	go func() {
-		time.Sleep(s.timeout)
+		time.Sleep(d)
 		close(timeout)
 	}()
 
@@ -677,7 +1213,12 @@ func (w *Workflow) runStep(ctx context.Context, s *Step) DError {
 	case err := <-e:
 		return err
 	case <-timeout:
-		return s.getTimeoutError()
+		err := s.getTimeoutError()
+		if clamped {
+			err = Errf("step %q did not complete within the %s left of the workflow timeout", s.name, d)
+		}
+		w.recordCancelCause(CancelKindDeadline, err.Error())
+		return err
 	}
 }
 
@@ -740,6 +1281,7 @@ func (w *Workflow) traverseDAG(f func(*Step) DError) DError {
 		// Get next finished step. Return the step error if it erred.
 		finished, err := stepsListen(running, done)
 		if err != nil {
+			w.recordCancelCause(CancelKindStepFailure, fmt.Sprintf("step %q failed: %v", finished, err))
 			return err
 		}
 
@@ -767,6 +1309,7 @@ func New() *Workflow {
 	w.autovars = map[string]string{}
 
 	// Resource registries and cleanup.
+	w.addresses = newAddressRegistry(w)
 	w.disks = newDiskRegistry(w)
 	w.forwardingRules = newForwardingRuleRegistry(w)
 	w.firewallRules = newFirewallRuleRegistry(w)
@@ -779,16 +1322,10 @@ func New() *Workflow {
 	w.targetInstances = newTargetInstanceRegistry(w)
 	w.snapshots = newSnapshotRegistry(w)
 	w.addCleanupHook(func() DError {
-		w.instances.cleanup() // instances need to be done before disks/networks
-		w.images.cleanup()
-		w.machineImages.cleanup()
-		w.disks.cleanup()
-		w.forwardingRules.cleanup()
-		w.targetInstances.cleanup()
-		w.firewallRules.cleanup()
-		w.subnetworks.cleanup()
-		w.networks.cleanup()
-		w.snapshots.cleanup()
+		regs := w.resourceRegistries()
+		for _, t := range resourceCleanupOrder {
+			regs[t].cleanup()
+		}
 		return nil
 	})
 
@@ -921,6 +1458,45 @@ func (w *Workflow) CancelWithReason(reason string) {
 	w.CancelWorkflow()
 }
 
+// CancelWithCause cancels the workflow, recording why it was canceled. The
+// cause is only recorded if the workflow isn't canceled yet, it's shared with
+// the parent and child workflows.
+func (w *Workflow) CancelWithCause(kind, detail string) {
+	w.recordCancelCause(kind, detail)
+	w.CancelWorkflow()
+}
+
+// recordCancelCause records why the workflow will be canceled, without
+// canceling it. Failed and timed out steps only record the cause, their
+// error propagates and the top level workflow is canceled by its cleanup, so
+// that a failing sub workflow doesn't cancel its parent early.
+func (w *Workflow) recordCancelCause(kind, detail string) {
+	root := w
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.cancelMx.Lock()
+	defer root.cancelMx.Unlock()
+	select {
+	case <-w.Cancel:
+	default:
+		if root.cancelCause == nil {
+			root.cancelCause = &CancelCause{Kind: kind, Detail: detail}
+		}
+	}
+}
+
+// CancelCause returns why the workflow was, or is about to be, canceled, or
+// nil if it wasn't canceled or was canceled without a cause.
+func (w *Workflow) CancelCause() *CancelCause {
+	for w.parent != nil {
+		w = w.parent
+	}
+	w.cancelMx.Lock()
+	defer w.cancelMx.Unlock()
+	return w.cancelCause
+}
+
 // CancelWorkflow cancels the workflow. Safe to call multiple times.
 // Prefer this to closing the w.Cancel channel,
 // which will panic if it has already been closed.
@@ -951,9 +1527,11 @@ func (w *Workflow) onStepCancel(s *Step, stepClass string) DError {
 	cancelReason := w.getCancelReason()
 	if cancelReason == "" {
 		cancelReason = "is canceled"
+		if c := w.CancelCause(); c != nil {
+			cancelReason = fmt.Sprintf("is canceled (%s)", c)
+		}
 	}
-	errorMessageFormat := "Step %q (%s) " + cancelReason + "."
 
-	s.w.LogWorkflowInfo(errorMessageFormat, s.name, stepClass)
-	return Errf(errorMessageFormat, s.name, stepClass)
+	s.logInfo("Step %q (%s) %s.", s.name, stepClass, cancelReason)
+	return Errf("Step %q (%s) %s.", s.name, stepClass, cancelReason)
 }
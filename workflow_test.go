@@ -32,7 +32,9 @@ import (
 	"time"
 
 	"cloud.google.com/go/storage"
+	daisyCompute "github.com/GoogleCloudPlatform/compute-daisy/compute"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
 	computeAlpha "google.golang.org/api/compute/v0.alpha"
 	computeBeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
@@ -147,6 +149,38 @@ func TestGenName(t *testing.T) {
 	}
 }
 
+func TestGenNameSeed(t *testing.T) {
+	w := &Workflow{Name: "wfname", id: "abcdef", NameSeed: "run-1"}
+	got := w.genName("name")
+	if !regexp.MustCompile(`^name-wfname-[0-9a-f]{6}$`).MatchString(got) {
+		t.Errorf("unexpected name format: %s", got)
+	}
+
+	// The same seed gives the same names, whatever the workflow ID.
+	if other := (&Workflow{Name: "wfname", id: "ghijkl", NameSeed: "run-1"}).genName("name"); other != got {
+		t.Errorf("names generated with the same seed differ: %s != %s", other, got)
+	}
+	// Another seed, or another resource, gives another name.
+	if other := (&Workflow{Name: "wfname", id: "abcdef", NameSeed: "run-2"}).genName("name"); other == got {
+		t.Errorf("names generated with different seeds are the same: %s", got)
+	}
+	if other := w.genName("name2"); strings.TrimPrefix(other, "name2-wfname-") == strings.TrimPrefix(got, "name-wfname-") {
+		t.Errorf("different resources got the same name suffix: %s, %s", got, other)
+	}
+
+	// Child workflows use the seed of the top level workflow.
+	child := &Workflow{Name: "child", id: "ghijkl", parent: w}
+	if got, want := child.genName("name"), (&Workflow{Name: "child", id: "mnopqr", parent: &Workflow{Name: "wfname", NameSeed: "run-1"}}).genName("name"); got != want || strings.HasSuffix(got, "ghijkl") {
+		t.Errorf("child workflow name not derived from the seed: got %s, want %s", got, want)
+	}
+
+	// Long names are truncated like random ones.
+	w.Name = "super-long-workflow-name-like-really-really-long"
+	if got := w.genName("super-long-name-really-long"); len(got) > 64 {
+		t.Errorf("result > 64 characters: %s", got)
+	}
+}
+
 func TestGetSourceGCSAPIPath(t *testing.T) {
 	w := testWorkflow()
 	w.sourcesPath = "my/sources"
@@ -705,6 +739,7 @@ func TestPopulate(t *testing.T) {
 		"test-var":  {Value: "wf-zone-this-should-populate-wf-name"},
 	}
 	want.autovars = got.autovars
+	want.unusedVarNames = []string{"bucket", "path", "test-var"}
 	want.bucket = "bar-project-daisy-bkt"
 	want.scratchPath = got.scratchPath
 	want.sourcesPath = fmt.Sprintf("%s/sources", got.scratchPath)
@@ -765,6 +800,51 @@ func TestRequiredVars(t *testing.T) {
 	}
 }
 
+func TestUnusedVars(t *testing.T) {
+	tests := []struct {
+		desc      string
+		strict    bool
+		shouldErr bool
+	}{
+		{"warning case", false, false},
+		{"strict case", true, true},
+	}
+
+	for _, tt := range tests {
+		w := testWorkflow()
+		w.StrictVars = tt.strict
+		w.Vars = map[string]Var{"used": {Value: "foo"}, "unused": {Value: "bar"}, "unused2": {Value: "${used}"}}
+		w.Steps = map[string]*Step{"s0": {Timeout: "10s", testType: &mockStep{}}}
+		w.Name = "wf-${used}"
+
+		if diffRes := diff(w.unusedVars(), []string{"unused", "unused2"}, 0); diffRes != "" {
+			t.Errorf("%s: unexpected unused vars: (-got +want)\n%s", tt.desc, diffRes)
+		}
+		err := w.populate(context.Background())
+		if tt.shouldErr {
+			if err == nil {
+				t.Errorf("%s: should have erred, but didn't", tt.desc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if err := w.validate(context.Background()); err != nil {
+			t.Errorf("%s: unexpected validate error: %v", tt.desc, err)
+		}
+		var warned bool
+		for _, e := range w.Logger.(*MockLogger).getEntries() {
+			if strings.Contains(e.Message, "vars are declared but never used: unused, unused2") {
+				warned = true
+			}
+		}
+		if !warned {
+			t.Errorf("%s: unused vars weren't logged", tt.desc)
+		}
+	}
+}
+
 func testTraverseWorkflow(mockRun func(i int) func(context.Context, *Step) DError) *Workflow {
 	// s0---->s1---->s3
 	//   \         /
@@ -852,6 +932,61 @@ func TestTraverseDAG(t *testing.T) {
 	}
 }
 
+func TestPopulateWaitFor(t *testing.T) {
+	w := testWorkflow()
+	rs := &ResourceStatus{Type: resourceTypeImage, Name: "image", Status: "READY"}
+	w.Steps = map[string]*Step{
+		"create": {name: "create", w: w, testType: &mockStep{}},
+		"use":    {name: "use", w: w, Timeout: "1h", WaitFor: []*ResourceStatus{rs}, testType: &mockStep{}},
+	}
+	w.Dependencies = map[string][]string{"use": {"create"}}
+
+	if err := w.populateWaitFor(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ws, ok := w.Steps["use-wait-for-resources"]
+	if !ok {
+		t.Fatal("wait step was not inserted")
+	}
+	if diffRes := diff(ws.WaitForResourceStatus, &WaitForResourceStatus{rs}, 0); diffRes != "" {
+		t.Errorf("wait step does not match expectation: (-got +want)\n%s", diffRes)
+	}
+	if ws.Timeout != "1h" {
+		t.Errorf("wait step timeout = %q, want %q", ws.Timeout, "1h")
+	}
+	if w.Steps["use"].WaitFor != nil {
+		t.Error("WaitFor was not cleared")
+	}
+	wantDeps := map[string][]string{
+		"use":                    {"create", "use-wait-for-resources"},
+		"use-wait-for-resources": {"create"},
+	}
+	if diffRes := diff(w.Dependencies, wantDeps, 0); diffRes != "" {
+		t.Errorf("dependencies do not match expectation: (-got +want)\n%s", diffRes)
+	}
+
+	// The wait step runs after the dependencies of the step, and before it.
+	var order []string
+	var mx sync.Mutex
+	if err := w.traverseDAG(func(s *Step) DError {
+		mx.Lock()
+		defer mx.Unlock()
+		order = append(order, s.name)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diffRes := diff(order, []string{"create", "use-wait-for-resources", "use"}, 0); diffRes != "" {
+		t.Errorf("steps did not run in order: (-got +want)\n%s", diffRes)
+	}
+
+	// A step with the name of the wait step is an error.
+	w.Steps["use"].WaitFor = []*ResourceStatus{rs}
+	if err := w.populateWaitFor(); err == nil {
+		t.Error("expected error for existing wait step name, got none")
+	}
+}
+
 func TestForceCleanupSetOnRunError(t *testing.T) {
 	doTestForceCleanup(t, true, true, true)
 }
@@ -897,6 +1032,31 @@ func doTestForceCleanup(t *testing.T, runErrorFromStep bool, forceCleanupOnError
 	}
 }
 
+func TestForceCleanupOnErrorKeepsAdoptedResources(t *testing.T) {
+	w := testWorkflow()
+	w.ForceCleanupOnError = true
+	created := &Resource{RealName: "created", link: "link", NoCleanup: true}
+	adopted := &Resource{RealName: "adopted", link: "link", ExistsOk: true}
+	w.Steps = map[string]*Step{
+		"s0": {name: "s0", testType: &mockStep{runImpl: func(_ context.Context, s *Step) DError {
+			created.creator, created.createdInWorkflow = s, true
+			adopted.creator, adopted.createdInWorkflow, adopted.adopted = s, true, true
+			w.disks.m = map[string]*Resource{"created": created, "adopted": adopted}
+			return Errf("failure")
+		}}, w: w},
+	}
+
+	if err := w.Run(context.Background()); err == nil {
+		t.Fatal("expected error from w.Run but nil received")
+	}
+	if !created.deleted {
+		t.Error("forced cleanup didn't delete the disk created by the workflow")
+	}
+	if adopted.deleted {
+		t.Error("forced cleanup deleted the adopted disk")
+	}
+}
+
 func TestPrint(t *testing.T) {
 	data := []byte(`{
 "Name": "some-name",
@@ -1095,6 +1255,165 @@ func TestRunStepTimeout(t *testing.T) {
 	}
 }
 
+func TestStepTimeout(t *testing.T) {
+	w := testWorkflow()
+	child := w.NewSubWorkflow()
+	s := &Step{timeout: 10 * time.Minute}
+
+	if got, clamped := w.stepTimeout(s); got != s.timeout || clamped {
+		t.Errorf("no deadline: got: (%v, %t), want: (%v, false)", got, clamped, s.timeout)
+	}
+
+	w.deadline = time.Now().Add(time.Minute)
+	if got, clamped := w.stepTimeout(s); got > time.Minute || got < 50*time.Second || !clamped {
+		t.Errorf("workflow deadline: got: (%v, %t), want about (1m, true)", got, clamped)
+	}
+	if got, clamped := child.stepTimeout(s); got > time.Minute || !clamped {
+		t.Errorf("parent workflow deadline: got: (%v, %t), want about (1m, true)", got, clamped)
+	}
+	short := &Step{timeout: time.Second}
+	if got, clamped := child.stepTimeout(short); got != time.Second || clamped {
+		t.Errorf("step timeout within budget: got: (%v, %t), want: (1s, false)", got, clamped)
+	}
+
+	w.deadline = time.Now().Add(-time.Second)
+	if got, clamped := w.stepTimeout(s); got != 0 || !clamped {
+		t.Errorf("passed deadline: got: (%v, %t), want: (0s, true)", got, clamped)
+	}
+}
+
+func TestRunWorkflowTimeout(t *testing.T) {
+	w := testWorkflow()
+	w.timeout = 100 * time.Millisecond
+	first, _ := w.NewStep("first")
+	first.timeout = time.Minute
+	first.testType = &mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}}
+	late, _ := w.NewStep("late")
+	late.timeout = time.Minute
+	late.testType = &mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+		<-s.w.Cancel
+		return nil
+	}}
+	w.AddDependency(late, first)
+
+	start := time.Now()
+	err := w.run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `step "late" did not complete within the`) || !strings.Contains(err.Error(), "left of the workflow timeout") {
+		t.Fatalf("expected a workflow timeout error for the late step, got: %v", err)
+	}
+	// The late step gets what is left of the workflow timeout, not a fresh
+	// step timeout.
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("workflow ran for %v, want about %v", elapsed, w.timeout)
+	}
+}
+
+func TestRunStepTimeoutCancelCause(t *testing.T) {
+	w := testWorkflow()
+	slow, _ := w.NewStep("slow")
+	slow.timeout = time.Millisecond
+	slow.testType = &mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+		<-s.w.Cancel
+		return nil
+	}}
+	causes := make(chan *CancelCause, 1)
+	sibling, _ := w.NewStep("sibling")
+	sibling.timeout = time.Minute
+	sibling.testType = &mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+		<-s.w.Cancel
+		causes <- s.w.CancelCause()
+		return nil
+	}}
+
+	if err := w.run(context.Background()); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	// The workflow is canceled by its cleanup, as Run does.
+	w.CancelWorkflow()
+	want := &CancelCause{Kind: CancelKindDeadline, Detail: `step "slow" did not complete within the specified timeout of 1ms`}
+	if diffRes := diff(w.CancelCause(), want, 0); diffRes != "" {
+		t.Errorf("unexpected cancel cause: (-got +want)\n%s", diffRes)
+	}
+	select {
+	case got := <-causes:
+		if diffRes := diff(got, want, 0); diffRes != "" {
+			t.Errorf("unexpected cancel cause seen by sibling step: (-got +want)\n%s", diffRes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("sibling step wasn't canceled")
+	}
+	if err := w.onStepCancel(sibling, "Dummy"); !strings.Contains(err.Error(), "is canceled (deadline: ") {
+		t.Errorf("cancel cause missing from step cancel error: %v", err)
+	}
+}
+
+func TestCancelWithCause(t *testing.T) {
+	// A step failure cancels the workflow.
+	w := testWorkflow()
+	s, _ := w.NewStep("fail")
+	s.timeout = time.Minute
+	s.testType = &mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+		return Errf("error")
+	}}
+	if err := w.run(context.Background()); err == nil {
+		t.Fatal("expected a step error")
+	}
+	if c := w.CancelCause(); c == nil || c.Kind != CancelKindStepFailure {
+		t.Errorf("unexpected cancel cause, got: %v, want kind: %q", c, CancelKindStepFailure)
+	}
+
+	// A failing sub workflow doesn't cancel its parent, the failure
+	// propagates as the error of the sub workflow step.
+	w = testWorkflow()
+	sw := w.NewSubWorkflow()
+	sw.Name = "sub"
+	swFail, _ := sw.NewStep("fail")
+	swFail.timeout = time.Minute
+	swFail.testType = &mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+		return Errf("error")
+	}}
+	sub, _ := w.NewStep("sub")
+	sub.timeout = time.Minute
+	sub.SubWorkflow = &SubWorkflow{Workflow: sw}
+	if err := w.populate(context.Background()); err != nil {
+		t.Fatalf("unexpected populate error: %v", err)
+	}
+	if err := w.run(context.Background()); err == nil {
+		t.Fatal("expected a sub workflow error")
+	}
+	select {
+	case <-w.Cancel:
+		t.Error("parent workflow canceled by the failure of its sub workflow")
+	default:
+	}
+	if c := w.CancelCause(); c == nil || c.Kind != CancelKindStepFailure {
+		t.Errorf("unexpected cancel cause, got: %v, want kind: %q", c, CancelKindStepFailure)
+	}
+
+	// The first cause is kept and is shared with child workflows.
+	parent := testWorkflow()
+	child := testWorkflow()
+	child.parent = parent
+	child.Cancel = parent.Cancel
+	child.CancelWithCause(CancelKindUser, "stop")
+	parent.CancelWithCause(CancelKindDeadline, "late")
+	want := &CancelCause{Kind: CancelKindUser, Detail: "stop"}
+	if diffRes := diff(parent.CancelCause(), want, 0); diffRes != "" {
+		t.Errorf("unexpected cancel cause: (-got +want)\n%s", diffRes)
+	}
+
+	// No cause is recorded if the workflow is already canceled.
+	w = testWorkflow()
+	w.CancelWorkflow()
+	w.CancelWithCause(CancelKindStepFailure, "canceled step")
+	if c := w.CancelCause(); c != nil {
+		t.Errorf("unexpected cancel cause: %v", c)
+	}
+}
+
 func TestPopulateClients(t *testing.T) {
 	w := testWorkflow()
 
@@ -1156,6 +1475,26 @@ func TestPopulateClients(t *testing.T) {
 	}
 }
 
+func TestPopulateClientsComputeOptions(t *testing.T) {
+	w := testWorkflow()
+	w.ComputeClient = nil
+	w.StorageClient = nil
+	w.externalLogging = true
+	// The compute options aren't passed to the storage and logging clients.
+	tryPopulateClients(t, w, option.WithoutAuthentication(), daisyCompute.WithUserAgent("my-tool/1.0"), daisyCompute.WithOperationPollInterval(time.Second))
+	if w.ComputeClient == nil || w.StorageClient == nil || w.CloudLoggingClient == nil {
+		t.Errorf("Did not populate clients.")
+	}
+
+	w.ComputeClient = nil
+	w.StorageClient = nil
+	w.CloudLoggingClient = nil
+	tryPopulateClients(t, w, daisyCompute.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"})))
+	if w.ComputeClient == nil || w.StorageClient == nil || w.CloudLoggingClient == nil {
+		t.Errorf("Did not populate clients with a token source.")
+	}
+}
+
 func tryPopulateClients(t *testing.T, w *Workflow, options ...option.ClientOption) {
 	if err := w.PopulateClients(context.Background(), options...); err != nil {
 		t.Errorf("Failed to populate clients for workflow: %v", err)
@@ -1248,3 +1587,221 @@ func TestOnStepCancelCustomCancelReason(t *testing.T) {
 		t.Errorf("Expected error message `%v` but got `%v` ", expectedErrorMessage, err.Error())
 	}
 }
+
+func TestCreatedResources(t *testing.T) {
+	w := testWorkflow()
+	created := time.Now()
+	w.disks.m = map[string]*Resource{
+		"d":        {RealName: "d-foo", Project: testProject, link: "projects/p/zones/z/disks/d-foo", createdInWorkflow: true, createdAt: created},
+		"deleted":  {RealName: "deleted-foo", Project: testProject, link: "projects/p/zones/z/disks/deleted-foo", createdInWorkflow: true, createdAt: created, deleted: true},
+		"failed":   {RealName: "failed-foo", Project: testProject, link: "projects/p/zones/z/disks/failed-foo"},
+		"adopted":  {RealName: "adopted", link: "projects/p/zones/z/disks/adopted", createdInWorkflow: true, external: true},
+		"external": {RealName: "external", link: "projects/p/zones/z/disks/external"},
+	}
+	w.instances.m = map[string]*Resource{
+		"i": {RealName: "i-foo", Project: "other", link: "projects/other/zones/z/instances/i-foo", createdInWorkflow: true, createdAt: created},
+	}
+
+	iw := New()
+	w.includeWorkflow(iw)
+	sw := iw.NewSubWorkflow()
+	sw.networks.m = map[string]*Resource{
+		"n": {RealName: "n-bar", Project: testProject, link: "projects/p/global/networks/n-bar", createdInWorkflow: true, createdAt: created},
+	}
+	iw.Steps = map[string]*Step{"sub": {SubWorkflow: &SubWorkflow{Workflow: sw}}}
+	w.Steps = map[string]*Step{"include": {IncludeWorkflow: &IncludeWorkflow{Workflow: iw}}}
+
+	want := []ResourceRef{
+		{Type: "disk", Name: "d", RealName: "d-foo", Project: testProject, Link: "projects/p/zones/z/disks/d-foo", Created: created},
+		{Type: "disk", Name: "deleted", RealName: "deleted-foo", Project: testProject, Link: "projects/p/zones/z/disks/deleted-foo", Created: created, Deleted: true},
+		{Type: "instance", Name: "i", RealName: "i-foo", Project: "other", Link: "projects/other/zones/z/instances/i-foo", Created: created},
+		{Type: "network", Name: "n", RealName: "n-bar", Project: testProject, Link: "projects/p/global/networks/n-bar", Created: created},
+	}
+	if diffRes := diff(w.CreatedResources(), want, 0); diffRes != "" {
+		t.Errorf("unexpected created resources: (-got +want)\n%s", diffRes)
+	}
+}
+
+func TestResourceMarkCreated(t *testing.T) {
+	r := &Resource{}
+	before := time.Now()
+	r.markCreated()
+	if !r.createdInWorkflow {
+		t.Error("resource not marked as created")
+	}
+	if r.createdAt.Before(before) {
+		t.Errorf("unexpected creation time: %v, want at or after %v", r.createdAt, before)
+	}
+}
+
+func TestRunCopiesComputeClient(t *testing.T) {
+	shared := testWorkflow().ComputeClient
+	var clients []daisyCompute.Client
+	var mx sync.Mutex
+	newWorkflow := func() *Workflow {
+		w := testWorkflow()
+		w.ComputeClient = shared
+		w.Steps = map[string]*Step{
+			"s": {name: "s", testType: &mockStep{runImpl: func(_ context.Context, s *Step) DError {
+				mx.Lock()
+				clients = append(clients, s.w.ComputeClient)
+				mx.Unlock()
+				return nil
+			}}, w: w},
+		}
+		return w
+	}
+
+	// The run settings of workflows sharing a client, e.g. their retry
+	// context, are made on their own copies.
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(w *Workflow) {
+			defer wg.Done()
+			if err := w.Run(context.Background()); err != nil {
+				t.Errorf("unexpected run error: %v", err)
+			}
+		}(newWorkflow())
+	}
+	wg.Wait()
+	if len(clients) != 2 || clients[0] == shared || clients[1] == shared || clients[0] == clients[1] {
+		t.Errorf("workflows didn't run with their own copies of the shared client")
+	}
+
+	// The client is put back after the run.
+	w := newWorkflow()
+	if err := w.Run(context.Background()); err != nil {
+		t.Errorf("unexpected run error: %v", err)
+	}
+	if w.ComputeClient != shared {
+		t.Errorf("the compute client wasn't put back after the run, got: %v", w.ComputeClient)
+	}
+
+	// Clients which can't be copied, e.g. mocks only implementing
+	// daisyCompute.Client, are used as they are.
+	mock := struct{ daisyCompute.Client }{shared}
+	clients = nil
+	w = newWorkflow()
+	w.ComputeClient = mock
+	if err := w.Run(context.Background()); err != nil {
+		t.Errorf("unexpected run error: %v", err)
+	}
+	if len(clients) != 1 || clients[0] != mock {
+		t.Errorf("workflow didn't run with the client which can't be copied")
+	}
+	// A call budget needs a client which has one.
+	w = newWorkflow()
+	w.ComputeClient = mock
+	w.MaxAPICalls = 1
+	if err := w.Run(context.Background()); err == nil {
+		t.Error("expected error for MaxAPICalls without a call budget, got none")
+	}
+}
+
+func TestRunMaxAPICalls(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	w.MaxAPICalls = 2
+	var ran []string
+	var mx sync.Mutex
+	getDisk := func(_ context.Context, s *Step) DError {
+		mx.Lock()
+		ran = append(ran, s.name)
+		mx.Unlock()
+		if _, err := s.w.ComputeClient.GetDisk(testProject, testZone, testDisk); errors.Is(err, daisyCompute.ErrCallBudgetExceeded) {
+			return newErr("failed to get disk", err)
+		}
+		return nil
+	}
+	w.Steps = map[string]*Step{
+		"s0": {name: "s0", testType: &mockStep{runImpl: getDisk}, w: w},
+		"s1": {name: "s1", testType: &mockStep{runImpl: getDisk}, w: w},
+		"s2": {name: "s2", testType: &mockStep{runImpl: getDisk}, w: w},
+		"s3": {name: "s3", testType: &mockStep{runImpl: getDisk}, w: w},
+	}
+	w.Dependencies = map[string][]string{
+		"s1": {"s0"},
+		"s2": {"s1"},
+		"s3": {"s2"},
+	}
+	var cleanupErr error
+	w.addCleanupHook(func() DError {
+		_, cleanupErr = w.ComputeClient.GetDisk(testProject, testZone, testDisk)
+		return nil
+	})
+
+	shared := w.ComputeClient
+	err := w.Run(ctx)
+	if err == nil || !strings.Contains(err.Error(), daisyCompute.ErrCallBudgetExceeded.Error()) {
+		t.Errorf("expected budget error, got: %v", err)
+	}
+	if got := shared.(*daisyCompute.TestClient).CallCount(); got != 0 {
+		t.Errorf("calls counted against the budget of the shared client: %d", got)
+	}
+	if diffRes := diff(ran, []string{"s0", "s1", "s2"}, 0); diffRes != "" {
+		t.Errorf("steps run do not match expectation: (-got +want)\n%s", diffRes)
+	}
+	if errors.Is(cleanupErr, daisyCompute.ErrCallBudgetExceeded) {
+		t.Errorf("cleanup API call failed: %v", cleanupErr)
+	}
+}
+
+func TestRunRecordReplay(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := r.URL.Path
+		switch {
+		case r.Method == "GET" && p == "/projects/"+testProject:
+			fmt.Fprintf(w, `{"name":%q}`, testProject)
+		case r.Method == "GET" && p == "/projects/"+testProject+"/zones":
+			fmt.Fprintf(w, `{"items":[{"name":%q}]}`, testZone)
+		case r.Method == "GET" && strings.HasSuffix(p, "/disks"):
+			fmt.Fprint(w, `{}`)
+		case r.Method == "POST" && strings.HasSuffix(p, "/disks"), r.Method == "DELETE" && strings.Contains(p, "/disks/"):
+			fmt.Fprint(w, `{"name":"op"}`)
+		case r.Method == "POST" && strings.HasSuffix(p, "/operations/op/wait"):
+			fmt.Fprint(w, `{"status":"DONE"}`)
+		case r.Method == "GET" && strings.Contains(p, "/disks/"):
+			fmt.Fprintf(w, `{"name":%q,"status":"READY"}`, filepath.Base(p))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":404}}`)
+		}
+	}))
+	ctx := context.Background()
+	recording := filepath.Join(t.TempDir(), "recording.json")
+
+	// run runs a workflow creating a disk, whose name is generated from the
+	// workflow ID, with c. It returns the name of the disk.
+	run := func(c daisyCompute.Client, id string) string {
+		w := testWorkflow()
+		w.id = id
+		w.ComputeClient = c
+		w.Steps = map[string]*Step{"create": {CreateDisks: &CreateDisks{{Disk: compute.Disk{Name: "d", SizeGb: 10}}}}}
+		if err := w.Run(ctx); err != nil {
+			t.Fatalf("workflow %s: unexpected error: %v", id, err)
+		}
+		d, _ := w.disks.get("d")
+		return d.RealName
+	}
+
+	rc, err := daisyCompute.NewRecordingClient(ctx, recording, option.WithEndpoint(svr.URL), option.WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := run(rc, "x7s2m")
+	if err := rc.(io.Closer).Close(); err != nil {
+		t.Fatalf("error closing recording client: %v", err)
+	}
+	svr.Close()
+
+	// Another run of the workflow has another ID, and so other resource
+	// names, it's replayed nonetheless.
+	pc, err := daisyCompute.NewReplayClient(ctx, recording, option.WithEndpoint(svr.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed := run(pc, "bq9dz"); replayed == recorded {
+		t.Errorf("replayed workflow created disk %q with the recorded name", replayed)
+	}
+}